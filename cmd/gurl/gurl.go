@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -13,15 +15,21 @@ import (
 	"time"
 
 	"github.com/antlabs/gurl/internal/api"
+	"github.com/antlabs/gurl/internal/baseline"
 	"github.com/antlabs/gurl/internal/batch"
 	"github.com/antlabs/gurl/internal/benchmark"
 	"github.com/antlabs/gurl/internal/compare"
 	"github.com/antlabs/gurl/internal/config"
 	"github.com/antlabs/gurl/internal/mcp"
+	"github.com/antlabs/gurl/internal/metrics"
 	"github.com/antlabs/gurl/internal/mock"
 	"github.com/antlabs/gurl/internal/notify"
 	"github.com/antlabs/gurl/internal/parser"
+	"github.com/antlabs/gurl/internal/progress"
+	"github.com/antlabs/gurl/internal/readiness"
+	"github.com/antlabs/gurl/internal/report"
 	"github.com/antlabs/gurl/internal/scheduler"
+	"github.com/antlabs/gurl/internal/sink"
 	"github.com/antlabs/gurl/internal/template"
 	"github.com/guonaihong/clop"
 )
@@ -35,6 +43,20 @@ type Args struct {
 	Rate        int           `clop:"-R;--rate" usage:"Work rate (requests/sec) 0=unlimited" default:"0"`
 	Timeout     time.Duration `clop:"--timeout" usage:"Socket/request timeout" default:"30s"`
 	Requests    int64         `clop:"-n;--requests" usage:"Total number of requests to perform (0=unlimited, duration-limited)" default:"0"`
+	Warmup      time.Duration `clop:"--warmup" usage:"Warm-up duration before measuring; its samples are excluded from the statistics"`
+
+	// 熔断选项：任一条件触发时提前结束运行，进程以退出码 3 退出
+	AbortErrorRate       float64       `clop:"--abort-error-rate" usage:"Abort when the error rate (percent) over --abort-error-window exceeds this value"`
+	AbortErrorWindow     time.Duration `clop:"--abort-error-window" usage:"Sliding window for --abort-error-rate" default:"10s"`
+	AbortP99             time.Duration `clop:"--abort-p99" usage:"Abort when the p99 latency stays above this value for --abort-p99-for"`
+	AbortP99For          time.Duration `clop:"--abort-p99-for" usage:"How long p99 must stay above --abort-p99 before aborting" default:"10s"`
+	AbortConnectFailures int           `clop:"--abort-connect-failures" usage:"Abort after this many consecutive connection failures"`
+
+	// 就绪检查选项：运行开始前轮询目标，直到断言通过或超时
+	WaitFor      string        `clop:"--wait-for" usage:"URL or curl command polled until it passes --wait-assert before the run starts"`
+	WaitAssert   []string      `clop:"--wait-assert" usage:"Readiness assertion in the asserts syntax, repeatable (default: status == 200)"`
+	WaitTimeout  time.Duration `clop:"--wait-timeout" usage:"How long to wait for the target to become ready" default:"60s"`
+	WaitInterval time.Duration `clop:"--wait-interval" usage:"Delay between readiness attempts" default:"1s"`
 
 	// curl解析选项
 	CurlCommand  string `clop:"--parse-curl" usage:"Parse curl command and use it for benchmarking"`
@@ -48,14 +70,48 @@ type Args struct {
 	ContentType string   `clop:"--content-type" usage:"Content-Type header"`
 
 	// 输出选项
-	Verbose      bool   `clop:"-v;--verbose" usage:"Verbose output"`
-	PrintLatency bool   `clop:"--latency" usage:"Print latency statistics"`
-	LiveUI       bool   `clop:"--live-ui" usage:"Enable live terminal UI with real-time stats"`
-	UITheme      string `clop:"--ui-theme" usage:"UI color theme: dark, light, or auto (default: auto)"`
+	Verbose          bool          `clop:"-v;--verbose" usage:"Verbose output"`
+	PrintLatency     bool          `clop:"--latency" usage:"Print latency statistics"`
+	LiveUI           bool          `clop:"--live-ui" usage:"Enable live terminal UI with real-time stats"`
+	UITheme          string        `clop:"--ui-theme" usage:"UI color theme: dark, light, or auto (default: auto)"`
+	HTMLReport       string        `clop:"--html-report" usage:"Write a self-contained HTML report with charts to this file"`
+	MetricsAddr      string        `clop:"--metrics-addr" usage:"Expose live Prometheus metrics on this address (e.g. :9100)"`
+	ControlAddr      string        `clop:"--control-addr" usage:"Expose the runtime control endpoint (pause, rate, connections) on this address (e.g. 127.0.0.1:9200)"`
+	Progress         string        `clop:"--progress" usage:"Periodic progress lines: auto (text when stdout is not a terminal), text, json, or off" default:"auto"`
+	ProgressInterval time.Duration `clop:"--progress-interval" usage:"Interval between progress lines" default:"5s"`
+
+	// 基线选项
+	SaveBaseline     string  `clop:"--save-baseline" usage:"Save the results as a named baseline"`
+	CompareBaseline  string  `clop:"--compare-baseline" usage:"Compare the results against a named baseline and fail on regressions"`
+	BaselineDir      string  `clop:"--baseline-dir" usage:"Directory where baselines are stored" default:".gurl/baselines"`
+	LatencyTolerance float64 `clop:"--latency-tolerance" usage:"Allowed p50/p95/p99 increase over the baseline, in percent" default:"10"`
+	RPSTolerance     float64 `clop:"--rps-tolerance" usage:"Allowed requests/sec decrease from the baseline, in percent" default:"10"`
+	ErrorTolerance   float64 `clop:"--error-rate-tolerance" usage:"Allowed error rate increase over the baseline, in percentage points" default:"1"`
+
+	// 历史记录选项
+	HistoryDB string `clop:"--history-db" usage:"Path of the local run history database" default:".gurl/history.db"`
+	NoHistory bool   `clop:"--no-history" usage:"Do not record this run in the history database"`
+
+	// 指标推送选项
+	Sinks        []string      `clop:"--sink" usage:"Push metrics to a sink: statsd=host:port, dogstatsd=host:port, influx=URL|file, otlp=URL"`
+	SinkTags     []string      `clop:"--sink-tag" usage:"Tag attached to pushed metrics (key=value), e.g. env=staging"`
+	SinkInterval time.Duration `clop:"--sink-interval" usage:"Interval between metric pushes during a run" default:"10s"`
 
 	// 引擎选项
 	UseNetHTTP bool `clop:"--use-nethttp" usage:"Force use standard library net/http instead of pulse"`
 
+	// 断言选项（作为批量测试的默认值，可被每个测试的 YAML 配置覆盖）
+	AssertSampleRate float64 `clop:"--assert-sample-rate" usage:"Fraction of responses whose asserts are evaluated, e.g. 0.01 (0 or 1 = all)" default:"0"`
+	MaxBodySize      int64   `clop:"--max-body-size" usage:"Maximum response body bytes captured for asserts (0 = 1MB)" default:"0"`
+
+	// Cookie 选项
+	CookieJar  string `clop:"--cookie-jar" usage:"Keep cookies per connection (connection) or across all connections (shared)"`
+	CookieFile string `clop:"--cookie-file" usage:"Preload cookies from a Netscape cookie file (curl -b/-c format)"`
+
+	// 认证选项
+	Auth       string `clop:"--auth" usage:"Add credentials to every request: basic=user:password or bearer=token"`
+	AuthConfig string `clop:"--auth-config" usage:"Path to auth provider configuration (YAML/JSON): basic, bearer, oauth2, hmac or sigv4"`
+
 	// 批量测试选项
 	BatchConfig      string `clop:"--batch-config" usage:"Path to batch test configuration file (YAML/JSON)"`
 	BatchConcurrency int    `clop:"--batch-concurrency" usage:"Maximum concurrent batch tests" default:"3"`
@@ -71,8 +127,13 @@ type Args struct {
 	MCPDebugLog string `clop:"--mcp-debug-log" usage:"Path to MCP debug log file (only used with --mcp)"`
 
 	// API服务器选项
-	APIServer bool `clop:"--api-server" usage:"Start as a RESTful API server"`
-	APIPort   int  `clop:"--api-port" usage:"Port for API server" default:"8080"`
+	APIServer           bool          `clop:"--api-server" usage:"Start as a RESTful API server"`
+	APIPort             int           `clop:"--api-port" usage:"Port for API server" default:"8080"`
+	APITaskTTL          time.Duration `clop:"--api-task-ttl" usage:"How long finished API tasks are kept in memory (0 = forever)" default:"24h"`
+	APIMaxTasks         int           `clop:"--api-max-tasks" usage:"Maximum API tasks kept in memory, oldest finished first evicted (0 = unlimited)" default:"1000"`
+	APIMaxConcurrent    int           `clop:"--api-max-concurrent" usage:"Maximum API tasks running at once, the others are queued (0 = unlimited)" default:"1"`
+	APIExclusiveTargets bool          `clop:"--api-exclusive-targets" usage:"Never run two API tasks against the same host at once"`
+	APIAuth             string        `clop:"--api-auth" usage:"YAML or JSON file of API keys with roles, rate limits and allowed targets (default: no authentication)"`
 
 	// Mock服务器选项
 	MockServer     bool   `clop:"--mock-server" usage:"Start a mock HTTP server for testing"`
@@ -102,6 +163,7 @@ func (a *Args) toConfig() config.Config {
 		Rate:         a.Rate,
 		Timeout:      a.Timeout,
 		Requests:     a.Requests,
+		Warmup:       a.Warmup,
 		CurlCommand:  a.CurlCommand,
 		CurlFile:     a.CurlFile,
 		LoadStrategy: a.LoadStrategy,
@@ -114,6 +176,12 @@ func (a *Args) toConfig() config.Config {
 		LiveUI:       a.LiveUI,
 		UITheme:      a.UITheme,
 		UseNetHTTP:   a.UseNetHTTP,
+
+		AssertSampleRate: a.AssertSampleRate,
+		MaxBodySize:      a.MaxBodySize,
+
+		CookieJar:  a.CookieJar,
+		CookieFile: a.CookieFile,
 	}
 }
 
@@ -125,6 +193,16 @@ func runBenchmark(args *Args) error {
 
 	cfg := args.toConfig()
 
+	sinks, err := args.sinkConfigs()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Auth, err = args.authConfig(); err != nil {
+		return err
+	}
+	cfg.Abort = args.abortConfig()
+
 	// 创建模板解析器并设置变量
 	templateParser := template.NewTemplateParser()
 	if len(args.Variables) > 0 {
@@ -214,6 +292,10 @@ func runBenchmark(args *Args) error {
 		cancel()
 	}()
 
+	if err := waitForTarget(ctx, args.waitConfig(), args.Verbose); err != nil {
+		return err
+	}
+
 	// 创建并运行基准测试
 	var bench *benchmark.Benchmark
 	var targetURL string
@@ -228,10 +310,32 @@ func runBenchmark(args *Args) error {
 		targetURL = req.URL.String()
 	}
 
+	observers, err := runObservers(sinks, map[string]string{"target": targetURL})
+	if err != nil {
+		return err
+	}
+	for _, o := range observers {
+		bench.AddObserver(o)
+	}
+
+	if args.ControlAddr != "" {
+		stopControl, err := startControlServer(args.ControlAddr, bench.Controller())
+		if err != nil {
+			return err
+		}
+		defer stopControl()
+	}
+
 	// 只在非 LiveUI 模式下输出初始信息
 	if !cfg.LiveUI {
 		fmt.Printf("Running %s test @ %s\n", cfg.Duration, targetURL)
 		fmt.Printf("  %d threads and %d connections\n", cfg.Threads, cfg.Connections)
+		if cfg.Warmup > 0 {
+			fmt.Printf("  %s warm-up, excluded from the statistics\n", cfg.Warmup)
+		}
+		if args.ControlAddr != "" {
+			fmt.Printf("  control endpoint at http://%s/control/\n", args.ControlAddr)
+		}
 	}
 
 	results, err := bench.Run(ctx)
@@ -242,6 +346,264 @@ func runBenchmark(args *Args) error {
 	// 打印结果
 	benchmark.PrintResults(results, cfg)
 
+	rep := report.FromBenchmark(targetURL, results, cfg)
+	recordHistory(args, rep)
+
+	if args.HTMLReport != "" {
+		if err := writeHTMLReport(args.HTMLReport, rep); err != nil {
+			return err
+		}
+	}
+
+	// 被熔断中止的结果不完整，不参与基线对比
+	if reason := results.AbortReason(); reason != "" {
+		return &abortedError{reason: reason}
+	}
+
+	return checkBaseline(args, map[string]baseline.Snapshot{
+		baseline.SingleKey: baseline.FromResults(targetURL, results),
+	}, nil)
+}
+
+// checkBaseline 将本次结果与 --compare-baseline 指定的基线对比，并按 --save-baseline 保存。
+// entries 以测试名为键，failed 记录未能完成的测试及原因；基线中的测试没有结果时按回归处理。
+// 超出容忍度或有测试未完成时返回错误，使进程以非零状态退出
+func checkBaseline(args *Args, entries map[string]baseline.Snapshot, failed map[string]string) error {
+	if args.CompareBaseline == "" && args.SaveBaseline == "" {
+		return nil
+	}
+
+	store := baseline.NewStore(args.BaselineDir)
+	regressed := false
+
+	if args.CompareBaseline != "" {
+		base, err := store.Load(args.CompareBaseline)
+		if err != nil {
+			return err
+		}
+
+		tol := baseline.Tolerances{
+			LatencyPct:   args.LatencyTolerance,
+			RPSPct:       args.RPSTolerance,
+			ErrorRatePts: args.ErrorTolerance,
+		}
+		comparisons := baseline.CompareAll(base, entries, failed, tol)
+		for _, cmp := range comparisons {
+			if cmp.Regressed() {
+				regressed = true
+			}
+		}
+		fmt.Print(baseline.Format(args.CompareBaseline, comparisons, tol))
+	}
+
+	if args.SaveBaseline != "" {
+		switch {
+		case regressed:
+			// 有回归时不覆盖基线，避免把变差的结果当成新的参照
+			fmt.Printf("Baseline '%s' not updated because a regression was detected\n", args.SaveBaseline)
+		case len(failed) > 0:
+			// 结果不完整时不保存，否则缺失的测试在之后的对比中不会被检查
+			return fmt.Errorf("baseline '%s' not saved: %d test(s) did not complete", args.SaveBaseline, len(failed))
+		default:
+			path, err := store.Save(&baseline.Baseline{
+				Name:      args.SaveBaseline,
+				CreatedAt: time.Now(),
+				Entries:   entries,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Baseline '%s' saved to %s\n", args.SaveBaseline, path)
+		}
+	}
+
+	if regressed {
+		return fmt.Errorf("performance regression detected against baseline '%s'", args.CompareBaseline)
+	}
+	return nil
+}
+
+// metricsRegistry 在指定 --metrics-addr 时创建，定时任务的多次运行共用同一个导出端点
+var metricsRegistry *metrics.Registry
+
+// progressFormat 是进度输出的格式，空字符串表示不输出；由 setupProgress 根据 --progress 设置
+var (
+	progressFormat   string
+	progressInterval time.Duration
+)
+
+// setupProgress 解析 --progress，LiveUI 模式下不输出进度行
+func setupProgress(args *Args) error {
+	format, err := progress.Resolve(args.Progress)
+	if err != nil {
+		return err
+	}
+	if args.LiveUI {
+		format = ""
+	}
+	progressFormat = format
+	progressInterval = args.ProgressInterval
+	if progressInterval <= 0 {
+		progressInterval = progress.DefaultInterval
+	}
+	return nil
+}
+
+// startMetricsServer 在后台启动 Prometheus 指标端点，端口无法监听时返回错误，
+// 避免在没有指标的情况下继续运行；返回的函数用于在运行结束后关闭
+func startMetricsServer(addr string) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start metrics endpoint: %w", err)
+	}
+
+	metricsRegistry = metrics.NewRegistry()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricsRegistry)
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+	return func() { server.Close() }, nil
+}
+
+// startControlServer 在后台启动运行时控制接口，返回的函数用于在运行结束后关闭
+func startControlServer(addr string, control *benchmark.Controller) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start control endpoint: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/control", http.StripPrefix("/control", control))
+	mux.Handle("/control/", http.StripPrefix("/control", control))
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+	return func() { server.Close() }, nil
+}
+
+// sinkConfigs 解析 --sink、--sink-tag 和 --sink-interval 参数
+func (a *Args) sinkConfigs() ([]config.SinkConfig, error) {
+	tags, err := config.ParseTags(a.SinkTags)
+	if err != nil {
+		return nil, err
+	}
+
+	sinks := make([]config.SinkConfig, 0, len(a.Sinks))
+	for _, spec := range a.Sinks {
+		cfg, err := config.ParseSinkSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		if a.SinkInterval > 0 {
+			cfg.Interval = a.SinkInterval.String()
+		}
+		cfg.Tags = tags
+		sinks = append(sinks, cfg)
+	}
+	return sinks, nil
+}
+
+// authConfig 解析 --auth 和 --auth-config 参数，两者都未设置时返回 nil
+func (a *Args) authConfig() (*config.AuthConfig, error) {
+	switch {
+	case a.Auth != "" && a.AuthConfig != "":
+		return nil, fmt.Errorf("--auth and --auth-config cannot be used together")
+	case a.Auth != "":
+		return config.ParseAuthSpec(a.Auth)
+	case a.AuthConfig != "":
+		return config.LoadAuthConfig(a.AuthConfig)
+	}
+	return nil, nil
+}
+
+// abortConfig 根据 --abort-* 参数构建熔断配置，没有设置任何条件时返回 nil
+func (a *Args) abortConfig() *config.AbortConfig {
+	if a.AbortErrorRate == 0 && a.AbortP99 == 0 && a.AbortConnectFailures == 0 {
+		return nil
+	}
+
+	abort := &config.AbortConfig{
+		ErrorRate:       a.AbortErrorRate,
+		ConnectFailures: a.AbortConnectFailures,
+	}
+	if a.AbortErrorWindow > 0 {
+		abort.ErrorWindow = a.AbortErrorWindow.String()
+	}
+	if a.AbortP99 > 0 {
+		abort.P99 = a.AbortP99.String()
+		if a.AbortP99For > 0 {
+			abort.P99For = a.AbortP99For.String()
+		}
+	}
+	return abort
+}
+
+// waitConfig 根据 --wait-* 参数构建就绪检查配置，没有设置 --wait-for 时返回 nil
+func (a *Args) waitConfig() *config.WaitConfig {
+	if a.WaitFor == "" {
+		return nil
+	}
+
+	wait := &config.WaitConfig{
+		Target: a.WaitFor,
+		Assert: strings.Join(a.WaitAssert, "\n"),
+	}
+	if a.WaitTimeout > 0 {
+		wait.Timeout = a.WaitTimeout.String()
+	}
+	if a.WaitInterval > 0 {
+		wait.Interval = a.WaitInterval.String()
+	}
+	return wait
+}
+
+// waitForTarget 在运行开始前等待目标就绪，wait 为 nil 时直接返回
+func waitForTarget(ctx context.Context, wait *config.WaitConfig, verbose bool) error {
+	if wait == nil {
+		return nil
+	}
+	if err := wait.Validate(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Waiting for %s to become ready (timeout %s)\n", wait.Target, wait.GetTimeout())
+	attempt, err := readiness.Wait(ctx, wait, func(a readiness.Attempt) {
+		if verbose && a.Err != nil {
+			fmt.Printf("  %s\n", a)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("readiness check failed: %w", err)
+	}
+	fmt.Printf("Target ready after %d attempt(s) (status %d)\n\n", attempt.N, attempt.Status)
+	return nil
+}
+
+// runObservers 返回一次运行需要挂载的 observer：进度输出、Prometheus 指标和各个指标推送 sink
+func runObservers(sinks []config.SinkConfig, labels map[string]string) ([]benchmark.Observer, error) {
+	var observers []benchmark.Observer
+	if progressFormat != "" {
+		observers = append(observers, progress.New(os.Stdout, progressFormat, progressInterval, labels))
+	}
+	if metricsRegistry != nil {
+		observers = append(observers, metricsRegistry.Collector(labels))
+	}
+
+	sinkObservers, err := sink.NewObservers(sinks, labels)
+	if err != nil {
+		return nil, fmt.Errorf("metrics sink error: %w", err)
+	}
+	for _, o := range sinkObservers {
+		observers = append(observers, o)
+	}
+	return observers, nil
+}
+
+// writeHTMLReport 写入 HTML 报告并提示文件位置
+func writeHTMLReport(path string, rep *report.Report) error {
+	if err := report.WriteHTMLFile(path, rep); err != nil {
+		return fmt.Errorf("failed to write html report: %w", err)
+	}
+	fmt.Printf("HTML report written to %s\n", path)
 	return nil
 }
 
@@ -317,8 +679,33 @@ func runCompare(args *Args) error {
 		return fmt.Errorf("failed to load compare config: %w", err)
 	}
 
-	// 执行指定场景
-	results, passed, failed, err := compare.RunScenario(cmpCfg, args.CompareName)
+	// 命令行的 cookie 选项作为场景未配置时的默认值
+	if err := config.ValidateCookieJar(args.CookieJar); err != nil {
+		return err
+	}
+	for i := range cmpCfg.Scenarios {
+		sc := &cmpCfg.Scenarios[i]
+		if sc.CookieJar == config.CookieJarOff {
+			sc.CookieJar = args.CookieJar
+		}
+		if sc.CookieFile == "" {
+			sc.CookieFile = args.CookieFile
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err = waitForTarget(ctx, args.waitConfig(), args.Verbose)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	// 执行指定场景，开启 --progress 时定期输出场景的请求进度
+	var observers []benchmark.Observer
+	if progressFormat != "" {
+		observers = append(observers, progress.New(os.Stdout, progressFormat, progressInterval, map[string]string{"test": args.CompareName}))
+	}
+	results, passed, failed, err := compare.RunScenario(cmpCfg, args.CompareName, observers...)
 	if err != nil {
 		return fmt.Errorf("failed to run compare scenario: %w", err)
 	}
@@ -382,6 +769,16 @@ func runCompare(args *Args) error {
 	}
 
 	fmt.Printf("Summary: %d passed, %d failed\n", passed, failed)
+
+	rep := report.FromCompare(scenario, results, passed, failed)
+	recordHistory(args, rep)
+
+	if args.HTMLReport != "" {
+		if err := writeHTMLReport(args.HTMLReport, rep); err != nil {
+			return err
+		}
+	}
+
 	if failed > 0 {
 		return fmt.Errorf("compare scenario failed")
 	}
@@ -399,8 +796,22 @@ func runBatchTest(args *Args) error {
 	// 创建默认配置
 	defaults := args.toConfig()
 
+	sinks, err := args.sinkConfigs()
+	if err != nil {
+		return err
+	}
+
+	if defaults.Auth, err = args.authConfig(); err != nil {
+		return err
+	}
+	defaults.Abort = args.abortConfig()
+	sinks = append(sinks, batchConfig.Sinks...)
+
 	// 创建批量执行器
 	executor := batch.NewExecutor(args.BatchConcurrency, args.Verbose)
+	executor.SetObserverFactory(func(testName string) ([]benchmark.Observer, error) {
+		return runObservers(sinks, map[string]string{"test": testName})
+	})
 
 	// 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
@@ -415,6 +826,15 @@ func runBatchTest(args *Args) error {
 		cancel()
 	}()
 
+	// 命令行的 --wait-for 优先于配置文件中的 wait_for
+	wait := args.waitConfig()
+	if wait == nil {
+		wait = batchConfig.WaitFor
+	}
+	if err := waitForTarget(ctx, wait, args.Verbose); err != nil {
+		return err
+	}
+
 	// 执行批量测试
 	var result *batch.BatchResult
 	if args.BatchSequential {
@@ -445,6 +865,15 @@ func runBatchTest(args *Args) error {
 	// 打印简要摘要
 	reporter.PrintSummary(result)
 
+	rep := report.FromBatch(result)
+	recordHistory(args, rep)
+
+	if args.HTMLReport != "" {
+		if err := writeHTMLReport(args.HTMLReport, rep); err != nil {
+			return err
+		}
+	}
+
 	// 统一批量通知（如飞书），避免每个断言单独发送
 	if batchConfig.Notifier != nil {
 		n := notify.NewFromConfig(batchConfig.Notifier)
@@ -455,7 +884,28 @@ func runBatchTest(args *Args) error {
 		}
 	}
 
-	return nil
+	// 成功完成的测试参与基线对比，出错或被中止的测试记为没有结果
+	entries := make(map[string]baseline.Snapshot, len(result.Tests))
+	failed := make(map[string]string)
+	var aborted []string
+	for _, test := range result.Tests {
+		switch {
+		case test.Stats != nil && test.Stats.AbortReason() != "":
+			aborted = append(aborted, fmt.Sprintf("%s: %s", test.Name, test.Stats.AbortReason()))
+			failed[test.Name] = "aborted: " + test.Stats.AbortReason()
+		case test.Error != nil:
+			failed[test.Name] = test.Error.Error()
+		case test.Stats == nil:
+			failed[test.Name] = "no results"
+		default:
+			entries[test.Name] = baseline.FromResults("", test.Stats)
+		}
+	}
+	err = checkBaseline(args, entries, failed)
+	if len(aborted) > 0 {
+		return &abortedError{reason: strings.Join(aborted, "; ")}
+	}
+	return err
 }
 
 // runMockServer 启动 mock HTTP 服务器
@@ -510,8 +960,42 @@ func runMockServer(args *Args) error {
 	return server.Start()
 }
 
+// exitAborted 是运行被熔断条件中止时的退出码，和普通错误（1）区分开
+const exitAborted = 3
+
+// abortedError 表示运行被熔断条件提前中止
+type abortedError struct {
+	reason string
+}
+
+func (e *abortedError) Error() string {
+	return "run aborted: " + e.reason
+}
+
+// exitOnError 打印错误并退出，熔断中止使用 exitAborted 退出码
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	var aborted *abortedError
+	if errors.As(err, &aborted) {
+		os.Exit(exitAborted)
+	}
+	os.Exit(1)
+}
+
 // Execute 执行命令行程序
 func main() {
+	// history 子命令单独解析
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		if err := runHistory(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	args := &Args{}
 
 	if err := clop.Bind(args); err != nil {
@@ -543,6 +1027,23 @@ func main() {
 	// 检查是否启动API服务器
 	if args.APIServer {
 		apiServer := api.NewHTTPServer(args.APIPort)
+		apiServer.SetTaskRetention(args.APITaskTTL, args.APIMaxTasks)
+		apiServer.SetConcurrency(args.APIMaxConcurrent, args.APIExclusiveTargets)
+		// 回调签名密钥从环境变量读取，避免出现在进程参数中
+		apiServer.SetWebhookSecret(os.Getenv(api.WebhookSecretEnv))
+		if args.APIAuth != "" {
+			if err := apiServer.SetAccess(args.APIAuth); err != nil {
+				fmt.Fprintf(os.Stderr, "API server error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		// 任务和运行历史保存在同一个历史库中，--no-history 时任务只保存在内存中
+		if !args.NoHistory {
+			if err := apiServer.SetHistoryDB(args.HistoryDB); err != nil {
+				fmt.Fprintf(os.Stderr, "API server error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
@@ -572,6 +1073,20 @@ func main() {
 		return
 	}
 
+	if args.MetricsAddr != "" {
+		stopMetrics, err := startMetricsServer(args.MetricsAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer stopMetrics()
+	}
+
+	if err := setupProgress(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// 检查是否为 compare 模式（优先于批量/基准测试）
 	if args.CompareConfig != "" {
 		if args.ScheduleCron != "" {
@@ -596,10 +1111,7 @@ func main() {
 				os.Exit(1)
 			}
 		} else {
-			if err := runBatchTest(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
-			}
+			exitOnError(runBatchTest(args))
 		}
 	} else {
 		// 执行基准测试模式
@@ -609,10 +1121,7 @@ func main() {
 				os.Exit(1)
 			}
 		} else {
-			if err := runBenchmark(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
-			}
+			exitOnError(runBenchmark(args))
 		}
 	}
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/antlabs/gurl/internal/history"
+	"github.com/antlabs/gurl/internal/report"
+	"github.com/guonaihong/clop"
+)
+
+// HistoryArgs 定义 gurl history 子命令的参数
+type HistoryArgs struct {
+	DB         string   `clop:"--history-db" usage:"Path of the local run history database" default:".gurl/history.db"`
+	Last       int      `clop:"-n;--last" usage:"Number of runs to list or include in a trend" default:"20"`
+	Kind       string   `clop:"--kind" usage:"Only list runs of this kind: benchmark, batch, compare, api"`
+	Test       string   `clop:"--test" usage:"Only list runs that contain this test"`
+	JSON       bool     `clop:"--json" usage:"Print the full run record as JSON (show)"`
+	HTMLReport string   `clop:"--html-report" usage:"Regenerate the HTML report of a run (show)"`
+	Args       []string `clop:"args=command" usage:"list | show <id> | diff <id> <id> | trend <test>"`
+}
+
+const historyUsage = `Usage:
+  gurl history list [-n 20] [--kind batch] [--test name]
+  gurl history show <id> [--json] [--html-report file]
+  gurl history diff <id> <id>
+  gurl history trend <test> [-n 20]
+`
+
+// runHistory 处理 gurl history 子命令。clop 的 subcommand 与位置参数 URL 冲突，
+// 因此在 main 中按 os.Args[1] 分发
+func runHistory(argv []string) error {
+	args := &HistoryArgs{}
+	if err := clop.New(argv).SetProcName("gurl history").Bind(args); err != nil {
+		return err
+	}
+	if len(args.Args) == 0 {
+		fmt.Print(historyUsage)
+		return nil
+	}
+
+	store, err := history.Open(args.DB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch cmd, params := args.Args[0], args.Args[1:]; cmd {
+	case "list", "ls":
+		records, err := store.List(history.Filter{Kind: args.Kind, Test: args.Test, Limit: args.Last})
+		if err != nil {
+			return err
+		}
+		history.PrintList(os.Stdout, records)
+
+	case "show":
+		if len(params) != 1 {
+			return fmt.Errorf("usage: gurl history show <id>")
+		}
+		rec, err := getHistoryRecord(store, params[0])
+		if err != nil {
+			return err
+		}
+		if args.JSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rec)
+		}
+		history.PrintRecord(os.Stdout, rec)
+		if args.HTMLReport != "" && rec.Report != nil {
+			return writeHTMLReport(args.HTMLReport, rec.Report)
+		}
+
+	case "diff":
+		if len(params) != 2 {
+			return fmt.Errorf("usage: gurl history diff <id> <id>")
+		}
+		a, err := getHistoryRecord(store, params[0])
+		if err != nil {
+			return err
+		}
+		b, err := getHistoryRecord(store, params[1])
+		if err != nil {
+			return err
+		}
+		history.PrintDiff(os.Stdout, a, b)
+
+	case "trend":
+		if len(params) != 1 {
+			return fmt.Errorf("usage: gurl history trend <test>")
+		}
+		points, err := store.Trend(params[0], args.Last)
+		if err != nil {
+			return err
+		}
+		history.PrintTrend(os.Stdout, params[0], points)
+
+	default:
+		fmt.Print(historyUsage)
+		return fmt.Errorf("unknown history command: %s", cmd)
+	}
+	return nil
+}
+
+// getHistoryRecord 按 ID 读取一条历史记录
+func getHistoryRecord(store *history.Store, id string) (*history.Record, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run id: %s", id)
+	}
+	rec, err := store.Get(n)
+	if err != nil {
+		return nil, fmt.Errorf("run #%d: %w", n, err)
+	}
+	return rec, nil
+}
+
+// recordHistory 把一次运行写入本地历史库，写入失败只打印警告，不影响运行结果
+func recordHistory(args *Args, rep *report.Report) {
+	if args.NoHistory || rep == nil {
+		return
+	}
+
+	store, err := history.Open(args.HistoryDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		return
+	}
+	defer store.Close()
+
+	rec := &history.Record{
+		Kind:      rep.Kind,
+		Title:     rep.Title,
+		CreatedAt: rep.CreatedAt,
+		Status:    history.StatusOf(rep),
+		Git:       history.DetectGit(),
+		Report:    rep,
+	}
+	if err := store.Add(rec); err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		return
+	}
+	fmt.Printf("Recorded as run #%d in %s\n", rec.ID, args.HistoryDB)
+}
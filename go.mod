@@ -4,7 +4,6 @@ go 1.24.1
 
 require (
 	github.com/antlabs/cronex v0.0.5
-	github.com/antlabs/httparser v0.0.11
 	github.com/antlabs/pcurl v0.0.11
 	github.com/antlabs/pulse v0.0.0-20250727044511-e31dbf68d422
 	github.com/gizak/termui/v3 v3.1.0
@@ -12,6 +11,7 @@ require (
 	github.com/guonaihong/clop v0.2.12
 	github.com/mark3labs/mcp-go v0.43.1
 	github.com/tidwall/gjson v1.18.0
+	go.etcd.io/bbolt v1.4.3
 	go.uber.org/ratelimit v0.3.1
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,438 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+// apiRole orders the roles of config.APIAccessConfig, higher roles are
+// allowed everything lower ones are
+type apiRole int
+
+const (
+	roleRead apiRole = iota + 1
+	roleSubmit
+	roleAdmin
+)
+
+var roleNames = map[string]apiRole{
+	config.APIRoleRead:   roleRead,
+	config.APIRoleSubmit: roleSubmit,
+	config.APIRoleAdmin:  roleAdmin,
+}
+
+func (r apiRole) String() string {
+	for name, role := range roleNames {
+		if role == r {
+			return name
+		}
+	}
+	return "none"
+}
+
+// targetLookupTimeout bounds the DNS lookup of hosts checked against CIDRs
+const targetLookupTimeout = 2 * time.Second
+
+// apiKey is a key of the access configuration, ready to check requests
+type apiKey struct {
+	name    string
+	secret  []byte
+	role    apiRole
+	limiter *submitLimiter // nil without a rate limit
+	hosts   []string       // exact host names and *.suffix wildcards
+	nets    []*net.IPNet
+}
+
+// accessControl authenticates the requests of the API server
+type accessControl struct {
+	keys  []*apiKey
+	audit *auditLog
+}
+
+// newAccessControl builds the access control of a validated configuration
+func newAccessControl(cfg *config.APIAccessConfig) (*accessControl, error) {
+	audit, err := openAuditLog(cfg.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+
+	ac := &accessControl{audit: audit}
+	for _, k := range cfg.Keys {
+		key := &apiKey{name: k.Name, secret: []byte(k.Key), role: roleNames[k.Role]}
+		if k.RateLimit != "" {
+			count, per, err := config.ParseRateLimit(k.RateLimit)
+			if err != nil {
+				audit.Close()
+				return nil, err
+			}
+			key.limiter = newSubmitLimiter(count, per)
+		}
+		for _, t := range k.Targets {
+			if _, ipNet, err := net.ParseCIDR(t); err == nil {
+				key.nets = append(key.nets, ipNet)
+				continue
+			}
+			key.hosts = append(key.hosts, strings.ToLower(t))
+		}
+		ac.keys = append(ac.keys, key)
+	}
+	return ac, nil
+}
+
+// authenticate returns the key of a request, from the Authorization or
+// X-API-Key header, or the api_key query parameter used by EventSource and
+// WebSocket clients that can't set headers. It returns nil for no or an
+// unknown key.
+func (ac *accessControl) authenticate(r *http.Request) *apiKey {
+	secret := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); secret == "" && auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			secret = strings.TrimSpace(token)
+		}
+	}
+	if secret == "" {
+		secret = r.URL.Query().Get("api_key")
+	}
+	if secret == "" {
+		return nil
+	}
+
+	for _, key := range ac.keys {
+		if subtle.ConstantTimeCompare(key.secret, []byte(secret)) == 1 {
+			return key
+		}
+	}
+	return nil
+}
+
+// allowsTarget reports whether the key may load host. Hosts checked against
+// CIDRs must resolve only to addresses inside them.
+func (k *apiKey) allowsTarget(ctx context.Context, host string) bool {
+	if len(k.hosts) == 0 && len(k.nets) == 0 {
+		return true
+	}
+
+	if k.matchesHost(host) {
+		return true
+	}
+	if len(k.nets) == 0 {
+		return false
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, targetLookupTimeout)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return false
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		inside := false
+		for _, n := range k.nets {
+			if n.Contains(ip) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesHost reports whether host matches a host name rule of the key
+func (k *apiKey) matchesHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range k.hosts {
+		if h == host || (strings.HasPrefix(h, "*.") && strings.HasSuffix(host, h[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialGuard holds every connection of the tasks of the key to its target
+// allowlist, including the connections of redirects and the addresses of
+// later DNS lookups: the host dialed must match a host rule, or the address
+// it resolved to must be inside a CIDR. It is nil for keys without targets.
+func (k *apiKey) dialGuard() config.DialGuard {
+	if len(k.hosts) == 0 && len(k.nets) == 0 {
+		return nil
+	}
+	return func(host string, ip net.IP) error {
+		if k.matchesHost(host) {
+			return nil
+		}
+		for _, n := range k.nets {
+			if ip != nil && n.Contains(ip) {
+				return nil
+			}
+		}
+		return fmt.Errorf("target %s (%s) is not allowed for key %s", host, ip, k.name)
+	}
+}
+
+// submitLimiter is a token bucket limiting the task submissions of a key
+type submitLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	burst  float64
+	perSec float64
+	last   time.Time
+}
+
+// newSubmitLimiter allows count submissions per period, all of them at once
+// when the bucket is full
+func newSubmitLimiter(count int, per time.Duration) *submitLimiter {
+	return &submitLimiter{
+		tokens: float64(count),
+		burst:  float64(count),
+		perSec: float64(count) / per.Seconds(),
+		last:   time.Now(),
+	}
+}
+
+// allow takes a token, or returns how long until one is available
+func (l *submitLimiter) allow(now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.perSec)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	wait := (1 - l.tokens) / l.perSec
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// auditEntry is a line of the audit log
+type auditEntry struct {
+	Time   time.Time `json:"time"`
+	Key    string    `json:"key,omitempty"`
+	Remote string    `json:"remote"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Status int       `json:"status"`
+	Reason string    `json:"reason"`
+}
+
+// auditLog appends rejected requests to a file as JSON lines
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  io.Closer
+}
+
+// openAuditLog opens the audit log at path for appending, or logs to stderr
+// when path is empty
+func openAuditLog(path string) (*auditLog, error) {
+	if path == "" {
+		return &auditLog{w: os.Stderr}, nil
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create audit log directory: %v", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	return &auditLog{w: f, c: f}, nil
+}
+
+func (l *auditLog) write(e auditEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(append(data, '\n')); err != nil {
+		fmt.Printf("audit log: %v\n", err)
+	}
+}
+
+// Close closes the audit log file
+func (l *auditLog) Close() error {
+	if l.c == nil {
+		return nil
+	}
+	return l.c.Close()
+}
+
+type apiKeyContextKey struct{}
+
+// requestKey returns the key that authenticated a request, nil when access
+// control is disabled
+func requestKey(r *http.Request) *apiKey {
+	key, _ := r.Context().Value(apiKeyContextKey{}).(*apiKey)
+	return key
+}
+
+// requestOwner is the owner recorded in the config of the tasks a request
+// submits, empty when access control is disabled
+func requestOwner(r *http.Request) string {
+	if key := requestKey(r); key != nil {
+		return key.name
+	}
+	return ""
+}
+
+// publicRoute reports whether a path is served without a key: the API
+// information, the OpenAPI document, the health check and the dashboard
+// pages, which ask for a key
+func publicRoute(path string) bool {
+	return path == "/" || path == "/health" || path == "/api/v1/openapi.json" || strings.HasPrefix(path, "/ui/")
+}
+
+// requiredRole is the role needed for a request: reads need read, anything
+// changing tasks needs submit
+func requiredRole(r *http.Request) apiRole {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return roleRead
+	}
+	return roleSubmit
+}
+
+// routeTaskID returns the task a request acts on, or ""
+func routeTaskID(path string) string {
+	for _, prefix := range []string{"/api/v1/tasks/", "/api/v1/control/"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			id, _, _ := strings.Cut(rest, "/")
+			return id
+		}
+	}
+	return ""
+}
+
+// authorize wraps the routes of the server with access control. Without an
+// access configuration every request is allowed.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.access == nil || publicRoute(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := s.access.authenticate(r)
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gurl"`)
+			s.reject(w, r, nil, http.StatusUnauthorized, "missing or unknown API key")
+			return
+		}
+		if need := requiredRole(r); key.role < need {
+			s.reject(w, r, key, http.StatusForbidden, fmt.Sprintf("role %s may not %s %s", key.role, r.Method, r.URL.Path))
+			return
+		}
+
+		// Submitters only act on their own tasks, admins on any
+		if id := routeTaskID(r.URL.Path); id != "" && requiredRole(r) == roleSubmit && key.role < roleAdmin {
+			if task, exists := s.taskManager.GetTask(id); exists {
+				if owner, _ := task.Config["owner"].(string); owner != key.name {
+					s.reject(w, r, key, http.StatusForbidden, fmt.Sprintf("task %s belongs to another key", id))
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// allowSubmit checks a task submission against the target allowlist and the
+// rate limit of its key, answering the request when it is rejected
+func (s *Server) allowSubmit(w http.ResponseWriter, r *http.Request, targets []string) bool {
+	key := requestKey(r)
+	if key == nil {
+		return true
+	}
+
+	for _, host := range targets {
+		if !key.allowsTarget(r.Context(), host) {
+			s.reject(w, r, key, http.StatusForbidden, fmt.Sprintf("target %s is not allowed for key %s", host, key.name))
+			return false
+		}
+	}
+	if key.limiter != nil {
+		if ok, wait := key.limiter.allow(time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			s.reject(w, r, key, http.StatusTooManyRequests, fmt.Sprintf("submission rate limit of key %s exceeded", key.name))
+			return false
+		}
+	}
+	return true
+}
+
+// allowServerFiles rejects a submission naming files on the server, such as
+// curl_file and cookie_file, unless its key is an admin: reading them is not
+// bounded by the target allowlist. Without access control the server trusts
+// every request, as authorize does.
+func (s *Server) allowServerFiles(w http.ResponseWriter, r *http.Request, files []string) bool {
+	key := requestKey(r)
+	if key == nil || key.role >= roleAdmin {
+		return true
+	}
+	for _, file := range files {
+		if file != "" {
+			s.reject(w, r, key, http.StatusForbidden, fmt.Sprintf("role %s may not read server file %s", key.role, file))
+			return false
+		}
+	}
+	return true
+}
+
+// requestDialGuard returns the guard holding the tasks of a request to the
+// target allowlist of its key, nil when there is none
+func requestDialGuard(r *http.Request) config.DialGuard {
+	if key := requestKey(r); key != nil {
+		return key.dialGuard()
+	}
+	return nil
+}
+
+// reject answers a request refused by access control and records it in the
+// audit log
+func (s *Server) reject(w http.ResponseWriter, r *http.Request, key *apiKey, status int, reason string) {
+	entry := auditEntry{
+		Time:   time.Now(),
+		Remote: r.RemoteAddr,
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Status: status,
+		Reason: reason,
+	}
+	if key != nil {
+		entry.Key = key.name
+	}
+	s.access.audit.write(entry)
+	writeError(w, status, reason)
+}
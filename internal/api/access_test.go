@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+func TestAccessControl(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	access, err := newAccessControl(&config.APIAccessConfig{
+		AuditLog: auditPath,
+		Keys: []config.APIKeyConfig{
+			{Name: "reader", Key: "r-key", Role: config.APIRoleRead},
+			{Name: "ci", Key: "ci-key", Role: config.APIRoleSubmit, RateLimit: "2/m", Targets: []string{"127.0.0.0/8"}},
+			{Name: "other", Key: "other-key", Role: config.APIRoleSubmit},
+			{Name: "root", Key: "root-key", Role: config.APIRoleAdmin},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer access.audit.Close()
+
+	hs := NewHTTPServer(0)
+	hs.apiServer.access = access
+	srv := httptest.NewServer(hs.server.Handler)
+	defer srv.Close()
+
+	do := func(key, method, path, body string) (int, BenchmarkResponse) {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var accepted BenchmarkResponse
+		json.NewDecoder(resp.Body).Decode(&accepted)
+		return resp.StatusCode, accepted
+	}
+	submit := func(key, url string) (int, BenchmarkResponse) {
+		return do(key, http.MethodPost, "/api/v1/benchmark",
+			`{"url": "`+url+`", "duration": "30s", "connections": 1, "threads": 1, "use_nethttp": true}`)
+	}
+
+	if code, _ := do("", http.MethodGet, "/health", ""); code != http.StatusOK {
+		t.Errorf("health: expected 200 without a key, got %d", code)
+	}
+	if code, _ := do("", http.MethodGet, "/api/v1/tasks", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %d", code)
+	}
+	if code, _ := do("r-key", http.MethodGet, "/api/v1/tasks?api_key=ignored", ""); code != http.StatusOK {
+		t.Errorf("expected a read key to list tasks, got %d", code)
+	}
+	if code, _ := submit("r-key", target.URL); code != http.StatusForbidden {
+		t.Errorf("expected a read key not to submit, got %d", code)
+	}
+	if code, _ := submit("ci-key", "http://10.1.2.3/"); code != http.StatusForbidden {
+		t.Errorf("expected a target outside the allowlist to be rejected, got %d", code)
+	}
+
+	code, first := submit("ci-key", target.URL)
+	if code != http.StatusAccepted || first.Config["owner"] != "ci" {
+		t.Fatalf("expected the submission to be accepted and owned by ci, got %d %+v", code, first)
+	}
+	defer hs.apiServer.taskManager.CancelTask(first.TaskID)
+	code, second := submit("ci-key", target.URL)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected the second submission within the rate limit to be accepted, got %d", code)
+	}
+	defer hs.apiServer.taskManager.CancelTask(second.TaskID)
+	if code, _ := submit("ci-key", target.URL); code != http.StatusTooManyRequests {
+		t.Errorf("expected the third submission to be rate limited, got %d", code)
+	}
+
+	if code, _ := do("other-key", http.MethodPost, "/api/v1/tasks/"+second.TaskID+"/cancel", ""); code != http.StatusForbidden {
+		t.Errorf("expected a submitter not to cancel the task of another key, got %d", code)
+	}
+	if code, _ := do("root-key", http.MethodPost, "/api/v1/tasks/"+second.TaskID+"/cancel", ""); code != http.StatusAccepted {
+		t.Errorf("expected an admin to cancel any task, got %d", code)
+	}
+	if code, _ := do("ci-key", http.MethodDelete, "/api/v1/tasks/"+first.TaskID, ""); code != http.StatusAccepted {
+		t.Errorf("expected the owner to cancel its task, got %d", code)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []int
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e auditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid audit line %q: %v", line, err)
+		}
+		statuses = append(statuses, e.Status)
+	}
+	want := []int{401, 403, 403, 429, 403}
+	if len(statuses) != len(want) {
+		t.Fatalf("expected audit statuses %v, got %v", want, statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("expected audit statuses %v, got %v", want, statuses)
+		}
+	}
+}
+
+func TestAPIKeyAllowsTarget(t *testing.T) {
+	access, err := newAccessControl(&config.APIAccessConfig{Keys: []config.APIKeyConfig{
+		{Name: "k", Key: "k", Role: config.APIRoleSubmit, Targets: []string{"api.example.com", "*.staging.example.com", "10.0.0.0/8"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := access.keys[0]
+	for host, allowed := range map[string]bool{
+		"api.example.com":        true,
+		"API.example.com":        true,
+		"eu.staging.example.com": true,
+		"staging.example.com":    false,
+		"www.example.com":        false,
+		"10.20.30.40":            true,
+		"192.168.1.1":            false,
+	} {
+		if got := key.allowsTarget(context.Background(), host); got != allowed {
+			t.Errorf("%s: expected allowed=%v", host, allowed)
+		}
+	}
+}
+
+func TestSubmitLimiterRefills(t *testing.T) {
+	l := newSubmitLimiter(2, time.Minute)
+	now := l.last
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow(now); !ok {
+			t.Fatalf("expected submission %d within the burst", i+1)
+		}
+	}
+	ok, wait := l.allow(now)
+	if ok || wait != 30*time.Second {
+		t.Errorf("expected a 30s wait once the burst is used, got %v %v", ok, wait)
+	}
+	if ok, _ := l.allow(now.Add(30 * time.Second)); !ok {
+		t.Error("expected a token after 30s")
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	for spec, want := range map[string]time.Duration{"10/m": time.Minute, "5/s": time.Second, "1/h": time.Hour, "3/30s": 30 * time.Second} {
+		if _, per, err := config.ParseRateLimit(spec); err != nil || per != want {
+			t.Errorf("%s: got %v %v", spec, per, err)
+		}
+	}
+	for _, spec := range []string{"10", "0/m", "x/m", "10/week"} {
+		if _, _, err := config.ParseRateLimit(spec); err == nil {
+			t.Errorf("%s: expected an error", spec)
+		}
+	}
+}
+
+func TestAPIKeyDialGuard(t *testing.T) {
+	var target *httptest.Server
+	target = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// Same server, under a host name the key doesn't allow
+			http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1)+"/", http.StatusFound)
+		}
+	}))
+	defer target.Close()
+
+	access, err := newAccessControl(&config.APIAccessConfig{Keys: []config.APIKeyConfig{
+		{Name: "open", Key: "o", Role: config.APIRoleSubmit},
+		{Name: "local", Key: "l", Role: config.APIRoleSubmit, Targets: []string{"127.0.0.1"}},
+		{Name: "net", Key: "n", Role: config.APIRoleSubmit, Targets: []string{"10.0.0.0/8"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if access.keys[0].dialGuard() != nil {
+		t.Error("expected no guard for a key without targets")
+	}
+	netGuard := access.keys[2].dialGuard()
+	if netGuard("db.internal", net.ParseIP("10.1.2.3")) != nil || netGuard("db.internal", net.ParseIP("192.168.1.1")) == nil {
+		t.Error("expected the guard to check resolved addresses against the CIDRs")
+	}
+
+	guard := access.keys[1].dialGuard()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return guard.Dial(ctx, net.Dialer{}, network, addr)
+		},
+	}}
+	resp, err := client.Get(target.URL + "/")
+	if err != nil {
+		t.Fatalf("expected the allowed host to be dialed: %v", err)
+	}
+	resp.Body.Close()
+	if _, err := client.Get(target.URL + "/redirect"); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected the redirect to another host to be refused, got %v", err)
+	}
+}
+
+func TestSubmitChecksTargets(t *testing.T) {
+	access, err := newAccessControl(&config.APIAccessConfig{
+		AuditLog: filepath.Join(t.TempDir(), "audit.log"),
+		Keys: []config.APIKeyConfig{
+			{Name: "ci", Key: "ci-key", Role: config.APIRoleSubmit},
+			{Name: "root", Key: "root-key", Role: config.APIRoleAdmin},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer access.audit.Close()
+	s := NewServer()
+	s.access = access
+
+	post := func(key string, handler http.HandlerFunc, body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		for _, k := range access.keys {
+			if k.name == key {
+				r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, k))
+			}
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		return rec.Code
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	fileBatch := `{"tests": [{"name": "a", "curl_file": "` + missing + `"}]}`
+	if code := post("ci", s.handleBatch, fileBatch); code != http.StatusForbidden {
+		t.Errorf("expected curl_file to be refused to a submitter, got %d", code)
+	}
+	if code := post("ci", s.handleBatch, `{"tests": [{"name": "a", "curl": "curl http://127.0.0.1:1/", "cookie_file": "/etc/passwd"}]}`); code != http.StatusForbidden {
+		t.Errorf("expected cookie_file to be refused to a submitter, got %d", code)
+	}
+	if code := post("root", s.handleBatch, fileBatch); code != http.StatusBadRequest {
+		t.Errorf("expected an unreadable curl_file to be rejected, got %d", code)
+	}
+	compare := `{"scenario": "s", "config_text": "requests:\n  - name: a\n    curl: \"curl http://[::1/\"\ncompare:\n  - name: s\n    base: a\n    target: a\n"}`
+	if code := post("ci", s.handleCompare, compare); code != http.StatusBadRequest {
+		t.Errorf("expected an unparseable curl command to be rejected, got %d", code)
+	}
+}
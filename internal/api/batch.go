@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/batch"
+	"github.com/antlabs/gurl/internal/benchmark"
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/notify"
+	"github.com/antlabs/gurl/internal/parser"
+	"github.com/antlabs/gurl/internal/report"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+const (
+	batchModeConcurrent = "concurrent"
+	batchModeSequential = "sequential"
+
+	// defaultBatchConcurrency matches the --batch-concurrency default
+	defaultBatchConcurrency = 3
+)
+
+// BatchRequest represents a batch test request. Tests take the same fields as
+// the tests of a --batch-config file.
+type BatchRequest struct {
+	Tests       []BatchTestRequest     `json:"tests" schema:"required,minItems=1"`
+	Mode        string                 `json:"mode,omitempty" schema:"enum=concurrent|sequential"` // "concurrent" (default) or "sequential"
+	Concurrency int                    `json:"concurrency,omitempty" schema:"minimum=0"`           // max tests run at once in concurrent mode
+	Notifier    *config.NotifierConfig `json:"notifier,omitempty"`
+	Priority    int                    `json:"priority,omitempty"`
+	CallbackURL string                 `json:"callback_url,omitempty" schema:"format=uri"` // receives the results once the batch has finished
+}
+
+// BatchTestRequest represents a single test in a batch. Instead of a curl
+// command, the request may be given as url, method, headers and body.
+type BatchTestRequest struct {
+	config.BatchTest
+	URL         string            `json:"url,omitempty"`
+	Method      string            `json:"method,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+}
+
+// batchDefaults are the load settings of tests that don't set their own,
+// the same as the defaults of a benchmark request
+func batchDefaults() config.Config {
+	return config.Config{
+		Connections: 10,
+		Duration:    10 * time.Second,
+		Threads:     2,
+		Timeout:     30 * time.Second,
+	}
+}
+
+// toBatchTest converts the request to a batch test, building the request
+// from url, method, headers and body when no curl command is given
+func (t BatchTestRequest) toBatchTest() (config.BatchTest, error) {
+	test := t.BatchTest
+	if test.Curl != "" || test.CurlFile != "" || t.URL == "" {
+		return test, nil
+	}
+
+	targetURL := t.URL
+	if !strings.HasPrefix(targetURL, "http://") && !strings.HasPrefix(targetURL, "https://") {
+		targetURL = "http://" + targetURL
+	}
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return test, fmt.Errorf("invalid URL: %v", err)
+	}
+	if parsedURL.Hostname() == "" {
+		return test, fmt.Errorf("URL %s has no host", t.URL)
+	}
+
+	cfg := config.Config{
+		Method:      t.Method,
+		Body:        t.Body,
+		ContentType: t.ContentType,
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	for k, v := range t.Headers {
+		cfg.Headers = append(cfg.Headers, fmt.Sprintf("%s: %s", k, v))
+	}
+	if test.Request, err = parser.BuildRequest(cfg, parsedURL); err != nil {
+		return test, fmt.Errorf("failed to build request: %v", err)
+	}
+	return test, nil
+}
+
+// buildBatchConfig validates a batch request and converts it to the batch
+// configuration run by the executor
+func buildBatchConfig(req *BatchRequest) (*config.BatchConfig, error) {
+	switch req.Mode {
+	case "", batchModeConcurrent, batchModeSequential:
+	default:
+		return nil, fmt.Errorf("invalid mode %q (expected %s or %s)", req.Mode, batchModeConcurrent, batchModeSequential)
+	}
+	if req.Concurrency < 0 {
+		return nil, fmt.Errorf("concurrency cannot be negative")
+	}
+
+	batchConfig := &config.BatchConfig{
+		Version:  "1.0",
+		Tests:    make([]config.BatchTest, 0, len(req.Tests)),
+		Notifier: req.Notifier,
+	}
+	seen := make(map[string]bool, len(req.Tests))
+	defaults := batchDefaults()
+	for i, t := range req.Tests {
+		test, err := t.toBatchTest()
+		if err != nil {
+			return nil, fmt.Errorf("test[%d] (%s): %v", i, t.Name, err)
+		}
+		if seen[test.Name] {
+			return nil, fmt.Errorf("test[%d]: duplicate name %q", i, test.Name)
+		}
+		seen[test.Name] = true
+		batchConfig.Tests = append(batchConfig.Tests, test)
+
+		if _, err := test.ToConfig(&defaults); err != nil {
+			return nil, err
+		}
+		if test.Curl != "" {
+			if _, err := parser.ParseCurl(test.Curl); err != nil {
+				return nil, fmt.Errorf("test[%d] (%s): failed to parse curl command: %v", i, test.Name, err)
+			}
+		}
+	}
+	if err := batchConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return batchConfig, nil
+}
+
+// handleBatch handles POST /api/v1/batch
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req BatchRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	batchConfig, err := buildBatchConfig(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid batch request: %v", err))
+		return
+	}
+
+	var callback *url.URL
+	if req.CallbackURL != "" {
+		if callback, err = s.parseCallbackURL(req.CallbackURL); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = batchModeConcurrent
+	}
+	concurrency := req.Concurrency
+	if concurrency == 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var curls, curlFiles, files []string
+	var built []*http.Request
+	for _, test := range batchConfig.Tests {
+		curls = append(curls, test.Curl)
+		curlFiles = append(curlFiles, test.CurlFile)
+		files = append(files, test.CurlFile, test.CookieFile)
+		if test.Request != nil {
+			built = append(built, test.Request)
+		}
+	}
+	if !s.allowServerFiles(w, r, files) {
+		return
+	}
+	targets, err := curlHosts(curls, curlFiles, built...)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid batch request: %v", err))
+		return
+	}
+	if !s.allowSubmit(w, r, withCallbackHost(targets, callback)) {
+		return
+	}
+	guard := requestDialGuard(r)
+
+	// Create batch task
+	taskID := generateTaskID()
+	configMap := map[string]interface{}{
+		"type":  report.KindBatch,
+		"tests": len(batchConfig.Tests),
+		"mode":  mode,
+	}
+	if mode == batchModeConcurrent {
+		configMap["concurrency"] = concurrency
+	}
+	if req.Priority != 0 {
+		configMap["priority"] = req.Priority
+	}
+	if owner := requestOwner(r); owner != "" {
+		configMap["owner"] = owner
+	}
+
+	s.taskManager.CreateTask(taskID, configMap)
+	s.watchTask(taskID, callback, guard, configMap)
+
+	executor := batch.NewExecutor(concurrency, false)
+	executor.SetObserverFactory(func(testName string) ([]benchmark.Observer, error) {
+		return []benchmark.Observer{s.metrics.Collector(map[string]string{"task": taskID, "test": testName})}, nil
+	})
+
+	opts := RunOptions{Priority: req.Priority, Targets: targets}
+
+	// Run batch tests asynchronously with a new context (not tied to HTTP request)
+	s.taskManager.RunTaskWithOptions(context.Background(), taskID, opts, func(ctx context.Context) (*stats.Results, error) {
+		defaults := batchDefaults()
+		defaults.DialGuard = guard
+		var result *batch.BatchResult
+		var err error
+		if mode == batchModeSequential {
+			result, err = executor.ExecuteSequential(ctx, batchConfig, &defaults)
+		} else {
+			result, err = executor.Execute(ctx, batchConfig, &defaults)
+		}
+		if err != nil {
+			s.recordTask(taskID, nil, err)
+			return nil, err
+		}
+
+		reporter := batch.NewReporter(false)
+		if perTest := reporter.GenerateJSONReport(result); json.Valid([]byte(perTest)) {
+			s.taskManager.SetTaskBatch(taskID, json.RawMessage(perTest))
+		}
+		rep := report.FromBatch(result)
+		s.taskManager.SetTaskReport(taskID, rep)
+
+		if n := notify.NewFromConfig(batchConfig.Notifier); n != nil {
+			if err := n.NotifyBatch(result, reporter.GenerateReport(result)); err != nil {
+				fmt.Printf("batch notifier: %v\n", err)
+			}
+		}
+
+		// Like the CLI, a batch only fails when a test was aborted; failed
+		// tests and asserts are reported per test
+		var aborted []string
+		for _, test := range result.Tests {
+			if test.Stats != nil && test.Stats.AbortReason() != "" {
+				aborted = append(aborted, fmt.Sprintf("%s: %s", test.Name, test.Stats.AbortReason()))
+			}
+		}
+		if len(aborted) > 0 {
+			err = fmt.Errorf("aborted: %s", strings.Join(aborted, "; "))
+		}
+		s.recordTask(taskID, rep, err)
+		return nil, err
+	})
+
+	s.writeAccepted(w, taskID, "Batch test task", configMap)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+func TestBatchTestRequestBuildsRequest(t *testing.T) {
+	req := BatchTestRequest{
+		BatchTest:   config.BatchTest{Name: "create"},
+		URL:         "example.com/users",
+		Method:      "post",
+		Headers:     map[string]string{"X-Note": `it's "quoted"`},
+		Body:        `{"name":"it's \"gurl\""}`,
+		ContentType: "application/json",
+	}
+	test, err := req.toBatchTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if test.Curl != "" || test.Request == nil {
+		t.Fatalf("expected a built request, got curl %q", test.Curl)
+	}
+	if test.Request.Method != http.MethodPost || test.Request.URL.String() != "http://example.com/users" {
+		t.Errorf("unexpected request line: %s %s", test.Request.Method, test.Request.URL)
+	}
+	if got := test.Request.Header.Get("X-Note"); got != `it's "quoted"` {
+		t.Errorf("unexpected header: %q", got)
+	}
+	if got := test.Request.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("unexpected content type: %q", got)
+	}
+	body, _ := io.ReadAll(test.Request.Body)
+	if string(body) != req.Body {
+		t.Errorf("unexpected body: %s", body)
+	}
+
+	req.URL = "http://"
+	if _, err := req.toBatchTest(); err == nil {
+		t.Error("expected an error for a URL without a host")
+	}
+}
+
+func TestBuildBatchConfigValidates(t *testing.T) {
+	for name, req := range map[string]BatchRequest{
+		"mode":      {Mode: "parallel", Tests: []BatchTestRequest{{BatchTest: config.BatchTest{Name: "a", Curl: "curl http://a"}}}},
+		"no curl":   {Tests: []BatchTestRequest{{BatchTest: config.BatchTest{Name: "a"}}}},
+		"duration":  {Tests: []BatchTestRequest{{BatchTest: config.BatchTest{Name: "a", Curl: "curl http://a", Duration: "soon"}}}},
+		"duplicate": {Tests: []BatchTestRequest{{BatchTest: config.BatchTest{Name: "a", Curl: "curl http://a"}}, {BatchTest: config.BatchTest{Name: "a", Curl: "curl http://b"}}}},
+	} {
+		if _, err := buildBatchConfig(&req); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestBatchTaskReturnsPerTestResults(t *testing.T) {
+	var posted atomic.Value
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/b" {
+			body, _ := io.ReadAll(r.Body)
+			posted.Store(string(body))
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer target.Close()
+
+	s := NewServer()
+	body := `{"mode": "sequential", "tests": [
+		{"name": "curl", "curl": "curl ` + target.URL + `/a", "requests": 20, "connections": 2, "threads": 1, "duration": "5s", "use_nethttp": true},
+		{"name": "url", "url": "` + target.URL + `/b", "method": "POST", "body": "it's \"both\"", "requests": 20, "connections": 2, "threads": 1, "duration": "5s", "use_nethttp": true,
+		 "asserts": "status == 201"}
+	]}`
+	rec := httptest.NewRecorder()
+	s.handleBatch(rec, httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body)
+	}
+	var accepted BenchmarkResponse
+	json.NewDecoder(rec.Body).Decode(&accepted)
+
+	var response *TaskResultsResponse
+	for i := 0; i < 200; i++ {
+		response, _ = s.taskResults(accepted.TaskID)
+		if response.Status == string(TaskStatusCompleted) || response.Status == string(TaskStatusFailed) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if response.Status != string(TaskStatusCompleted) {
+		t.Fatalf("expected the batch to complete, got %s (%s)", response.Status, response.Error)
+	}
+
+	var perTest struct {
+		TotalTests int `json:"total_tests"`
+		Tests      []struct {
+			Name     string `json:"name"`
+			Requests int64  `json:"requests"`
+			Errors   int    `json:"errors"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(response.Batch, &perTest); err != nil {
+		t.Fatalf("invalid batch results %s: %v", response.Batch, err)
+	}
+	if perTest.TotalTests != 2 || perTest.Tests[0].Name != "curl" || perTest.Tests[1].Name != "url" {
+		t.Fatalf("unexpected batch results: %s", response.Batch)
+	}
+	if perTest.Tests[0].Requests == 0 || perTest.Tests[0].Errors != 0 {
+		t.Errorf("expected the first test to pass: %+v", perTest.Tests[0])
+	}
+	if perTest.Tests[1].Errors == 0 {
+		t.Errorf("expected the assert of the second test to fail: %+v", perTest.Tests[1])
+	}
+	if got, _ := posted.Load().(string); got != `it's "both"` {
+		t.Errorf("unexpected body sent by the url test: %q", got)
+	}
+}
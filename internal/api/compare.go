@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/antlabs/gurl/internal/compare"
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/report"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// CompareTaskRequest represents a compare request. The configuration is the
+// same as the --compare-config file, given either as an object or as YAML or
+// JSON text.
+type CompareTaskRequest struct {
+	Scenario    string                `json:"scenario" schema:"required"`
+	Config      *config.CompareConfig `json:"config,omitempty"`
+	ConfigText  string                `json:"config_text,omitempty"`
+	Priority    int                   `json:"priority,omitempty"`
+	CallbackURL string                `json:"callback_url,omitempty" schema:"format=uri"` // receives the results once the task has finished
+}
+
+// handleCompare handles POST /api/v1/compare
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req CompareTaskRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	cmpCfg := req.Config
+	if req.ConfigText != "" {
+		var err error
+		if cmpCfg, err = config.ParseCompareConfig([]byte(req.ConfigText)); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if cmpCfg == nil {
+		writeError(w, http.StatusBadRequest, "Either 'config' or 'config_text' must be provided")
+		return
+	}
+	var scenario *config.CompareScenario
+	for i := range cmpCfg.Scenarios {
+		if cmpCfg.Scenarios[i].Name == req.Scenario {
+			scenario = &cmpCfg.Scenarios[i]
+			break
+		}
+	}
+	if scenario == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Scenario '%s' not found in compare config", req.Scenario))
+		return
+	}
+	if err := config.ValidateCookieJar(scenario.CookieJar); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var callback *url.URL
+	if req.CallbackURL != "" {
+		var err error
+		if callback, err = s.parseCallbackURL(req.CallbackURL); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var curls []string
+	for _, r := range cmpCfg.Requests {
+		curls = append(curls, r.Curl)
+	}
+	for _, set := range cmpCfg.RequestSets {
+		for _, r := range set {
+			curls = append(curls, r.Curl)
+		}
+	}
+	if !s.allowServerFiles(w, r, []string{scenario.CookieFile}) {
+		return
+	}
+	targets, err := curlHosts(curls, nil)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !s.allowSubmit(w, r, withCallbackHost(targets, callback)) {
+		return
+	}
+	cmpCfg.DialGuard = requestDialGuard(r)
+
+	taskID := generateTaskID()
+	configMap := map[string]interface{}{
+		"type":     report.KindCompare,
+		"scenario": scenario.Name,
+		"mode":     scenario.Mode,
+	}
+	if req.Priority != 0 {
+		configMap["priority"] = req.Priority
+	}
+	if owner := requestOwner(r); owner != "" {
+		configMap["owner"] = owner
+	}
+	s.taskManager.CreateTask(taskID, configMap)
+	s.watchTask(taskID, callback, cmpCfg.DialGuard, configMap)
+	opts := RunOptions{Priority: req.Priority, Targets: targets}
+
+	s.taskManager.RunTaskWithOptions(context.Background(), taskID, opts, func(ctx context.Context) (*stats.Results, error) {
+		results, passed, failed, err := compare.RunScenario(cmpCfg, scenario.Name)
+		var rep *report.Report
+		if err == nil {
+			rep = report.FromCompare(scenario, results, passed, failed)
+			s.taskManager.SetTaskReport(taskID, rep)
+		}
+		s.recordTask(taskID, rep, err)
+		if err != nil {
+			return nil, err
+		}
+		if failed > 0 {
+			return nil, fmt.Errorf("compare scenario failed: %d of %d assertions failed", failed, passed+failed)
+		}
+		return nil, nil
+	})
+
+	s.writeAccepted(w, taskID, "Compare task", configMap)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/antlabs/gurl/internal/benchmark"
+)
+
+// controlRegistry keeps the runtime controllers of running benchmark tasks
+type controlRegistry struct {
+	mu       sync.Mutex
+	controls map[string]*benchmark.Controller
+}
+
+func newControlRegistry() *controlRegistry {
+	return &controlRegistry{controls: make(map[string]*benchmark.Controller)}
+}
+
+func (r *controlRegistry) add(taskID string, c *benchmark.Controller) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.controls[taskID] = c
+}
+
+func (r *controlRegistry) remove(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.controls, taskID)
+}
+
+func (r *controlRegistry) get(taskID string) (*benchmark.Controller, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.controls[taskID]
+	return c, ok
+}
+
+// ControlRequest is the body of POST /api/v1/control/:id/:action: rate and
+// connections take a value or a step, annotate takes a label
+type ControlRequest struct {
+	Value *int   `json:"value,omitempty" schema:"minimum=0"` // a rate of 0 is unlimited, connections need at least 1
+	Step  string `json:"step,omitempty" schema:"enum=up|down"`
+	Label string `json:"label,omitempty"`
+}
+
+// apply sets the value of the request, or steps it up or down
+func (req *ControlRequest) apply(set func(int) error, step func(up bool) error) error {
+	switch {
+	case req.Value != nil:
+		return set(*req.Value)
+	case req.Step != "":
+		return step(req.Step == "up")
+	default:
+		return fmt.Errorf("value or step is required")
+	}
+}
+
+// handleControl handles /api/v1/control/:id[/action]. GET returns the current
+// load parameters of a running benchmark, POST pauses, resumes or adjusts it.
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/control/")
+	taskID, action, _ := strings.Cut(rest, "/")
+	if taskID == "" {
+		writeError(w, http.StatusBadRequest, "Task ID required")
+		return
+	}
+
+	control, ok := s.controls.get(taskID)
+	if !ok {
+		if _, exists := s.taskManager.GetTask(taskID); exists {
+			writeError(w, http.StatusConflict, "Task is not running")
+			return
+		}
+		writeError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if action == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		writeControlState(w, control)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// pause and resume take no body
+	var req ControlRequest
+	switch action {
+	case "pause", "resume":
+	case "rate", "connections", "annotate":
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown control action %q", action))
+		return
+	}
+
+	var err error
+	switch action {
+	case "pause":
+		control.Pause()
+	case "resume":
+		control.Resume()
+	case "rate":
+		err = req.apply(control.SetRate, control.StepRate)
+	case "connections":
+		if req.Value != nil && *req.Value < 1 {
+			writeValidationError(w, []FieldError{{Field: "value", Message: fmt.Sprintf("must be at least 1 for connections, got %d", *req.Value)}})
+			return
+		}
+		err = req.apply(control.SetConnections, control.StepConnections)
+	case "annotate":
+		if strings.TrimSpace(req.Label) == "" {
+			writeValidationError(w, []FieldError{{Field: "label", Message: "is required"}})
+			return
+		}
+		control.Annotate(req.Label)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeControlState(w, control)
+}
+
+func writeControlState(w http.ResponseWriter, control *benchmark.Controller) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(control.State())
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/report"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// dashboardFiles holds the single-page web dashboard served under /ui/. The
+// charts and run sections are drawn by the HTML report scripts, served from
+// report.Assets under /ui/assets/.
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// TaskReportResponse is returned by GET /api/v1/report/:id. Live is set when
+// the report is a snapshot of a task that is still running.
+type TaskReportResponse struct {
+	ID            string         `json:"id"`
+	Status        string         `json:"status"`
+	Error         string         `json:"error,omitempty"`
+	QueuePosition int            `json:"queue_position,omitempty"`
+	Live          bool           `json:"live"`
+	Report        *report.Report `json:"report"`
+}
+
+// liveRun keeps the latest sample of a running benchmark so the dashboard can
+// chart it before the task finishes
+type liveRun struct {
+	target string
+	cfg    config.Config
+
+	mu      sync.Mutex
+	results *stats.Results
+	elapsed time.Duration
+}
+
+// OnSample implements benchmark.Observer
+func (l *liveRun) OnSample(results *stats.Results, sample stats.Sample) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.results = results
+	l.elapsed = sample.Elapsed
+}
+
+// OnFinish implements benchmark.Observer; the final report replaces the
+// live one
+func (l *liveRun) OnFinish(results *stats.Results) {}
+
+// report returns a snapshot of the run, nil before the first sample (e.g.
+// during warm-up)
+func (l *liveRun) report() *report.Report {
+	l.mu.Lock()
+	results, elapsed := l.results, l.elapsed
+	l.mu.Unlock()
+	if results == nil {
+		return nil
+	}
+	return report.FromLive(l.target, results, l.cfg, elapsed)
+}
+
+// liveRegistry keeps the live runs of running benchmark tasks
+type liveRegistry struct {
+	mu   sync.Mutex
+	runs map[string]*liveRun
+}
+
+func newLiveRegistry() *liveRegistry {
+	return &liveRegistry{runs: make(map[string]*liveRun)}
+}
+
+// add registers a live run for the task and returns it to be added as an
+// observer of the benchmark
+func (r *liveRegistry) add(taskID, target string, cfg config.Config) *liveRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := &liveRun{target: target, cfg: cfg}
+	r.runs[taskID] = run
+	return run
+}
+
+func (r *liveRegistry) remove(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.runs, taskID)
+}
+
+func (r *liveRegistry) get(taskID string) (*liveRun, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[taskID]
+	return run, ok
+}
+
+// dashboardHandler serves the dashboard page and the report assets it uses
+func dashboardHandler() http.Handler {
+	page, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	assets, err := fs.Sub(report.Assets, "assets")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ui/assets/", http.StripPrefix("/ui/assets/", http.FileServerFS(assets)))
+	mux.Handle("/ui/", http.StripPrefix("/ui/", http.FileServerFS(page)))
+	return mux
+}
+
+// handleReport handles GET /api/v1/report/:id, the chartable report of a
+// task. Running benchmarks return a snapshot of the data so far.
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	taskID := strings.TrimPrefix(r.URL.Path, "/api/v1/report/")
+	if taskID == "" {
+		writeError(w, http.StatusBadRequest, "Task ID required")
+		return
+	}
+
+	task, exists := s.taskManager.GetTask(taskID)
+	if !exists {
+		writeError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	response := TaskReportResponse{
+		ID:            task.ID,
+		Status:        string(task.Status),
+		Error:         task.Error,
+		QueuePosition: task.QueuePosition,
+		Report:        task.Report,
+	}
+	if response.Report == nil {
+		if run, ok := s.live.get(taskID); ok {
+			response.Report = run.report()
+			response.Live = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
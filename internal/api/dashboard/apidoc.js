@@ -0,0 +1,109 @@
+// GurlAPIDoc renders the OpenAPI document of the API server
+// (/api/v1/openapi.json) as a reference page of the dashboard.
+(function (global) {
+  "use strict";
+
+  var el = GurlReport.el;
+
+  function refName(ref) {
+    return ref.slice(ref.lastIndexOf("/") + 1);
+  }
+
+  // schemaLink links to the section of a component; the dashboard routes on
+  // the URL hash, so it scrolls instead of navigating
+  function schemaLink(name) {
+    var a = el("a", { "href": "#/api" }, [name]);
+    a.addEventListener("click", function (e) {
+      e.preventDefault();
+      var target = document.getElementById("schema-" + name);
+      if (target) target.scrollIntoView();
+    });
+    return a;
+  }
+
+  // typeOf describes a schema in one line, e.g. "array of BatchTestRequest"
+  function typeOf(s) {
+    if (!s) return el("span", {}, ["any"]);
+    if (s.$ref) return schemaLink(refName(s.$ref));
+    if (s.type === "array") return el("span", {}, ["array of ", typeOf(s.items)]);
+    if (s.type === "object" && s.additionalProperties) return el("span", {}, ["map of ", typeOf(s.additionalProperties)]);
+    return el("span", {}, [(s.type || "any") + (s.format ? " (" + s.format + ")" : "")]);
+  }
+
+  // constraints lists the validation rules of a schema
+  function constraints(s, required) {
+    var rules = [];
+    if (required) rules.push("required");
+    if (s.enum) rules.push("one of " + s.enum.join(", "));
+    if (s.minimum !== undefined) rules.push("≥ " + s.minimum);
+    if (s.maximum !== undefined) rules.push("≤ " + s.maximum);
+    if (s.minItems !== undefined) rules.push("at least " + s.minItems + " items");
+    return rules.join("; ");
+  }
+
+  function operation(method, path, op) {
+    var children = [
+      el("h3", {}, [el("code", {}, [method.toUpperCase() + " " + path]), " — " + op.summary]),
+      op.description ? el("p", {}, [op.description]) : null
+    ];
+    if (op.parameters && op.parameters.length) {
+      children.push(GurlReport.table(["Parameter", "In", "Type", "Rules", "Description"], op.parameters.map(function (p) {
+        return [p.name, p.in, typeOf(p.schema), constraints(p.schema, p.required), p.description || ""];
+      })));
+    }
+    if (op.requestBody) {
+      var body = op.requestBody.content["application/json"];
+      children.push(el("p", {}, ["Request body: ", typeOf(body.schema)]));
+    }
+    children.push(GurlReport.table(["Status", "Description", "Body"], Object.keys(op.responses).sort().map(function (code) {
+      var r = op.responses[code];
+      var types = Object.keys(r.content || {}).map(function (type) {
+        return el("span", {}, [typeOf(r.content[type].schema), type === "application/json" ? "" : " (" + type + ")"]);
+      });
+      return [code, r.description, el("span", {}, types)];
+    })));
+    return el("div", { "class": "card" }, children);
+  }
+
+  function component(name, s) {
+    var required = s.required || [];
+    var rows = Object.keys(s.properties || {}).sort().map(function (field) {
+      var p = s.properties[field];
+      return [el("code", {}, [field]), typeOf(p), constraints(p, required.indexOf(field) >= 0)];
+    });
+    return el("div", { "class": "card", "id": "schema-" + name }, [
+      el("h3", {}, [name]),
+      rows.length ? GurlReport.table(["Field", "Type", "Rules"], rows) : el("p", { "class": "empty" }, ["no fields"])
+    ]);
+  }
+
+  // render returns the reference page of an OpenAPI document, operations
+  // grouped by tag followed by the schemas
+  function render(spec) {
+    var groups = {}, order = [];
+    Object.keys(spec.paths).forEach(function (path) {
+      Object.keys(spec.paths[path]).forEach(function (method) {
+        var op = spec.paths[path][method];
+        var tag = (op.tags || ["other"])[0];
+        if (!groups[tag]) { groups[tag] = []; order.push(tag); }
+        groups[tag].push(operation(method, path, op));
+      });
+    });
+
+    var children = [
+      el("h1", {}, [spec.info.title + " " + spec.info.version]),
+      el("p", { "class": "meta" }, [spec.info.description + " ", el("a", { "href": "/api/v1/openapi.json", "target": "_blank" }, ["openapi.json"])])
+    ];
+    order.forEach(function (tag) {
+      children.push(el("h2", {}, [tag]));
+      children = children.concat(groups[tag]);
+    });
+    children.push(el("h2", {}, ["Schemas"]));
+    Object.keys(spec.components.schemas).sort().forEach(function (name) {
+      children.push(component(name, spec.components.schemas[name]));
+    });
+    return el("div", {}, children);
+  }
+
+  global.GurlAPIDoc = { render: render };
+})(window);
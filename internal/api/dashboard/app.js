@@ -0,0 +1,372 @@
+// gurl dashboard: submits tasks to the API server, lists them and draws their
+// results with the same code as the HTML report (GurlReport, GurlCharts).
+(function (global) {
+  "use strict";
+
+  var el = GurlReport.el;
+  var view = document.getElementById("view");
+  var POLL_MS = 2000;
+  var timer = null;
+  var stream = null;
+
+  // ---- API key, when the server is started with --api-auth ----
+
+  var KEY_STORAGE = "gurl_api_key";
+  var keyPrompted = false;
+
+  function apiKey() {
+    try { return global.localStorage.getItem(KEY_STORAGE) || ""; } catch (e) { return ""; }
+  }
+
+  // askKey prompts for a key once per page load, returning whether one was entered
+  function askKey() {
+    if (keyPrompted || !global.prompt) return false;
+    keyPrompted = true;
+    var key = global.prompt("This gurl API server requires an API key:");
+    if (!key) return false;
+    try { global.localStorage.setItem(KEY_STORAGE, key.trim()); } catch (e) { return false; }
+    return true;
+  }
+
+  // withKey adds the key to URLs that can't carry headers: EventSource and links
+  function withKey(path) {
+    var key = apiKey();
+    return key ? path + (path.indexOf("?") < 0 ? "?" : "&") + "api_key=" + encodeURIComponent(key) : path;
+  }
+
+  function api(method, path, body) {
+    var opts = { method: method, headers: {} };
+    if (body !== undefined) {
+      opts.headers["Content-Type"] = "application/json";
+      opts.body = JSON.stringify(body);
+    }
+    var key = apiKey();
+    if (key) opts.headers["Authorization"] = "Bearer " + key;
+    return fetch(path, opts).then(function (resp) {
+      if (resp.status === 401 && askKey()) return api(method, path, body);
+      return resp.text().then(function (text) {
+        if (!resp.ok) throw new Error(errorMessage(text) || resp.statusText);
+        return text ? JSON.parse(text) : null;
+      });
+    });
+  }
+
+  // errorMessage reads an ErrorResponse body, one line per invalid field
+  function errorMessage(text) {
+    var body;
+    try { body = JSON.parse(text); } catch (e) { return text.trim(); }
+    if (!body || !body.message) return text.trim();
+    if (!body.details || body.details.length < 2) return body.message;
+    return body.details.map(function (d) { return d.field + ": " + d.message; }).join("\n");
+  }
+
+  function poll(fn) {
+    fn();
+    timer = global.setInterval(fn, POLL_MS);
+  }
+
+  function show(children) {
+    view.innerHTML = "";
+    children.forEach(function (c) { if (c) view.appendChild(c); });
+  }
+
+  function duration(task) {
+    if (!task.started_at) return "";
+    var end = task.completed_at ? new Date(task.completed_at) : new Date();
+    return ((end - new Date(task.started_at)) / 1000).toFixed(1) + "s";
+  }
+
+  // ---- task list ----
+
+  function isFinished(status) {
+    return status === "completed" || status === "failed" || status === "canceled" || status === "interrupted";
+  }
+
+  // statusText shows the queue position of queued tasks, e.g. "queued #2"
+  function statusText(task) {
+    return task.status + (task.status === "queued" && task.queue_position ? " #" + task.queue_position : "");
+  }
+
+  function renderTasks() {
+    var filter = el("select", {}, ["", "queued", "running", "completed", "failed", "canceled", "interrupted"].map(function (s) {
+      return el("option", { "value": s }, [s || "all statuses"]);
+    }));
+    var body = el("div", {}, [el("p", { "class": "empty" }, ["loading..."])]);
+    show([el("h1", {}, ["Tasks"]), el("div", { "class": "toolbar" }, [filter]), body]);
+    var load = function () {
+      var query = filter.value ? "?status=" + encodeURIComponent(filter.value) : "";
+      api("GET", "/api/v1/tasks" + query).then(function (data) {
+        body.innerHTML = "";
+        var tasks = data.tasks || [];
+        if (!tasks.length) {
+          body.appendChild(el("p", { "class": "empty" }, ["no tasks yet, submit one with the links above"]));
+          return;
+        }
+        var t = GurlReport.table(["Task", "Kind", "Target", "Status", "Created", "Duration"], tasks.map(function (task) {
+          return [task.id, task.kind, task.title, el("span", { "class": "status-" + task.status }, [statusText(task)]),
+            new Date(task.created_at).toLocaleString(), duration(task)];
+        }));
+        Array.prototype.forEach.call(t.querySelectorAll("tbody tr"), function (row, i) {
+          row.className = "task";
+          row.addEventListener("click", function () { location.hash = "#/task/" + tasks[i].id; });
+        });
+        body.appendChild(el("div", { "class": "card" }, [t]));
+      }).catch(function (err) {
+        body.innerHTML = "";
+        body.appendChild(el("p", { "class": "error" }, [err.message]));
+      });
+    };
+    filter.addEventListener("change", load);
+    poll(load);
+  }
+
+  // ---- task detail ----
+
+  function controlButton(id, action, label) {
+    var b = el("button", { "class": "secondary", "type": "button" }, [label]);
+    b.addEventListener("click", function () { api("POST", "/api/v1/control/" + id + "/" + action, {}).catch(function () {}); });
+    return b;
+  }
+
+  function taskButton(label, method, path, done) {
+    var b = el("button", { "class": "secondary", "type": "button" }, [label]);
+    b.addEventListener("click", function () {
+      api(method, path).then(done).catch(function (err) { global.alert(err.message); });
+    });
+    return b;
+  }
+
+  function renderTask(id) {
+    var status = el("span", {}, []);
+    var toolbar = el("div", { "class": "toolbar" }, [
+      el("a", { "href": "#/tasks" }, ["← Tasks"]), status,
+      el("a", { "href": withKey("/api/v1/results/" + encodeURIComponent(id)), "target": "_blank" }, ["raw results"])
+    ]);
+    var controls = el("span", {}, []);
+    toolbar.appendChild(controls);
+    var body = el("div", {}, [el("p", { "class": "empty" }, ["loading..."])]);
+    show([toolbar, body]);
+
+    var finished = false;
+    var progress = "";
+    var refresh = function () {
+      if (finished) return;
+      api("GET", "/api/v1/report/" + encodeURIComponent(id)).then(function (data) {
+        status.className = "status-" + data.status;
+        status.textContent = statusText(data) + (data.live ? " (live" + progress + ")" : "");
+        if (data.status === "running" && !stream && global.EventSource) {
+          // Snapshots only drive the progress text, the charts follow the poll interval
+          stream = new EventSource(withKey("/api/v1/tasks/" + encodeURIComponent(id) + "/stream"));
+          stream.addEventListener("snapshot", function (e) {
+            progress = ", " + JSON.parse(e.data).percent_complete.toFixed(0) + "%";
+          });
+          stream.addEventListener("done", function () {
+            stream.close();
+            stream = null;
+            refresh();
+          });
+        }
+        controls.innerHTML = "";
+        var path = "/api/v1/tasks/" + encodeURIComponent(id);
+        if (data.status === "running" && data.live) {
+          controls.appendChild(controlButton(id, "pause", "Pause"));
+          controls.appendChild(document.createTextNode(" "));
+          controls.appendChild(controlButton(id, "resume", "Resume"));
+          controls.appendChild(document.createTextNode(" "));
+        }
+        finished = isFinished(data.status);
+        if (finished) {
+          controls.appendChild(taskButton("Delete", "DELETE", path, function () { location.hash = "#/tasks"; }));
+        } else {
+          controls.appendChild(taskButton("Cancel", "POST", path + "/cancel", function () {}));
+        }
+
+        body.innerHTML = "";
+        if (data.error) body.appendChild(el("p", { "class": "error" }, [data.error]));
+        if (data.report) {
+          var container = el("div", {}, []);
+          body.appendChild(container);
+          GurlReport.render(container, data.report);
+        } else if (!finished) {
+          body.appendChild(el("p", { "class": "empty" }, ["waiting for the first sample..."]));
+        }
+      }).catch(function (err) {
+        finished = true;
+        body.innerHTML = "";
+        body.appendChild(el("p", { "class": "error" }, [err.message]));
+      });
+    };
+    poll(refresh);
+  }
+
+  // ---- forms ----
+
+  function field(label, name, value, attrs) {
+    var input = el("input", Object.assign({ "name": name, "value": value === undefined ? "" : value }, attrs || {}));
+    return el("label", {}, [label, input]);
+  }
+
+  function textarea(label, name, placeholder, value) {
+    var t = el("textarea", { "name": name, "placeholder": placeholder || "" }, []);
+    t.value = value || "";
+    return el("label", { "class": "wide" }, [label, t]);
+  }
+
+  function loadFields() {
+    return el("div", { "class": "row" }, [
+      field("connections", "connections", 10, { "type": "number", "min": "1" }),
+      field("threads", "threads", 2, { "type": "number", "min": "1" }),
+      field("duration", "duration", "10s"),
+      field("rate (req/s, 0 = unlimited)", "rate", 0, { "type": "number", "min": "0" }),
+      field("warm-up", "warmup", ""),
+      field("timeout", "timeout", "30s"),
+      el("label", { "class": "check" }, [el("input", { "type": "checkbox", "name": "use_nethttp" }, []), "use net/http"])
+    ]);
+  }
+
+  function loadValues(form) {
+    var v = {
+      connections: parseInt(form.elements.connections.value, 10) || 0,
+      threads: parseInt(form.elements.threads.value, 10) || 0,
+      duration: form.elements.duration.value.trim(),
+      rate: parseInt(form.elements.rate.value, 10) || 0,
+      warmup: form.elements.warmup.value.trim(),
+      timeout: form.elements.timeout.value.trim(),
+      use_nethttp: form.elements.use_nethttp.checked
+    };
+    Object.keys(v).forEach(function (k) { if (v[k] === "" || v[k] === 0) delete v[k]; });
+    return v;
+  }
+
+  function parseHeaders(text) {
+    var headers = {};
+    text.split("\n").forEach(function (line) {
+      var i = line.indexOf(":");
+      if (i > 0) headers[line.slice(0, i).trim()] = line.slice(i + 1).trim();
+    });
+    return headers;
+  }
+
+  function renderForm(title, rows, build, path) {
+    var error = el("p", { "class": "error" }, []);
+    var form = el("form", { "class": "card" }, rows.concat([
+      el("div", { "class": "toolbar" }, [el("button", { "type": "submit" }, ["Start"])]),
+      error
+    ]));
+    form.addEventListener("submit", function (e) {
+      e.preventDefault();
+      error.textContent = "";
+      var body;
+      try {
+        body = build(form);
+      } catch (err) {
+        error.textContent = err.message;
+        return;
+      }
+      api("POST", path, body).then(function (resp) {
+        location.hash = "#/task/" + resp.task_id;
+      }).catch(function (err) { error.textContent = err.message; });
+    });
+    show([el("h1", {}, [title]), form]);
+  }
+
+  function renderBenchmarkForm() {
+    var method = el("select", { "name": "method" }, ["GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"].map(function (m) {
+      return el("option", {}, [m]);
+    }));
+    renderForm("New benchmark", [
+      el("p", { "class": "meta" }, ["Paste a curl command, or fill in the request below."]),
+      el("div", { "class": "row" }, [textarea("curl command", "curl", "curl -X POST https://example.com/api -H 'Content-Type: application/json' -d '{}'")]),
+      el("div", { "class": "row" }, [el("label", {}, ["method", method]), field("URL", "url", "", { "size": "60" })]),
+      el("div", { "class": "row" }, [textarea("headers (one per line)", "headers", "Authorization: Bearer ..."), textarea("body", "body", "")]),
+      loadFields()
+    ], function (form) {
+      var req = loadValues(form);
+      var curl = form.elements.curl.value.trim();
+      if (curl) {
+        req.curl = curl;
+      } else {
+        req.url = form.elements.url.value.trim();
+        if (!req.url) throw new Error("a curl command or a URL is required");
+        req.method = form.elements.method.value;
+        req.headers = parseHeaders(form.elements.headers.value);
+        if (form.elements.body.value) req.body = form.elements.body.value;
+      }
+      return req;
+    }, "/api/v1/benchmark");
+  }
+
+  function renderBatchForm() {
+    renderForm("New batch", [
+      el("p", { "class": "meta" }, ["One curl command per line, run with the load settings below, or a JSON batch request ({\"tests\": [...]})."]),
+      el("div", { "class": "row" }, [textarea("tests", "tests", "curl https://example.com/a\ncurl https://example.com/b")]),
+      loadFields(),
+      el("div", { "class": "row" }, [
+        el("label", { "class": "check" }, [el("input", { "type": "checkbox", "name": "sequential" }, []), "run tests one after another"])
+      ])
+    ], function (form) {
+      var text = form.elements.tests.value.trim();
+      if (!text) throw new Error("at least one test is required");
+      if (text.charAt(0) === "{" || text.charAt(0) === "[") {
+        var parsed = JSON.parse(text);
+        return Array.isArray(parsed) ? { tests: parsed } : parsed;
+      }
+      var load = loadValues(form);
+      var tests = text.split("\n").map(function (l) { return l.trim(); }).filter(Boolean).map(function (curl, i) {
+        return Object.assign({ name: "test " + (i + 1), curl: curl }, load);
+      });
+      return { tests: tests, mode: form.elements.sequential.checked ? "sequential" : "concurrent" };
+    }, "/api/v1/batch");
+  }
+
+  function renderCompareForm() {
+    renderForm("New compare", [
+      el("p", { "class": "meta" }, ["The same YAML or JSON as a --compare-config file."]),
+      el("div", { "class": "row" }, [field("scenario", "scenario", "", { "size": "30" })]),
+      el("div", { "class": "row" }, [textarea("compare config", "config", "requests:\n  - name: old\n    curl: curl https://old.example.com/api\n  - name: new\n    curl: curl https://new.example.com/api\ncompare:\n  - name: old-vs-new\n    base: old\n    target: new\n    response_compare: |\n      status == 200")])
+    ], function (form) {
+      var scenario = form.elements.scenario.value.trim();
+      if (!scenario) throw new Error("scenario is required");
+      return { scenario: scenario, config_text: form.elements.config.value };
+    }, "/api/v1/compare");
+  }
+
+  // ---- API reference ----
+
+  function renderAPI() {
+    show([el("p", { "class": "empty" }, ["loading..."])]);
+    api("GET", "/api/v1/openapi.json").then(function (spec) {
+      show([GurlAPIDoc.render(spec)]);
+    }).catch(function (err) {
+      show([el("p", { "class": "error" }, [err.message])]);
+    });
+  }
+
+  // ---- routing ----
+
+  function route() {
+    if (timer) global.clearInterval(timer);
+    timer = null;
+    if (stream) stream.close();
+    stream = null;
+
+    var parts = (location.hash || "#/tasks").slice(2).split("/");
+    var page = parts[0] === "new" ? parts[1] : parts[0];
+    Array.prototype.forEach.call(document.querySelectorAll("nav.top a"), function (a) {
+      a.className = a.getAttribute("data-nav") === page ? "active" : "";
+    });
+
+    switch (parts[0]) {
+      case "task": return renderTask(decodeURIComponent(parts.slice(1).join("/")));
+      case "api": return renderAPI();
+      case "new":
+        if (parts[1] === "batch") return renderBatchForm();
+        if (parts[1] === "compare") return renderCompareForm();
+        return renderBenchmarkForm();
+      default: return renderTasks();
+    }
+  }
+
+  global.addEventListener("hashchange", route);
+  route();
+})(window);
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ErrorResponse is the body of every error answered by the API
+type ErrorResponse struct {
+	Status  int          `json:"status"`
+	Error   string       `json:"error"` // the status as a code, e.g. bad_request or not_found
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"` // the fields of a request body that don't match its schema
+}
+
+// FieldError is a field of a request body that doesn't match its schema.
+// Field is a path such as tests[0].duration.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// errorCode turns a status into the code of an ErrorResponse, e.g. 404 into
+// not_found
+func errorCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// writeError answers a request with an ErrorResponse
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeErrorResponse(w, ErrorResponse{Status: status, Error: errorCode(status), Message: message})
+}
+
+// writeValidationError answers a request whose body doesn't match its schema
+func writeValidationError(w http.ResponseWriter, details []FieldError) {
+	message := "Request body does not match the schema: " + details[0].Field + " " + details[0].Message
+	if len(details) > 1 {
+		message += " (and more, see details)"
+	}
+	writeErrorResponse(w, ErrorResponse{
+		Status:  http.StatusBadRequest,
+		Error:   errorCode(http.StatusBadRequest),
+		Message: message,
+		Details: details,
+	})
+}
+
+func writeErrorResponse(w http.ResponseWriter, resp ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(resp.Status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -11,7 +11,9 @@ import (
 
 	"github.com/antlabs/gurl/internal/benchmark"
 	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/metrics"
 	"github.com/antlabs/gurl/internal/parser"
+	"github.com/antlabs/gurl/internal/report"
 	"github.com/antlabs/gurl/internal/stats"
 )
 
@@ -19,59 +21,45 @@ import (
 type BenchmarkRequest struct {
 	URL         string                 `json:"url"`
 	Curl        string                 `json:"curl,omitempty"`
-	Connections int                    `json:"connections,omitempty"`
-	Duration    string                 `json:"duration,omitempty"`
-	Threads     int                    `json:"threads,omitempty"`
-	Rate        int                    `json:"rate,omitempty"`
-	Requests    int64                  `json:"requests,omitempty"`
-	Timeout     string                 `json:"timeout,omitempty"`
+	Connections int                    `json:"connections,omitempty" schema:"minimum=0"`
+	Duration    string                 `json:"duration,omitempty" schema:"format=duration"`
+	Threads     int                    `json:"threads,omitempty" schema:"minimum=0"`
+	Rate        int                    `json:"rate,omitempty" schema:"minimum=0"`
+	Requests    int64                  `json:"requests,omitempty" schema:"minimum=0"`
+	Warmup      string                 `json:"warmup,omitempty" schema:"format=duration"`
+	Timeout     string                 `json:"timeout,omitempty" schema:"format=duration"`
 	Method      string                 `json:"method,omitempty"`
 	Headers     map[string]string      `json:"headers,omitempty"`
 	Body        string                 `json:"body,omitempty"`
 	ContentType string                 `json:"content_type,omitempty"`
 	UseNetHTTP  bool                   `json:"use_nethttp,omitempty"`
+	Abort       *config.AbortConfig    `json:"abort,omitempty"`
+	Priority    int                    `json:"priority,omitempty"`
+	CallbackURL string                 `json:"callback_url,omitempty" schema:"format=uri"` // receives the results once the task has finished
 	Extra       map[string]interface{} `json:"extra,omitempty"`
 }
 
-// BatchRequest represents a batch test request
-type BatchRequest struct {
-	Tests []BatchTestRequest `json:"tests"`
-}
-
-// BatchTestRequest represents a single test in a batch
-type BatchTestRequest struct {
-	Name        string            `json:"name"`
-	Curl        string            `json:"curl,omitempty"`
-	URL         string            `json:"url,omitempty"`
-	Connections int               `json:"connections,omitempty"`
-	Duration    string            `json:"duration,omitempty"`
-	Threads     int               `json:"threads,omitempty"`
-	Rate        int               `json:"rate,omitempty"`
-	Timeout     string            `json:"timeout,omitempty"`
-	Method      string            `json:"method,omitempty"`
-	Headers     map[string]string `json:"headers,omitempty"`
-	Body        string            `json:"body,omitempty"`
-	ContentType string            `json:"content_type,omitempty"`
-	UseNetHTTP  bool              `json:"use_nethttp,omitempty"`
-}
-
 // BenchmarkResponse represents a benchmark response
 type BenchmarkResponse struct {
-	TaskID  string                 `json:"task_id"`
-	Status  string                 `json:"status"`
-	Message string                 `json:"message,omitempty"`
-	Config  map[string]interface{} `json:"config,omitempty"`
+	TaskID        string                 `json:"task_id"`
+	Status        string                 `json:"status"`
+	Message       string                 `json:"message,omitempty"`
+	QueuePosition int                    `json:"queue_position,omitempty"`
+	Config        map[string]interface{} `json:"config,omitempty"`
 }
 
 // TaskStatusResponse represents a task status response
 type TaskStatusResponse struct {
-	ID          string                 `json:"id"`
-	Status      string                 `json:"status"`
-	CreatedAt   time.Time              `json:"created_at"`
-	StartedAt   *time.Time             `json:"started_at,omitempty"`
-	CompletedAt *time.Time             `json:"completed_at,omitempty"`
-	Error       string                 `json:"error,omitempty"`
-	Config      map[string]interface{} `json:"config,omitempty"`
+	ID            string                 `json:"id"`
+	Status        string                 `json:"status"`
+	CreatedAt     time.Time              `json:"created_at"`
+	StartedAt     *time.Time             `json:"started_at,omitempty"`
+	CompletedAt   *time.Time             `json:"completed_at,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	Priority      int                    `json:"priority,omitempty"`
+	QueuePosition int                    `json:"queue_position,omitempty"`
+	Config        map[string]interface{} `json:"config,omitempty"`
+	Webhook       *WebhookStatus         `json:"webhook,omitempty"`
 }
 
 // TaskResultsResponse represents task results response
@@ -83,6 +71,7 @@ type TaskResultsResponse struct {
 	CompletedAt *time.Time            `json:"completed_at,omitempty"`
 	Error       string                `json:"error,omitempty"`
 	Results     *BenchmarkResultsJSON `json:"results,omitempty"`
+	Batch       json.RawMessage       `json:"batch,omitempty"` // per-test results of a batch task
 }
 
 // BenchmarkResultsJSON represents benchmark results in JSON format
@@ -99,18 +88,60 @@ type BenchmarkResultsJSON struct {
 	LatencyPercentiles map[string]string      `json:"latency_percentiles"` // Changed from map[float64]string to map[string]string
 	TotalBytes         int64                  `json:"total_bytes"`
 	EndpointStats      map[string]interface{} `json:"endpoint_stats,omitempty"`
+	Warmup             *WarmupJSON            `json:"warmup,omitempty"`
+	AbortReason        string                 `json:"abort_reason,omitempty"`
+	Annotations        []AnnotationJSON       `json:"annotations,omitempty"`
+}
+
+// AnnotationJSON is a timeline annotation, such as a load adjustment made
+// through the control endpoint
+type AnnotationJSON struct {
+	Elapsed string `json:"elapsed"`
+	Label   string `json:"label"`
+}
+
+// WarmupJSON summarizes the warm-up phase, which is excluded from the results
+type WarmupJSON struct {
+	TotalRequests      int64             `json:"total_requests"`
+	TotalErrors        int64             `json:"total_errors"`
+	Duration           string            `json:"duration"`
+	RequestsPerSec     float64           `json:"requests_per_sec"`
+	AverageLatency     string            `json:"average_latency"`
+	LatencyPercentiles map[string]string `json:"latency_percentiles"`
 }
 
 // Server represents the API server
 type Server struct {
 	taskManager *TaskManager
+	metrics     *metrics.Registry
+	history     *FileTaskStore // nil when runs are not recorded
+	controls    *controlRegistry
+	live        *liveRegistry
+	streams     *streamHub
+	access      *accessControl // nil when the API is open
+	webhooks    *webhookSender
 }
 
 // NewServer creates a new API server
 func NewServer() *Server {
-	return &Server{
+	s := &Server{
 		taskManager: NewTaskManager(),
-	}
+		metrics:     metrics.NewRegistry(),
+		controls:    newControlRegistry(),
+		live:        newLiveRegistry(),
+		streams:     newStreamHub(),
+		webhooks:    newWebhookSender(),
+	}
+	s.taskManager.OnFinish(s.finishStream)
+	s.taskManager.OnFinish(s.sendWebhook)
+	s.taskManager.OnRemove(s.removeMetrics)
+	return s
+}
+
+// removeMetrics drops the metrics of a deleted or evicted task, including
+// those of every test of a batch task
+func (s *Server) removeMetrics(id string) {
+	s.metrics.RemoveMatching(map[string]string{"task": id})
 }
 
 // generateTaskID generates a unique task ID
@@ -121,19 +152,18 @@ func generateTaskID() string {
 // handleBenchmark handles POST /api/v1/benchmark
 func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req BenchmarkRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Validate request
 	if req.Curl == "" && req.URL == "" {
-		http.Error(w, "Either 'url' or 'curl' must be provided", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Either 'url' or 'curl' must be provided")
 		return
 	}
 
@@ -157,20 +187,29 @@ func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
 	// Parse duration and timeout
 	duration, err := time.ParseDuration(req.Duration)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid duration format: %v", err), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid duration format: %v", err))
 		return
 	}
 
 	timeout, err := time.ParseDuration(req.Timeout)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid timeout format: %v", err), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid timeout format: %v", err))
 		return
 	}
 
+	var warmup time.Duration
+	if req.Warmup != "" {
+		if warmup, err = time.ParseDuration(req.Warmup); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid warmup format: %v", err))
+			return
+		}
+	}
+
 	// Create config
 	cfg := config.Config{
 		Connections: req.Connections,
 		Duration:    duration,
+		Warmup:      warmup,
 		Threads:     req.Threads,
 		Rate:        req.Rate,
 		Requests:    req.Requests,
@@ -180,6 +219,7 @@ func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
 		Body:        req.Body,
 		ContentType: req.ContentType,
 		UseNetHTTP:  req.UseNetHTTP,
+		Abort:       req.Abort,
 	}
 
 	// Convert headers
@@ -194,7 +234,7 @@ func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
 	if req.Curl != "" {
 		httpReq, err = parser.ParseCurl(req.Curl)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to parse curl command: %v", err), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse curl command: %v", err))
 			return
 		}
 	} else {
@@ -205,22 +245,36 @@ func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
 
 		parsedURL, err := url.Parse(targetURL)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid URL: %v", err), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid URL: %v", err))
 			return
 		}
 
 		httpReq, err = parser.BuildRequest(cfg, parsedURL)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to build request: %v", err), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to build request: %v", err))
 			return
 		}
 	}
 
 	// Validate config
 	if err := cfg.Validate(); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid configuration: %v", err), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid configuration: %v", err))
+		return
+	}
+
+	var callback *url.URL
+	if req.CallbackURL != "" {
+		if callback, err = s.parseCallbackURL(req.CallbackURL); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	targets := requestHosts(httpReq)
+	if !s.allowSubmit(w, r, withCallbackHost(targets, callback)) {
 		return
 	}
+	cfg.DialGuard = requestDialGuard(r)
 
 	// Create task
 	taskID := generateTaskID()
@@ -232,164 +286,178 @@ func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
 		"threads":     req.Threads,
 		"rate":        req.Rate,
 		"requests":    req.Requests,
+		"warmup":      req.Warmup,
 		"timeout":     req.Timeout,
 		"method":      req.Method,
 		"headers":     req.Headers,
 		"use_nethttp": req.UseNetHTTP,
 	}
+	if req.Abort != nil {
+		configMap["abort"] = req.Abort
+	}
+	if req.Priority != 0 {
+		configMap["priority"] = req.Priority
+	}
 	if req.Extra != nil {
 		for k, v := range req.Extra {
 			configMap[k] = v
 		}
 	}
+	if owner := requestOwner(r); owner != "" {
+		configMap["owner"] = owner
+	}
 
 	s.taskManager.CreateTask(taskID, configMap)
+	s.watchTask(taskID, callback, cfg.DialGuard, configMap)
 
 	// Create benchmark runner
 	bench := benchmark.New(cfg, httpReq)
+	bench.AddObserver(s.metrics.Collector(map[string]string{"task": taskID}))
+	target := httpReq.URL.String()
+	bench.AddObserver(&streamObserver{hub: s.streams, taskID: taskID, cfg: cfg})
 
 	// Run task asynchronously with a new context (not tied to HTTP request)
 	// This ensures the benchmark continues even after the HTTP request completes
 	benchCtx := context.Background()
-	s.taskManager.RunTask(benchCtx, taskID, func(ctx context.Context) (*stats.Results, error) {
+	opts := RunOptions{Priority: req.Priority, Targets: targets}
+	s.taskManager.RunTaskWithOptions(benchCtx, taskID, opts, func(ctx context.Context) (*stats.Results, error) {
+		// The live view and the controller exist while the task runs; a task
+		// canceled in the queue never registers them
+		bench.AddObserver(s.live.add(taskID, target, cfg))
+		s.controls.add(taskID, bench.Controller())
+		defer s.controls.remove(taskID)
+		defer s.live.remove(taskID)
 		results, err := bench.Run(ctx)
+		var rep *report.Report
+		if err == nil {
+			rep = report.FromBenchmark(target, results, cfg)
+			s.taskManager.SetTaskReport(taskID, rep)
+		}
+		s.recordTask(taskID, rep, err)
 		return results, err
 	})
 
-	// Return response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(BenchmarkResponse{
+	s.writeAccepted(w, taskID, "Benchmark task", configMap)
+}
+
+// writeAccepted answers the submission of a task, telling whether it was
+// started or queued
+func (s *Server) writeAccepted(w http.ResponseWriter, taskID, what string, configMap map[string]interface{}) {
+	response := BenchmarkResponse{
 		TaskID:  taskID,
 		Status:  "accepted",
-		Message: "Benchmark task created and started",
+		Message: what + " created and started",
 		Config:  configMap,
-	})
-}
-
-// handleBatch handles POST /api/v1/batch
-func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req BatchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
-		return
 	}
-
-	if len(req.Tests) == 0 {
-		http.Error(w, "No tests provided", http.StatusBadRequest)
-		return
-	}
-
-	// Create batch task
-	taskID := generateTaskID()
-	configMap := map[string]interface{}{
-		"type":  "batch",
-		"tests": len(req.Tests),
+	if task, ok := s.taskManager.GetTask(taskID); ok && task.Status == TaskStatusQueued {
+		response.Message = fmt.Sprintf("%s created and queued at position %d", what, task.QueuePosition)
+		response.QueuePosition = task.QueuePosition
 	}
 
-	s.taskManager.CreateTask(taskID, configMap)
-
-	// Run batch tests asynchronously with a new context (not tied to HTTP request)
-	benchCtx := context.Background()
-	s.taskManager.RunTask(benchCtx, taskID, func(ctx context.Context) (*stats.Results, error) {
-		// For batch tests, we'll run them sequentially and aggregate results
-		// This is a simplified implementation
-		// In a full implementation, you might want to use the batch executor
-		return nil, fmt.Errorf("batch execution not yet implemented in API")
-	})
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(BenchmarkResponse{
-		TaskID:  taskID,
-		Status:  "accepted",
-		Message: "Batch test task created",
-		Config:  configMap,
-	})
+	json.NewEncoder(w).Encode(response)
 }
 
 // handleStatus handles GET /api/v1/status/:id
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Extract task ID from path
 	path := r.URL.Path
 	if !strings.HasPrefix(path, "/api/v1/status/") {
-		http.Error(w, "Invalid path", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid path")
 		return
 	}
 	taskID := strings.TrimPrefix(path, "/api/v1/status/")
 	if taskID == "" {
-		http.Error(w, "Task ID required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Task ID required")
 		return
 	}
 
-	task, exists := s.taskManager.GetTask(taskID)
-	if !exists {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	response, ok := s.taskStatus(taskID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Task not found")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(TaskStatusResponse{
-		ID:          task.ID,
-		Status:      string(task.Status),
-		CreatedAt:   task.CreatedAt,
-		StartedAt:   task.StartedAt,
-		CompletedAt: task.CompletedAt,
-		Error:       task.Error,
-		Config:      task.Config,
-	})
+	json.NewEncoder(w).Encode(response)
+}
+
+// taskStatus builds the status response of a task held in memory or in the
+// task store
+func (s *Server) taskStatus(taskID string) (*TaskStatusResponse, bool) {
+	task, exists := s.taskManager.GetTask(taskID)
+	if !exists {
+		return nil, false
+	}
+
+	return &TaskStatusResponse{
+		ID:            task.ID,
+		Status:        string(task.Status),
+		CreatedAt:     task.CreatedAt,
+		StartedAt:     task.StartedAt,
+		CompletedAt:   task.CompletedAt,
+		Error:         task.Error,
+		Priority:      task.Priority,
+		QueuePosition: task.QueuePosition,
+		Config:        task.Config,
+		Webhook:       task.Webhook,
+	}, true
 }
 
 // handleResults handles GET /api/v1/results/:id
 func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Extract task ID from path
 	path := r.URL.Path
 	if !strings.HasPrefix(path, "/api/v1/results/") {
-		http.Error(w, "Invalid path", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid path")
 		return
 	}
 	taskID := strings.TrimPrefix(path, "/api/v1/results/")
 	if taskID == "" {
-		http.Error(w, "Task ID required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Task ID required")
+		return
+	}
+
+	response, ok := s.taskResults(taskID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Task not found")
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// taskResults builds the results response of a task held in memory or in
+// the task store
+func (s *Server) taskResults(taskID string) (*TaskResultsResponse, bool) {
 	task, exists := s.taskManager.GetTask(taskID)
 	if !exists {
-		http.Error(w, "Task not found", http.StatusNotFound)
-		return
+		return nil, false
 	}
 
-	response := TaskResultsResponse{
+	response := &TaskResultsResponse{
 		ID:          task.ID,
 		Status:      string(task.Status),
 		CreatedAt:   task.CreatedAt,
 		StartedAt:   task.StartedAt,
 		CompletedAt: task.CompletedAt,
 		Error:       task.Error,
+		Batch:       task.Batch,
+		Results:     task.ResultsJSON(),
 	}
-
-	if task.Results != nil {
-		response.Results = convertResultsToJSON(task.Results)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	return response, true
 }
 
 // convertResultsToJSON converts stats.Results to JSON format
@@ -418,25 +486,58 @@ func convertResultsToJSON(results *stats.Results) *BenchmarkResultsJSON {
 
 	// Convert endpoint stats
 	endpointStatsMap := make(map[string]interface{})
-	for url, epStats := range results.GetEndpointStats() {
+	for key, epStats := range results.GetEndpointStats() {
 		epAvgLatency := epStats.GetAverageLatency()
+		epPercentiles := epStats.GetLatencyPercentiles()
 		var epRequestsPerSec float64
 		if results.Duration > 0 {
 			epRequestsPerSec = float64(epStats.Requests) / results.Duration.Seconds()
 		}
 
-		endpointStatsMap[url] = map[string]interface{}{
+		endpointStatsMap[key] = map[string]interface{}{
+			"url":              epStats.URL,
 			"requests":         epStats.Requests,
 			"errors":           epStats.Errors,
+			"share":            epStats.Share,
 			"requests_per_sec": epRequestsPerSec,
 			"average_latency":  formatDuration(epAvgLatency),
 			"min_latency":      formatDuration(epStats.MinLatency),
 			"max_latency":      formatDuration(epStats.MaxLatency),
-			"status_codes":     epStats.StatusCodes,
-			"total_bytes":      epStats.ReadBytes,
+			"latency_percentiles": map[string]string{
+				"p50": formatDuration(epPercentiles[50]),
+				"p90": formatDuration(epPercentiles[90]),
+				"p99": formatDuration(epPercentiles[99]),
+			},
+			"status_codes":    epStats.StatusCodes,
+			"errors_by_class": epStats.ErrorClasses,
+			"total_bytes":     epStats.ReadBytes,
+		}
+	}
+
+	var warmup *WarmupJSON
+	if w := results.Warmup; w != nil {
+		warmupPercentiles := w.GetLatencyPercentiles()
+		warmup = &WarmupJSON{
+			TotalRequests:  w.TotalRequests,
+			TotalErrors:    w.TotalErrors,
+			Duration:       formatDuration(w.Duration),
+			AverageLatency: formatDuration(w.GetAverageLatency()),
+			LatencyPercentiles: map[string]string{
+				"p50": formatDuration(warmupPercentiles[50]),
+				"p90": formatDuration(warmupPercentiles[90]),
+				"p99": formatDuration(warmupPercentiles[99]),
+			},
+		}
+		if w.Duration > 0 {
+			warmup.RequestsPerSec = float64(w.TotalRequests) / w.Duration.Seconds()
 		}
 	}
 
+	var annotations []AnnotationJSON
+	for _, a := range results.GetAnnotations() {
+		annotations = append(annotations, AnnotationJSON{Elapsed: formatDuration(a.Elapsed), Label: a.Label})
+	}
+
 	return &BenchmarkResultsJSON{
 		TotalRequests:      results.TotalRequests,
 		TotalErrors:        results.TotalErrors,
@@ -450,6 +551,9 @@ func convertResultsToJSON(results *stats.Results) *BenchmarkResultsJSON {
 		LatencyPercentiles: percentilesMap,
 		TotalBytes:         results.GetTotalBytes(),
 		EndpointStats:      endpointStatsMap,
+		Warmup:             warmup,
+		AbortReason:        results.AbortReason(),
+		Annotations:        annotations,
 	}
 }
 
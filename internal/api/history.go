@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/antlabs/gurl/internal/history"
+	"github.com/antlabs/gurl/internal/report"
+)
+
+// SetHistoryDB keeps tasks in the local run history database shared with
+// the `gurl history` command: the config, every status change and the
+// results of each task are stored there, and finished tasks are recorded as
+// runs. Tasks survive a restart of the server; tasks that had not finished
+// when it stopped are marked interrupted.
+func (s *HTTPServer) SetHistoryDB(path string) error {
+	store, err := OpenFileTaskStore(path)
+	if err != nil {
+		return err
+	}
+	interrupted, err := s.apiServer.taskManager.SetStore(store)
+	if err != nil {
+		return fmt.Errorf("failed to load tasks from %s: %v", path, err)
+	}
+	if interrupted > 0 {
+		fmt.Printf("Marked %d unfinished tasks in %s as interrupted\n", interrupted, path)
+	}
+	s.apiServer.history = store
+	return nil
+}
+
+// recordTask stores the outcome of a finished task in the run history
+func (s *Server) recordTask(taskID string, rep *report.Report, runErr error) {
+	if s.history == nil {
+		return
+	}
+
+	rec := &history.Record{
+		Kind:   history.KindAPI,
+		TaskID: taskID,
+		Title:  taskID,
+		Status: history.StatusOf(rep),
+		Report: rep,
+	}
+	if rep != nil {
+		rec.Title = rep.Title
+		rec.CreatedAt = rep.CreatedAt
+	}
+	if runErr != nil {
+		rec.Status = history.StatusFailed
+		rec.Error = runErr.Error()
+	}
+	if s.taskManager.IsCanceled(taskID) {
+		rec.Status = history.StatusCanceled
+		rec.Error = "canceled"
+	}
+	if err := s.history.record(rec); err != nil {
+		fmt.Printf("history: %v\n", err)
+	}
+}
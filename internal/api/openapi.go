@@ -0,0 +1,370 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/antlabs/gurl/internal/benchmark"
+)
+
+// openAPIVersion is the version of the API described by the document
+const openAPIVersion = "1.0"
+
+// openAPIDocument is the OpenAPI 3 document served at /api/v1/openapi.json
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+	Security   []map[string][]string                   `json:"security,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*Schema               `json:"schemas"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	Name   string `json:"name,omitempty"`
+	In     string `json:"in,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    *[]map[string][]string      `json:"security,omitempty"` // empty for public routes
+}
+
+type openAPIParameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// apiOperation describes a route of the API. Body and Response are values
+// of the request and response types, whose schemas are generated from them.
+type apiOperation struct {
+	Method      string
+	Path        string
+	ID          string
+	Tag         string
+	Summary     string
+	Description string
+	Params      []openAPIParameter
+	Body        interface{}
+	Status      int         // of the response, 200 by default
+	Response    interface{} // nil for a response without a JSON body
+	ContentType string      // of the response when it is not JSON
+	Errors      []int
+	Public      bool // served without an API key
+}
+
+var (
+	taskIDParam = openAPIParameter{Name: "id", In: "path", Required: true, Description: "Task ID", Schema: &Schema{Type: "string"}}
+
+	// submitErrors are the errors of the task submissions
+	submitErrors = []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests}
+	// taskErrors are the errors of the routes of a task
+	taskErrors = []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}
+)
+
+// apiOperations are the routes registered by NewHTTPServer, in the order of
+// the document
+var apiOperations = []apiOperation{
+	{
+		Method: http.MethodPost, Path: "/api/v1/benchmark", ID: "submitBenchmark", Tag: "tasks",
+		Summary:     "Submit a benchmark task",
+		Description: "Either url or curl is required. The task runs once a slot of the task queue is free.",
+		Body:        BenchmarkRequest{}, Status: http.StatusAccepted, Response: BenchmarkResponse{}, Errors: submitErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/batch", ID: "submitBatch", Tag: "tasks",
+		Summary:     "Submit a batch test task",
+		Description: "Tests take the fields of the tests of a --batch-config file, or url, method, headers and body instead of curl.",
+		Body:        BatchRequest{}, Status: http.StatusAccepted, Response: BenchmarkResponse{}, Errors: submitErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/compare", ID: "submitCompare", Tag: "tasks",
+		Summary:     "Submit a compare task",
+		Description: "The configuration is the same as a --compare-config file, given as config or as YAML or JSON config_text.",
+		Body:        CompareTaskRequest{}, Status: http.StatusAccepted, Response: BenchmarkResponse{}, Errors: submitErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/tasks", ID: "listTasks", Tag: "tasks",
+		Summary: "List tasks",
+		Params: []openAPIParameter{
+			{Name: "status", In: "query", Description: "Comma separated statuses", Schema: &Schema{Type: "string"}},
+			{Name: "kind", In: "query", Schema: &Schema{Type: "string", Enum: []string{"benchmark", "batch", "compare"}}},
+			{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Minimum: float64Ptr(1), Maximum: float64Ptr(maxTaskPageSize)}},
+			{Name: "offset", In: "query", Schema: &Schema{Type: "integer", Minimum: float64Ptr(0)}},
+		},
+		Response: TaskListResponse{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/tasks/{id}", ID: "getTask", Tag: "tasks",
+		Summary: "Get the status of a task",
+		Params:  []openAPIParameter{taskIDParam}, Response: TaskStatusResponse{}, Errors: taskErrors,
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/tasks/{id}", ID: "deleteTask", Tag: "tasks",
+		Summary:     "Cancel a running task or delete a finished one",
+		Description: "Canceling answers 202 with a canceling status, deleting answers 204.",
+		Params:      []openAPIParameter{taskIDParam}, Status: http.StatusAccepted, Response: BenchmarkResponse{},
+		Errors: append([]int{http.StatusConflict}, taskErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/tasks/{id}/cancel", ID: "cancelTask", Tag: "tasks",
+		Summary: "Cancel a running task",
+		Params:  []openAPIParameter{taskIDParam}, Status: http.StatusAccepted, Response: BenchmarkResponse{},
+		Errors: append([]int{http.StatusConflict}, taskErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/tasks/{id}/stream", ID: "streamTask", Tag: "tasks",
+		Summary:     "Stream the progress of a task",
+		Description: "Server-Sent Events: snapshot events (StreamSnapshot) every second, then a done event (TaskResultsResponse). A WebSocket upgrade sends them as {\"event\": ..., \"data\": ...} messages.",
+		Params:      []openAPIParameter{taskIDParam}, Response: StreamSnapshot{}, ContentType: "text/event-stream", Errors: taskErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/status/{id}", ID: "getStatus", Tag: "tasks",
+		Summary: "Get the status of a task",
+		Params:  []openAPIParameter{taskIDParam}, Response: TaskStatusResponse{}, Errors: taskErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/results/{id}", ID: "getResults", Tag: "tasks",
+		Summary: "Get the results of a task",
+		Params:  []openAPIParameter{taskIDParam}, Response: TaskResultsResponse{}, Errors: taskErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/report/{id}", ID: "getReport", Tag: "tasks",
+		Summary:     "Get the chartable report of a task",
+		Description: "Running benchmarks return a snapshot of the data so far, with live set.",
+		Params:      []openAPIParameter{taskIDParam}, Response: TaskReportResponse{}, Errors: taskErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/control/{id}", ID: "getControl", Tag: "control",
+		Summary: "Get the load parameters of a running benchmark",
+		Params:  []openAPIParameter{taskIDParam}, Response: benchmark.ControlState{},
+		Errors: append([]int{http.StatusConflict}, taskErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/control/{id}/{action}", ID: "controlTask", Tag: "control",
+		Summary:     "Pause, resume or adjust a running benchmark",
+		Description: "rate and connections take a value or a step; annotate takes a label. A rate of 0 is unlimited, connections need at least 1.",
+		Params: []openAPIParameter{taskIDParam, {
+			Name: "action", In: "path", Required: true,
+			Schema: &Schema{Type: "string", Enum: []string{"pause", "resume", "rate", "connections", "annotate"}},
+		}},
+		Body: ControlRequest{}, Response: benchmark.ControlState{},
+		Errors: append([]int{http.StatusBadRequest, http.StatusConflict}, taskErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/metrics", ID: "getMetrics", Tag: "server",
+		Summary: "Prometheus metrics of tasks", ContentType: "text/plain",
+		Errors: []int{http.StatusUnauthorized},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/openapi.json", ID: "getOpenAPI", Tag: "server",
+		Summary: "This OpenAPI document", Public: true,
+	},
+	{
+		Method: http.MethodGet, Path: "/health", ID: "getHealth", Tag: "server",
+		Summary: "Health check", Public: true,
+	},
+	{
+		Method: http.MethodGet, Path: "/", ID: "getInfo", Tag: "server",
+		Summary:     "API information",
+		Description: "Browsers asking for text/html are redirected to the dashboard at /ui/.",
+		Public:      true,
+	},
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+// apiSpec is the OpenAPI document of the API, and the generator of its
+// schemas validating request bodies
+type apiSpec struct {
+	doc     openAPIDocument
+	schemas *schemaGenerator
+}
+
+// openAPISpec returns the OpenAPI document, generated on first use
+var openAPISpec = sync.OnceValue(func() *apiSpec {
+	g := newSchemaGenerator()
+	g.schemaOf(reflect.TypeOf(ErrorResponse{}))
+	errorSchema := &Schema{Ref: schemaRefPrefix + "ErrorResponse"}
+
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "gurl API",
+			Version:     openAPIVersion,
+			Description: "Submit benchmark, batch and compare tasks to a gurl API server and query their results. Errors are answered with an ErrorResponse.",
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: g.components,
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				"bearerAuth":   {Type: "http", Scheme: "bearer"},
+				"apiKeyHeader": {Type: "apiKey", Name: "X-API-Key", In: "header"},
+				"apiKeyQuery":  {Type: "apiKey", Name: "api_key", In: "query"},
+			},
+		},
+	}
+
+	for _, op := range apiOperations {
+		o := &openAPIOperation{
+			OperationID: op.ID,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Tags:        []string{op.Tag},
+			Parameters:  op.Params,
+			Responses:   make(map[string]*openAPIResponse),
+		}
+		if op.Public {
+			o.Security = &[]map[string][]string{}
+		}
+		if op.Body != nil {
+			o.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content:  map[string]openAPIMediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(op.Body))}},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := &openAPIResponse{Description: http.StatusText(status)}
+		switch {
+		case op.Response != nil && op.ContentType != "":
+			resp.Content = map[string]openAPIMediaType{op.ContentType: {Schema: g.schemaOf(reflect.TypeOf(op.Response))}}
+		case op.Response != nil:
+			resp.Content = map[string]openAPIMediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(op.Response))}}
+		case op.ContentType != "":
+			resp.Content = map[string]openAPIMediaType{op.ContentType: {Schema: &Schema{Type: "string"}}}
+		default:
+			resp.Content = map[string]openAPIMediaType{"application/json": {Schema: &Schema{Type: "object"}}}
+		}
+		o.Responses[fmt.Sprint(status)] = resp
+		for _, code := range op.Errors {
+			o.Responses[fmt.Sprint(code)] = &openAPIResponse{
+				Description: http.StatusText(code),
+				Content:     map[string]openAPIMediaType{"application/json": {Schema: errorSchema}},
+			}
+		}
+
+		if doc.Paths[op.Path] == nil {
+			doc.Paths[op.Path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[op.Path][strings.ToLower(op.Method)] = o
+	}
+	return &apiSpec{doc: doc, schemas: g}
+})
+
+// handleOpenAPI handles GET /api/v1/openapi.json. The document requires an
+// API key on the routes that do when access control is enabled.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	doc := openAPISpec().doc
+	if s.access != nil {
+		doc.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKeyHeader": {}}, {"apiKeyQuery": {}}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
+
+// maxRequestBody caps the size of request bodies
+const maxRequestBody = 1 << 20
+
+// decodeRequest decodes the JSON body of a request into v, a pointer to a
+// request type of apiOperations, and validates it against the schema of
+// its type. Invalid bodies are answered with an ErrorResponse listing the
+// mismatching fields.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	var errs []FieldError
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(v)
+	switch {
+	case errors.As(err, &typeErr):
+		// The decoder goes on with the other fields, which are validated below
+		errs = append(errs, FieldError{
+			Field:   fieldPath(decoderPath(typeErr.Field)),
+			Message: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		})
+	case errors.As(err, &tooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit))
+		return false
+	case err == io.EOF:
+		writeError(w, http.StatusBadRequest, "Request body is required")
+		return false
+	case err != nil:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+
+	errs = append(errs, openAPISpec().schemas.validateValue(v)...)
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		writeValidationError(w, errs)
+		return false
+	}
+	return true
+}
+
+// decoderPath turns the path of a field in a json decoding error, such as
+// tests.0.connections, into the form of FieldError, tests[0].connections
+func decoderPath(field string) string {
+	var at string
+	for _, name := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(name); err == nil && at != "" {
+			at += "[" + name + "]"
+			continue
+		}
+		at = joinField(at, name)
+	}
+	return at
+}
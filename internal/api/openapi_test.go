@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/antlabs/gurl/internal/benchmark"
+	"github.com/antlabs/gurl/internal/config"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	hs := NewHTTPServer(0)
+	srv := httptest.NewServer(hs.server.Handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var doc openAPIDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Security != nil {
+		t.Errorf("unexpected document header %q %v", doc.OpenAPI, doc.Security)
+	}
+
+	for _, op := range apiOperations {
+		if doc.Paths[op.Path][strings.ToLower(op.Method)] == nil {
+			t.Errorf("missing operation %s %s", op.Method, op.Path)
+		}
+	}
+
+	// The fields of the request types, including the embedded BatchTest of
+	// the tests of a batch, come from their JSON tags
+	batchTest := doc.Components.Schemas["BatchTestRequest"]
+	for _, field := range []string{"name", "curl", "duration", "url", "headers"} {
+		if batchTest.Properties[field] == nil {
+			t.Errorf("BatchTestRequest: missing field %s", field)
+		}
+	}
+	bench := doc.Components.Schemas["BenchmarkRequest"]
+	if got := reflect.TypeOf(BenchmarkRequest{}).NumField(); len(bench.Properties) != got {
+		t.Errorf("BenchmarkRequest: expected %d fields, got %d", got, len(bench.Properties))
+	}
+	if bench.Properties["duration"].Format != "duration" || bench.Properties["abort"].Ref != schemaRefPrefix+"AbortConfig" {
+		t.Errorf("unexpected BenchmarkRequest schema %+v", bench.Properties)
+	}
+	if tests := doc.Components.Schemas["BatchRequest"]; len(tests.Required) != 1 || tests.Required[0] != "tests" {
+		t.Errorf("expected tests to be required, got %v", tests.Required)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	s := NewServer()
+	post := func(handler http.HandlerFunc, body string) (int, ErrorResponse) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		var resp ErrorResponse
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected a JSON error, got %s: %s", ct, rec.Body)
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+	fields := func(resp ErrorResponse) []string {
+		var names []string
+		for _, d := range resp.Details {
+			names = append(names, d.Field)
+		}
+		return names
+	}
+
+	code, resp := post(s.handleBenchmark, `{"url": "http://127.0.0.1:1/", "connections": "ten", "duration": "soon", "threads": -1}`)
+	if code != http.StatusBadRequest || resp.Error != "bad_request" {
+		t.Fatalf("expected 400 bad_request, got %d %+v", code, resp)
+	}
+	if got := fields(resp); !reflect.DeepEqual(got, []string{"connections", "duration", "threads"}) {
+		t.Errorf("unexpected invalid fields %v", resp.Details)
+	}
+
+	_, resp = post(s.handleBatch, `{"tests": [{"name": "a", "curl": "curl http://127.0.0.1:1/", "connections": 1.5}], "mode": "parallel"}`)
+	if got := fields(resp); !reflect.DeepEqual(got, []string{"mode", "tests[0].connections"}) {
+		t.Errorf("unexpected invalid fields %v", resp.Details)
+	}
+	_, resp = post(s.handleBatch, `{"tests": []}`)
+	if got := fields(resp); !reflect.DeepEqual(got, []string{"tests"}) {
+		t.Errorf("expected an empty batch to be rejected, got %v", resp.Details)
+	}
+	_, resp = post(s.handleCompare, `{"config_text": "compare: []"}`)
+	if len(resp.Details) != 1 || resp.Details[0].Field != "scenario" || resp.Details[0].Message != "is required" {
+		t.Errorf("expected scenario to be required, got %+v", resp)
+	}
+
+	// Errors found by the handlers have no details
+	code, resp = post(s.handleBenchmark, `{"duration": "10s"}`)
+	if code != http.StatusBadRequest || resp.Message == "" || resp.Details != nil {
+		t.Errorf("expected a plain 400, got %d %+v", code, resp)
+	}
+
+	errs := openAPISpec().schemas.validateValue(&ControlRequest{Step: "sideways"})
+	if len(errs) != 1 || errs[0].Field != "step" {
+		t.Errorf("expected an invalid step, got %v", errs)
+	}
+
+	large := `{"url": "http://127.0.0.1:1/", "body": "` + strings.Repeat("x", maxRequestBody) + `"}`
+	if code, resp = post(s.handleBenchmark, large); code != http.StatusRequestEntityTooLarge || resp.Error != "request_entity_too_large" {
+		t.Errorf("expected 413 for a large body, got %d %+v", code, resp)
+	}
+}
+
+func TestControlValidatesActions(t *testing.T) {
+	s := NewServer()
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:1/", nil)
+	control := benchmark.New(config.Config{Connections: 2, Rate: 10}, req).Controller()
+	s.controls.add("t1", control)
+
+	post := func(action, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleControl(rec, httptest.NewRequest(http.MethodPost, "/api/v1/control/t1/"+action, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post("pause", ""); rec.Code != http.StatusOK || !control.State().Paused {
+		t.Errorf("expected pause without a body to succeed, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post("rate", `{"value": 0}`); rec.Code != http.StatusOK || control.State().Rate != 0 {
+		t.Errorf("expected a rate of 0 to be accepted, got %d %s", rec.Code, rec.Body)
+	}
+
+	rec := post("connections", `{"value": 0}`)
+	var resp ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusBadRequest || len(resp.Details) != 1 || resp.Details[0].Field != "value" {
+		t.Errorf("expected 0 connections to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post("rate", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a rate without a body to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post("reboot", "{}"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected an unknown action to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	hs := NewHTTPServer(0)
+	srv := httptest.NewServer(hs.server.Handler)
+	defer srv.Close()
+
+	for path, status := range map[string]int{
+		"/api/v1/status/missing":  http.StatusNotFound,
+		"/api/v1/tasks?limit=0":   http.StatusBadRequest,
+		"/api/v1/control/missing": http.StatusNotFound,
+		"/nowhere":                http.StatusNotFound,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body ErrorResponse
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != status || body.Status != status || body.Error != errorCode(status) || body.Message == "" {
+			t.Errorf("%s: expected a %d ErrorResponse, got %d %+v (%v)", path, status, resp.StatusCode, body, err)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaRefPrefix prefixes the references to the schemas of the components
+// of the OpenAPI document
+const schemaRefPrefix = "#/components/schemas/"
+
+// Schema is a schema of the OpenAPI document. The schemas of the request and
+// response types are generated from their Go types; request fields add
+// constraints with a schema struct tag, a comma separated list of required,
+// minimum=N, maximum=N, minItems=N, enum=a|b and format=duration|uri.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaGenerator generates the schemas of Go types, keeping one component
+// per named struct type
+type schemaGenerator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf returns the schema of a type as encoded by encoding/json, a
+// reference for struct types
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{} // any JSON value
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaOf(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			g.addFields(s, t)
+			return s
+		}
+		return g.ref(t)
+	default:
+		return &Schema{} // interface{}: any JSON value
+	}
+}
+
+// ref returns a reference to the component of a struct type, generating it
+// on first use
+func (g *schemaGenerator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.components[name]; taken {
+			pkg := path.Base(t.PkgPath())
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		g.names[t] = name
+
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		g.components[name] = s
+		g.addFields(s, t)
+	}
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// addFields adds the JSON fields of a struct to its schema, including the
+// fields of embedded structs
+func (g *schemaGenerator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schemaOf(f.Type)
+		if err := applySchemaTag(s, prop, name, f.Tag.Get("schema")); err != nil {
+			panic(fmt.Sprintf("%s.%s: %v", t.Name(), f.Name, err))
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applySchemaTag adds the constraints of the schema tag of a field to its
+// schema, and to the required fields of its parent
+func applySchemaTag(parent, s *Schema, name, tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "required":
+			parent.Required = append(parent.Required, name)
+		case "minimum", "maximum":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", key, value)
+			}
+			if key == "minimum" {
+				s.Minimum = &v
+			} else {
+				s.Maximum = &v
+			}
+		case "minItems":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid minItems %q", value)
+			}
+			s.MinItems = &n
+		case "enum":
+			s.Enum = strings.Split(value, "|")
+		case "format":
+			s.Format = value
+		default:
+			return fmt.Errorf("unknown schema option %q", key)
+		}
+	}
+	return nil
+}
+
+// validateValue checks a decoded request body, a pointer to a struct,
+// against the schema of its type
+func (g *schemaGenerator) validateValue(v interface{}) []FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	name, ok := g.names[rv.Type()]
+	if !ok {
+		panic(fmt.Sprintf("no schema for %s", rv.Type()))
+	}
+	return g.validate(g.components[name], rv, "", nil)
+}
+
+// validate checks a decoded value against a schema, appending the mismatches
+// to errs. Absent, null and empty fields decode to the zero value, so zero
+// values count as unset and are only checked by required; a nil pointer is
+// unset, the value of any other pointer is checked.
+func (g *schemaGenerator) validate(s *Schema, v reflect.Value, at string, errs []FieldError) []FieldError {
+	if s.Ref != "" {
+		s = g.components[strings.TrimPrefix(s.Ref, schemaRefPrefix)]
+	}
+	if s.Type == "" {
+		return errs // any JSON value
+	}
+	set := false
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return errs
+		}
+		v = v.Elem()
+		set = true
+	}
+	if !set && v.IsZero() && s.Type != "object" {
+		return errs
+	}
+	fail := func(format string, args ...interface{}) []FieldError {
+		return append(errs, FieldError{Field: fieldPath(at), Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		if v.Kind() == reflect.Map {
+			keys := v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, key := range keys {
+				errs = g.validate(s.AdditionalProperties, v.MapIndex(key), joinField(at, key.String()), errs)
+			}
+			return errs
+		}
+		fields := jsonFields(v)
+		for _, name := range s.Required {
+			if f, ok := fields[name]; !ok || f.IsZero() {
+				errs = append(errs, FieldError{Field: joinField(at, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop := s.Properties[name]; prop != nil {
+				errs = g.validate(prop, fields[name], joinField(at, name), errs)
+			}
+		}
+
+	case "array":
+		if s.MinItems != nil && v.Len() < *s.MinItems {
+			return fail("must have at least %d items", *s.MinItems)
+		}
+		if s.Items != nil {
+			for i := 0; i < v.Len(); i++ {
+				errs = g.validate(s.Items, v.Index(i), fmt.Sprintf("%s[%d]", at, i), errs)
+			}
+		}
+
+	case "string":
+		if v.Kind() != reflect.String {
+			return errs // time.Time, []byte
+		}
+		str := v.String()
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			return fail("must be one of %s, got %q", strings.Join(s.Enum, ", "), str)
+		}
+		if err := checkFormat(s.Format, str); err != nil {
+			return fail("%v", err)
+		}
+
+	case "integer", "number":
+		var f float64
+		switch {
+		case v.CanInt():
+			f = float64(v.Int())
+		case v.CanUint():
+			f = float64(v.Uint())
+		default:
+			f = v.Float()
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fail("must be at least %v, got %v", *s.Minimum, f)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fail("must be at most %v, got %v", *s.Maximum, f)
+		}
+	}
+	return errs
+}
+
+// jsonFields returns the fields of a struct by their JSON names, including
+// the fields of embedded structs, as addFields lists them in the schema
+func jsonFields(v reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			fv := reflect.Indirect(v.Field(i))
+			if fv.Kind() == reflect.Struct {
+				for n, sub := range jsonFields(fv) {
+					fields[n] = sub
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+// checkFormat checks a string against the format of its schema
+func checkFormat(format, s string) error {
+	switch format {
+	case "duration":
+		if _, err := time.ParseDuration(s); err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 1m30s, got %q", s)
+		}
+	case "uri":
+		if u, err := url.Parse(s); err != nil || !u.IsAbs() {
+			return fmt.Errorf("must be an absolute URL, got %q", s)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("must be an RFC 3339 time, got %q", s)
+		}
+	}
+	return nil
+}
+
+// jsonTypeName names the JSON type expected by a Go type in errors
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+func joinField(at, name string) string {
+	if at == "" {
+		return name
+	}
+	return at + "." + name
+}
+
+// fieldPath names the field at a path in errors, the root being the body
+func fieldPath(at string) string {
+	if at == "" {
+		return "body"
+	}
+	return at
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/antlabs/gurl/internal/config"
 )
 
 // HTTPServer wraps the HTTP server
@@ -23,8 +26,16 @@ func NewHTTPServer(port int) *HTTPServer {
 	// Register routes
 	mux.HandleFunc("/api/v1/benchmark", apiServer.handleBenchmark)
 	mux.HandleFunc("/api/v1/batch", apiServer.handleBatch)
+	mux.HandleFunc("/api/v1/compare", apiServer.handleCompare)
+	mux.HandleFunc("/api/v1/tasks", apiServer.handleTasks)
+	mux.HandleFunc("/api/v1/tasks/", apiServer.handleTaskRoutes)
 	mux.HandleFunc("/api/v1/status/", apiServer.handleStatus)
 	mux.HandleFunc("/api/v1/results/", apiServer.handleResults)
+	mux.HandleFunc("/api/v1/control/", apiServer.handleControl)
+	mux.HandleFunc("/api/v1/report/", apiServer.handleReport)
+	mux.HandleFunc("/api/v1/openapi.json", apiServer.handleOpenAPI)
+	mux.Handle("/metrics", apiServer.metrics)
+	mux.Handle("/ui/", dashboardHandler())
 
 	// Health check endpoint
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -36,7 +47,12 @@ func NewHTTPServer(port int) *HTTPServer {
 	// Root endpoint with API info
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
-			http.NotFound(w, r)
+			writeError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		// Browsers are sent to the dashboard, API clients get the route list
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			http.Redirect(w, r, "/ui/", http.StatusFound)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -47,8 +63,19 @@ func NewHTTPServer(port int) *HTTPServer {
 			"endpoints": {
 				"benchmark": "POST /api/v1/benchmark",
 				"batch": "POST /api/v1/batch",
+				"compare": "POST /api/v1/compare",
+				"tasks": "GET /api/v1/tasks[?status=&kind=&limit=&offset=]",
+				"task": "GET|DELETE /api/v1/tasks/:id",
+				"cancel": "POST /api/v1/tasks/:id/cancel",
+				"stream": "GET /api/v1/tasks/:id/stream (SSE or WebSocket)",
 				"status": "GET /api/v1/status/:id",
 				"results": "GET /api/v1/results/:id",
+				"control": "GET|POST /api/v1/control/:id[/pause|/resume|/rate|/connections|/annotate]",
+				"report": "GET /api/v1/report/:id",
+				"openapi": "GET /api/v1/openapi.json",
+				"dashboard": "GET /ui/",
+				"docs": "GET /ui/#/api",
+				"metrics": "GET /metrics",
 				"health": "GET /health"
 			}
 		}`)
@@ -56,7 +83,7 @@ func NewHTTPServer(port int) *HTTPServer {
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      mux,
+		Handler:      apiServer.authorize(mux),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -69,23 +96,77 @@ func NewHTTPServer(port int) *HTTPServer {
 	}
 }
 
+// SetTaskRetention sets how long finished tasks are kept in memory and how
+// many tasks are kept at most. Evicted tasks are still served from the task
+// store, see SetHistoryDB.
+func (s *HTTPServer) SetTaskRetention(ttl time.Duration, maxTasks int) {
+	s.apiServer.taskManager.SetRetention(ttl, maxTasks)
+}
+
+// SetAccess requires the API keys of an access file (see
+// config.APIAccessConfig) on every request but the health check and the
+// dashboard page
+func (s *HTTPServer) SetAccess(path string) error {
+	cfg, err := config.LoadAPIAccessConfig(path)
+	if err != nil {
+		return err
+	}
+	access, err := newAccessControl(cfg)
+	if err != nil {
+		return err
+	}
+	s.apiServer.access = access
+	return nil
+}
+
+// SetConcurrency sets how many tasks run at once, the others wait in a
+// queue, and whether two tasks may load the same host at once
+func (s *HTTPServer) SetConcurrency(maxConcurrent int, exclusiveTargets bool) {
+	s.apiServer.taskManager.SetConcurrency(maxConcurrent, exclusiveTargets)
+}
+
 // Start starts the HTTP server
 func (s *HTTPServer) Start() error {
 	fmt.Printf("Starting gurl API server on port %d\n", s.port)
 	fmt.Printf("API endpoints:\n")
 	fmt.Printf("  POST   /api/v1/benchmark - Submit a benchmark task\n")
 	fmt.Printf("  POST   /api/v1/batch     - Submit a batch test task\n")
+	fmt.Printf("  POST   /api/v1/compare   - Submit a compare task\n")
+	fmt.Printf("  GET    /api/v1/tasks     - List tasks (filter by status, kind; limit, offset)\n")
+	fmt.Printf("  GET    /api/v1/tasks/:id - Get task status\n")
+	fmt.Printf("  DELETE /api/v1/tasks/:id - Cancel a running task or delete a finished one\n")
+	fmt.Printf("  POST   /api/v1/tasks/:id/cancel - Cancel a running task\n")
+	fmt.Printf("  GET    /api/v1/tasks/:id/stream - Live progress (SSE or WebSocket)\n")
 	fmt.Printf("  GET    /api/v1/status/:id - Get task status\n")
 	fmt.Printf("  GET    /api/v1/results/:id - Get task results\n")
+	fmt.Printf("  POST   /api/v1/control/:id/:action - Pause, resume or adjust a running benchmark\n")
+	fmt.Printf("  GET    /api/v1/report/:id - Get the chartable report of a task\n")
+	fmt.Printf("  GET    /api/v1/openapi.json - OpenAPI 3 document of the API\n")
+	fmt.Printf("  GET    /ui/              - Web dashboard, API reference at /ui/#/api\n")
+	fmt.Printf("  GET    /metrics          - Prometheus metrics of tasks\n")
 	fmt.Printf("  GET    /health           - Health check\n")
 	fmt.Printf("  GET    /                 - API information\n")
+	if s.apiServer.access == nil {
+		fmt.Printf("Warning: API authentication is disabled, anyone who can reach port %d can start load tests (see --api-auth)\n", s.port)
+	} else {
+		fmt.Printf("API authentication enabled with %d keys\n", len(s.apiServer.access.keys))
+	}
 
 	return s.server.ListenAndServe()
 }
 
-// Stop stops the HTTP server gracefully
+// Stop stops the HTTP server gracefully, gives up the webhook deliveries
+// still pending and closes the task store
 func (s *HTTPServer) Stop(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+	s.apiServer.webhooks.close()
+	if cerr := s.apiServer.taskManager.Close(); err == nil {
+		err = cerr
+	}
+	if s.apiServer.access != nil {
+		s.apiServer.access.audit.Close()
+	}
+	return err
 }
 
 // StartWithContext starts the server and handles shutdown signals
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+const (
+	// streamEventSnapshot is sent every second while a benchmark runs
+	streamEventSnapshot = "snapshot"
+	// streamEventDone is sent once with the final results, then the stream ends
+	streamEventDone = "done"
+
+	// streamBuffer is how many events a slow client may lag behind before
+	// snapshots are dropped for it
+	streamBuffer = 16
+	// streamKeepAlive is the interval of SSE comments that keep idle
+	// connections (e.g. during warm-up) open through proxies
+	streamKeepAlive = 15 * time.Second
+)
+
+// StreamSnapshot is the per-second progress of a running benchmark. Interval
+// values cover the last second, totals the whole measured run so far.
+type StreamSnapshot struct {
+	TaskID          string           `json:"task_id"`
+	ElapsedSec      float64          `json:"elapsed_sec"`
+	PercentComplete float64          `json:"percent_complete"`
+	Requests        int64            `json:"requests"`
+	Errors          int64            `json:"errors"`
+	RequestsPerSec  float64          `json:"requests_per_sec"`
+	Latency         StreamLatency    `json:"latency"`
+	StatusCodes     map[int]int64    `json:"status_codes,omitempty"`
+	TotalRequests   int64            `json:"total_requests"`
+	TotalErrors     int64            `json:"total_errors"`
+	Annotations     []AnnotationJSON `json:"annotations,omitempty"`
+}
+
+// StreamLatency holds the latency percentiles of one second, in milliseconds
+type StreamLatency struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+// streamEvent is an encoded event ready to be written to subscribers
+type streamEvent struct {
+	name string
+	data []byte
+}
+
+// streamHub fans out the events of running tasks to their subscribers
+type streamHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan streamEvent]struct{}
+	last map[string]streamEvent // latest snapshot, sent to new subscribers
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{
+		subs: make(map[string]map[chan streamEvent]struct{}),
+		last: make(map[string]streamEvent),
+	}
+}
+
+// subscribe returns a channel receiving the events of a task, starting with
+// the latest snapshot, and a func to unsubscribe
+func (h *streamHub) subscribe(taskID string) (<-chan streamEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan streamEvent, streamBuffer)
+	if ev, ok := h.last[taskID]; ok {
+		ch <- ev
+	}
+	if h.subs[taskID] == nil {
+		h.subs[taskID] = make(map[chan streamEvent]struct{})
+	}
+	h.subs[taskID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if subs, ok := h.subs[taskID]; ok {
+			if _, ok := subs[ch]; ok {
+				delete(subs, ch)
+				close(ch)
+			}
+			if len(subs) == 0 {
+				delete(h.subs, taskID)
+			}
+		}
+	}
+}
+
+// publish sends a snapshot to the subscribers of a task. Subscribers that
+// are too slow miss it rather than holding up the sampling loop.
+func (h *streamHub) publish(taskID string, ev streamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.last[taskID] = ev
+	for ch := range h.subs[taskID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// finish sends the final event of a task and ends its streams
+func (h *streamHub) finish(taskID string, ev streamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[taskID] {
+		// Make room so the final event is never the one that is dropped. The
+		// subscriber may have drained the channel in the meantime, so the
+		// receive must not block; only the hub sends, so there is room after it.
+		select {
+		case ch <- ev:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- ev
+		}
+		close(ch)
+	}
+	delete(h.subs, taskID)
+	delete(h.last, taskID)
+}
+
+// streamObserver publishes the samples of a benchmark task to the hub
+type streamObserver struct {
+	hub    *streamHub
+	taskID string
+	cfg    config.Config
+
+	totalRequests int64
+	totalErrors   int64
+	lastElapsed   time.Duration
+}
+
+// OnSample implements benchmark.Observer
+func (o *streamObserver) OnSample(results *stats.Results, sample stats.Sample) {
+	o.totalRequests += sample.Requests
+	o.totalErrors += sample.Errors
+
+	snapshot := StreamSnapshot{
+		TaskID:          o.taskID,
+		ElapsedSec:      sample.Elapsed.Seconds(),
+		PercentComplete: percentComplete(o.cfg, sample.Elapsed, o.totalRequests),
+		Requests:        sample.Requests,
+		Errors:          sample.Errors,
+		Latency: StreamLatency{
+			P50: float64(sample.P50) / float64(time.Millisecond),
+			P90: float64(sample.P90) / float64(time.Millisecond),
+			P99: float64(sample.P99) / float64(time.Millisecond),
+		},
+		StatusCodes:   sample.StatusCodes,
+		TotalRequests: o.totalRequests,
+		TotalErrors:   o.totalErrors,
+	}
+	if window := sample.Elapsed - o.lastElapsed; window > 0 {
+		snapshot.RequestsPerSec = float64(sample.Requests) / window.Seconds()
+	}
+	o.lastElapsed = sample.Elapsed
+	for _, a := range results.GetAnnotations() {
+		snapshot.Annotations = append(snapshot.Annotations, AnnotationJSON{Elapsed: formatDuration(a.Elapsed), Label: a.Label})
+	}
+
+	if ev, err := encodeStreamEvent(streamEventSnapshot, snapshot); err == nil {
+		o.hub.publish(o.taskID, ev)
+	}
+}
+
+// OnFinish implements benchmark.Observer; the done event is sent once the
+// task has stored its results
+func (o *streamObserver) OnFinish(results *stats.Results) {}
+
+// percentComplete estimates the progress of a run from its duration, or from
+// its request count when that limit is reached first
+func percentComplete(cfg config.Config, elapsed time.Duration, requests int64) float64 {
+	var pct float64
+	if cfg.Duration > 0 {
+		pct = float64(elapsed) / float64(cfg.Duration) * 100
+	}
+	if cfg.Requests > 0 {
+		pct = max(pct, float64(requests)/float64(cfg.Requests)*100)
+	}
+	return min(pct, 100)
+}
+
+func encodeStreamEvent(name string, v any) (streamEvent, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return streamEvent{}, err
+	}
+	return streamEvent{name: name, data: data}, nil
+}
+
+// finishStream ends the streams of a task with its final results
+func (s *Server) finishStream(task *Task) {
+	response, ok := s.taskResults(task.ID)
+	if !ok {
+		return
+	}
+	if ev, err := encodeStreamEvent(streamEventDone, response); err == nil {
+		s.streams.finish(task.ID, ev)
+	}
+}
+
+// handleStream handles GET /api/v1/tasks/:id/stream. Events are sent as
+// Server-Sent Events, or as JSON text messages {"event": ..., "data": ...}
+// when the request is a WebSocket upgrade.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Subscribe before looking at the status so a task finishing in between
+	// still delivers its done event
+	events, unsubscribe := s.streams.subscribe(taskID)
+	defer unsubscribe()
+
+	var done *streamEvent
+	task, exists := s.taskManager.GetTask(taskID)
+	if !exists || task.Status.Finished() {
+		response, ok := s.taskResults(taskID)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Task not found")
+			return
+		}
+		ev, err := encodeStreamEvent(streamEventDone, response)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		done = &ev
+	}
+
+	if isWebSocketRequest(r) {
+		s.streamWebSocket(w, r, events, done)
+		return
+	}
+	s.streamSSE(w, r, events, done)
+}
+
+// streamSSE writes events as Server-Sent Events until the done event
+func (s *Server) streamSSE(w http.ResponseWriter, r *http.Request, events <-chan streamEvent, done *streamEvent) {
+	rc := http.NewResponseController(w)
+	// A stream lasts as long as the task, beyond the server write timeout
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(ev streamEvent) error {
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if done != nil {
+		write(*done)
+		return
+	}
+	rc.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if write(ev) != nil || ev.name == streamEventDone {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// streamWebSocket writes events as WebSocket text messages until the done
+// event or until the client goes away
+func (s *Server) streamWebSocket(w http.ResponseWriter, r *http.Request, events <-chan streamEvent, done *streamEvent) {
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		ws.readLoop()
+		close(closed)
+	}()
+
+	write := func(ev streamEvent) error {
+		msg := fmt.Sprintf(`{"event":%q,"data":%s}`, ev.name, ev.data)
+		return ws.WriteText([]byte(msg))
+	}
+
+	if done != nil {
+		write(*done)
+		return
+	}
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if write(ev) != nil || ev.name == streamEventDone {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func TestWebSocketAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3
+	if got := wsAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("wsAcceptKey = %q", got)
+	}
+}
+
+func TestPercentComplete(t *testing.T) {
+	cfg := config.Config{Duration: 10 * time.Second}
+	if got := percentComplete(cfg, 2500*time.Millisecond, 0); got != 25 {
+		t.Errorf("expected 25%%, got %v", got)
+	}
+	cfg.Requests = 100
+	if got := percentComplete(cfg, time.Second, 60); got != 60 {
+		t.Errorf("the request limit is reached first, expected 60%%, got %v", got)
+	}
+	if got := percentComplete(cfg, 20*time.Second, 0); got != 100 {
+		t.Errorf("expected progress to be capped at 100%%, got %v", got)
+	}
+}
+
+// startStreamTask creates a running task that finishes when the returned
+// channel is closed
+func startStreamTask(s *Server) (string, chan struct{}) {
+	release := make(chan struct{})
+	taskID := generateTaskID()
+	s.taskManager.CreateTask(taskID, map[string]interface{}{"url": "http://127.0.0.1"})
+	s.taskManager.RunTask(context.Background(), taskID, func(ctx context.Context) (*stats.Results, error) {
+		<-release
+		results := stats.NewResults()
+		results.TotalRequests = 42
+		return results, nil
+	})
+	return taskID, release
+}
+
+func TestStreamSSE(t *testing.T) {
+	s := NewServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleTaskRoutes))
+	defer srv.Close()
+
+	taskID, release := startStreamTask(s)
+	observer := &streamObserver{hub: s.streams, taskID: taskID, cfg: config.Config{Duration: 4 * time.Second}}
+	observer.OnSample(stats.NewResults(), stats.Sample{Elapsed: time.Second, Requests: 10, P99: 5 * time.Millisecond})
+
+	resp, err := http.Get(srv.URL + "/api/v1/tasks/" + taskID + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	name, data := readSSEEvent(t, reader)
+	if name != streamEventSnapshot {
+		t.Fatalf("expected the latest snapshot first, got %q", name)
+	}
+	var snapshot StreamSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Requests != 10 || snapshot.PercentComplete != 25 || snapshot.Latency.P99 != 5 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+
+	close(release)
+	name, data = readSSEEvent(t, reader)
+	if name != streamEventDone {
+		t.Fatalf("expected the done event, got %q", name)
+	}
+	var done TaskResultsResponse
+	if err := json.Unmarshal(data, &done); err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != string(TaskStatusCompleted) || done.Results == nil || done.Results.TotalRequests != 42 {
+		t.Errorf("unexpected done event: %s", data)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("expected the stream to end after the done event, got %v", err)
+	}
+}
+
+func TestStreamWebSocketFinishedTask(t *testing.T) {
+	s := NewServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleTaskRoutes))
+	defer srv.Close()
+
+	taskID, release := startStreamTask(s)
+	close(release)
+	for i := 0; i < 100; i++ {
+		if task, _ := s.taskManager.GetTask(taskID); task.Status == TaskStatusCompleted {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET /api/v1/tasks/%s/stream HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", taskID)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected handshake response: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	opcode, payload := readWSFrame(t, reader)
+	if opcode != wsOpText {
+		t.Fatalf("expected a text frame, got opcode %d", opcode)
+	}
+	var msg struct {
+		Event string              `json:"event"`
+		Data  TaskResultsResponse `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Event != streamEventDone || msg.Data.ID != taskID {
+		t.Errorf("unexpected message: %s", payload)
+	}
+	if opcode, _ := readWSFrame(t, reader); opcode != wsOpClose {
+		t.Errorf("expected a close frame after the done event, got opcode %d", opcode)
+	}
+}
+
+func readSSEEvent(t *testing.T, r *bufio.Reader) (string, []byte) {
+	t.Helper()
+	var name string
+	var data []byte
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended early: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = []byte(strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func readWSFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(r, ext[:])
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestStreamHubFinishWithFullBuffer(t *testing.T) {
+	h := newStreamHub()
+	for i := 0; i < 100; i++ {
+		ch, _ := h.subscribe("t1")
+		for j := 0; j < streamBuffer; j++ {
+			h.publish("t1", streamEvent{name: "snapshot"})
+		}
+
+		// The subscriber drains the channel while the hub finishes
+		var last streamEvent
+		drained := make(chan struct{})
+		go func() {
+			defer close(drained)
+			for ev := range ch {
+				last = ev
+			}
+		}()
+		finished := make(chan struct{})
+		go func() {
+			h.finish("t1", streamEvent{name: "done"})
+			close(finished)
+		}()
+
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("finish blocked on a subscriber that drained its channel")
+		}
+		<-drained
+		if last.name != "done" {
+			t.Fatalf("expected the final event last, got %q", last.name)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/antlabs/gurl/internal/report"
 	"github.com/antlabs/gurl/internal/stats"
 )
 
@@ -14,9 +18,34 @@ type TaskStatus string
 
 const (
 	TaskStatusPending   TaskStatus = "pending"
+	TaskStatusQueued    TaskStatus = "queued"
 	TaskStatusRunning   TaskStatus = "running"
 	TaskStatusCompleted TaskStatus = "completed"
 	TaskStatusFailed    TaskStatus = "failed"
+	TaskStatusCanceled  TaskStatus = "canceled"
+
+	// TaskStatusInterrupted is the status of stored tasks that had not
+	// finished when the API server stopped
+	TaskStatusInterrupted TaskStatus = "interrupted"
+)
+
+// Finished reports whether a task in this status has stopped running
+func (s TaskStatus) Finished() bool {
+	return s == TaskStatusCompleted || s == TaskStatusFailed || s == TaskStatusCanceled || s == TaskStatusInterrupted
+}
+
+const (
+	// DefaultTaskTTL is how long finished tasks are kept in memory
+	DefaultTaskTTL = 24 * time.Hour
+	// DefaultMaxTasks is how many tasks are kept in memory; the oldest
+	// finished tasks are evicted first
+	DefaultMaxTasks = 1000
+)
+
+var (
+	ErrTaskNotFound   = errors.New("task not found")
+	ErrTaskNotRunning = errors.New("task is not running")
+	ErrTaskRunning    = errors.New("task is still running")
 )
 
 // Task represents a benchmark task
@@ -29,25 +58,144 @@ type Task struct {
 	Error       string                 `json:"error,omitempty"`
 	Results     *stats.Results         `json:"results,omitempty"`
 	Config      map[string]interface{} `json:"config,omitempty"`
+	Report      *report.Report         `json:"-"`               // chartable report, set when the task finishes
+	Batch       json.RawMessage        `json:"batch,omitempty"` // per-test results of a batch task
+
+	Priority      int            `json:"priority,omitempty"`
+	QueuePosition int            `json:"queue_position,omitempty"` // 1-based position while queued
+	Webhook       *WebhookStatus `json:"webhook,omitempty"`        // completion webhook, when a callback URL was given
+
+	canceled      bool                  // cancellation was requested
+	storedResults *BenchmarkResultsJSON // results of a task loaded from the task store
+}
+
+// ResultsJSON returns the results of the task as returned by the results
+// endpoint, or nil when it has none
+func (t *Task) ResultsJSON() *BenchmarkResultsJSON {
+	if t.Results != nil {
+		return convertResultsToJSON(t.Results)
+	}
+	return t.storedResults
 }
 
 // TaskManager manages benchmark tasks
 type TaskManager struct {
-	mu    sync.RWMutex
-	tasks map[string]*Task
+	mu       sync.RWMutex
+	tasks    map[string]*Task
+	cancels  map[string]context.CancelFunc
+	ttl      time.Duration
+	maxTasks int
+	store    TaskStore
+
+	// Scheduling, see task_queue.go
+	queue            []*queuedRun
+	running          map[string]*queuedRun
+	seq              uint64
+	maxConcurrent    int
+	exclusiveTargets bool
+
+	// Writes to the store happen outside mu: persistLocked queues snapshots
+	// in pending, flushStore writes them in order under storeMu
+	storeMu sync.Mutex
+	pending []*Task
+
+	hooksMu     sync.Mutex
+	finishHooks []func(task *Task)
+	removeHooks []func(id string)
 }
 
 // NewTaskManager creates a new task manager
 func NewTaskManager() *TaskManager {
 	return &TaskManager{
-		tasks: make(map[string]*Task),
+		tasks:         make(map[string]*Task),
+		cancels:       make(map[string]context.CancelFunc),
+		ttl:           DefaultTaskTTL,
+		maxTasks:      DefaultMaxTasks,
+		store:         NewMemoryTaskStore(),
+		running:       make(map[string]*queuedRun),
+		maxConcurrent: DefaultMaxConcurrentTasks,
+	}
+}
+
+// SetRetention sets how long finished tasks are kept and how many tasks are
+// kept at most; 0 disables the limit
+func (tm *TaskManager) SetRetention(ttl time.Duration, maxTasks int) {
+	tm.mu.Lock()
+	tm.ttl = ttl
+	tm.maxTasks = maxTasks
+	evicted := tm.evictLocked(time.Now())
+	tm.mu.Unlock()
+	tm.notifyRemove(evicted)
+}
+
+// SetStore replaces the task store. Stored tasks that had not finished when
+// the server stopped are marked interrupted; their number is returned.
+func (tm *TaskManager) SetStore(store TaskStore) (int, error) {
+	interrupted, err := interruptStored(store)
+	if err != nil {
+		return interrupted, err
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.store = store
+	return interrupted, nil
+}
+
+// Close closes the task store once the pending writes are done. Tasks still
+// running are then only kept in memory.
+func (tm *TaskManager) Close() error {
+	tm.storeMu.Lock()
+	defer tm.storeMu.Unlock()
+	tm.flushStoreLocked()
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	err := tm.store.Close()
+	tm.store = NewMemoryTaskStore()
+	return err
+}
+
+// persistLocked queues a snapshot of the current state of a task to be
+// written through to the store. The caller calls flushStore once it has
+// released mu, so converting the results and writing them don't block other
+// tasks.
+func (tm *TaskManager) persistLocked(task *Task) {
+	if _, inMemory := tm.store.(memoryTaskStore); inMemory {
+		// Nothing to write, skip the snapshot
+		return
 	}
+	snapshot := *task
+	tm.pending = append(tm.pending, &snapshot)
+}
+
+// flushStore writes the queued task snapshots to the store
+func (tm *TaskManager) flushStore() {
+	tm.storeMu.Lock()
+	defer tm.storeMu.Unlock()
+	tm.flushStoreLocked()
+}
+
+// flushStoreLocked writes the queued task snapshots in the order they were
+// taken, so the store ends with the latest state of each task, and returns
+// the store. storeMu must be held.
+func (tm *TaskManager) flushStoreLocked() TaskStore {
+	tm.mu.Lock()
+	pending, store := tm.pending, tm.store
+	tm.pending = nil
+	tm.mu.Unlock()
+
+	for _, task := range pending {
+		if err := store.Save(storedTask(task)); err != nil {
+			fmt.Printf("task store: %v\n", err)
+		}
+	}
+	return store
 }
 
 // CreateTask creates a new task
 func (tm *TaskManager) CreateTask(id string, config map[string]interface{}) *Task {
 	tm.mu.Lock()
-	defer tm.mu.Unlock()
 
 	task := &Task{
 		ID:        id,
@@ -57,18 +205,34 @@ func (tm *TaskManager) CreateTask(id string, config map[string]interface{}) *Tas
 	}
 
 	tm.tasks[id] = task
+	tm.persistLocked(task)
+	evicted := tm.evictLocked(task.CreatedAt)
+	tm.mu.Unlock()
+	tm.flushStore()
+	tm.notifyRemove(evicted)
 	return task
 }
 
-// GetTask retrieves a task by ID
+// GetTask retrieves a task by ID. Tasks no longer held in memory are loaded
+// from the task store.
 func (tm *TaskManager) GetTask(id string) (*Task, bool) {
 	tm.mu.RLock()
-	defer tm.mu.RUnlock()
-
 	task, exists := tm.tasks[id]
 	if !exists {
-		return nil, false
+		// Read the store without holding the lock
+		store := tm.store
+		tm.mu.RUnlock()
+
+		stored, err := store.Get(id)
+		if err != nil {
+			if !errors.Is(err, ErrTaskNotFound) {
+				fmt.Printf("task store: %v\n", err)
+			}
+			return nil, false
+		}
+		return stored.task(), true
 	}
+	defer tm.mu.RUnlock()
 
 	// Return a copy to avoid race conditions
 	return &Task{
@@ -80,12 +244,45 @@ func (tm *TaskManager) GetTask(id string) (*Task, bool) {
 		Error:       task.Error,
 		Results:     task.Results, // Results is already thread-safe
 		Config:      task.Config,
+		Report:      task.Report,
+		Batch:       task.Batch,
+
+		Priority:      task.Priority,
+		QueuePosition: task.QueuePosition,
+		Webhook:       task.Webhook,
+
+		storedResults: task.storedResults,
 	}, true
 }
 
+// ListStoredTasks returns the stored tasks that are no longer held in
+// memory, without their results
+func (tm *TaskManager) ListStoredTasks() []*Task {
+	tm.mu.RLock()
+	store := tm.store
+	tm.mu.RUnlock()
+
+	stored, err := store.List()
+	if err != nil {
+		fmt.Printf("task store: %v\n", err)
+		return nil
+	}
+
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	tasks := make([]*Task, 0, len(stored))
+	for _, st := range stored {
+		if _, inMemory := tm.tasks[st.ID]; !inMemory {
+			tasks = append(tasks, st.task())
+		}
+	}
+	return tasks
+}
+
 // UpdateTaskStatus updates the status of a task
 func (tm *TaskManager) UpdateTaskStatus(id string, status TaskStatus) error {
 	tm.mu.Lock()
+	defer tm.flushStore()
 	defer tm.mu.Unlock()
 
 	task, exists := tm.tasks[id]
@@ -107,12 +304,14 @@ func (tm *TaskManager) UpdateTaskStatus(id string, status TaskStatus) error {
 		}
 	}
 
+	tm.persistLocked(task)
 	return nil
 }
 
 // SetTaskError sets the error message for a task
 func (tm *TaskManager) SetTaskError(id string, err error) error {
 	tm.mu.Lock()
+	defer tm.flushStore()
 	defer tm.mu.Unlock()
 
 	task, exists := tm.tasks[id]
@@ -127,12 +326,14 @@ func (tm *TaskManager) SetTaskError(id string, err error) error {
 		task.CompletedAt = &now
 	}
 
+	tm.persistLocked(task)
 	return nil
 }
 
 // SetTaskResults sets the results for a completed task
 func (tm *TaskManager) SetTaskResults(id string, results *stats.Results) error {
 	tm.mu.Lock()
+	defer tm.flushStore()
 	defer tm.mu.Unlock()
 
 	task, exists := tm.tasks[id]
@@ -147,9 +348,202 @@ func (tm *TaskManager) SetTaskResults(id string, results *stats.Results) error {
 		task.CompletedAt = &now
 	}
 
+	tm.persistLocked(task)
 	return nil
 }
 
+// SetTaskCanceled marks a task as canceled, keeping the results measured
+// until it stopped
+func (tm *TaskManager) SetTaskCanceled(id string, results *stats.Results) error {
+	tm.mu.Lock()
+	defer tm.flushStore()
+	defer tm.mu.Unlock()
+
+	task, exists := tm.tasks[id]
+	if !exists {
+		return fmt.Errorf("task %s not found", id)
+	}
+
+	task.Results = results
+	task.Status = TaskStatusCanceled
+	task.Error = "canceled"
+	now := time.Now()
+	if task.CompletedAt == nil {
+		task.CompletedAt = &now
+	}
+
+	tm.persistLocked(task)
+	return nil
+}
+
+// SetTaskReport stores the chartable report of a finished task
+func (tm *TaskManager) SetTaskReport(id string, rep *report.Report) error {
+	tm.mu.Lock()
+	defer tm.flushStore()
+	defer tm.mu.Unlock()
+
+	task, exists := tm.tasks[id]
+	if !exists {
+		return fmt.Errorf("task %s not found", id)
+	}
+
+	task.Report = rep
+	tm.persistLocked(task)
+	return nil
+}
+
+// SetTaskBatch stores the per-test results of a finished batch task
+func (tm *TaskManager) SetTaskBatch(id string, results json.RawMessage) error {
+	tm.mu.Lock()
+	defer tm.flushStore()
+	defer tm.mu.Unlock()
+
+	task, exists := tm.tasks[id]
+	if !exists {
+		return fmt.Errorf("task %s not found", id)
+	}
+
+	task.Batch = results
+	tm.persistLocked(task)
+	return nil
+}
+
+// SetTaskWebhook stores the delivery log of the completion webhook of a
+// task. The status is replaced, never modified, so copies of the task may
+// share it.
+func (tm *TaskManager) SetTaskWebhook(id string, status *WebhookStatus) error {
+	tm.mu.Lock()
+	defer tm.flushStore()
+	defer tm.mu.Unlock()
+
+	task, exists := tm.tasks[id]
+	if !exists {
+		return fmt.Errorf("task %s not found", id)
+	}
+
+	task.Webhook = status
+	tm.persistLocked(task)
+	return nil
+}
+
+// CancelTask stops a running task, or removes a queued one. A running task
+// keeps running until its runner returns, then its status becomes canceled.
+func (tm *TaskManager) CancelTask(id string) error {
+	tm.mu.Lock()
+
+	task, exists := tm.tasks[id]
+	if !exists {
+		tm.mu.Unlock()
+		if _, stored := tm.GetTask(id); stored {
+			return ErrTaskNotRunning
+		}
+		return ErrTaskNotFound
+	}
+	cancel, ok := tm.cancels[id]
+	if !ok || task.Status.Finished() {
+		tm.mu.Unlock()
+		return ErrTaskNotRunning
+	}
+
+	task.canceled = true
+	cancel()
+	if !tm.dequeueLocked(id) {
+		tm.mu.Unlock()
+		return nil
+	}
+
+	// A queued task never started, it is finished right away
+	delete(tm.cancels, id)
+	now := time.Now()
+	task.Status = TaskStatusCanceled
+	task.Error = "canceled"
+	task.QueuePosition = 0
+	task.CompletedAt = &now
+	tm.persistLocked(task)
+	tm.mu.Unlock()
+
+	tm.flushStore()
+	tm.notifyFinish(id)
+	return nil
+}
+
+// IsCanceled reports whether cancellation of a task was requested
+func (tm *TaskManager) IsCanceled(id string) bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	task, exists := tm.tasks[id]
+	return exists && task.canceled
+}
+
+// DeleteTask removes a finished task from memory and from the task store
+func (tm *TaskManager) DeleteTask(id string) error {
+	tm.mu.Lock()
+	task, exists := tm.tasks[id]
+	if exists && !task.Status.Finished() {
+		tm.mu.Unlock()
+		return ErrTaskRunning
+	}
+
+	delete(tm.tasks, id)
+	tm.mu.Unlock()
+
+	// Write the pending snapshots first, so none of them brings the task back
+	tm.storeMu.Lock()
+	err := tm.flushStoreLocked().Delete(id)
+	tm.storeMu.Unlock()
+
+	if exists || err == nil {
+		tm.notifyRemove([]string{id})
+	}
+	if exists && errors.Is(err, ErrTaskNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Evict removes the finished tasks that are past the TTL or beyond the
+// maximum task count, and returns how many were removed
+func (tm *TaskManager) Evict() int {
+	tm.mu.Lock()
+	evicted := tm.evictLocked(time.Now())
+	tm.mu.Unlock()
+	tm.notifyRemove(evicted)
+	return len(evicted)
+}
+
+// evictLocked removes the finished tasks past the retention limits from
+// memory and returns their IDs; the caller calls notifyRemove once it has
+// released the lock
+func (tm *TaskManager) evictLocked(now time.Time) []string {
+	var finished []*Task
+	var evicted []string
+	for id, task := range tm.tasks {
+		if !task.Status.Finished() || task.CompletedAt == nil {
+			continue
+		}
+		if tm.ttl > 0 && now.Sub(*task.CompletedAt) > tm.ttl {
+			delete(tm.tasks, id)
+			evicted = append(evicted, id)
+			continue
+		}
+		finished = append(finished, task)
+	}
+
+	if tm.maxTasks > 0 && len(tm.tasks) > tm.maxTasks {
+		// Running tasks are never evicted, the oldest finished ones go first
+		sort.Slice(finished, func(i, j int) bool { return finished[i].CompletedAt.Before(*finished[j].CompletedAt) })
+		for _, task := range finished {
+			if len(tm.tasks) <= tm.maxTasks {
+				break
+			}
+			delete(tm.tasks, task.ID)
+			evicted = append(evicted, task.ID)
+		}
+	}
+	return evicted
+}
+
 // ListTasks returns all tasks
 func (tm *TaskManager) ListTasks() []*Task {
 	tm.mu.RLock()
@@ -166,26 +560,63 @@ func (tm *TaskManager) ListTasks() []*Task {
 			Error:       task.Error,
 			Config:      task.Config,
 			// Don't include Results in list to save memory
+
+			Priority:      task.Priority,
+			QueuePosition: task.QueuePosition,
 		})
 	}
 
 	return tasks
 }
 
-// RunTask runs a benchmark task asynchronously
-func (tm *TaskManager) RunTask(ctx context.Context, id string, runner func(context.Context) (*stats.Results, error)) {
-	// Update status to running
-	tm.UpdateTaskStatus(id, TaskStatusRunning)
+// OnFinish registers fn to be called with a copy of each task once it has
+// completed or failed
+func (tm *TaskManager) OnFinish(fn func(task *Task)) {
+	tm.hooksMu.Lock()
+	defer tm.hooksMu.Unlock()
+	tm.finishHooks = append(tm.finishHooks, fn)
+}
 
-	// Run benchmark in goroutine
-	go func() {
-		results, err := runner(ctx)
-		if err != nil {
-			tm.SetTaskError(id, err)
-			return
+// notifyFinish calls the finish hooks for a task
+func (tm *TaskManager) notifyFinish(id string) {
+	task, exists := tm.GetTask(id)
+	if !exists {
+		return
+	}
+
+	tm.hooksMu.Lock()
+	hooks := append([]func(*Task){}, tm.finishHooks...)
+	tm.hooksMu.Unlock()
+	for _, fn := range hooks {
+		fn(task)
+	}
+}
+
+// OnRemove registers fn to be called with the ID of each task deleted or
+// evicted from memory, to release the state kept for it elsewhere
+func (tm *TaskManager) OnRemove(fn func(id string)) {
+	tm.hooksMu.Lock()
+	defer tm.hooksMu.Unlock()
+	tm.removeHooks = append(tm.removeHooks, fn)
+}
+
+// notifyRemove calls the remove hooks for tasks
+func (tm *TaskManager) notifyRemove(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	tm.hooksMu.Lock()
+	hooks := append([]func(string){}, tm.removeHooks...)
+	tm.hooksMu.Unlock()
+	for _, id := range ids {
+		for _, fn := range hooks {
+			fn(id)
 		}
+	}
+}
 
-		tm.SetTaskResults(id, results)
-	}()
+// RunTask queues a task with default options, see RunTaskWithOptions
+func (tm *TaskManager) RunTask(ctx context.Context, id string, runner func(context.Context) (*stats.Results, error)) {
+	tm.RunTaskWithOptions(ctx, id, RunOptions{}, runner)
 }
 
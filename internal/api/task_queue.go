@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/parser"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// DefaultMaxConcurrentTasks is how many tasks run at once; more tasks would
+// compete for CPU and skew each other's numbers
+const DefaultMaxConcurrentTasks = 1
+
+// RunOptions control how a task is scheduled
+type RunOptions struct {
+	// Priority orders the queue, higher first; tasks of the same priority
+	// run in submission order
+	Priority int
+	// Targets are the hosts the task loads. With exclusive targets, a task
+	// waits while another task loading one of them is running.
+	Targets []string
+}
+
+// queuedRun is a task scheduled by the queue, waiting for a slot or running
+type queuedRun struct {
+	id      string
+	ctx     context.Context
+	runner  func(context.Context) (*stats.Results, error)
+	opts    RunOptions
+	seq     uint64
+	targets map[string]bool
+}
+
+// SetConcurrency sets how many tasks run at once (0 for no limit) and
+// whether tasks loading the same host are kept from running together
+func (tm *TaskManager) SetConcurrency(maxConcurrent int, exclusiveTargets bool) {
+	tm.mu.Lock()
+	tm.maxConcurrent = maxConcurrent
+	tm.exclusiveTargets = exclusiveTargets
+	started := tm.dispatchLocked()
+	tm.mu.Unlock()
+	tm.flushStore()
+
+	for _, run := range started {
+		tm.start(run)
+	}
+}
+
+// RunTaskWithOptions queues a task and runs it asynchronously once a slot is
+// free. The runner context is canceled by CancelTask.
+func (tm *TaskManager) RunTaskWithOptions(ctx context.Context, id string, opts RunOptions, runner func(context.Context) (*stats.Results, error)) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	tm.mu.Lock()
+	task, exists := tm.tasks[id]
+	if !exists {
+		tm.mu.Unlock()
+		cancel()
+		return
+	}
+
+	run := &queuedRun{id: id, ctx: ctx, runner: runner, opts: opts, targets: make(map[string]bool, len(opts.Targets))}
+	for _, t := range opts.Targets {
+		run.targets[t] = true
+	}
+	tm.seq++
+	run.seq = tm.seq
+	tm.cancels[id] = cancel
+	tm.queue = append(tm.queue, run)
+	task.Status = TaskStatusQueued
+	task.Priority = opts.Priority
+	started := tm.dispatchLocked()
+	if task.Status == TaskStatusQueued {
+		tm.persistLocked(task)
+	}
+	tm.mu.Unlock()
+	tm.flushStore()
+
+	for _, r := range started {
+		tm.start(r)
+	}
+}
+
+// dispatchLocked moves the queued tasks that may run now to running and
+// returns them; the caller starts them after releasing the lock
+func (tm *TaskManager) dispatchLocked() []*queuedRun {
+	sort.SliceStable(tm.queue, func(i, j int) bool {
+		a, b := tm.queue[i], tm.queue[j]
+		if a.opts.Priority != b.opts.Priority {
+			return a.opts.Priority > b.opts.Priority
+		}
+		return a.seq < b.seq
+	})
+
+	var started []*queuedRun
+	waiting := tm.queue[:0]
+	for _, run := range tm.queue {
+		if (tm.maxConcurrent > 0 && len(tm.running) >= tm.maxConcurrent) || tm.targetBusyLocked(run) {
+			waiting = append(waiting, run)
+			continue
+		}
+		tm.running[run.id] = run
+		started = append(started, run)
+	}
+	tm.queue = waiting
+
+	for i, run := range tm.queue {
+		if task, ok := tm.tasks[run.id]; ok {
+			task.QueuePosition = i + 1
+		}
+	}
+	now := time.Now()
+	for _, run := range started {
+		if task, ok := tm.tasks[run.id]; ok {
+			task.Status = TaskStatusRunning
+			task.QueuePosition = 0
+			task.StartedAt = &now
+			tm.persistLocked(task)
+		}
+	}
+	return started
+}
+
+// targetBusyLocked reports whether a running task loads one of the hosts
+// of run while targets are exclusive
+func (tm *TaskManager) targetBusyLocked(run *queuedRun) bool {
+	if !tm.exclusiveTargets {
+		return false
+	}
+	for _, other := range tm.running {
+		for t := range run.targets {
+			if other.targets[t] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// start runs a dispatched task in its own goroutine
+func (tm *TaskManager) start(run *queuedRun) {
+	go func() {
+		defer tm.notifyFinish(run.id)
+		defer tm.finishRun(run.id)
+
+		results, err := run.runner(run.ctx)
+		if tm.IsCanceled(run.id) {
+			tm.SetTaskCanceled(run.id, results)
+			return
+		}
+		if err != nil {
+			tm.SetTaskError(run.id, err)
+			return
+		}
+
+		tm.SetTaskResults(run.id, results)
+	}()
+}
+
+// finishRun frees the slot of a finished task and starts the next ones
+func (tm *TaskManager) finishRun(id string) {
+	tm.mu.Lock()
+	if cancel, ok := tm.cancels[id]; ok {
+		cancel()
+		delete(tm.cancels, id)
+	}
+	delete(tm.running, id)
+	started := tm.dispatchLocked()
+	tm.mu.Unlock()
+	tm.flushStore()
+
+	for _, run := range started {
+		tm.start(run)
+	}
+}
+
+// dequeueLocked removes a task from the queue, reporting whether it was
+// queued
+func (tm *TaskManager) dequeueLocked(id string) bool {
+	for i, run := range tm.queue {
+		if run.id != id {
+			continue
+		}
+		tm.queue = append(tm.queue[:i], tm.queue[i+1:]...)
+		for j, rest := range tm.queue {
+			if task, ok := tm.tasks[rest.id]; ok {
+				task.QueuePosition = j + 1
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// requestHosts returns the distinct hosts of requests, the targets of a task
+func requestHosts(reqs ...*http.Request) []string {
+	seen := make(map[string]bool, len(reqs))
+	hosts := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if req == nil || req.URL == nil {
+			continue
+		}
+		host := strings.ToLower(req.URL.Hostname())
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// curlHosts returns the distinct hosts of curl commands, curl files and
+// requests built by the caller. Empty entries are skipped; a command or file
+// that fails to parse, or a request without a host, is an error, as its
+// target can't be checked against the allowlist.
+func curlHosts(curls, curlFiles []string, built ...*http.Request) ([]string, error) {
+	reqs := append([]*http.Request(nil), built...)
+	for _, curl := range curls {
+		if curl == "" {
+			continue
+		}
+		req, err := parser.ParseCurl(curl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse curl command: %v", err)
+		}
+		reqs = append(reqs, req)
+	}
+	for _, file := range curlFiles {
+		if file == "" {
+			continue
+		}
+		fileReqs, err := parser.ParseCurlFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse curl file %s: %v", file, err)
+		}
+		reqs = append(reqs, fileReqs...)
+	}
+	for _, req := range reqs {
+		if req.URL.Hostname() == "" {
+			return nil, fmt.Errorf("request %s has no target host", req.URL)
+		}
+	}
+	return requestHosts(reqs...), nil
+}
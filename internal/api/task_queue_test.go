@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// blockingRunner returns a runner that records its task id in order and
+// waits until release is closed or the task is canceled
+func blockingRunner(id string, order *[]string, mu *sync.Mutex, release <-chan struct{}) func(context.Context) (*stats.Results, error) {
+	return func(ctx context.Context) (*stats.Results, error) {
+		mu.Lock()
+		*order = append(*order, id)
+		mu.Unlock()
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil, nil
+	}
+}
+
+func TestTaskQueueRunsByPriority(t *testing.T) {
+	tm := NewTaskManager()
+	var mu sync.Mutex
+	var order []string
+	release := make(chan struct{})
+
+	for _, task := range []struct {
+		id       string
+		priority int
+	}{{"first", 0}, {"low", 0}, {"high", 5}, {"low2", 0}} {
+		tm.CreateTask(task.id, nil)
+		tm.RunTaskWithOptions(context.Background(), task.id, RunOptions{Priority: task.priority}, blockingRunner(task.id, &order, &mu, release))
+	}
+
+	if task, _ := tm.GetTask("first"); task.Status != TaskStatusRunning {
+		t.Fatalf("expected the first task to start, got %s", task.Status)
+	}
+	for id, pos := range map[string]int{"high": 1, "low": 2, "low2": 3} {
+		task, _ := tm.GetTask(id)
+		if task.Status != TaskStatusQueued || task.QueuePosition != pos {
+			t.Errorf("%s: expected queued at %d, got %s at %d", id, pos, task.Status, task.QueuePosition)
+		}
+	}
+
+	close(release)
+	waitForStatus(t, tm, "low2", TaskStatusCompleted)
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"first", "high", "low", "low2"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected run order %v, got %v", want, order)
+	}
+}
+
+func TestCancelQueuedTask(t *testing.T) {
+	tm := NewTaskManager()
+	var mu sync.Mutex
+	var order []string
+	release := make(chan struct{})
+
+	for _, id := range []string{"a", "b", "c"} {
+		tm.CreateTask(id, nil)
+		tm.RunTask(context.Background(), id, blockingRunner(id, &order, &mu, release))
+	}
+	if err := tm.CancelTask("b"); err != nil {
+		t.Fatal(err)
+	}
+	if task, _ := tm.GetTask("b"); task.Status != TaskStatusCanceled || task.QueuePosition != 0 {
+		t.Errorf("expected the queued task to be canceled at once, got %s at %d", task.Status, task.QueuePosition)
+	}
+	if task, _ := tm.GetTask("c"); task.QueuePosition != 1 {
+		t.Errorf("expected the next task to move up the queue, got position %d", task.QueuePosition)
+	}
+
+	close(release)
+	waitForStatus(t, tm, "c", TaskStatusCompleted)
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"a", "c"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected run order %v, got %v", want, order)
+	}
+}
+
+func TestTaskQueueExclusiveTargets(t *testing.T) {
+	tm := NewTaskManager()
+	tm.SetConcurrency(2, true)
+	var mu sync.Mutex
+	var order []string
+	release := make(chan struct{})
+	defer close(release)
+
+	for _, task := range []struct {
+		id     string
+		target string
+	}{{"a1", "a.example"}, {"a2", "a.example"}, {"b1", "b.example"}} {
+		tm.CreateTask(task.id, nil)
+		tm.RunTaskWithOptions(context.Background(), task.id, RunOptions{Targets: []string{task.target}}, blockingRunner(task.id, &order, &mu, release))
+	}
+
+	for id, status := range map[string]TaskStatus{"a1": TaskStatusRunning, "a2": TaskStatusQueued, "b1": TaskStatusRunning} {
+		if task, _ := tm.GetTask(id); task.Status != status {
+			t.Errorf("%s: expected %s, got %s", id, status, task.Status)
+		}
+	}
+
+	// a2 starts once a1 no longer loads the host
+	tm.CancelTask("a1")
+	waitForStatus(t, tm, "a2", TaskStatusRunning)
+}
+
+func TestRequestHosts(t *testing.T) {
+	a, _ := http.NewRequest(http.MethodGet, "http://API.example.com:8080/a", nil)
+	b, _ := http.NewRequest(http.MethodGet, "https://api.example.com/b", nil)
+	c, _ := http.NewRequest(http.MethodGet, "http://other.example.com/c", nil)
+	if hosts := requestHosts(a, b, nil, c); !reflect.DeepEqual(hosts, []string{"api.example.com", "other.example.com"}) {
+		t.Errorf("unexpected hosts %v", hosts)
+	}
+	if hosts, err := curlHosts([]string{"curl http://a.example/x", ""}, []string{""}); err != nil || !reflect.DeepEqual(hosts, []string{"a.example"}) {
+		t.Errorf("unexpected curl hosts %v (%v)", hosts, err)
+	}
+	for _, curl := range []string{"curl http://[::1/", "curl -X GET"} {
+		if _, err := curlHosts([]string{curl}, nil); err == nil {
+			t.Errorf("%s: expected a command without a valid target to be an error", curl)
+		}
+	}
+}
+
+func TestCanceledQueuedBenchmarkIsNotControllable(t *testing.T) {
+	s := NewServer()
+	release := make(chan struct{})
+	defer close(release)
+	var mu sync.Mutex
+	var order []string
+	s.taskManager.CreateTask("busy", nil)
+	s.taskManager.RunTask(context.Background(), "busy", blockingRunner("busy", &order, &mu, release))
+
+	rec := httptest.NewRecorder()
+	s.handleBenchmark(rec, httptest.NewRequest(http.MethodPost, "/api/v1/benchmark",
+		strings.NewReader(`{"url": "http://127.0.0.1:1/", "duration": "1s", "use_nethttp": true}`)))
+	var accepted BenchmarkResponse
+	json.NewDecoder(rec.Body).Decode(&accepted)
+	if accepted.QueuePosition != 1 {
+		t.Fatalf("expected the benchmark to be queued, got %d %s", rec.Code, rec.Body)
+	}
+	if _, ok := s.controls.get(accepted.TaskID); ok {
+		t.Error("expected a queued task to have no controller yet")
+	}
+
+	if err := s.taskManager.CancelTask(accepted.TaskID); err != nil {
+		t.Fatal(err)
+	}
+	_, controllable := s.controls.get(accepted.TaskID)
+	_, live := s.live.get(accepted.TaskID)
+	if controllable || live {
+		t.Errorf("expected a task canceled in the queue to leave no controller (%v) or live view (%v)", controllable, live)
+	}
+}
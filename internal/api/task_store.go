@@ -0,0 +1,281 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/history"
+	"github.com/antlabs/gurl/internal/report"
+)
+
+// interruptedError is the error of tasks that were still pending, queued or
+// running when the API server stopped
+const interruptedError = "interrupted: the API server stopped before the task finished"
+
+// TaskStore persists tasks so they outlive the API server process. The task
+// manager writes every status transition through to the store, and serves
+// tasks that are no longer in memory from it.
+type TaskStore interface {
+	// Save creates or replaces a task
+	Save(task *StoredTask) error
+	// Get returns a task with its results, or ErrTaskNotFound
+	Get(id string) (*StoredTask, error)
+	// List returns all tasks without their results, batch results and report
+	List() ([]*StoredTask, error)
+	// Delete removes a task, or returns ErrTaskNotFound
+	Delete(id string) error
+	Close() error
+}
+
+// StoredTask is the persisted form of a task. stats.Results can't be restored,
+// so results are stored as returned by the results endpoint.
+type StoredTask struct {
+	ID          string                 `json:"id"`
+	Status      TaskStatus             `json:"status"`
+	CreatedAt   time.Time              `json:"created_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Priority    int                    `json:"priority,omitempty"`
+	Config      map[string]interface{} `json:"config,omitempty"`
+	Webhook     *WebhookStatus         `json:"webhook,omitempty"`
+
+	Results *BenchmarkResultsJSON `json:"results,omitempty"`
+	Batch   json.RawMessage       `json:"batch,omitempty"`
+	Report  *report.Report        `json:"report,omitempty"`
+}
+
+// storedTask converts a task to its persisted form
+func storedTask(task *Task) *StoredTask {
+	return &StoredTask{
+		ID:          task.ID,
+		Status:      task.Status,
+		CreatedAt:   task.CreatedAt,
+		StartedAt:   task.StartedAt,
+		CompletedAt: task.CompletedAt,
+		Error:       task.Error,
+		Priority:    task.Priority,
+		Config:      task.Config,
+		Webhook:     task.Webhook,
+		Results:     task.ResultsJSON(),
+		Batch:       task.Batch,
+		Report:      task.Report,
+	}
+}
+
+// task rebuilds the task view of a stored task
+func (st *StoredTask) task() *Task {
+	return &Task{
+		ID:          st.ID,
+		Status:      st.Status,
+		CreatedAt:   st.CreatedAt,
+		StartedAt:   st.StartedAt,
+		CompletedAt: st.CompletedAt,
+		Error:       st.Error,
+		Config:      st.Config,
+		Report:      st.Report,
+		Batch:       st.Batch,
+		Priority:    st.Priority,
+		Webhook:     st.Webhook,
+
+		storedResults: st.Results,
+	}
+}
+
+// memoryTaskStore is the default store: tasks live only in the memory of
+// the task manager and are lost when the server stops
+type memoryTaskStore struct{}
+
+// NewMemoryTaskStore returns the in-memory task store
+func NewMemoryTaskStore() TaskStore {
+	return memoryTaskStore{}
+}
+
+func (memoryTaskStore) Save(task *StoredTask) error        { return nil }
+func (memoryTaskStore) Get(id string) (*StoredTask, error) { return nil, ErrTaskNotFound }
+func (memoryTaskStore) List() ([]*StoredTask, error)       { return nil, nil }
+func (memoryTaskStore) Delete(id string) error             { return ErrTaskNotFound }
+func (memoryTaskStore) Close() error                       { return nil }
+
+// FileTaskStore keeps tasks in the run history database (see the history
+// package), next to the runs recorded for `gurl history`. The file is locked
+// while it is open, so it is only opened for the duration of an operation;
+// the history command and CLI runs can use it while the server runs.
+type FileTaskStore struct {
+	path string
+	mu   sync.Mutex // serializes the server's own access to the file
+}
+
+// OpenFileTaskStore returns the task store of the history database at path,
+// creating the database if needed
+func OpenFileTaskStore(path string) (*FileTaskStore, error) {
+	s := &FileTaskStore{path: path}
+	if err := s.with(func(*history.Store) error { return nil }); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// with opens the database for the duration of fn
+func (s *FileTaskStore) with(fn func(db *history.Store) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := history.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return fn(db)
+}
+
+// taskOutput is the part of a stored task kept apart from its state
+type taskOutput struct {
+	Results *BenchmarkResultsJSON `json:"results,omitempty"`
+	Batch   json.RawMessage       `json:"batch,omitempty"`
+	Report  *report.Report        `json:"report,omitempty"`
+}
+
+// Save creates or replaces a task
+func (s *FileTaskStore) Save(task *StoredTask) error {
+	state := *task
+	state.Results, state.Batch, state.Report = nil, nil, nil
+	stateData, err := json.Marshal(&state)
+	if err != nil {
+		return err
+	}
+
+	var outputData []byte
+	if task.Results != nil || task.Batch != nil || task.Report != nil {
+		outputData, err = json.Marshal(taskOutput{Results: task.Results, Batch: task.Batch, Report: task.Report})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.with(func(db *history.Store) error {
+		return db.PutTask(task.ID, stateData, outputData)
+	})
+}
+
+// Get returns a task with its results
+func (s *FileTaskStore) Get(id string) (*StoredTask, error) {
+	var stateData, outputData []byte
+	err := s.with(func(db *history.Store) error {
+		var err error
+		stateData, outputData, err = db.GetTask(id)
+		return err
+	})
+	if errors.Is(err, history.ErrNotFound) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := decodeStoredTask(stateData)
+	if err != nil || outputData == nil {
+		return task, err
+	}
+	var output taskOutput
+	if err := json.Unmarshal(outputData, &output); err != nil {
+		return nil, fmt.Errorf("corrupt results of task %s: %v", id, err)
+	}
+	task.Results, task.Batch, task.Report = output.Results, output.Batch, output.Report
+	return task, nil
+}
+
+// List returns all tasks without their results
+func (s *FileTaskStore) List() ([]*StoredTask, error) {
+	var tasks []*StoredTask
+	err := s.with(func(db *history.Store) error {
+		return db.Tasks(func(data []byte) error {
+			task, err := decodeStoredTask(data)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+			return nil
+		})
+	})
+	return tasks, err
+}
+
+// Delete removes a task and the run recorded for it in the history
+func (s *FileTaskStore) Delete(id string) error {
+	err := s.with(func(db *history.Store) error {
+		return db.DeleteTask(id)
+	})
+	if errors.Is(err, history.ErrNotFound) {
+		return ErrTaskNotFound
+	}
+	return err
+}
+
+// Close implements TaskStore; the database is not held open
+func (s *FileTaskStore) Close() error {
+	return nil
+}
+
+// record adds a finished task to the run history
+func (s *FileTaskStore) record(rec *history.Record) error {
+	return s.with(func(db *history.Store) error {
+		return db.Add(rec)
+	})
+}
+
+func decodeStoredTask(data []byte) (*StoredTask, error) {
+	if data == nil {
+		return nil, ErrTaskNotFound
+	}
+	var task StoredTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("corrupt stored task: %v", err)
+	}
+	return &task, nil
+}
+
+// interruptStored marks the stored tasks that never finished as interrupted
+// and returns how many there were. Webhook deliveries that were still
+// pending are marked failed, they are not resumed.
+func interruptStored(store TaskStore) (int, error) {
+	tasks, err := store.List()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	interrupted := 0
+	for _, t := range tasks {
+		pendingWebhook := t.Webhook != nil && t.Webhook.State == WebhookPending
+		if t.Status.Finished() && !pendingWebhook {
+			continue
+		}
+		task, err := store.Get(t.ID)
+		if err != nil {
+			if errors.Is(err, ErrTaskNotFound) {
+				continue
+			}
+			return interrupted, err
+		}
+		if pendingWebhook {
+			webhook := *task.Webhook
+			webhook.State = WebhookFailed
+			webhook.Error = webhookStoppedError
+			task.Webhook = &webhook
+		}
+		if !task.Status.Finished() {
+			task.Status = TaskStatusInterrupted
+			task.Error = interruptedError
+			task.CompletedAt = &now
+			interrupted++
+		}
+		if err := store.Save(task); err != nil {
+			return interrupted, err
+		}
+	}
+	return interrupted, nil
+}
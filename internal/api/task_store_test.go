@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/history"
+	"github.com/antlabs/gurl/internal/report"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func TestFileTaskStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	open := func() *TaskManager {
+		store, err := OpenFileTaskStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm := NewTaskManager()
+		tm.SetConcurrency(0, false)
+		if _, err := tm.SetStore(store); err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tm := open()
+	tm.CreateTask("done", map[string]interface{}{"type": report.KindBatch, "tests": 2})
+	tm.RunTask(context.Background(), "done", func(ctx context.Context) (*stats.Results, error) {
+		results := stats.NewResults()
+		results.TotalRequests = 7
+		return results, nil
+	})
+	waitForStatus(t, tm, "done", TaskStatusCompleted)
+	tm.SetTaskReport("done", &report.Report{Title: "done"})
+
+	release := make(chan struct{})
+	defer close(release)
+	tm.CreateTask("running", nil)
+	tm.SetTaskWebhook("running", &WebhookStatus{URL: "http://hooks.example/", State: WebhookPending})
+	tm.RunTask(context.Background(), "running", func(ctx context.Context) (*stats.Results, error) {
+		<-release
+		return nil, nil
+	})
+	if err := tm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tm = open()
+	defer tm.Close()
+	// The database is shared with `gurl history`, which can open it while
+	// the server runs
+	db, err := history.Open(path)
+	if err != nil {
+		t.Fatalf("history database held open by the task store: %v", err)
+	}
+	db.Close()
+
+	task, ok := tm.GetTask("done")
+	if !ok || task.Status != TaskStatusCompleted || task.Report == nil || task.Report.Title != "done" {
+		t.Fatalf("expected the finished task to be restored, got %+v", task)
+	}
+	if results := task.ResultsJSON(); results == nil || results.TotalRequests != 7 {
+		t.Errorf("expected the results to be restored, got %+v", results)
+	}
+	if title := taskTitle(task.Config); title != "2 tests" {
+		t.Errorf("unexpected title of a restored task %q", title)
+	}
+	if task, _ := tm.GetTask("running"); task == nil || task.Status != TaskStatusInterrupted || task.CompletedAt == nil {
+		t.Errorf("expected the running task to be interrupted, got %+v", task)
+	} else if task.Webhook == nil || task.Webhook.State != WebhookFailed || task.Webhook.Error == "" {
+		t.Errorf("expected the pending webhook to be marked failed, got %+v", task.Webhook)
+	}
+	if n := len(tm.ListStoredTasks()); n != 2 {
+		t.Errorf("expected 2 stored tasks, got %d", n)
+	}
+
+	if err := tm.CancelTask("done"); err != ErrTaskNotRunning {
+		t.Errorf("expected ErrTaskNotRunning canceling a stored task, got %v", err)
+	}
+	if err := tm.DeleteTask("done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tm.GetTask("done"); ok {
+		t.Error("expected the deleted task to be removed from the store")
+	}
+	if err := tm.DeleteTask("done"); err != ErrTaskNotFound {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+// slowTaskStore is a file task store whose writes wait for release
+type slowTaskStore struct {
+	*FileTaskStore
+	saving  chan string
+	release chan struct{}
+}
+
+func (s *slowTaskStore) Save(task *StoredTask) error {
+	s.saving <- task.ID
+	<-s.release
+	return s.FileTaskStore.Save(task)
+}
+
+func TestTaskStoreWritesDontHoldTheLock(t *testing.T) {
+	file, err := OpenFileTaskStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &slowTaskStore{FileTaskStore: file, saving: make(chan string, 1), release: make(chan struct{})}
+	tm := NewTaskManager()
+	if _, err := tm.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	defer tm.Close()
+
+	created := make(chan struct{})
+	go func() {
+		tm.CreateTask("slow", nil)
+		close(created)
+	}()
+	<-store.saving
+
+	// The write of "slow" is in progress; reading the tasks must not wait
+	done := make(chan struct{})
+	go func() {
+		tm.GetTask("slow")
+		tm.ListTasks()
+		tm.GetTask("missing")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("reading tasks blocked on a store write")
+	}
+
+	close(store.release)
+	<-created
+	if stored, err := file.Get("slow"); err != nil || stored.ID != "slow" {
+		t.Errorf("expected the task to be written, got %v %v", stored, err)
+	}
+}
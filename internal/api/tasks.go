@@ -0,0 +1,275 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/report"
+)
+
+const (
+	// defaultTaskPageSize and maxTaskPageSize bound the limit parameter of
+	// the task list
+	defaultTaskPageSize = 100
+	maxTaskPageSize     = 1000
+)
+
+// TaskSummary is one entry of the task list
+type TaskSummary struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	Title       string     `json:"title"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+
+	Priority      int `json:"priority,omitempty"`
+	QueuePosition int `json:"queue_position,omitempty"`
+}
+
+// TaskListResponse is returned by GET /api/v1/tasks. Total counts the tasks
+// matching the filters, before pagination.
+type TaskListResponse struct {
+	Tasks  []TaskSummary `json:"tasks"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// taskFilter holds the query parameters of the task list
+type taskFilter struct {
+	statuses map[string]bool
+	kind     string
+	limit    int
+	offset   int
+}
+
+// parseTaskFilter reads ?status=running,failed&kind=batch&limit=20&offset=40
+func parseTaskFilter(r *http.Request) (*taskFilter, error) {
+	q := r.URL.Query()
+	f := &taskFilter{kind: q.Get("kind"), limit: defaultTaskPageSize}
+
+	if v := q.Get("status"); v != "" {
+		f.statuses = make(map[string]bool)
+		for _, status := range strings.Split(v, ",") {
+			switch TaskStatus(status) {
+			case TaskStatusPending, TaskStatusQueued, TaskStatusRunning, TaskStatusCompleted, TaskStatusFailed, TaskStatusCanceled, TaskStatusInterrupted:
+				f.statuses[status] = true
+			default:
+				return nil, fmt.Errorf("invalid status %q", status)
+			}
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTaskPageSize {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxTaskPageSize)
+		}
+		f.limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("offset must be a non-negative integer")
+		}
+		f.offset = n
+	}
+	return f, nil
+}
+
+func (f *taskFilter) match(t TaskSummary) bool {
+	if f.statuses != nil && !f.statuses[t.Status] {
+		return false
+	}
+	return f.kind == "" || t.Kind == f.kind
+}
+
+// handleTasks handles GET /api/v1/tasks. Tasks held in memory come first,
+// followed by the tasks of the task store, newest first.
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.taskManager.Evict()
+	tasks := s.taskManager.ListTasks()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].CreatedAt.After(tasks[j].CreatedAt) })
+
+	list := make([]TaskSummary, 0, len(tasks))
+	for _, task := range tasks {
+		list = append(list, TaskSummary{
+			ID:          task.ID,
+			Kind:        taskKind(task.Config),
+			Title:       taskTitle(task.Config),
+			Status:      string(task.Status),
+			CreatedAt:   task.CreatedAt,
+			StartedAt:   task.StartedAt,
+			CompletedAt: task.CompletedAt,
+			Error:       task.Error,
+
+			Priority:      task.Priority,
+			QueuePosition: task.QueuePosition,
+		})
+	}
+
+	stored := s.taskManager.ListStoredTasks()
+	sort.Slice(stored, func(i, j int) bool { return stored[i].CreatedAt.After(stored[j].CreatedAt) })
+	for _, task := range stored {
+		list = append(list, TaskSummary{
+			ID:          task.ID,
+			Kind:        taskKind(task.Config),
+			Title:       taskTitle(task.Config),
+			Status:      string(task.Status),
+			CreatedAt:   task.CreatedAt,
+			StartedAt:   task.StartedAt,
+			CompletedAt: task.CompletedAt,
+			Error:       task.Error,
+			Priority:    task.Priority,
+		})
+	}
+
+	matched := list[:0]
+	for _, t := range list {
+		if filter.match(t) {
+			matched = append(matched, t)
+		}
+	}
+	response := TaskListResponse{Tasks: []TaskSummary{}, Total: len(matched), Limit: filter.limit, Offset: filter.offset}
+	if filter.offset < len(matched) {
+		response.Tasks = matched[filter.offset:min(filter.offset+filter.limit, len(matched))]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleTaskRoutes handles /api/v1/tasks/:id and /api/v1/tasks/:id/...
+func (s *Server) handleTaskRoutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/tasks/")
+	taskID, action, _ := strings.Cut(rest, "/")
+	if taskID == "" {
+		writeError(w, http.StatusBadRequest, "Task ID required")
+		return
+	}
+
+	switch action {
+	case "":
+		s.handleTask(w, r, taskID)
+	case "cancel":
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		s.cancelTask(w, taskID)
+	case "stream":
+		s.handleStream(w, r, taskID)
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// handleTask handles GET and DELETE /api/v1/tasks/:id. DELETE cancels a
+// running task and removes a finished one.
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request, taskID string) {
+	switch r.Method {
+	case http.MethodGet:
+		response, ok := s.taskStatus(taskID)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Task not found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	case http.MethodDelete:
+		if task, exists := s.taskManager.GetTask(taskID); exists && !task.Status.Finished() {
+			s.cancelTask(w, taskID)
+			return
+		}
+		s.deleteTask(w, taskID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// cancelTask stops a running task; it becomes canceled once its runner
+// has returned
+func (s *Server) cancelTask(w http.ResponseWriter, taskID string) {
+	switch err := s.taskManager.CancelTask(taskID); {
+	case errors.Is(err, ErrTaskNotFound):
+		writeError(w, http.StatusNotFound, "Task not found")
+		return
+	case err != nil:
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(BenchmarkResponse{
+		TaskID:  taskID,
+		Status:  "canceling",
+		Message: "Task cancellation requested",
+	})
+}
+
+// deleteTask removes a finished task from memory and from the task store
+func (s *Server) deleteTask(w http.ResponseWriter, taskID string) {
+	switch err := s.taskManager.DeleteTask(taskID); {
+	case errors.Is(err, ErrTaskNotFound):
+		writeError(w, http.StatusNotFound, "Task not found")
+		return
+	case errors.Is(err, ErrTaskRunning):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// taskKind returns the kind of task from its config: benchmark, batch or compare
+func taskKind(cfg map[string]interface{}) string {
+	if kind, ok := cfg["type"].(string); ok && kind != "" {
+		return kind
+	}
+	return report.KindBenchmark
+}
+
+// taskTitle returns a short description of the task for the task list
+func taskTitle(cfg map[string]interface{}) string {
+	for _, key := range []string{"scenario", "url", "curl"} {
+		if v, ok := cfg[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	// Configs loaded from the task store hold numbers as float64
+	var n int
+	switch v := cfg["tests"].(type) {
+	case int:
+		n = v
+	case float64:
+		n = int(v)
+	default:
+		return ""
+	}
+	if n == 1 {
+		return "1 test"
+	}
+	return fmt.Sprintf("%d tests", n)
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// waitForStatus polls a task until it reaches status or the test times out
+func waitForStatus(t *testing.T, tm *TaskManager, id string, status TaskStatus) *Task {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if task, ok := tm.GetTask(id); ok && task.Status == status {
+			return task
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	task, _ := tm.GetTask(id)
+	t.Fatalf("task %s did not reach %s: %+v", id, status, task)
+	return nil
+}
+
+func TestCancelTask(t *testing.T) {
+	tm := NewTaskManager()
+	tm.CreateTask("t1", nil)
+	tm.RunTask(context.Background(), "t1", func(ctx context.Context) (*stats.Results, error) {
+		<-ctx.Done()
+		results := stats.NewResults()
+		results.TotalRequests = 7
+		return results, nil
+	})
+
+	if err := tm.DeleteTask("t1"); err != ErrTaskRunning {
+		t.Errorf("expected ErrTaskRunning deleting a running task, got %v", err)
+	}
+	if err := tm.CancelTask("t1"); err != nil {
+		t.Fatal(err)
+	}
+	task := waitForStatus(t, tm, "t1", TaskStatusCanceled)
+	if task.Results == nil || task.Results.TotalRequests != 7 {
+		t.Errorf("expected the partial results to be kept, got %+v", task.Results)
+	}
+	if err := tm.CancelTask("t1"); err != ErrTaskNotRunning {
+		t.Errorf("expected ErrTaskNotRunning, got %v", err)
+	}
+	if err := tm.DeleteTask("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.CancelTask("t1"); err != ErrTaskNotFound {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestTaskEviction(t *testing.T) {
+	tm := NewTaskManager()
+	tm.SetRetention(time.Hour, 3)
+
+	running := make(chan struct{})
+	defer close(running)
+	tm.CreateTask("running", nil)
+	tm.RunTask(context.Background(), "running", func(ctx context.Context) (*stats.Results, error) {
+		<-running
+		return nil, nil
+	})
+
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("done%d", i)
+		tm.CreateTask(id, nil)
+		tm.SetTaskResults(id, nil)
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := tm.GetTask("running"); !ok {
+		t.Error("running tasks must not be evicted")
+	}
+	for id, kept := range map[string]bool{"done0": false, "done1": false, "done2": true, "done3": true} {
+		if _, ok := tm.GetTask(id); ok != kept {
+			t.Errorf("%s: expected kept=%v", id, kept)
+		}
+	}
+
+	// Finished tasks past the TTL are evicted
+	tm.mu.Lock()
+	old := time.Now().Add(-2 * time.Hour)
+	tm.tasks["done2"].CompletedAt = &old
+	tm.mu.Unlock()
+	if n := tm.Evict(); n != 1 {
+		t.Errorf("expected 1 task past the TTL to be evicted, got %d", n)
+	}
+}
+
+func TestTaskListFiltersAndPagination(t *testing.T) {
+	s := NewServer()
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("task_%d", i)
+		s.taskManager.CreateTask(id, map[string]interface{}{"type": "benchmark"})
+		if i%2 == 0 {
+			s.taskManager.SetTaskResults(id, nil)
+		} else {
+			s.taskManager.SetTaskError(id, fmt.Errorf("boom"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	list := func(query string) (int, TaskListResponse) {
+		rec := httptest.NewRecorder()
+		s.handleTasks(rec, httptest.NewRequest(http.MethodGet, "/api/v1/tasks"+query, nil))
+		var resp TaskListResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp
+	}
+
+	_, resp := list("?status=completed&limit=2&offset=1")
+	if resp.Total != 3 || len(resp.Tasks) != 2 || resp.Tasks[0].ID != "task_2" || resp.Tasks[1].ID != "task_0" {
+		t.Errorf("unexpected page: %+v", resp)
+	}
+	if _, resp = list("?kind=batch"); resp.Total != 0 || resp.Tasks == nil {
+		t.Errorf("expected an empty list, got %+v", resp)
+	}
+	if _, resp = list("?offset=10"); resp.Total != 5 || len(resp.Tasks) != 0 {
+		t.Errorf("expected no tasks past the end, got %+v", resp)
+	}
+	for _, query := range []string{"?status=done", "?limit=0", "?offset=-1"} {
+		if code, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, code)
+		}
+	}
+}
+
+func TestTaskRoutesCancelAndDelete(t *testing.T) {
+	s := NewServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleTaskRoutes))
+	defer srv.Close()
+
+	s.taskManager.CreateTask("t1", nil)
+	s.taskManager.RunTask(context.Background(), "t1", func(ctx context.Context) (*stats.Results, error) {
+		<-ctx.Done()
+		return nil, nil
+	})
+
+	do := func(method, path string) int {
+		req, _ := http.NewRequest(method, srv.URL+"/api/v1/tasks/"+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := do(http.MethodPost, "t1/cancel"); code != http.StatusAccepted {
+		t.Fatalf("cancel: expected 202, got %d", code)
+	}
+	waitForStatus(t, s.taskManager, "t1", TaskStatusCanceled)
+	if code := do(http.MethodPost, "t1/cancel"); code != http.StatusConflict {
+		t.Errorf("cancel of a finished task: expected 409, got %d", code)
+	}
+	if code := do(http.MethodGet, "t1"); code != http.StatusOK {
+		t.Errorf("get: expected 200, got %d", code)
+	}
+	if code := do(http.MethodDelete, "t1"); code != http.StatusNoContent {
+		t.Errorf("delete: expected 204, got %d", code)
+	}
+	if code := do(http.MethodDelete, "t1"); code != http.StatusNotFound {
+		t.Errorf("delete of a deleted task: expected 404, got %d", code)
+	}
+}
+
+func TestRemovedTasksDropMetrics(t *testing.T) {
+	s := NewServer()
+	s.taskManager.SetRetention(time.Hour, 2)
+	series := func() string {
+		var b strings.Builder
+		s.metrics.Write(&b)
+		return b.String()
+	}
+
+	s.metrics.Collector(map[string]string{"task": "batch", "test": "a"})
+	s.metrics.Collector(map[string]string{"task": "batch", "test": "b"})
+	s.metrics.Collector(map[string]string{"task": "single"})
+	for _, id := range []string{"batch", "single"} {
+		s.taskManager.CreateTask(id, nil)
+		s.taskManager.SetTaskResults(id, nil)
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := s.taskManager.DeleteTask("batch"); err != nil {
+		t.Fatal(err)
+	}
+	if out := series(); strings.Contains(out, `task="batch"`) || !strings.Contains(out, `task="single"`) {
+		t.Errorf("expected only the series of the deleted task to be removed:\n%s", out)
+	}
+
+	// Creating two more tasks evicts the oldest finished one
+	s.taskManager.CreateTask("next1", nil)
+	s.taskManager.CreateTask("next2", nil)
+	if out := series(); strings.Contains(out, `task="single"`) {
+		t.Errorf("expected the series of the evicted task to be removed:\n%s", out)
+	}
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+// Webhook delivery states
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+const (
+	// webhookMaxAttempts is how many times a delivery is tried
+	webhookMaxAttempts = 5
+	// webhookTimeout bounds a single delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookBackoff is the wait before the first retry, doubled after each
+	// failed attempt up to webhookMaxBackoff
+	webhookBackoff    = 2 * time.Second
+	webhookMaxBackoff = 30 * time.Second
+
+	// webhookStoppedError is the error of deliveries that were still
+	// pending when the API server stopped
+	webhookStoppedError = "the API server stopped before the webhook was delivered"
+)
+
+// WebhookSecretEnv is the environment variable holding the secret that
+// signs completion webhooks
+const WebhookSecretEnv = "GURL_WEBHOOK_SECRET"
+
+// WebhookStatus is the delivery log of the completion webhook of a task
+type WebhookStatus struct {
+	URL      string           `json:"url"`
+	State    string           `json:"state"`           // pending, delivered or failed
+	Error    string           `json:"error,omitempty"` // why a delivery was given up without a failed attempt
+	Attempts []WebhookAttempt `json:"attempts,omitempty"`
+
+	// guard holds the deliveries to the target allowlist of the key that
+	// submitted the task, nil when there is none
+	guard config.DialGuard
+}
+
+// WebhookAttempt is one delivery attempt of a webhook
+type WebhookAttempt struct {
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Duration   string    `json:"duration"`
+}
+
+// webhookSender posts the results of finished tasks to their callback URLs
+type webhookSender struct {
+	secret  []byte // signs the payloads, callback URLs are refused without it
+	backoff time.Duration
+
+	// ctx is canceled when the server stops, deliveries still pending
+	// are then given up
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newWebhookSender() *webhookSender {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookSender{
+		backoff: webhookBackoff,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// SetWebhookSecret sets the secret signing the payloads of completion
+// webhooks. Without it, submissions with a callback_url are rejected.
+func (s *HTTPServer) SetWebhookSecret(secret string) {
+	s.apiServer.webhooks.secret = []byte(secret)
+}
+
+// start registers a delivery, it returns false once the sender is closed
+func (w *webhookSender) start() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	w.wg.Add(1)
+	return true
+}
+
+// close gives up the pending deliveries and waits until their failure has
+// been logged
+func (w *webhookSender) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.cancel()
+	w.wg.Wait()
+}
+
+// parseCallbackURL validates the callback_url of a task submission. The
+// payloads are signed, so a callback URL needs the webhook secret.
+func (s *Server) parseCallbackURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid callback_url %q: expected an http or https URL", raw)
+	}
+	if len(s.webhooks.secret) == 0 {
+		return nil, fmt.Errorf("callback_url is not available: the server has no webhook secret (set %s)", WebhookSecretEnv)
+	}
+	return u, nil
+}
+
+// withCallbackHost adds the host of the callback URL to the hosts checked
+// against the target allowlist of a submission
+func withCallbackHost(targets []string, callback *url.URL) []string {
+	if callback == nil {
+		return targets
+	}
+	return append(append([]string{}, targets...), callback.Hostname())
+}
+
+// watchTask registers the callback URL of a new task, its results are
+// posted there once it has finished. The callback host is checked against
+// the allowlist at submission; guard checks it again at every connection.
+func (s *Server) watchTask(taskID string, callback *url.URL, guard config.DialGuard, configMap map[string]interface{}) {
+	if callback == nil {
+		return
+	}
+	configMap["callback_url"] = callback.String()
+	s.taskManager.SetTaskWebhook(taskID, &WebhookStatus{URL: callback.String(), State: WebhookPending, guard: guard})
+}
+
+// sendWebhook is the finish hook posting the results of a task to its
+// callback URL. Failed deliveries are retried with backoff; every attempt
+// is logged in the webhook status of the task.
+func (s *Server) sendWebhook(task *Task) {
+	if task.Webhook == nil {
+		return
+	}
+
+	results, ok := s.taskResults(task.ID)
+	if !ok {
+		return
+	}
+	body, err := json.Marshal(results)
+	if err != nil {
+		fmt.Printf("webhook: %v\n", err)
+		return
+	}
+
+	if !s.webhooks.start() {
+		s.taskManager.SetTaskWebhook(task.ID, &WebhookStatus{URL: task.Webhook.URL, State: WebhookFailed, Error: webhookStoppedError})
+		return
+	}
+
+	go func() {
+		defer s.webhooks.wg.Done()
+		status := WebhookStatus{URL: task.Webhook.URL, State: WebhookPending, guard: task.Webhook.guard}
+		client := webhookClient(status.guard)
+		defer client.CloseIdleConnections()
+		event := "task." + string(task.Status)
+		backoff := s.webhooks.backoff
+		for attempt := 1; ; attempt++ {
+			a, retry := s.webhooks.post(client, status.URL, event, task.ID, body)
+			status.Attempts = append(status.Attempts, a)
+			switch {
+			case a.Error == "":
+				status.State = WebhookDelivered
+			case s.webhooks.ctx.Err() != nil:
+				status.State = WebhookFailed
+				status.Error = webhookStoppedError
+			case !retry || attempt == webhookMaxAttempts:
+				status.State = WebhookFailed
+			}
+
+			snapshot := status
+			snapshot.Attempts = append([]WebhookAttempt(nil), status.Attempts...)
+			s.taskManager.SetTaskWebhook(task.ID, &snapshot)
+			if status.State != WebhookPending {
+				return
+			}
+
+			select {
+			case <-time.After(backoff):
+			case <-s.webhooks.ctx.Done():
+				status.State = WebhookFailed
+				status.Error = webhookStoppedError
+				s.taskManager.SetTaskWebhook(task.ID, &status)
+				return
+			}
+			backoff = min(backoff*2, webhookMaxBackoff)
+		}
+	}()
+}
+
+// webhookClient returns the client delivering the webhook of a task. As for
+// the load of a task, the guard vets every connection when it is dialed, so
+// a later DNS change can't point the callback at a host outside the
+// allowlist. Redirects are not followed, a 3xx response is a failed attempt.
+func webhookClient(guard config.DialGuard) *http.Client {
+	client := &http.Client{
+		Timeout: webhookTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if guard != nil {
+		// Dial directly, so the guard checks the real callback address
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return guard.Dial(ctx, net.Dialer{Timeout: 30 * time.Second}, network, addr)
+		}
+		client.Transport = transport
+	}
+	return client
+}
+
+// post makes one delivery attempt, reporting whether a failure is worth
+// retrying: network errors, 408, 429 and 5xx responses are
+func (w *webhookSender) post(client *http.Client, callbackURL, event, taskID string, body []byte) (WebhookAttempt, bool) {
+	start := time.Now()
+	a := WebhookAttempt{Time: start}
+
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		a.Error = err.Error()
+		a.Duration = formatDuration(time.Since(start))
+		return a, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gurl-webhook")
+	req.Header.Set("X-Gurl-Event", event)
+	req.Header.Set("X-Gurl-Task", taskID)
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("X-Gurl-Timestamp", timestamp)
+	req.Header.Set("X-Gurl-Signature", signWebhook(w.secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	a.Duration = formatDuration(time.Since(start))
+	if err != nil {
+		a.Error = err.Error()
+		return a, true
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	a.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return a, false
+	}
+	a.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return a, retry
+}
+
+// signWebhook returns the X-Gurl-Signature of a payload: "sha256=" and the
+// hex HMAC-SHA256 of the timestamp, a dot and the body. Signing the
+// timestamp lets receivers reject replayed deliveries.
+func signWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
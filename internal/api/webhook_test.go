@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// waitForWebhook polls a task until its webhook is no longer pending
+func waitForWebhook(t *testing.T, tm *TaskManager, id string) *WebhookStatus {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if task, ok := tm.GetTask(id); ok && task.Webhook != nil && task.Webhook.State != WebhookPending {
+			return task.Webhook
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("webhook of task %s was not delivered", id)
+	return nil
+}
+
+func TestWebhookRetriesAndSigns(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var body []byte
+	var header http.Header
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header.Clone()
+	}))
+	defer receiver.Close()
+
+	s := NewServer()
+	s.webhooks.backoff = 10 * time.Millisecond
+	s.webhooks.secret = []byte("s3cret")
+
+	callback, _ := url.Parse(receiver.URL + "/hook")
+	s.taskManager.CreateTask("t1", map[string]interface{}{})
+	s.watchTask("t1", callback, nil, map[string]interface{}{})
+	s.taskManager.RunTask(context.Background(), "t1", func(ctx context.Context) (*stats.Results, error) {
+		results := stats.NewResults()
+		results.TotalRequests = 3
+		return results, nil
+	})
+
+	status := waitForWebhook(t, s.taskManager, "t1")
+	if status.State != WebhookDelivered || len(status.Attempts) != 2 {
+		t.Fatalf("expected delivery on the second attempt, got %+v", status)
+	}
+	if status.Attempts[0].StatusCode != http.StatusBadGateway || status.Attempts[0].Error == "" || status.Attempts[1].StatusCode != http.StatusOK {
+		t.Errorf("unexpected delivery log %+v", status.Attempts)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	timestamp := header.Get("X-Gurl-Timestamp")
+	if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		t.Errorf("unexpected timestamp %q", timestamp)
+	}
+	if got, want := header.Get("X-Gurl-Signature"), signWebhook([]byte("s3cret"), timestamp, body); got != want {
+		t.Errorf("expected signature %s, got %s", want, got)
+	}
+	if event := header.Get("X-Gurl-Event"); event != "task.completed" {
+		t.Errorf("unexpected event %q", event)
+	}
+	var payload TaskResultsResponse
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ID != "t1" || payload.Status != string(TaskStatusCompleted) || payload.Results == nil || payload.Results.TotalRequests != 3 {
+		t.Errorf("unexpected payload %s", body)
+	}
+}
+
+func TestWebhookGivesUpOnClientError(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer receiver.Close()
+
+	s := NewServer()
+	s.webhooks.backoff = 10 * time.Millisecond
+	callback, _ := url.Parse(receiver.URL)
+	s.taskManager.CreateTask("t1", map[string]interface{}{})
+	s.watchTask("t1", callback, nil, map[string]interface{}{})
+	s.taskManager.SetTaskError("t1", io.ErrUnexpectedEOF)
+	s.taskManager.notifyFinish("t1")
+
+	status := waitForWebhook(t, s.taskManager, "t1")
+	if status.State != WebhookFailed || len(status.Attempts) != 1 || status.Attempts[0].StatusCode != http.StatusNotFound {
+		t.Errorf("expected a single failed attempt, got %+v", status)
+	}
+}
+
+func TestWebhookGivenUpOnStop(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	s := NewServer()
+	s.webhooks.backoff = time.Hour
+	callback, _ := url.Parse(receiver.URL)
+	s.taskManager.CreateTask("t1", map[string]interface{}{})
+	s.watchTask("t1", callback, nil, map[string]interface{}{})
+	s.taskManager.SetTaskError("t1", io.ErrUnexpectedEOF)
+	s.taskManager.notifyFinish("t1")
+
+	// Wait for the first attempt, the retry is an hour away
+	for i := 0; i < 200; i++ {
+		if task, _ := s.taskManager.GetTask("t1"); len(task.Webhook.Attempts) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.webhooks.close()
+
+	task, _ := s.taskManager.GetTask("t1")
+	if task.Webhook.State != WebhookFailed || task.Webhook.Error != webhookStoppedError || len(task.Webhook.Attempts) != 1 {
+		t.Errorf("expected the pending retry to be given up, got %+v", task.Webhook)
+	}
+
+	// Tasks finishing after the stop are not delivered
+	s.taskManager.CreateTask("t2", map[string]interface{}{})
+	s.watchTask("t2", callback, nil, map[string]interface{}{})
+	s.taskManager.SetTaskError("t2", io.ErrUnexpectedEOF)
+	s.taskManager.notifyFinish("t2")
+	if task, _ := s.taskManager.GetTask("t2"); task.Webhook.State != WebhookFailed || len(task.Webhook.Attempts) != 0 {
+		t.Errorf("expected no delivery after the stop, got %+v", task.Webhook)
+	}
+}
+
+func TestWebhookStaysInsideTheAllowlist(t *testing.T) {
+	var internalHits int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&internalHits, 1)
+	}))
+	defer internal.Close()
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer receiver.Close()
+
+	s := NewServer()
+	s.webhooks.backoff = 10 * time.Millisecond
+	callback, _ := url.Parse(receiver.URL)
+
+	// Redirects are not followed
+	s.taskManager.CreateTask("t1", map[string]interface{}{})
+	s.watchTask("t1", callback, nil, map[string]interface{}{})
+	s.taskManager.SetTaskError("t1", io.ErrUnexpectedEOF)
+	s.taskManager.notifyFinish("t1")
+	status := waitForWebhook(t, s.taskManager, "t1")
+	if status.State != WebhookFailed || len(status.Attempts) != 1 || status.Attempts[0].StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("expected the redirect to fail the delivery, got %+v", status)
+	}
+	if hits := atomic.LoadInt32(&internalHits); hits != 0 {
+		t.Errorf("the redirect target received %d deliveries", hits)
+	}
+
+	// The guard of the submitting key vets every connection
+	guard := config.DialGuard(func(host string, ip net.IP) error {
+		return fmt.Errorf("target %s is not allowed", host)
+	})
+	s.taskManager.CreateTask("t2", map[string]interface{}{})
+	s.watchTask("t2", callback, guard, map[string]interface{}{})
+	s.taskManager.SetTaskError("t2", io.ErrUnexpectedEOF)
+	s.taskManager.notifyFinish("t2")
+	status = waitForWebhook(t, s.taskManager, "t2")
+	if status.State != WebhookFailed || len(status.Attempts) == 0 || !strings.Contains(status.Attempts[0].Error, "not allowed") {
+		t.Errorf("expected the guard to refuse the delivery, got %+v", status)
+	}
+}
+
+func TestBenchmarkRejectsInvalidCallbackURL(t *testing.T) {
+	s := NewServer()
+	submit := func(callback string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		body := `{"url": "http://127.0.0.1:1/", "callback_url": "` + callback + `"}`
+		s.handleBenchmark(rec, httptest.NewRequest(http.MethodPost, "/api/v1/benchmark", strings.NewReader(body)))
+		return rec
+	}
+
+	// Without a secret the payloads can't be signed
+	if rec := submit("http://example.com/hook"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), WebhookSecretEnv) {
+		t.Errorf("expected 400 for a callback_url without a webhook secret, got %d %s", rec.Code, rec.Body)
+	}
+
+	s.webhooks.secret = []byte("s3cret")
+	if rec := submit("ftp://example.com/hook"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "callback_url") {
+		t.Errorf("expected 400 for an invalid callback_url, got %d %s", rec.Code, rec.Body)
+	}
+}
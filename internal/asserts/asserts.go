@@ -36,6 +36,33 @@ func Evaluate(assertsText string, resp *HTTPResponse) error {
 	return nil
 }
 
+// Result is the outcome of a single assertion line
+type Result struct {
+	Line   int    // 1-based line number in the asserts text
+	Assert string // the trimmed assertion expression
+	Err    error  // nil when the assertion passed
+}
+
+// EvaluateEach evaluates every assertion line independently and returns one
+// Result per line, so callers can track pass/fail counts per assertion.
+func EvaluateEach(assertsText string, resp *HTTPResponse) []Result {
+	lines := strings.Split(assertsText, "\n")
+	results := make([]Result, 0, len(lines))
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		res := Result{Line: i + 1, Assert: line}
+		if err := evalSingle(line, resp); err != nil {
+			res.Err = fmt.Errorf("assertion failed at line %d: %s: %w", i+1, line, err)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
 func evalSingle(line string, resp *HTTPResponse) error {
 	// Very small hand-written parser for the supported grammar.
 	// Targets: status | header "Name" | gjson "Path" | body | duration_ms
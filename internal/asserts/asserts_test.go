@@ -104,3 +104,27 @@ func TestEvaluateGJSONRegexAndExists(t *testing.T) {
 		t.Fatalf("expected gjson regex/exists assertions to pass, got: %v", err)
 	}
 }
+
+func TestEvaluateEach(t *testing.T) {
+	resp := &HTTPResponse{
+		Status: 500,
+		Body:   []byte(`{"ok":true}`),
+	}
+
+	assertText := `
+	# comment lines are skipped
+	status == 200
+	gjson "ok" == true
+	`
+
+	results := EvaluateEach(assertText, resp)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Assert != "status == 200" || results[0].Line != 3 || results[0].Err == nil {
+		t.Errorf("expected status assertion at line 3 to fail, got %+v", results[0])
+	}
+	if results[1].Err != nil {
+		t.Errorf("expected gjson assertion to pass, got %v", results[1].Err)
+	}
+}
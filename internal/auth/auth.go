@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+// Provider adds credentials to outgoing requests. Apply is called for every
+// request of a run, from many goroutines at once.
+type Provider interface {
+	// Apply sets the auth headers on req. body is the request body that will
+	// be sent, or nil when the request has none.
+	Apply(req *http.Request, body []byte) error
+}
+
+// New creates the provider described by cfg. It returns nil when cfg is nil.
+func New(cfg *config.AuthConfig) (Provider, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case config.AuthBasic:
+		return &basicProvider{username: os.ExpandEnv(cfg.Username), password: os.ExpandEnv(cfg.Password)}, nil
+	case config.AuthBearer:
+		return &bearerProvider{header: "Bearer " + os.ExpandEnv(cfg.Token)}, nil
+	case config.AuthOAuth2:
+		return newOAuth2(cfg), nil
+	case config.AuthHMAC:
+		return newHMAC(cfg), nil
+	case config.AuthSigV4:
+		return newSigV4(cfg), nil
+	}
+	return nil, fmt.Errorf("unsupported auth type '%s'", cfg.Type)
+}
+
+// basicProvider sends HTTP basic credentials
+type basicProvider struct {
+	username string
+	password string
+}
+
+func (p *basicProvider) Apply(req *http.Request, _ []byte) error {
+	req.SetBasicAuth(p.username, p.password)
+	return nil
+}
+
+// bearerProvider sends a static bearer token
+type bearerProvider struct {
+	header string
+}
+
+func (p *bearerProvider) Apply(req *http.Request, _ []byte) error {
+	req.Header.Set("Authorization", p.header)
+	return nil
+}
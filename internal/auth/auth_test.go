@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+func TestBasicAndBearer(t *testing.T) {
+	t.Setenv("GURL_TEST_TOKEN", "tok")
+
+	p, err := New(&config.AuthConfig{Type: config.AuthBearer, Token: "${GURL_TEST_TOKEN}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("Authorization", "Bearer stale")
+	if err := p.Apply(req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("bearer: got %q", got)
+	}
+
+	p, _ = New(&config.AuthConfig{Type: config.AuthBasic, Username: "u", Password: "p"})
+	_ = p.Apply(req, nil)
+	if user, pass, ok := req.BasicAuth(); !ok || user != "u" || pass != "p" {
+		t.Errorf("basic: got %q %q %v", user, pass, ok)
+	}
+
+	if p, err := New(nil); p != nil || err != nil {
+		t.Errorf("nil config: got %v %v", p, err)
+	}
+}
+
+func TestOAuth2Refresh(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		_ = r.ParseForm()
+		if user != "id" || pass != "secret" || r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := calls.Add(1)
+		fmt.Fprintf(w, `{"access_token":"t%d","token_type":"bearer","expires_in":120}`, n)
+	}))
+	defer srv.Close()
+
+	p := newOAuth2(&config.AuthConfig{
+		Type: config.AuthOAuth2, TokenURL: srv.URL, ClientID: "id", ClientSecret: "secret",
+		Scopes: []string{"read", "write"}, RefreshBefore: "60s",
+	})
+	var mu sync.Mutex
+	now := time.Unix(1000, 0)
+	p.now = func() time.Time { mu.Lock(); defer mu.Unlock(); return now }
+	advance := func(d time.Duration) { mu.Lock(); now = now.Add(d); mu.Unlock() }
+
+	token := func() string {
+		req, _ := http.NewRequest("GET", "http://example.com/", nil)
+		if err := p.Apply(req, nil); err != nil {
+			t.Fatal(err)
+		}
+		return req.Header.Get("Authorization")
+	}
+
+	if got := token(); got != "Bearer t1" {
+		t.Fatalf("first token: got %q", got)
+	}
+	advance(30 * time.Second)
+	if got := token(); got != "Bearer t1" || calls.Load() != 1 {
+		t.Fatalf("cached token: got %q after %d calls", got, calls.Load())
+	}
+
+	// Inside the refresh window the current token is still used while a new
+	// one is fetched in the background
+	advance(40 * time.Second)
+	if got := token(); got != "Bearer t1" {
+		t.Fatalf("token during refresh: got %q", got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for token() != "Bearer t2" {
+		if time.Now().After(deadline) {
+			t.Fatal("token was not refreshed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// An expired token is replaced synchronously
+	advance(10 * time.Minute)
+	if got := token(); got != "Bearer t3" {
+		t.Fatalf("token after expiry: got %q", got)
+	}
+}
+
+func TestOAuth2Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	p, _ := New(&config.AuthConfig{Type: config.AuthOAuth2, TokenURL: srv.URL, ClientID: "id"})
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if err := p.Apply(req, nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected token error, got %v", err)
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	p := newHMAC(&config.AuthConfig{
+		Type:            config.AuthHMAC,
+		KeyID:           "key1",
+		Secret:          "s3cr3t",
+		Canonical:       "{method}\n{path}?{query}\n{timestamp}\n{header:X-Tenant}\n{body_sha256}",
+		Header:          "Authorization",
+		Format:          "HMAC {key_id}:{signature}",
+		TimestampHeader: "X-Timestamp",
+	})
+	p.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	body := []byte(`{"a":1}`)
+	req, _ := http.NewRequest("POST", "http://example.com/v1/items?x=1", nil)
+	req.Header.Set("X-Tenant", "acme")
+	if err := p.Apply(req, body); err != nil {
+		t.Fatal(err)
+	}
+
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte("POST\n/v1/items?x=1\n1700000000\nacme\n" + hex.EncodeToString(bodySum[:])))
+	want := "HMAC key1:" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("signature header:\n got %q\nwant %q", got, want)
+	}
+	if got := req.Header.Get("X-Timestamp"); got != "1700000000" {
+		t.Errorf("timestamp header: got %q", got)
+	}
+}
+
+// The expected signatures come from the AWS Signature Version 4 test suite
+// (get-vanilla) and the IAM ListUsers example of the AWS documentation.
+func TestSigV4(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		service  string
+		header   map[string]string
+		expected string
+	}{
+		{
+			name:     "get-vanilla",
+			url:      "https://example.amazonaws.com/",
+			service:  "service",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:     "iam list users",
+			url:      "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			service:  "iam",
+			header:   map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"},
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+
+	for _, tt := range tests {
+		p := newSigV4(&config.AuthConfig{
+			Type: config.AuthSigV4, AccessKey: "AKIDEXAMPLE", SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			Region: "us-east-1", Service: tt.service,
+		})
+		p.now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+		req, _ := http.NewRequest("GET", tt.url, nil)
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		if err := p.Apply(req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got != tt.expected {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+// Defaults of the hmac provider
+const (
+	defaultHMACCanonical = "{method}\n{path}\n{timestamp}\n{body_sha256}"
+	defaultHMACHeader    = "X-Signature"
+	defaultHMACFormat    = "{signature}"
+)
+
+// placeholderRe matches {name} and {header:Name} placeholders
+var placeholderRe = regexp.MustCompile(`\{([a-z_0-9]+)(?::([^}]+))?\}`)
+
+// hmacProvider signs a configurable canonical string of each request
+type hmacProvider struct {
+	keyID           string
+	secret          []byte
+	newHash         func() hash.Hash
+	base64          bool
+	canonical       string
+	header          string
+	format          string
+	timestampHeader string
+	nonceHeader     string
+	now             func() time.Time
+}
+
+func newHMAC(cfg *config.AuthConfig) *hmacProvider {
+	p := &hmacProvider{
+		keyID:           os.ExpandEnv(cfg.KeyID),
+		secret:          []byte(os.ExpandEnv(cfg.Secret)),
+		newHash:         sha256.New,
+		base64:          strings.EqualFold(cfg.Encoding, "base64"),
+		canonical:       cfg.Canonical,
+		header:          cfg.Header,
+		format:          cfg.Format,
+		timestampHeader: cfg.TimestampHeader,
+		nonceHeader:     cfg.NonceHeader,
+		now:             time.Now,
+	}
+	switch strings.ToLower(cfg.Algorithm) {
+	case "sha1":
+		p.newHash = sha1.New
+	case "sha512":
+		p.newHash = sha512.New
+	}
+	if p.canonical == "" {
+		p.canonical = defaultHMACCanonical
+	}
+	if p.header == "" {
+		p.header = defaultHMACHeader
+	}
+	if p.format == "" {
+		p.format = defaultHMACFormat
+	}
+	return p
+}
+
+func (p *hmacProvider) Apply(req *http.Request, body []byte) error {
+	timestamp := strconv.FormatInt(p.now().Unix(), 10)
+	nonce := newNonce()
+
+	// The timestamp and nonce headers are set first so {header:...} can refer to them
+	if p.timestampHeader != "" {
+		req.Header.Set(p.timestampHeader, timestamp)
+	}
+	if p.nonceHeader != "" {
+		req.Header.Set(p.nonceHeader, nonce)
+	}
+
+	values := map[string]string{
+		"method":    req.Method,
+		"path":      req.URL.EscapedPath(),
+		"query":     req.URL.RawQuery,
+		"host":      requestHost(req),
+		"timestamp": timestamp,
+		"nonce":     nonce,
+		"key_id":    p.keyID,
+	}
+	if strings.Contains(p.canonical, "{body_sha256}") {
+		sum := sha256.Sum256(body)
+		values["body_sha256"] = hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(p.newHash, p.secret)
+	mac.Write([]byte(expand(p.canonical, values, req.Header)))
+	sum := mac.Sum(nil)
+
+	if p.base64 {
+		values["signature"] = base64.StdEncoding.EncodeToString(sum)
+	} else {
+		values["signature"] = hex.EncodeToString(sum)
+	}
+	req.Header.Set(p.header, expand(p.format, values, req.Header))
+	return nil
+}
+
+// expand replaces the placeholders of tmpl. Unknown placeholders are kept as is.
+func expand(tmpl string, values map[string]string, header http.Header) string {
+	return placeholderRe.ReplaceAllStringFunc(tmpl, func(m string) string {
+		sub := placeholderRe.FindStringSubmatch(m)
+		if sub[1] == "header" && sub[2] != "" {
+			return header.Get(sub[2])
+		}
+		if v, ok := values[sub[1]]; ok && sub[2] == "" {
+			return v
+		}
+		return m
+	})
+}
+
+// requestHost returns the host that will be sent in the Host header
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// newNonce returns 16 random bytes in hex
+func newNonce() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
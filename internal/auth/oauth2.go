@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+// retryAfterFailure delays the next background refresh after a failed attempt
+// so that a broken token endpoint is not hit on every request
+const retryAfterFailure = 5 * time.Second
+
+// oauth2Provider fetches tokens with the client credentials grant and
+// refreshes them before they expire. Requests keep using the current token
+// while a refresh runs in the background; they only wait for the token
+// endpoint when there is no valid token at all.
+type oauth2Provider struct {
+	tokenURL      string
+	clientID      string
+	clientSecret  string
+	scopes        []string
+	params        map[string]string
+	paramsStyle   bool
+	refreshBefore time.Duration
+	client        *http.Client
+	now           func() time.Time
+
+	mu         sync.Mutex
+	token      string
+	expiry     time.Time // zero when the token does not expire
+	refreshAt  time.Time
+	refreshing bool
+}
+
+func newOAuth2(cfg *config.AuthConfig) *oauth2Provider {
+	params := make(map[string]string, len(cfg.Params))
+	for k, v := range cfg.Params {
+		params[k] = os.ExpandEnv(v)
+	}
+	return &oauth2Provider{
+		tokenURL:      os.ExpandEnv(cfg.TokenURL),
+		clientID:      os.ExpandEnv(cfg.ClientID),
+		clientSecret:  os.ExpandEnv(cfg.ClientSecret),
+		scopes:        cfg.Scopes,
+		params:        params,
+		paramsStyle:   cfg.AuthStyle == "params",
+		refreshBefore: cfg.GetRefreshBefore(),
+		client:        &http.Client{Timeout: 30 * time.Second},
+		now:           time.Now,
+	}
+}
+
+func (p *oauth2Provider) Apply(req *http.Request, _ []byte) error {
+	token, err := p.currentToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// currentToken returns a valid token, starting a background refresh when the
+// token is about to expire
+func (p *oauth2Provider) currentToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.token != "" && (p.expiry.IsZero() || now.Before(p.expiry)) {
+		if !p.refreshing && !p.refreshAt.IsZero() && !now.Before(p.refreshAt) {
+			p.refreshing = true
+			go p.refresh()
+		}
+		return p.token, nil
+	}
+
+	// No usable token: fetch one while holding the lock so that concurrent
+	// requests wait for a single token request
+	token, expiresIn, err := p.fetch()
+	if err != nil {
+		return "", err
+	}
+	p.store(token, expiresIn)
+	return p.token, nil
+}
+
+// refresh fetches a new token in the background. On failure the current
+// token stays in use and the refresh is retried a little later.
+func (p *oauth2Provider) refresh() {
+	token, expiresIn, err := p.fetch()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refreshing = false
+	if err != nil {
+		p.refreshAt = p.now().Add(retryAfterFailure)
+		return
+	}
+	p.store(token, expiresIn)
+}
+
+// store saves a token and schedules its refresh. Must be called with mu held.
+func (p *oauth2Provider) store(token string, expiresIn time.Duration) {
+	now := p.now()
+	p.token = token
+	p.expiry = time.Time{}
+	p.refreshAt = time.Time{}
+	if expiresIn <= 0 {
+		return
+	}
+
+	before := p.refreshBefore
+	if before >= expiresIn {
+		// Short-lived tokens are refreshed half way through their lifetime
+		before = expiresIn / 2
+	}
+	p.expiry = now.Add(expiresIn)
+	p.refreshAt = p.expiry.Add(-before)
+}
+
+// tokenResponse is the token endpoint reply defined by RFC 6749 section 5.1
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// fetch requests a token from the token endpoint
+func (p *oauth2Provider) fetch() (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(p.scopes) > 0 {
+		form.Set("scope", strings.Join(p.scopes, " "))
+	}
+	for k, v := range p.params {
+		form.Set(k, v)
+	}
+	if p.paramsStyle {
+		form.Set("client_id", p.clientID)
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !p.paramsStyle {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 token request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 token request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("oauth2 token request: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return "", 0, fmt.Errorf("oauth2 token response: %v", err)
+	}
+	if tr.AccessToken == "" {
+		return "", 0, fmt.Errorf("oauth2 token response: missing access_token")
+	}
+	return tr.AccessToken, time.Duration(tr.ExpiresIn) * time.Second, nil
+}
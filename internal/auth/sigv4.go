@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// sigV4Provider signs requests with AWS Signature Version 4
+type sigV4Provider struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+	service      string
+	now          func() time.Time
+}
+
+func newSigV4(cfg *config.AuthConfig) *sigV4Provider {
+	return &sigV4Provider{
+		accessKey:    os.ExpandEnv(cfg.AccessKey),
+		secretKey:    os.ExpandEnv(cfg.SecretKey),
+		sessionToken: os.ExpandEnv(cfg.SessionToken),
+		region:       cfg.Region,
+		service:      cfg.Service,
+		now:          time.Now,
+	}
+}
+
+func (p *sigV4Provider) Apply(req *http.Request, body []byte) error {
+	t := p.now().UTC()
+	amzDate := t.Format(sigV4TimeFormat)
+	date := amzDate[:8]
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if p.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", p.sessionToken)
+	}
+	if p.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		p.canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + p.region + "/" + p.service + "/aws4_request"
+	crSum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+p.secretKey), date)
+	key = hmacSHA256(key, p.region)
+	key = hmacSHA256(key, p.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", sigV4Algorithm+" Credential="+p.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+	return nil
+}
+
+// canonicalURI encodes the path as SigV4 expects: once for S3, twice for
+// every other service
+func (p *sigV4Provider) canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if p.service == "s3" {
+		path = u.Path
+	}
+	if path == "" {
+		return "/"
+	}
+	return awsEscape(path, false)
+}
+
+// canonicalQuery sorts the query parameters by name and value
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	pairs := make([]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			pairs = append(pairs, awsEscape(k, true)+"="+awsEscape(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeaders signs the host, content type and all x-amz-* headers
+func canonicalHeaders(req *http.Request) (canonical, signed string) {
+	values := map[string]string{"host": requestHost(req)}
+	for name, vs := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "content-md5" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(vs))
+			for i, v := range vs {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			values[lower] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(values[name])
+		b.WriteByte('\n')
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// awsEscape percent-encodes everything except the unreserved characters of
+// RFC 3986, and '/' unless encodeSlash is set
+func awsEscape(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
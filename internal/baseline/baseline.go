@@ -0,0 +1,133 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// DefaultDir is where baselines are stored when no directory is given
+const DefaultDir = ".gurl/baselines"
+
+// SingleKey is the entry key used for a plain (non-batch) benchmark
+const SingleKey = "benchmark"
+
+// Baseline is a named set of reference results, one entry per benchmark or
+// batch test
+type Baseline struct {
+	Name      string              `json:"name"`
+	CreatedAt time.Time           `json:"created_at"`
+	Entries   map[string]Snapshot `json:"entries"`
+}
+
+// Snapshot holds the metrics of one run that are compared against later runs
+type Snapshot struct {
+	Target         string  `json:"target,omitempty"`
+	Requests       int64   `json:"requests"`
+	Errors         int64   `json:"errors"`
+	ErrorRate      float64 `json:"error_rate"` // percent
+	RequestsPerSec float64 `json:"requests_per_sec"`
+	P50Ms          float64 `json:"p50_ms"`
+	P95Ms          float64 `json:"p95_ms"`
+	P99Ms          float64 `json:"p99_ms"`
+	DurationSec    float64 `json:"duration_sec"`
+}
+
+// FromResults captures the comparable metrics of results
+func FromResults(target string, results *stats.Results) Snapshot {
+	s := Snapshot{
+		Target:      target,
+		Requests:    results.TotalRequests,
+		Errors:      results.TotalErrors,
+		DurationSec: results.Duration.Seconds(),
+	}
+	if results.TotalRequests > 0 {
+		s.ErrorRate = float64(results.TotalErrors) / float64(results.TotalRequests) * 100
+	}
+	if results.Duration > 0 {
+		s.RequestsPerSec = float64(results.TotalRequests) / results.Duration.Seconds()
+	}
+
+	percentiles := results.GetLatencyPercentiles()
+	s.P50Ms = toMs(percentiles[50])
+	s.P95Ms = toMs(percentiles[95])
+	s.P99Ms = toMs(percentiles[99])
+	return s
+}
+
+// Store keeps baselines as JSON files in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir (DefaultDir when empty)
+func NewStore(dir string) *Store {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Store{dir: dir}
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+func (s *Store) path(name string) (string, error) {
+	if !validName.MatchString(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid baseline name '%s': use letters, digits, '.', '_' or '-'", name)
+	}
+	return filepath.Join(s.dir, name+".json"), nil
+}
+
+// Load reads the baseline called name
+func (s *Store) Load(name string) (*Baseline, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("baseline '%s' not found in %s", name, s.dir)
+		}
+		return nil, fmt.Errorf("failed to read baseline: %v", err)
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline '%s': %v", name, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline, replacing any previous one with the same name
+func (s *Store) Save(b *Baseline) (string, error) {
+	path, err := s.path(b.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create baseline directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// Write to a temp file first so an interrupted save never leaves a partial baseline
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write baseline: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("failed to write baseline: %v", err)
+	}
+	return path, nil
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,94 @@
+package baseline
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	store := NewStore(t.TempDir())
+	want := &Baseline{
+		Name:      "nightly",
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Entries:   map[string]Snapshot{SingleKey: {Requests: 1000, RequestsPerSec: 100, P99Ms: 12.5}},
+	}
+	if _, err := store.Save(want); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	got, err := store.Load("nightly")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got.Entries[SingleKey] != want.Entries[SingleKey] || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("baseline changed after round trip: %+v", got)
+	}
+
+	if _, err := store.Load("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, err := store.Save(&Baseline{Name: "../escape"}); err == nil {
+		t.Error("names with path separators must be rejected")
+	}
+}
+
+func TestCompareDetectsRegressions(t *testing.T) {
+	base := &Baseline{Entries: map[string]Snapshot{
+		"login": {RequestsPerSec: 1000, ErrorRate: 0.1, P50Ms: 10, P95Ms: 20, P99Ms: 40},
+	}}
+
+	within := Snapshot{RequestsPerSec: 950, ErrorRate: 0.5, P50Ms: 10.5, P95Ms: 21, P99Ms: 43}
+	if cmp := Compare(base, "login", within, DefaultTolerances); cmp.Regressed() {
+		t.Errorf("changes within tolerance must not regress: %+v", cmp.Checks)
+	}
+
+	worse := Snapshot{RequestsPerSec: 800, ErrorRate: 2, P50Ms: 10, P95Ms: 20, P99Ms: 60}
+	cmp := Compare(base, "login", worse, DefaultTolerances)
+	regressed := map[string]bool{}
+	for _, c := range cmp.Checks {
+		regressed[c.Metric] = c.Regressed
+	}
+	for metric, want := range map[string]bool{"p50": false, "p95": false, "p99": true, "rps": true, "error_rate": true} {
+		if regressed[metric] != want {
+			t.Errorf("%s: regressed=%v, want %v", metric, regressed[metric], want)
+		}
+	}
+
+	if cmp := Compare(base, "checkout", worse, DefaultTolerances); !cmp.Missing || cmp.Regressed() {
+		t.Errorf("tests without a baseline entry should be skipped: %+v", cmp)
+	}
+
+	out := Format("nightly", []Comparison{cmp}, DefaultTolerances)
+	if !strings.Contains(out, "REGRESSION") || !strings.Contains(out, "login") {
+		t.Errorf("report should flag the regression:\n%s", out)
+	}
+}
+
+func TestCompareAllCountsMissingTests(t *testing.T) {
+	base := &Baseline{Entries: map[string]Snapshot{
+		"login":    {RequestsPerSec: 1000, P50Ms: 10},
+		"checkout": {RequestsPerSec: 500, P50Ms: 20},
+		"search":   {RequestsPerSec: 800, P50Ms: 15},
+	}}
+	entries := map[string]Snapshot{"login": {RequestsPerSec: 1000, P50Ms: 10}}
+	failed := map[string]string{"checkout": "connection refused"}
+
+	got := map[string]Comparison{}
+	for _, cmp := range CompareAll(base, entries, failed, DefaultTolerances) {
+		got[cmp.Entry] = cmp
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected every baseline test to be compared, got %+v", got)
+	}
+	if login := got["login"]; login.Regressed() {
+		t.Errorf("unchanged test regressed: %+v", login)
+	}
+	// errored and missing tests both count as regressions
+	if checkout := got["checkout"]; !checkout.Regressed() || checkout.Failed != "connection refused" {
+		t.Errorf("errored test should regress: %+v", checkout)
+	}
+	if search := got["search"]; !search.Regressed() || search.Failed != "not run" {
+		t.Errorf("missing test should regress: %+v", search)
+	}
+}
@@ -0,0 +1,157 @@
+package baseline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tolerances define how much worse than the baseline a run may get before it
+// is reported as a regression
+type Tolerances struct {
+	LatencyPct   float64 // allowed p50/p95/p99 increase, in percent
+	RPSPct       float64 // allowed requests/sec decrease, in percent
+	ErrorRatePts float64 // allowed error rate increase, in percentage points
+}
+
+// DefaultTolerances are used when no tolerance flags are given
+var DefaultTolerances = Tolerances{LatencyPct: 10, RPSPct: 10, ErrorRatePts: 1}
+
+// Check is the comparison of a single metric
+type Check struct {
+	Metric    string
+	Baseline  float64
+	Current   float64
+	Change    float64 // percent for latency/rps, percentage points for error rate
+	Unit      string
+	Regressed bool
+}
+
+// Comparison holds the checks of one benchmark or batch test
+type Comparison struct {
+	Entry   string
+	Missing bool   // the baseline has no entry for this test
+	Failed  string // why a test named in the baseline has no result in this run
+	Checks  []Check
+}
+
+// Regressed reports whether any check exceeded its tolerance, or a test
+// named in the baseline did not complete
+func (c *Comparison) Regressed() bool {
+	if c.Failed != "" {
+		return true
+	}
+	for _, check := range c.Checks {
+		if check.Regressed {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareAll compares every completed test of the run and every test named
+// in the baseline. failed maps the tests that did not complete to the reason;
+// baseline tests that failed or were not run at all count as regressions.
+func CompareAll(b *Baseline, entries map[string]Snapshot, failed map[string]string, tol Tolerances) []Comparison {
+	comparisons := make([]Comparison, 0, len(entries)+len(b.Entries))
+	for name, snapshot := range entries {
+		comparisons = append(comparisons, Compare(b, name, snapshot, tol))
+	}
+	for name := range b.Entries {
+		if _, ok := entries[name]; ok {
+			continue
+		}
+		reason, ok := failed[name]
+		if !ok {
+			reason = "not run"
+		}
+		comparisons = append(comparisons, Comparison{Entry: name, Failed: reason})
+	}
+	return comparisons
+}
+
+// Compare checks current against the entry of the baseline
+func Compare(b *Baseline, entry string, current Snapshot, tol Tolerances) Comparison {
+	cmp := Comparison{Entry: entry}
+	base, ok := b.Entries[entry]
+	if !ok {
+		cmp.Missing = true
+		return cmp
+	}
+
+	latency := func(name string, base, cur float64) Check {
+		c := Check{Metric: name, Baseline: base, Current: cur, Unit: "ms"}
+		if base > 0 {
+			c.Change = (cur - base) / base * 100
+			c.Regressed = c.Change > tol.LatencyPct
+		}
+		return c
+	}
+
+	rps := Check{Metric: "rps", Baseline: base.RequestsPerSec, Current: current.RequestsPerSec}
+	if base.RequestsPerSec > 0 {
+		rps.Change = (current.RequestsPerSec - base.RequestsPerSec) / base.RequestsPerSec * 100
+		rps.Regressed = -rps.Change > tol.RPSPct
+	}
+
+	errRate := Check{Metric: "error_rate", Baseline: base.ErrorRate, Current: current.ErrorRate, Unit: "%"}
+	errRate.Change = current.ErrorRate - base.ErrorRate
+	errRate.Regressed = errRate.Change > tol.ErrorRatePts
+
+	cmp.Checks = []Check{
+		latency("p50", base.P50Ms, current.P50Ms),
+		latency("p95", base.P95Ms, current.P95Ms),
+		latency("p99", base.P99Ms, current.P99Ms),
+		rps,
+		errRate,
+	}
+	return cmp
+}
+
+// Format renders comparisons as a text table
+func Format(name string, comparisons []Comparison, tol Tolerances) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nBaseline comparison against '%s' (tolerances: latency +%.1f%%, rps -%.1f%%, error rate +%.2fpt)\n",
+		name, tol.LatencyPct, tol.RPSPct, tol.ErrorRatePts)
+
+	sorted := append([]Comparison(nil), comparisons...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Entry < sorted[j].Entry })
+
+	for _, cmp := range sorted {
+		if len(sorted) > 1 || cmp.Entry != SingleKey {
+			fmt.Fprintf(&b, "\n  %s\n", cmp.Entry)
+		}
+		if cmp.Missing {
+			b.WriteString("    no baseline entry, skipped\n")
+			continue
+		}
+		if cmp.Failed != "" {
+			fmt.Fprintf(&b, "    no result (%s)  REGRESSION\n", cmp.Failed)
+			continue
+		}
+		fmt.Fprintf(&b, "    %-12s %14s %14s %10s\n", "Metric", "Baseline", "Current", "Change")
+		for _, c := range cmp.Checks {
+			status := "ok"
+			if c.Regressed {
+				status = "REGRESSION"
+			}
+			change := fmt.Sprintf("%+.1f%%", c.Change)
+			if c.Metric == "error_rate" {
+				change = fmt.Sprintf("%+.2fpt", c.Change)
+			}
+			fmt.Fprintf(&b, "    %-12s %14s %14s %10s  %s\n", c.Metric, formatValue(c.Baseline, c.Unit), formatValue(c.Current, c.Unit), change, status)
+		}
+	}
+	return b.String()
+}
+
+func formatValue(v float64, unit string) string {
+	switch unit {
+	case "ms":
+		return fmt.Sprintf("%.2fms", v)
+	case "%":
+		return fmt.Sprintf("%.2f%%", v)
+	default:
+		return fmt.Sprintf("%.2f", v)
+	}
+}
@@ -1,6 +1,7 @@
 package batch
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -38,6 +39,7 @@ type BatchResult struct {
 type Executor struct {
 	maxConcurrency int
 	verbose        bool
+	observers      func(testName string) ([]benchmark.Observer, error)
 }
 
 // NewExecutor creates a new batch executor
@@ -51,6 +53,13 @@ func NewExecutor(maxConcurrency int, verbose bool) *Executor {
 	}
 }
 
+// SetObserverFactory registers a function that returns the observers attached
+// to the benchmark of each test. A test whose observers can't be created
+// fails without running.
+func (e *Executor) SetObserverFactory(factory func(testName string) ([]benchmark.Observer, error)) {
+	e.observers = factory
+}
+
 // Execute runs all tests in the batch configuration
 func (e *Executor) Execute(ctx context.Context, batchConfig *config.BatchConfig, defaults *config.Config) (*BatchResult, error) {
 	if err := batchConfig.Validate(); err != nil {
@@ -98,10 +107,11 @@ func (e *Executor) Execute(ctx context.Context, batchConfig *config.BatchConfig,
 	// Calculate success rate
 	successCount := 0
 	for _, result := range results {
-		// A test is considered successful only if there is no top-level error
-		// and no per-request errors recorded in stats (e.g. assertion failures).
+		// A test is considered successful only if there is no top-level error,
+		// no per-request errors recorded in stats (e.g. assertion failures)
+		// and it was not aborted by a circuit breaker.
 		if result.Error == nil {
-			if result.Stats == nil || len(result.Stats.GetErrors()) == 0 {
+			if result.Stats == nil || (len(result.Stats.GetErrors()) == 0 && result.Stats.AbortReason() == "") {
 				successCount++
 			}
 		}
@@ -136,12 +146,21 @@ func (e *Executor) executeTest(ctx context.Context, batchTest *config.BatchTest,
 	}
 	result.Config = cfg
 
-	// Parse curl command if provided and create http.Request
+	// Parse curl file or curl command and create http.Request
 	var req *http.Request
-	if cfg.CurlCommand != "" {
-		parsedReq, err := parser.ParseCurl(cfg.CurlCommand)
+	var requests []*http.Request
+	if cfg.CurlFile != "" {
+		requests, err = parser.ParseCurlFile(cfg.CurlFile)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to parse curl file: %v", err)
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+	} else if cfg.CurlCommand != "" || batchTest.Request != nil {
+		parsedReq, err := testRequest(ctx, batchTest, cfg.CurlCommand)
 		if err != nil {
-			result.Error = fmt.Errorf("failed to parse curl command: %v", err)
+			result.Error = err
 			result.EndTime = time.Now()
 			result.Duration = result.EndTime.Sub(result.StartTime)
 			return result
@@ -165,6 +184,8 @@ func (e *Executor) executeTest(ctx context.Context, batchTest *config.BatchTest,
 				return result
 			}
 			cfg.Body = string(body)
+			// The benchmark reads the body from the request again
+			req.Body = io.NopCloser(bytes.NewReader(body))
 		}
 	} else {
 		// TODO raw http request
@@ -189,7 +210,24 @@ func (e *Executor) executeTest(ctx context.Context, batchTest *config.BatchTest,
 	}
 
 	// Create and run benchmark
-	bench := benchmark.New(*cfg, req)
+	var bench *benchmark.Benchmark
+	if len(requests) > 0 {
+		bench = benchmark.NewWithMultipleRequests(*cfg, requests)
+	} else {
+		bench = benchmark.New(*cfg, req)
+	}
+	if e.observers != nil {
+		observers, err := e.observers(batchTest.Name)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to create observers: %v", err)
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+		for _, o := range observers {
+			bench.AddObserver(o)
+		}
+	}
 
 	// Run the benchmark
 	benchStats, err := bench.Run(ctx)
@@ -207,6 +245,30 @@ func (e *Executor) executeTest(ctx context.Context, batchTest *config.BatchTest,
 	return result
 }
 
+// testRequest returns the request of a test: a copy of the request set by
+// the caller, or the parsed curl command
+func testRequest(ctx context.Context, batchTest *config.BatchTest, curl string) (*http.Request, error) {
+	if batchTest.Request == nil {
+		req, err := parser.ParseCurl(curl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse curl command: %v", err)
+		}
+		return req, nil
+	}
+
+	// The body is consumed when it is copied into the config, so every run
+	// reads its own copy
+	req := batchTest.Request.Clone(ctx)
+	if batchTest.Request.GetBody != nil {
+		body, err := batchTest.Request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+		req.Body = body
+	}
+	return req, nil
+}
+
 // ExecuteSequential runs tests sequentially (for debugging or when concurrency is not desired)
 func (e *Executor) ExecuteSequential(ctx context.Context, batchConfig *config.BatchConfig, defaults *config.Config) (*BatchResult, error) {
 	if err := batchConfig.Validate(); err != nil {
@@ -238,10 +300,11 @@ func (e *Executor) ExecuteSequential(ctx context.Context, batchConfig *config.Ba
 	// Calculate success rate
 	successCount := 0
 	for _, result := range results {
-		// A test is considered successful only if there is no top-level error
-		// and no per-request errors recorded in stats (e.g. assertion failures).
+		// A test is considered successful only if there is no top-level error,
+		// no per-request errors recorded in stats (e.g. assertion failures)
+		// and it was not aborted by a circuit breaker.
 		if result.Error == nil {
-			if result.Stats == nil || len(result.Stats.GetErrors()) == 0 {
+			if result.Stats == nil || (len(result.Stats.GetErrors()) == 0 && result.Stats.AbortReason() == "") {
 				successCount++
 			}
 		}
@@ -1,10 +1,14 @@
 package batch
 
 import (
+	"encoding/csv"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/antlabs/gurl/internal/benchmark"
+	"github.com/antlabs/gurl/internal/stats"
 )
 
 // Reporter handles batch test result reporting
@@ -44,20 +48,28 @@ func (r *Reporter) GenerateReport(result *BatchResult) string {
 		report.WriteString(fmt.Sprintf("   Duration: %v\n", test.Duration))
 
 		var statsErrorCount int
+		var abortReason string
 		if test.Stats != nil {
 			statsErrorCount = len(test.Stats.GetErrors())
+			abortReason = test.Stats.AbortReason()
 		}
 
 		// A test is considered FAILED if there is a top-level error
-		// or any per-request errors (e.g. assertion failures).
-		if test.Error != nil || statsErrorCount > 0 {
-			report.WriteString("   Status: FAILED\n")
+		// or any per-request errors (e.g. assertion failures), and
+		// ABORTED if a circuit breaker stopped it early.
+		if test.Error != nil || statsErrorCount > 0 || abortReason != "" {
+			if test.Error == nil && abortReason != "" {
+				report.WriteString("   Status: ABORTED\n")
+				report.WriteString(fmt.Sprintf("   Aborted: %s\n", abortReason))
+			} else {
+				report.WriteString("   Status: FAILED\n")
+			}
 			if test.Error != nil {
 				report.WriteString(fmt.Sprintf("   Error: %v\n", test.Error))
 			}
 			if test.Stats != nil {
 				report.WriteString(fmt.Sprintf("   Requests: %d\n", test.Stats.TotalRequests))
-				rps := float64(test.Stats.TotalRequests) / test.Stats.Duration.Seconds()
+				rps := rate(test.Stats.TotalRequests, test.Stats.Duration)
 				report.WriteString(fmt.Sprintf("   RPS: %.2f\n", rps))
 				report.WriteString(fmt.Sprintf("   Avg Latency: %v\n", test.Stats.GetAverageLatency()))
 				if statsErrorCount > 0 {
@@ -74,13 +86,30 @@ func (r *Reporter) GenerateReport(result *BatchResult) string {
 			report.WriteString("   Status: SUCCESS\n")
 			if test.Stats != nil {
 				report.WriteString(fmt.Sprintf("   Requests: %d\n", test.Stats.TotalRequests))
-				rps := float64(test.Stats.TotalRequests) / test.Stats.Duration.Seconds()
+				rps := rate(test.Stats.TotalRequests, test.Stats.Duration)
 				report.WriteString(fmt.Sprintf("   RPS: %.2f\n", rps))
 				report.WriteString(fmt.Sprintf("   Avg Latency: %v\n", test.Stats.GetAverageLatency()))
 			}
 			successCount++
 		}
 
+		if test.Stats != nil {
+			report.WriteString(benchmark.FormatWarmup(test.Stats, "   "))
+			report.WriteString(benchmark.FormatAsserts(test.Stats, "   "))
+			if endpoints := sortedEndpoints(test.Stats); len(endpoints) > 0 {
+				report.WriteString("   Endpoints:\n")
+				for _, ep := range endpoints {
+					label := ep.URL
+					if ep.Name != "" {
+						label = ep.Name
+					}
+					p := ep.GetLatencyPercentiles()
+					report.WriteString(fmt.Sprintf("     - %s: %d requests (%.1f%%), p50=%v p90=%v p99=%v, errors=%d\n",
+						label, ep.Requests, ep.Share*100, p[50], p[90], p[99], ep.Errors))
+				}
+			}
+		}
+
 		if r.verbose && test.Config != nil {
 			report.WriteString(fmt.Sprintf("   Config: c=%d, t=%d, d=%v\n",
 				test.Config.Connections, test.Config.Threads, test.Config.Duration))
@@ -100,7 +129,7 @@ func (r *Reporter) GenerateReport(result *BatchResult) string {
 		for _, test := range result.Tests {
 			if test.Error == nil && test.Stats != nil {
 				totalRequests += test.Stats.TotalRequests
-				rps := float64(test.Stats.TotalRequests) / test.Stats.Duration.Seconds()
+				rps := rate(test.Stats.TotalRequests, test.Stats.Duration)
 				totalRPS += rps
 				latencies = append(latencies, test.Stats.GetAverageLatency())
 			}
@@ -185,10 +214,11 @@ func (r *Reporter) groupErrors(errs []error) map[string]errorGroup {
 
 // GenerateCSVReport generates a CSV format report
 func (r *Reporter) GenerateCSVReport(result *BatchResult) string {
-	var csv strings.Builder
+	var out strings.Builder
+	w := csv.NewWriter(&out)
 
 	// CSV Header
-	csv.WriteString("Name,Status,Duration,Requests,RPS,AvgLatency,Errors,Error\n")
+	_ = w.Write([]string{"Name", "Status", "Duration", "Requests", "RPS", "AvgLatency", "Errors", "Error"})
 
 	// CSV Data
 	for _, test := range result.Tests {
@@ -201,21 +231,51 @@ func (r *Reporter) GenerateCSVReport(result *BatchResult) string {
 
 		if test.Error != nil {
 			status = "FAILED"
-			errorMsg = strings.ReplaceAll(test.Error.Error(), ",", ";")
+			errorMsg = test.Error.Error()
 		} else if test.Stats != nil {
+			if reason := test.Stats.AbortReason(); reason != "" {
+				status = "ABORTED"
+				errorMsg = reason
+			}
 			requests = fmt.Sprintf("%d", test.Stats.TotalRequests)
-			rpsVal := float64(test.Stats.TotalRequests) / test.Stats.Duration.Seconds()
-			rps = fmt.Sprintf("%.2f", rpsVal)
+			rps = fmt.Sprintf("%.2f", rate(test.Stats.TotalRequests, test.Stats.Duration))
 			avgLatency = test.Stats.GetAverageLatency().String()
 			errorCount := len(test.Stats.GetErrors())
 			errors = fmt.Sprintf("%d", errorCount)
 		}
 
-		csv.WriteString(fmt.Sprintf("%s,%s,%v,%s,%s,%s,%s,%s\n",
-			test.Name, status, test.Duration, requests, rps, avgLatency, errors, errorMsg))
+		_ = w.Write([]string{test.Name, status, test.Duration.String(), requests, rps, avgLatency, errors, errorMsg})
+	}
+
+	// Per-endpoint table of the tests that ran a curl file
+	header := false
+	for _, test := range result.Tests {
+		if test.Error != nil {
+			continue
+		}
+		for _, ep := range sortedEndpoints(test.Stats) {
+			if !header {
+				w.Flush()
+				out.WriteString("\n")
+				_ = w.Write([]string{"Test", "Endpoint", "URL", "Requests", "Share", "RPS", "P50", "P90", "P99", "Errors", "StatusCodes", "ErrorsByClass"})
+				header = true
+			}
+			p := ep.GetLatencyPercentiles()
+			_ = w.Write([]string{
+				test.Name, ep.Name, ep.URL,
+				fmt.Sprintf("%d", ep.Requests),
+				fmt.Sprintf("%.4f", ep.Share),
+				fmt.Sprintf("%.2f", rate(ep.Requests, test.Stats.Duration)),
+				p[50].String(), p[90].String(), p[99].String(),
+				fmt.Sprintf("%d", ep.Errors),
+				joinCounts(ep.StatusCodes, ":", ";", false),
+				joinClasses(ep.ErrorClasses, ":", ";", false),
+			})
+		}
 	}
 
-	return csv.String()
+	w.Flush()
+	return out.String()
 }
 
 // GenerateJSONReport generates a JSON format report
@@ -233,7 +293,7 @@ func (r *Reporter) GenerateJSONReport(result *BatchResult) string {
 
 	for i, test := range result.Tests {
 		json.WriteString("    {\n")
-		json.WriteString(fmt.Sprintf("      \"name\": \"%s\",\n", test.Name))
+		json.WriteString(fmt.Sprintf("      \"name\": %q,\n", test.Name))
 		json.WriteString(fmt.Sprintf("      \"duration\": \"%v\",\n", test.Duration))
 
 		if test.Error != nil {
@@ -241,15 +301,41 @@ func (r *Reporter) GenerateJSONReport(result *BatchResult) string {
 			errorMsg := strings.ReplaceAll(test.Error.Error(), "\"", "\\\"")
 			json.WriteString(fmt.Sprintf("      \"error\": \"%s\"\n", errorMsg))
 		} else {
-			json.WriteString("      \"status\": \"SUCCESS\"")
+			if test.Stats != nil && test.Stats.AbortReason() != "" {
+				json.WriteString("      \"status\": \"ABORTED\",\n")
+				json.WriteString(fmt.Sprintf("      \"abort_reason\": %q", test.Stats.AbortReason()))
+			} else {
+				json.WriteString("      \"status\": \"SUCCESS\"")
+			}
 			if test.Stats != nil {
 				json.WriteString(",\n")
 				json.WriteString(fmt.Sprintf("      \"requests\": %d,\n", test.Stats.TotalRequests))
-				rpsVal := float64(test.Stats.TotalRequests) / test.Stats.Duration.Seconds()
+				rpsVal := rate(test.Stats.TotalRequests, test.Stats.Duration)
 				json.WriteString(fmt.Sprintf("      \"rps\": %.2f,\n", rpsVal))
 				json.WriteString(fmt.Sprintf("      \"avg_latency\": \"%v\",\n", test.Stats.GetAverageLatency()))
 				errorCount := len(test.Stats.GetErrors())
-				json.WriteString(fmt.Sprintf("      \"errors\": %d\n", errorCount))
+				json.WriteString(fmt.Sprintf("      \"errors\": %d", errorCount))
+				if w := test.Stats.Warmup; w != nil {
+					p := w.GetLatencyPercentiles()
+					json.WriteString(fmt.Sprintf(",\n      \"warmup\": {\"requests\": %d, \"errors\": %d, \"duration\": \"%v\", \"avg_latency\": \"%v\", \"p50\": \"%v\", \"p99\": \"%v\"}",
+						w.TotalRequests, w.TotalErrors, w.Duration, w.GetAverageLatency(), p[50], p[99]))
+				}
+				if endpoints := sortedEndpoints(test.Stats); len(endpoints) > 0 {
+					json.WriteString(",\n      \"endpoints\": [\n")
+					for j, ep := range endpoints {
+						p := ep.GetLatencyPercentiles()
+						json.WriteString(fmt.Sprintf("        {\"name\": %q, \"url\": %q, \"requests\": %d, \"share\": %.4f, \"errors\": %d, "+
+							"\"p50\": \"%v\", \"p90\": \"%v\", \"p99\": \"%v\", \"status_codes\": {%s}, \"errors_by_class\": {%s}}",
+							ep.Name, ep.URL, ep.Requests, ep.Share, ep.Errors, p[50], p[90], p[99],
+							joinCounts(ep.StatusCodes, ": ", ", ", true), joinClasses(ep.ErrorClasses, ": ", ", ", true)))
+						if j < len(endpoints)-1 {
+							json.WriteString(",")
+						}
+						json.WriteString("\n")
+					}
+					json.WriteString("      ]")
+				}
+				json.WriteString("\n")
 			} else {
 				json.WriteString("\n")
 			}
@@ -268,6 +354,72 @@ func (r *Reporter) GenerateJSONReport(result *BatchResult) string {
 	return json.String()
 }
 
+// rate returns n per second over d, 0 when d is zero
+func rate(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// sortedEndpoints returns the endpoint stats of a run ordered by name/URL
+func sortedEndpoints(results *stats.Results) []*stats.EndpointStats {
+	if results == nil {
+		return nil
+	}
+	byKey := results.GetEndpointStats()
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	endpoints := make([]*stats.EndpointStats, 0, len(keys))
+	for _, key := range keys {
+		endpoints = append(endpoints, byKey[key])
+	}
+	return endpoints
+}
+
+// joinCounts formats status code counts as "200:10;404:2", quoting the
+// codes when they are used as JSON keys
+func joinCounts(counts map[int]int64, kv, sep string, quote bool) string {
+	codes := make([]int, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		key := fmt.Sprintf("%d", code)
+		if quote {
+			key = fmt.Sprintf("%q", key)
+		}
+		parts = append(parts, fmt.Sprintf("%s%s%d", key, kv, counts[code]))
+	}
+	return strings.Join(parts, sep)
+}
+
+// joinClasses formats error class counts the same way as joinCounts
+func joinClasses(counts map[string]int64, kv, sep string, quote bool) string {
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	parts := make([]string, 0, len(classes))
+	for _, class := range classes {
+		key := class
+		if quote {
+			key = fmt.Sprintf("%q", class)
+		}
+		parts = append(parts, fmt.Sprintf("%s%s%d", key, kv, counts[class]))
+	}
+	return strings.Join(parts, sep)
+}
+
 // calculateAverageLatency calculates the average of a slice of durations
 func (r *Reporter) calculateAverageLatency(latencies []time.Duration) time.Duration {
 	if len(latencies) == 0 {
@@ -286,7 +438,7 @@ func (r *Reporter) calculateAverageLatency(latencies []time.Duration) time.Durat
 func (r *Reporter) PrintSummary(result *BatchResult) {
 	successCount := 0
 	for _, test := range result.Tests {
-		if test.Error == nil {
+		if test.Error == nil && (test.Stats == nil || test.Stats.AbortReason() == "") {
 			successCount++
 		}
 	}
@@ -300,6 +452,8 @@ func (r *Reporter) PrintSummary(result *BatchResult) {
 		for _, test := range result.Tests {
 			if test.Error != nil {
 				fmt.Printf("  - %s: %v\n", test.Name, test.Error)
+			} else if test.Stats != nil && test.Stats.AbortReason() != "" {
+				fmt.Printf("  - %s: aborted: %s\n", test.Name, test.Stats.AbortReason())
 			}
 		}
 	}
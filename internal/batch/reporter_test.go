@@ -0,0 +1,49 @@
+package batch
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func TestGenerateCSVReportQuotesFields(t *testing.T) {
+	results := stats.NewResults()
+	results.AddEndpointResult("list, paged", "http://localhost/a?x=1,2", time.Millisecond, 200, 10, 0, nil)
+	results.TotalRequests = 1
+
+	report := NewReporter(false).GenerateCSVReport(&BatchResult{
+		Tests: []TestResult{{Name: "users, v2", Stats: results}},
+	})
+
+	summary, endpoints, ok := strings.Cut(report, "\n\n")
+	if !ok {
+		t.Fatalf("want a summary and an endpoint table, got:\n%s", report)
+	}
+	for _, table := range []string{summary, endpoints} {
+		records, err := csv.NewReader(strings.NewReader(table)).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v\n%s", err, table)
+		}
+		for _, rec := range records[1:] {
+			if rec[0] != "users, v2" {
+				t.Errorf("first column = %q, want the test name", rec[0])
+			}
+		}
+	}
+
+	records, _ := csv.NewReader(strings.NewReader(endpoints)).ReadAll()
+	ep := records[1]
+	if ep[1] != "list, paged" || ep[2] != "http://localhost/a?x=1,2" {
+		t.Errorf("endpoint columns = %q, %q", ep[1], ep[2])
+	}
+	// The run has no duration, so the rates are 0 rather than +Inf
+	if ep[5] != "0.00" {
+		t.Errorf("endpoint RPS = %q, want 0.00", ep[5])
+	}
+	if strings.Contains(report, "Inf") || strings.Contains(report, "NaN") {
+		t.Errorf("report contains a non-finite rate:\n%s", report)
+	}
+}
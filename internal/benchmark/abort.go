@@ -0,0 +1,91 @@
+package benchmark
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// samplingInterval 是采样循环的间隔，熔断条件的时间窗口按采样次数换算
+const samplingInterval = time.Second
+
+// abortBreaker 在采样循环中评估熔断条件，任一条件触发时返回中止原因
+type abortBreaker struct {
+	errorRate   float64 // 错误率阈值（0-1），0 表示不检查
+	errorWindow time.Duration
+	window      []stats.Sample // 最近 errorWindow 内的采样
+
+	p99       time.Duration // p99 阈值，0 表示不检查
+	p99For    time.Duration
+	slowSince time.Duration // p99 连续超过阈值的时长
+
+	connectFailures int64 // 连续连接失败次数阈值，0 表示不检查
+}
+
+// newAbortBreaker 根据配置创建熔断器，没有启用任何条件时返回 nil
+func newAbortBreaker(cfg *config.AbortConfig) *abortBreaker {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &abortBreaker{
+		errorRate:       cfg.ErrorRate / 100,
+		errorWindow:     cfg.GetErrorWindow(),
+		p99:             cfg.GetP99(),
+		p99For:          cfg.GetP99For(),
+		connectFailures: int64(cfg.ConnectFailures),
+	}
+}
+
+// check 在每次采样之后调用，返回中止原因；没有触发时返回空字符串
+func (b *abortBreaker) check(results *stats.Results, sample stats.Sample) string {
+	if b == nil {
+		return ""
+	}
+
+	if b.connectFailures > 0 {
+		if streak := results.TakeConnectFailureStreak(); streak >= b.connectFailures {
+			return fmt.Sprintf("%d consecutive connection failures", streak)
+		}
+	}
+
+	if b.errorRate > 0 && sample.Requests > 0 {
+		// 没有请求完成的采样不进入窗口，目标卡住时之前的错误不会被挤出窗口
+		b.window = append(b.window, sample)
+		size := int((b.errorWindow + samplingInterval - 1) / samplingInterval)
+		if len(b.window) > size {
+			b.window = b.window[len(b.window)-size:]
+		}
+		// 窗口填满后才评估，避免刚开始的少量请求造成误判
+		if len(b.window) == size {
+			var requests, errors int64
+			for _, s := range b.window {
+				requests += s.Requests
+				errors += s.Errors
+			}
+			if requests > 0 && float64(errors)/float64(requests) > b.errorRate {
+				return fmt.Sprintf("error rate %.1f%% over the last %s exceeded %.1f%%",
+					float64(errors)/float64(requests)*100, b.errorWindow, b.errorRate*100)
+			}
+		}
+	}
+
+	if b.p99 > 0 {
+		// 一个请求都没有完成的采样（目标完全卡住）同样算作慢
+		if sample.Requests == 0 || sample.P99 > b.p99 {
+			b.slowSince += samplingInterval
+			if b.slowSince >= b.p99For {
+				last := sample.P99.String()
+				if sample.Requests == 0 {
+					last = "no responses"
+				}
+				return fmt.Sprintf("p99 latency above %s for %s (last %s)", b.p99, b.slowSince, last)
+			}
+		} else {
+			b.slowSince = 0
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,174 @@
+package benchmark
+
+import (
+	"context"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func TestNewAbortBreakerDisabled(t *testing.T) {
+	if b := newAbortBreaker(nil); b != nil {
+		t.Fatalf("nil config should disable the breaker")
+	}
+	if b := newAbortBreaker(&config.AbortConfig{ErrorWindow: "5s"}); b != nil {
+		t.Fatalf("a window without a threshold should disable the breaker")
+	}
+	// nil 熔断器的 check 永远不触发
+	var b *abortBreaker
+	if reason := b.check(stats.NewResults(), stats.Sample{}); reason != "" {
+		t.Fatalf("nil breaker aborted: %s", reason)
+	}
+}
+
+func TestAbortBreakerErrorRate(t *testing.T) {
+	b := newAbortBreaker(&config.AbortConfig{ErrorRate: 50, ErrorWindow: "3s"})
+	results := stats.NewResults()
+
+	// 窗口未填满之前不评估，即使错误率是 100%
+	for i := 0; i < 2; i++ {
+		if reason := b.check(results, stats.Sample{Requests: 10, Errors: 10}); reason != "" {
+			t.Fatalf("sample %d: aborted before the window filled: %s", i, reason)
+		}
+	}
+	// 窗口内 25/30 失败，超过 50%
+	reason := b.check(results, stats.Sample{Requests: 10, Errors: 5})
+	if !strings.Contains(reason, "error rate") {
+		t.Fatalf("expected error rate abort, got %q", reason)
+	}
+
+	// 旧的采样滑出窗口后错误率回落，不再触发
+	for i := 0; i < 3; i++ {
+		reason = b.check(results, stats.Sample{Requests: 10, Errors: 1})
+	}
+	if reason != "" {
+		t.Fatalf("healthy window aborted: %s", reason)
+	}
+}
+
+func TestAbortBreakerP99(t *testing.T) {
+	b := newAbortBreaker(&config.AbortConfig{P99: "100ms", P99For: "3s"})
+	results := stats.NewResults()
+	slow := stats.Sample{Requests: 10, P99: 200 * time.Millisecond}
+	fast := stats.Sample{Requests: 10, P99: 50 * time.Millisecond}
+
+	// 中间出现一次正常的采样会重新计时
+	for i, s := range []stats.Sample{slow, slow, fast, slow, slow} {
+		if reason := b.check(results, s); reason != "" {
+			t.Fatalf("sample %d: aborted too early: %s", i, reason)
+		}
+	}
+	reason := b.check(results, slow)
+	if !strings.Contains(reason, "p99 latency above 100ms for 3s") {
+		t.Fatalf("expected p99 abort, got %q", reason)
+	}
+}
+
+func TestAbortBreakerEmptySamples(t *testing.T) {
+	// 目标卡住时没有请求完成，p99 为 0 的采样同样算作慢
+	b := newAbortBreaker(&config.AbortConfig{P99: "100ms", P99For: "3s"})
+	results := stats.NewResults()
+	slow := stats.Sample{Requests: 10, P99: 200 * time.Millisecond}
+	for i, s := range []stats.Sample{slow, {}} {
+		if reason := b.check(results, s); reason != "" {
+			t.Fatalf("sample %d: aborted too early: %s", i, reason)
+		}
+	}
+	reason := b.check(results, stats.Sample{})
+	if reason != "p99 latency above 100ms for 3s (last no responses)" {
+		t.Fatalf("expected p99 abort on empty samples, got %q", reason)
+	}
+
+	// 空采样不会把之前的错误挤出错误率窗口
+	b = newAbortBreaker(&config.AbortConfig{ErrorRate: 50, ErrorWindow: "2s"})
+	b.check(results, stats.Sample{Requests: 10, Errors: 10})
+	for i := 0; i < 3; i++ {
+		if reason := b.check(results, stats.Sample{}); reason != "" {
+			t.Fatalf("empty sample %d aborted: %s", i, reason)
+		}
+	}
+	reason = b.check(results, stats.Sample{Requests: 10, Errors: 5})
+	if !strings.Contains(reason, "error rate") {
+		t.Fatalf("expected error rate abort, got %q", reason)
+	}
+}
+
+func TestAbortBreakerConnectFailures(t *testing.T) {
+	b := newAbortBreaker(&config.AbortConfig{ConnectFailures: 3})
+	results := stats.NewResults()
+
+	// 连接失败之间出现了响应，连续次数被打断
+	results.AddError(syscall.ECONNREFUSED)
+	results.AddError(syscall.ECONNREFUSED)
+	results.AddStatusCode(200)
+	results.AddError(syscall.ECONNREFUSED)
+	if reason := b.check(results, stats.Sample{}); reason != "" {
+		t.Fatalf("interrupted streak aborted: %s", reason)
+	}
+
+	results.AddError(syscall.ECONNREFUSED)
+	results.AddError(syscall.ECONNREFUSED)
+	reason := b.check(results, stats.Sample{})
+	if reason != "3 consecutive connection failures" {
+		t.Fatalf("expected connect abort, got %q", reason)
+	}
+}
+
+func TestAbortBreakerSkipsWarmup(t *testing.T) {
+	b := newAbortBreaker(&config.AbortConfig{ConnectFailures: 1})
+	results := stats.NewResults()
+	results.AddError(syscall.ECONNREFUSED)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requests, errors int64
+	phase := newRunPhase(time.Minute)
+	done := StartSampling(ctx, cancel, &requests, &errors, results, nil, nil, phase, b, nil)
+
+	// 预热期间的采样不参与熔断判断
+	time.Sleep(samplingInterval + 200*time.Millisecond)
+	if reason := results.AbortReason(); reason != "" {
+		t.Fatalf("breaker tripped during warm-up: %s", reason)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("run canceled during warm-up")
+	}
+	cancel()
+	<-done
+}
+
+func TestAbortBreakerResetsAfterWarmup(t *testing.T) {
+	b := newAbortBreaker(&config.AbortConfig{ConnectFailures: 2})
+	results := stats.NewResults()
+	// 预热期间目标不可用，之后恢复
+	results.AddError(syscall.ECONNREFUSED)
+	results.AddError(syscall.ECONNREFUSED)
+	results.AddError(syscall.ECONNREFUSED)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requests, errors int64
+	phase := newRunPhase(100 * time.Millisecond)
+	done := StartSampling(ctx, cancel, &requests, &errors, results, nil, nil, phase, b, nil)
+
+	// 测量阶段的第一次采样不应计入预热期间的连接失败
+	time.Sleep(200*time.Millisecond + samplingInterval)
+	if phase.isWarming() {
+		t.Fatalf("warm-up did not end")
+	}
+	if reason := results.AbortReason(); reason != "" {
+		t.Fatalf("warm-up connection failures tripped the breaker: %s", reason)
+	}
+
+	// 测量阶段新的连续失败照常触发
+	results.AddError(syscall.ECONNREFUSED)
+	results.AddError(syscall.ECONNREFUSED)
+	<-done
+	if reason := results.AbortReason(); reason != "2 consecutive connection failures" {
+		t.Fatalf("expected connect abort after warm-up, got %q", reason)
+	}
+}
@@ -0,0 +1,42 @@
+package benchmark
+
+import (
+	"sync/atomic"
+
+	"github.com/antlabs/gurl/internal/asserts"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// assertSampler 按固定比例挑选需要执行断言的响应。
+// 采用计数而不是随机数，保证 rate=0.01 时恰好每 100 个响应评估一次
+type assertSampler struct {
+	rate float64
+	n    uint64
+}
+
+func newAssertSampler(rate float64) *assertSampler {
+	return &assertSampler{rate: rate}
+}
+
+// sample 返回本次响应是否需要执行断言，rate 为 0 或 >=1 时全部执行
+func (s *assertSampler) sample() bool {
+	if s == nil || s.rate <= 0 || s.rate >= 1 {
+		return true
+	}
+	n := atomic.AddUint64(&s.n, 1)
+	return uint64(float64(n)*s.rate) != uint64(float64(n-1)*s.rate)
+}
+
+// evaluateAsserts 逐行执行断言并记录每条断言的通过/失败情况，
+// 返回第一条失败断言的错误，用于计入请求错误
+func evaluateAsserts(text string, resp *asserts.HTTPResponse, results *stats.Results) error {
+	var first error
+	results.AddAssertEvaluated()
+	for _, res := range asserts.EvaluateEach(text, resp) {
+		results.AddAssertResult(res.Assert, res.Err, resp.Status, resp.Body)
+		if res.Err != nil && first == nil {
+			first = res.Err
+		}
+	}
+	return first
+}
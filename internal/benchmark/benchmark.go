@@ -10,7 +10,9 @@ import (
 
 // Benchmark 统一的基准测试接口
 type Benchmark struct {
-	runner Runner
+	runner    Runner
+	observers []Observer
+	control   *Controller
 }
 
 // New 创建新的基准测试实例，根据URL自动选择实现
@@ -28,7 +30,8 @@ func New(cfg config.Config, req *http.Request) *Benchmark {
 	}
 
 	return &Benchmark{
-		runner: runner,
+		runner:  runner,
+		control: newController(cfg),
 	}
 }
 
@@ -52,12 +55,42 @@ func NewWithMultipleRequests(cfg config.Config, requests []*http.Request) *Bench
 	}
 
 	return &Benchmark{
-		runner: runner,
+		runner:  runner,
+		control: newController(cfg),
 	}
 }
 
+// AddObserver 注册一个在运行期间接收采样快照的 Observer，需在 Run 之前调用
+func (b *Benchmark) AddObserver(o Observer) {
+	if o != nil {
+		b.observers = append(b.observers, o)
+	}
+}
+
+// Controller 返回运行时控制器，用于暂停/恢复、调整速率和连接数
+func (b *Benchmark) Controller() *Controller {
+	return b.control
+}
+
 // Run 执行基准测试
-func (b *Benchmark) Run(ctx context.Context) (*stats.Results, error) {
+func (b *Benchmark) Run(ctx context.Context) (results *stats.Results, err error) {
+	if hr, ok := b.runner.(hookedRunner); ok {
+		hr.hooks().observers = b.observers
+		hr.hooks().control = b.control
+	}
+
+	// 运行出错（例如被取消）时同样通知 observer，sink 才能推送最后的数据并关闭，
+	// Prometheus 的 active 指标也不会一直停留在 1
+	defer func() {
+		finished := results
+		if finished == nil {
+			finished = stats.NewResults()
+		}
+		for _, o := range b.observers {
+			o.OnFinish(finished)
+		}
+	}()
+
 	return b.runner.Run(ctx)
 }
 
@@ -0,0 +1,108 @@
+package benchmark
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// connTracker 为 net/http 客户端建立的连接计数，运行开始后通过 attach 绑定到本次结果
+type connTracker struct {
+	dialer  net.Dialer
+	guard   config.DialGuard // 检查每个连接的目标地址，包括重定向建立的连接，nil 表示不检查
+	results atomic.Pointer[stats.Results]
+}
+
+// attach 绑定当前运行的统计结果，之后建立的连接都会记录到该结果中
+func (t *connTracker) attach(results *stats.Results) {
+	t.results.Store(results)
+}
+
+// DialContext 建立连接，并在连接建立/关闭时更新统计
+func (t *connTracker) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := t.guard.Dial(ctx, t.dialer, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	results := t.results.Load()
+	if results == nil {
+		return conn, nil
+	}
+	results.ConnOpened()
+	return &trackedConn{Conn: conn, results: results}, nil
+}
+
+// trackedConn 包装 net.Conn，统计线上读写的字节数，并在关闭时更新连接计数
+type trackedConn struct {
+	net.Conn
+	results   *stats.Results
+	closeOnce sync.Once
+	read      atomic.Int64
+	written   atomic.Int64
+}
+
+// Read 读取数据并累计读取字节数
+func (c *trackedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+// Write 写入数据并累计写入字节数
+func (c *trackedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+// takeBytes 返回上次调用以来读写的字节数。HTTP/1.1 连接上同一时间只有一个请求，
+// 请求完成时取出的字节数（包括新连接的 TLS 握手）都属于该请求
+func (c *trackedConn) takeBytes() (read, written int64) {
+	return c.read.Swap(0), c.written.Swap(0)
+}
+
+// Close 关闭连接，重复关闭只计数一次；未归属到请求的字节数计入总量
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() {
+		read, written := c.takeBytes()
+		c.results.AddBytes(read)
+		c.results.AddWriteBytes(written)
+		c.results.ConnClosed()
+	})
+	return c.Conn.Close()
+}
+
+// asTrackedConn 从 httptrace 拿到的连接中取出 trackedConn，HTTPS 连接需要先解开 TLS
+func asTrackedConn(conn net.Conn) *trackedConn {
+	for conn != nil {
+		switch c := conn.(type) {
+		case *trackedConn:
+			return c
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// newNetHTTPClient 创建压测使用的 HTTP 客户端，所有连接都经过 tracker 计数
+func newNetHTTPClient(cfg config.Config, tracker *connTracker) *http.Client {
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:         tracker.DialContext,
+			MaxIdleConns:        cfg.Connections,
+			MaxIdleConnsPerHost: cfg.Connections,
+			IdleConnTimeout:     30 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,281 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+	"go.uber.org/ratelimit"
+)
+
+// controlStep 是按键/步进调整速率和连接数时的比例（至少调整 1）
+const controlStep = 0.1
+
+// ControlState 是运行时可调整的负载参数的当前值
+type ControlState struct {
+	Paused      bool `json:"paused"`
+	Rate        int  `json:"rate"` // 每秒请求数，0 表示不限速
+	Connections int  `json:"connections"`
+}
+
+// limiterBox 包装限流器，便于通过 atomic.Pointer 原子替换
+type limiterBox struct {
+	ratelimit.Limiter
+}
+
+// Controller 在运行期间调整负载：暂停/恢复发送、修改速率和连接数。
+// LiveUI 的按键和控制接口都通过它操作，每次调整都作为注释记录在时间线上。
+type Controller struct {
+	mu          sync.Mutex
+	paused      bool
+	pausedFlag  atomic.Bool   // paused 的副本，请求路径上无锁读取
+	resumed     chan struct{} // 暂停期间打开，恢复时关闭
+	rate        int
+	connections int
+	observedRPS atomic.Int64 // 最近一秒的实际吞吐，从不限速切换到限速时作为起点
+
+	limiter atomic.Pointer[limiterBox] // nil 表示不限速
+	surplus atomic.Int64               // 等待退出的多余连接数
+
+	// 以下字段在运行开始时由引擎设置
+	results *stats.Results
+	phase   *runPhase
+	spawn   func() // 新建一个连接，不能阻塞
+}
+
+// newController 根据配置创建控制器，初始速率和连接数来自配置
+func newController(cfg config.Config) *Controller {
+	c := &Controller{
+		rate:        cfg.Rate,
+		connections: cfg.Connections,
+		resumed:     make(chan struct{}),
+	}
+	close(c.resumed)
+	c.setLimiter(cfg.Rate)
+	return c
+}
+
+// attach 在运行开始时由引擎调用，之后的调整才会记录注释，连接数才可以调整
+func (c *Controller) attach(results *stats.Results, phase *runPhase, spawn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = results
+	c.phase = phase
+	c.spawn = spawn
+}
+
+// State 返回当前的负载参数
+func (c *Controller) State() ControlState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ControlState{Paused: c.paused, Rate: c.rate, Connections: c.connections}
+}
+
+// Pause 暂停发送新请求，已发出的请求照常完成
+func (c *Controller) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		return
+	}
+	c.paused = true
+	c.resumed = make(chan struct{})
+	c.pausedFlag.Store(true)
+	c.annotate("paused")
+}
+
+// Resume 恢复发送请求
+func (c *Controller) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	c.pausedFlag.Store(false)
+	close(c.resumed)
+	c.annotate("resumed")
+}
+
+// TogglePause 在暂停和运行之间切换
+func (c *Controller) TogglePause() {
+	if c.State().Paused {
+		c.Resume()
+	} else {
+		c.Pause()
+	}
+}
+
+// SetRate 修改速率限制，0 表示不限速
+func (c *Controller) SetRate(rate int) error {
+	if rate < 0 {
+		return fmt.Errorf("rate cannot be negative")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rate == c.rate {
+		return nil
+	}
+	c.annotate(fmt.Sprintf("rate %s -> %s", formatRate(c.rate), formatRate(rate)))
+	c.rate = rate
+	c.setLimiter(rate)
+	return nil
+}
+
+// StepRate 按 controlStep 提高或降低速率。不限速时降低速率以最近一秒的实际吞吐为起点，
+// 提高速率没有意义，直接忽略
+func (c *Controller) StepRate(up bool) error {
+	rate := c.State().Rate
+	if rate == 0 {
+		if up {
+			return nil
+		}
+		rate = int(c.observedRPS.Load())
+		if rate == 0 {
+			return fmt.Errorf("no throughput observed yet")
+		}
+	}
+	return c.SetRate(stepValue(rate, up))
+}
+
+// SetConnections 修改连接数：增加时立即新建连接，减少时多余的连接在发送下一个请求前关闭
+func (c *Controller) SetConnections(n int) error {
+	if n < 1 {
+		return fmt.Errorf("connections must be at least 1")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.spawn == nil {
+		return fmt.Errorf("the run has not started yet")
+	}
+	if n == c.connections {
+		return nil
+	}
+	c.annotate(fmt.Sprintf("connections %d -> %d", c.connections, n))
+
+	delta := n - c.connections
+	c.connections = n
+	if delta < 0 {
+		c.surplus.Add(int64(-delta))
+		return nil
+	}
+
+	// 还没来得及退出的连接直接保留，不足的部分再新建
+	for delta > 0 {
+		surplus := c.surplus.Load()
+		if surplus == 0 {
+			break
+		}
+		keep := min(surplus, int64(delta))
+		if c.surplus.CompareAndSwap(surplus, surplus-keep) {
+			delta -= int(keep)
+		}
+	}
+	for i := 0; i < delta; i++ {
+		c.spawn()
+	}
+	return nil
+}
+
+// StepConnections 按 controlStep 增加或减少连接数
+func (c *Controller) StepConnections(up bool) error {
+	return c.SetConnections(stepValue(c.State().Connections, up))
+}
+
+// Annotate 在时间线上记录一条注释，例如外部脚本执行了一次部署
+func (c *Controller) Annotate(label string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.annotate(label)
+}
+
+// annotate 在持有锁时调用，运行开始前的调整不记录
+func (c *Controller) annotate(label string) {
+	if c.results == nil {
+		return
+	}
+	c.results.Annotate(time.Since(c.phase.measureStart()), label)
+}
+
+// setLimiter 替换限流器，正在等待旧限流器的请求不受影响
+func (c *Controller) setLimiter(rate int) {
+	if rate <= 0 {
+		c.limiter.Store(nil)
+		return
+	}
+	c.limiter.Store(&limiterBox{ratelimit.New(rate)})
+}
+
+// rateLimiter 返回当前的限流器，不限速时返回 nil
+func (c *Controller) rateLimiter() ratelimit.Limiter {
+	if l := c.limiter.Load(); l != nil {
+		return l.Limiter
+	}
+	return nil
+}
+
+// isPaused 无锁判断当前是否暂停
+func (c *Controller) isPaused() bool {
+	return c.pausedFlag.Load()
+}
+
+// wait 在暂停期间阻塞，ctx 结束时返回 false
+func (c *Controller) wait(ctx context.Context) bool {
+	if !c.isPaused() {
+		return true
+	}
+
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retire 在连接数被调低时由连接在发送下一个请求前调用，返回 true 表示该连接应当关闭
+func (c *Controller) retire() bool {
+	for {
+		surplus := c.surplus.Load()
+		if surplus <= 0 {
+			return false
+		}
+		if c.surplus.CompareAndSwap(surplus, surplus-1) {
+			return true
+		}
+	}
+}
+
+// observe 由采样循环每秒调用，记录最近一秒的实际吞吐
+func (c *Controller) observe(reqPerSec int64) {
+	if c != nil {
+		c.observedRPS.Store(reqPerSec)
+	}
+}
+
+// stepValue 按 controlStep 的比例调整 n，至少调整 1，结果不小于 1
+func stepValue(n int, up bool) int {
+	delta := max(int(float64(n)*controlStep), 1)
+	if up {
+		return n + delta
+	}
+	return max(n-delta, 1)
+}
+
+// formatRate 格式化速率，0 表示不限速
+func formatRate(rate int) string {
+	if rate == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d/s", rate)
+}
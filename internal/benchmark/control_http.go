@@ -0,0 +1,94 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// controlRequest 是控制接口的请求体，value 和 step 二选一
+type controlRequest struct {
+	Value *int   `json:"value,omitempty"`
+	Step  string `json:"step,omitempty"` // "up" 或 "down"
+	Label string `json:"label,omitempty"`
+}
+
+// ServeHTTP 提供本地控制接口，路径相对于挂载点：
+//
+//	GET  /             当前状态
+//	POST /pause        暂停
+//	POST /resume       恢复
+//	POST /rate         {"value": 500} 或 {"step": "up"|"down"}，0 表示不限速
+//	POST /connections  {"value": 20} 或 {"step": "up"|"down"}
+//	POST /annotate     {"label": "deploy v2"}
+//
+// 所有请求都返回调整后的状态
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := strings.Trim(r.URL.Path, "/")
+	if action == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		c.writeState(w)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req controlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch action {
+	case "pause":
+		c.Pause()
+	case "resume":
+		c.Resume()
+	case "rate":
+		err = req.apply(c.SetRate, c.StepRate)
+	case "connections":
+		err = req.apply(c.SetConnections, c.StepConnections)
+	case "annotate":
+		if strings.TrimSpace(req.Label) == "" {
+			err = fmt.Errorf("label is required")
+		} else {
+			c.Annotate(req.Label)
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.writeState(w)
+}
+
+// apply 按请求设置绝对值或者步进调整
+func (req controlRequest) apply(set func(int) error, step func(up bool) error) error {
+	switch {
+	case req.Value != nil:
+		return set(*req.Value)
+	case req.Step == "up" || req.Step == "down":
+		return step(req.Step == "up")
+	case req.Step != "":
+		return fmt.Errorf("invalid step '%s', expected up or down", req.Step)
+	default:
+		return fmt.Errorf("value or step is required")
+	}
+}
+
+func (c *Controller) writeState(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.State())
+}
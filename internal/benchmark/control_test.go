@@ -0,0 +1,245 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// newAttachedController 创建一个已经开始运行的控制器，spawn 只计数
+func newAttachedController(cfg config.Config, spawned *atomic.Int32) (*Controller, *stats.Results) {
+	c := newController(cfg)
+	results := stats.NewResults()
+	c.attach(results, newRunPhase(0), func() { spawned.Add(1) })
+	return c, results
+}
+
+func TestControllerPauseResume(t *testing.T) {
+	var spawned atomic.Int32
+	c, results := newAttachedController(config.Config{Connections: 1}, &spawned)
+
+	c.Pause()
+	c.Pause() // 重复暂停不重复记录
+	done := make(chan bool, 1)
+	go func() { done <- c.wait(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("wait returned while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c.Resume()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("wait should report true after resume")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait did not return after resume")
+	}
+
+	// 暂停期间 ctx 结束，wait 返回 false
+	c.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if c.wait(ctx) {
+		t.Fatal("wait should report false when ctx is done")
+	}
+
+	var labels []string
+	for _, a := range results.GetAnnotations() {
+		labels = append(labels, a.Label)
+	}
+	if got := strings.Join(labels, ","); got != "paused,resumed,paused" {
+		t.Fatalf("unexpected annotations: %s", got)
+	}
+}
+
+func TestControllerRate(t *testing.T) {
+	var spawned atomic.Int32
+	c, results := newAttachedController(config.Config{Connections: 1}, &spawned)
+
+	if c.rateLimiter() != nil {
+		t.Fatal("no rate configured, expected no limiter")
+	}
+	// 不限速时没有吞吐数据，无法按步进降低
+	if err := c.StepRate(false); err == nil {
+		t.Fatal("expected an error without observed throughput")
+	}
+	c.observe(1000)
+	if err := c.StepRate(false); err != nil {
+		t.Fatal(err)
+	}
+	if state := c.State(); state.Rate != 900 || c.rateLimiter() == nil {
+		t.Fatalf("expected rate 900 with a limiter, got %+v", state)
+	}
+	if err := c.SetRate(0); err != nil {
+		t.Fatal(err)
+	}
+	if c.rateLimiter() != nil {
+		t.Fatal("rate 0 should remove the limiter")
+	}
+	if err := c.SetRate(-1); err == nil {
+		t.Fatal("negative rate should be rejected")
+	}
+
+	annotations := results.GetAnnotations()
+	if len(annotations) != 2 || annotations[0].Label != "rate unlimited -> 900/s" || annotations[1].Label != "rate 900/s -> unlimited" {
+		t.Fatalf("unexpected annotations: %+v", annotations)
+	}
+}
+
+func TestControllerConnections(t *testing.T) {
+	// 运行开始前不能调整连接数
+	if err := newController(config.Config{Connections: 10}).SetConnections(12); err == nil {
+		t.Fatal("expected an error before the run starts")
+	}
+
+	var spawned atomic.Int32
+	c, _ := newAttachedController(config.Config{Connections: 10}, &spawned)
+
+	if err := c.SetConnections(7); err != nil {
+		t.Fatal(err)
+	}
+	// 一个连接已经退出，还剩两个多余的连接
+	if !c.retire() {
+		t.Fatal("expected a surplus connection to retire")
+	}
+
+	// 增加时先保留还没退出的连接，不足的部分再新建
+	if err := c.SetConnections(11); err != nil {
+		t.Fatal(err)
+	}
+	if c.retire() {
+		t.Fatal("surplus should have been reclaimed")
+	}
+	if spawned.Load() != 2 {
+		t.Fatalf("expected 2 new connections, got %d", spawned.Load())
+	}
+
+	if err := c.StepConnections(true); err != nil || c.State().Connections != 12 {
+		t.Fatalf("expected 12 connections, got %d (%v)", c.State().Connections, err)
+	}
+	if err := c.SetConnections(0); err == nil {
+		t.Fatal("expected an error for zero connections")
+	}
+}
+
+func TestStepValue(t *testing.T) {
+	for _, tc := range []struct {
+		n    int
+		up   bool
+		want int
+	}{
+		{100, true, 110},
+		{100, false, 90},
+		{5, true, 6},
+		{1, false, 1},
+	} {
+		if got := stepValue(tc.n, tc.up); got != tc.want {
+			t.Errorf("stepValue(%d, %v) = %d, want %d", tc.n, tc.up, got, tc.want)
+		}
+	}
+}
+
+func TestControllerServeHTTP(t *testing.T) {
+	var spawned atomic.Int32
+	c, results := newAttachedController(config.Config{Connections: 2, Rate: 100}, &spawned)
+	srv := httptest.NewServer(http.StripPrefix("/control", c))
+	defer srv.Close()
+
+	post := func(path, body string) (*http.Response, ControlState) {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/control"+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var state ControlState
+		json.NewDecoder(resp.Body).Decode(&state)
+		return resp, state
+	}
+
+	if _, state := post("/pause", ""); !state.Paused {
+		t.Fatalf("expected paused state, got %+v", state)
+	}
+	if _, state := post("/rate", `{"value":250}`); state.Rate != 250 {
+		t.Fatalf("expected rate 250, got %+v", state)
+	}
+	if _, state := post("/connections", `{"step":"up"}`); state.Connections != 3 || spawned.Load() != 1 {
+		t.Fatalf("expected 3 connections, got %+v", state)
+	}
+	if resp, _ := post("/rate", `{"step":"sideways"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid step, got %d", resp.StatusCode)
+	}
+	if resp, _ := post("/annotate", `{"label":"deploy v2"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("annotate failed with %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(srv.URL + "/control/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET state failed with %d", resp.StatusCode)
+	}
+
+	annotations := results.GetAnnotations()
+	if len(annotations) != 4 || annotations[3].Label != "deploy v2" {
+		t.Fatalf("unexpected annotations: %+v", annotations)
+	}
+}
+
+func TestPulsePauseResume(t *testing.T) {
+	var served atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	b := New(config.Config{Connections: 4, Threads: 1, Duration: 2 * time.Second, Timeout: time.Second}, req)
+	control := b.Controller()
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.Run(context.Background())
+		done <- err
+	}()
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			if cond() {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s", what)
+	}
+	waitFor("the first requests", func() bool { return served.Load() > 0 })
+
+	// 暂停后每个连接最多再完成一个已经发出的请求
+	control.Pause()
+	time.Sleep(100 * time.Millisecond)
+	paused := served.Load()
+	time.Sleep(200 * time.Millisecond)
+	if got := served.Load(); got != paused {
+		t.Fatalf("requests kept going while paused: %d -> %d", paused, got)
+	}
+
+	// 所有挂起的连接在恢复后继续发送
+	control.Resume()
+	waitFor("requests after resume", func() bool { return served.Load() > paused+100 })
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,48 @@
+package benchmark
+
+import (
+	"net/http"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/cookies"
+)
+
+// cookieJars 按配置为连接分配 cookie jar：connection 模式下每个连接（虚拟用户）
+// 使用独立的 jar，shared 模式下所有连接共用一个 jar
+type cookieJars struct {
+	mode   string
+	file   *cookies.File
+	shared http.CookieJar
+}
+
+// newCookieJars 读取 cookie 文件并创建 jar 分配器；未开启 cookie jar 时返回 nil
+func newCookieJars(cfg config.Config) (*cookieJars, error) {
+	mode := cfg.GetCookieJar()
+	if mode == config.CookieJarOff {
+		return nil, nil
+	}
+
+	j := &cookieJars{mode: mode}
+	if cfg.CookieFile != "" {
+		f, err := cookies.Load(cfg.CookieFile)
+		if err != nil {
+			return nil, err
+		}
+		j.file = f
+	}
+	if mode == config.CookieJarShared {
+		j.shared = cookies.NewJar(j.file)
+	}
+	return j, nil
+}
+
+// forConnection 返回一个连接使用的 jar，j 为 nil 时返回 nil
+func (j *cookieJars) forConnection() http.CookieJar {
+	if j == nil {
+		return nil
+	}
+	if j.shared != nil {
+		return j.shared
+	}
+	return cookies.NewJar(j.file)
+}
@@ -0,0 +1,316 @@
+package benchmark
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// maxResponseLineSize 限制状态行、头部行和 chunk 大小行的长度
+const maxResponseLineSize = 64 << 10
+
+var errResponseLineTooLong = errors.New("HTTP parse error: response line too long")
+
+// responseParser 的解析状态
+const (
+	respStatusLine = iota
+	respHeaders
+	respBody           // 按 Content-Length 读取响应体
+	respChunkSize      // chunk 大小行
+	respChunkData      // chunk 数据
+	respChunkDataEnd   // chunk 数据之后的 CRLF
+	respTrailers       // 最后一个 chunk 之后的 trailer
+	respBodyUntilClose // 没有长度信息，响应体持续到连接关闭
+	respDone
+)
+
+// responseParser 增量解析 HTTP/1.x 响应，按 RFC 9112 确定消息长度：
+// 1xx 中间响应会被跳过，HEAD 请求以及 204/304 响应没有响应体，
+// 其余响应依次按 chunked、Content-Length、连接关闭确定响应体边界
+type responseParser struct {
+	state int
+	line  []byte // 尚未读到换行的半行数据
+
+	headRequest    bool  // 当前请求是否为 HEAD
+	capture        bool  // 是否为断言收集头部和响应体
+	maxBodySize    int64 // 收集响应体的上限
+	collectCookies bool  // 是否收集 Set-Cookie（开启 cookie jar 时）
+
+	statusCode    int
+	contentLength int64 // -1 表示没有 Content-Length
+	chunked       bool
+	connClose     bool  // 响应带有 Connection: close
+	remaining     int64 // 当前响应体或 chunk 剩余字节数
+	bodySize      int64 // 解码后的响应体字节数
+	headers       http.Header
+	body          []byte
+	setCookies    []string
+}
+
+// reset 为下一个请求的响应做准备
+func (p *responseParser) reset(headRequest, capture bool) {
+	p.state = respStatusLine
+	p.line = p.line[:0]
+	p.headRequest = headRequest
+	p.capture = capture
+	p.bodySize = 0
+	p.body = p.body[:0]
+	p.resetHeaders()
+}
+
+// resetHeaders 清空单个响应（包括 1xx 中间响应）的头部状态
+func (p *responseParser) resetHeaders() {
+	p.statusCode = 0
+	p.contentLength = -1
+	p.chunked = false
+	p.connClose = false
+	p.remaining = 0
+	p.setCookies = p.setCookies[:0]
+	if p.capture {
+		if p.headers == nil {
+			p.headers = make(http.Header)
+		}
+		for k := range p.headers {
+			delete(p.headers, k)
+		}
+	}
+}
+
+// feed 解析 data，返回消费的字节数以及最终响应是否已经完整。
+// 响应完成时 data 中可能还剩余未消费的数据，由调用方 reset 后继续传入
+func (p *responseParser) feed(data []byte) (int, bool, error) {
+	n := 0
+	for n < len(data) && p.state != respDone {
+		switch p.state {
+		case respBody, respChunkData:
+			take := int64(len(data) - n)
+			if take > p.remaining {
+				take = p.remaining
+			}
+			p.addBody(data[n : n+int(take)])
+			n += int(take)
+			p.remaining -= take
+			if p.remaining == 0 {
+				if p.state == respBody {
+					p.state = respDone
+				} else {
+					p.state = respChunkDataEnd
+				}
+			}
+
+		case respBodyUntilClose:
+			p.addBody(data[n:])
+			n = len(data)
+
+		default:
+			line, used, ok, err := p.readLine(data[n:])
+			n += used
+			if err != nil {
+				return n, false, err
+			}
+			if !ok {
+				return n, false, nil
+			}
+			if err := p.handleLine(line); err != nil {
+				return n, false, err
+			}
+		}
+	}
+	return n, p.state == respDone, nil
+}
+
+// closed 在连接关闭时调用，返回以连接关闭作为结束的响应是否完整
+func (p *responseParser) closed() bool {
+	if p.state == respBodyUntilClose {
+		p.state = respDone
+		return true
+	}
+	return false
+}
+
+// inProgress 返回是否已经收到当前响应的部分数据
+func (p *responseParser) inProgress() bool {
+	return p.state != respStatusLine || len(p.line) > 0
+}
+
+// readLine 从 data 中读取一行（不含 CRLF），跨越多次 OnData 的半行会被缓存
+func (p *responseParser) readLine(data []byte) (line []byte, used int, ok bool, err error) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		if len(p.line)+len(data) > maxResponseLineSize {
+			return nil, len(data), false, errResponseLineTooLong
+		}
+		p.line = append(p.line, data...)
+		return nil, len(data), false, nil
+	}
+	if len(p.line)+i > maxResponseLineSize {
+		return nil, i + 1, false, errResponseLineTooLong
+	}
+
+	line = data[:i]
+	if len(p.line) > 0 {
+		p.line = append(p.line, line...)
+		line = p.line
+	}
+	p.line = p.line[:0]
+	return bytes.TrimSuffix(line, []byte{'\r'}), i + 1, true, nil
+}
+
+func (p *responseParser) handleLine(line []byte) error {
+	switch p.state {
+	case respStatusLine:
+		// 容忍响应之间多余的空行
+		if len(line) == 0 {
+			return nil
+		}
+		return p.parseStatusLine(line)
+
+	case respHeaders:
+		if len(line) == 0 {
+			p.headersDone()
+			return nil
+		}
+		return p.parseHeader(line)
+
+	case respChunkSize:
+		if i := bytes.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		size, err := strconv.ParseInt(string(bytes.TrimSpace(line)), 16, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("HTTP parse error: invalid chunk size %q", line)
+		}
+		if size == 0 {
+			p.state = respTrailers
+			return nil
+		}
+		p.remaining = size
+		p.state = respChunkData
+		return nil
+
+	case respChunkDataEnd:
+		if len(line) != 0 {
+			return fmt.Errorf("HTTP parse error: missing CRLF after chunk data")
+		}
+		p.state = respChunkSize
+		return nil
+
+	case respTrailers:
+		if len(line) == 0 {
+			p.state = respDone
+		}
+		return nil
+	}
+	return nil
+}
+
+func (p *responseParser) parseStatusLine(line []byte) error {
+	// HTTP/1.1 200 OK
+	if !bytes.HasPrefix(line, []byte("HTTP/")) {
+		return fmt.Errorf("HTTP parse error: invalid status line %q", line)
+	}
+	i := bytes.IndexByte(line, ' ')
+	if i < 0 || len(line) < i+4 {
+		return fmt.Errorf("HTTP parse error: invalid status line %q", line)
+	}
+	code, err := strconv.Atoi(string(line[i+1 : i+4]))
+	if err != nil || code < 100 || code > 999 {
+		return fmt.Errorf("HTTP parse error: invalid status code in %q", line)
+	}
+	p.statusCode = code
+	p.state = respHeaders
+	return nil
+}
+
+func (p *responseParser) parseHeader(line []byte) error {
+	i := bytes.IndexByte(line, ':')
+	if i <= 0 {
+		return fmt.Errorf("HTTP parse error: invalid header line %q", line)
+	}
+	name := line[:i]
+	value := bytes.TrimSpace(line[i+1:])
+
+	switch {
+	case asciiEqualFold(name, "Content-Length"):
+		n, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("HTTP parse error: invalid Content-Length %q", value)
+		}
+		p.contentLength = n
+	case asciiEqualFold(name, "Transfer-Encoding"):
+		// chunked 必须是最后一个编码
+		codings := bytes.Split(value, []byte{','})
+		p.chunked = asciiEqualFold(bytes.TrimSpace(codings[len(codings)-1]), "chunked")
+	case asciiEqualFold(name, "Connection"):
+		for _, opt := range bytes.Split(value, []byte{','}) {
+			if asciiEqualFold(bytes.TrimSpace(opt), "close") {
+				p.connClose = true
+			}
+		}
+	}
+
+	if p.collectCookies && asciiEqualFold(name, "Set-Cookie") {
+		p.setCookies = append(p.setCookies, string(value))
+	}
+	if p.capture {
+		p.headers.Add(string(name), string(value))
+	}
+	return nil
+}
+
+// headersDone 根据状态码、请求方法和头部决定响应体的边界
+func (p *responseParser) headersDone() {
+	switch {
+	case p.statusCode >= 100 && p.statusCode < 200 && p.statusCode != http.StatusSwitchingProtocols:
+		// 100 Continue 等中间响应，继续等待最终响应
+		p.state = respStatusLine
+		p.resetHeaders()
+	case p.headRequest, p.statusCode == http.StatusNoContent, p.statusCode == http.StatusNotModified,
+		p.statusCode == http.StatusSwitchingProtocols:
+		p.state = respDone
+	case p.chunked:
+		p.state = respChunkSize
+	case p.contentLength == 0:
+		p.state = respDone
+	case p.contentLength > 0:
+		p.remaining = p.contentLength
+		p.state = respBody
+	default:
+		p.state = respBodyUntilClose
+	}
+}
+
+func (p *responseParser) addBody(buf []byte) {
+	p.bodySize += int64(len(buf))
+	if !p.capture {
+		return
+	}
+	if room := p.maxBodySize - int64(len(p.body)); room > 0 {
+		if int64(len(buf)) > room {
+			buf = buf[:room]
+		}
+		p.body = append(p.body, buf...)
+	}
+}
+
+// asciiEqualFold 不分配内存地比较头部名称
+func asciiEqualFold(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	for i := 0; i < len(b); i++ {
+		c1, c2 := b[i], s[i]
+		if 'A' <= c1 && c1 <= 'Z' {
+			c1 += 'a' - 'A'
+		}
+		if 'A' <= c2 && c2 <= 'Z' {
+			c2 += 'a' - 'A'
+		}
+		if c1 != c2 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+// feedAll 把响应按 step 字节分段喂给解析器，模拟多次 OnData 回调
+func feedAll(t *testing.T, p *responseParser, raw string, step int) (done bool, rest string) {
+	t.Helper()
+	data := []byte(raw)
+	for len(data) > 0 {
+		chunk := data
+		if step > 0 && len(chunk) > step {
+			chunk = chunk[:step]
+		}
+		n, ok, err := p.feed(chunk)
+		if err != nil {
+			t.Fatalf("feed: %v", err)
+		}
+		data = data[n:]
+		if ok {
+			return true, string(data)
+		}
+	}
+	return false, ""
+}
+
+func TestResponseParserFraming(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     bool
+		raw      string
+		status   int
+		body     string
+		bodySize int64
+	}{
+		{
+			name:   "content-length",
+			raw:    "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhello",
+			status: 200, body: "hello", bodySize: 5,
+		},
+		{
+			name:   "chunked with extension and trailer",
+			raw:    "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5;ext=1\r\nhello\r\n6\r\n world\r\n0\r\nX-Trailer: v\r\n\r\n",
+			status: 200, body: "hello world", bodySize: 11,
+		},
+		{
+			name:   "head response with content-length has no body",
+			head:   true,
+			raw:    "HTTP/1.1 200 OK\r\nContent-Length: 1234\r\n\r\n",
+			status: 200,
+		},
+		{
+			name:   "204 without length",
+			raw:    "HTTP/1.1 204 No Content\r\n\r\n",
+			status: 204,
+		},
+		{
+			name:   "304 with content-length has no body",
+			raw:    "HTTP/1.1 304 Not Modified\r\nContent-Length: 10\r\n\r\n",
+			status: 304,
+		},
+		{
+			name:   "100-continue before final response",
+			raw:    "HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 201 Created\r\nContent-Length: 2\r\n\r\nok",
+			status: 201, body: "ok", bodySize: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, step := range []int{0, 1, 7} {
+			p := &responseParser{maxBodySize: 1 << 20}
+			p.reset(tt.head, true)
+			done, rest := feedAll(t, p, tt.raw, step)
+			if !done {
+				t.Fatalf("%s (step %d): response not complete", tt.name, step)
+			}
+			if rest != "" {
+				t.Errorf("%s (step %d): unexpected leftover %q", tt.name, step, rest)
+			}
+			if p.statusCode != tt.status || string(p.body) != tt.body || p.bodySize != tt.bodySize {
+				t.Errorf("%s (step %d): got status=%d body=%q size=%d", tt.name, step, p.statusCode, p.body, p.bodySize)
+			}
+		}
+	}
+}
+
+func TestResponseParserPipelined(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\nContent-Length: 1\r\n\r\naHTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"
+	p := &responseParser{}
+	p.reset(false, false)
+
+	done, rest := feedAll(t, p, raw, 0)
+	if !done || p.statusCode != 200 {
+		t.Fatalf("first response: done=%v status=%d", done, p.statusCode)
+	}
+	p.reset(false, false)
+	done, rest = feedAll(t, p, rest, 0)
+	if !done || p.statusCode != 404 || rest != "" {
+		t.Fatalf("second response: done=%v status=%d rest=%q", done, p.statusCode, rest)
+	}
+}
+
+func TestResponseParserUntilClose(t *testing.T) {
+	p := &responseParser{maxBodySize: 4}
+	p.reset(false, true)
+
+	done, _ := feedAll(t, p, "HTTP/1.0 200 OK\r\nConnection: close\r\n\r\nbody until eof", 0)
+	if done {
+		t.Fatal("response without length must not complete before the connection closes")
+	}
+	if !p.closed() {
+		t.Fatal("expected the response to complete on close")
+	}
+	if !p.connClose || p.bodySize != 14 || string(p.body) != "body" {
+		t.Errorf("got connClose=%v size=%d body=%q", p.connClose, p.bodySize, p.body)
+	}
+	if got := p.headers.Get("connection"); got != "close" {
+		t.Errorf("expected captured Connection header, got %q", got)
+	}
+}
+
+func TestResponseParserErrors(t *testing.T) {
+	for _, raw := range []string{
+		"garbage\r\n",
+		"HTTP/1.1 200 OK\r\nContent-Length: abc\r\n\r\n",
+		"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n",
+		"HTTP/1.1 200 OK\r\nX: " + strings.Repeat("a", maxResponseLineSize) + "\r\n",
+	} {
+		p := &responseParser{}
+		p.reset(false, false)
+		if _, _, err := p.feed([]byte(raw)); err == nil {
+			t.Errorf("expected parse error for %.40q", raw)
+		}
+	}
+}
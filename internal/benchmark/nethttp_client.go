@@ -1,18 +1,20 @@
 package benchmark
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net/http"
-	"strings"
+	"net/http/httptrace"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/antlabs/gurl/internal/asserts"
+	"github.com/antlabs/gurl/internal/auth"
 	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/parser"
 	"github.com/antlabs/gurl/internal/stats"
-	"go.uber.org/ratelimit"
 )
 
 // Runner 定义基准测试运行器接口
@@ -24,53 +26,55 @@ type Runner interface {
 type NetHTTPBenchmark struct {
 	config      config.Config
 	request     *http.Request
-	bodyContent string       // 存储请求体内容，用于每次创建新的 Body
-	requestPool *RequestPool // 多请求池
+	bodies      map[*http.Request][]byte // 存储每个请求的请求体内容，用于每次创建新的 Body
+	requestPool *RequestPool             // 多请求池
 	client      *http.Client
-	rateLimiter ratelimit.Limiter // Uber 限流器
+	tracker     *connTracker   // 连接计数
+	conns       *connGroup     // 运行中的连接 goroutine
+	sampler     *assertSampler // 断言采样
+	cookieJars  *cookieJars    // cookie jar 分配，未开启时为 nil
+	auth        auth.Provider  // 为每个请求添加认证信息，未配置时为 nil
+	phase       *runPhase      // 预热/测量阶段
+	runHooks
 }
 
 // NewNetHTTPBenchmark creates a new net/http benchmark instance
 func NewNetHTTPBenchmark(cfg config.Config, req *http.Request) *NetHTTPBenchmark {
 	// 创建HTTP客户端
-	client := &http.Client{
-		Timeout: cfg.Timeout,
-		Transport: &http.Transport{
-			MaxIdleConns:        cfg.Connections,
-			MaxIdleConnsPerHost: cfg.Connections,
-			IdleConnTimeout:     30 * time.Second,
-		},
-	}
-
-	// 创建 Uber 限流器（所有连接共享）
-	var limiter ratelimit.Limiter
-	if cfg.Rate > 0 {
-		limiter = ratelimit.New(cfg.Rate) // 每秒请求数
-	}
+	tracker := &connTracker{guard: cfg.DialGuard}
+	client := newNetHTTPClient(cfg, tracker)
 
 	// 读取并保存 body 内容
-	bodyContent := ""
+	bodies := make(map[*http.Request][]byte, 1)
 	if req.Body != nil {
 		bodyBytes, _ := io.ReadAll(req.Body)
 		req.Body.Close()
-		bodyContent = string(bodyBytes)
+		if len(bodyBytes) > 0 {
+			bodies[req] = bodyBytes
+		}
 		// 重新设置 Body 以便第一次使用
-		req.Body = io.NopCloser(strings.NewReader(bodyContent))
+		req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 	}
 
 	return &NetHTTPBenchmark{
 		config:      cfg,
 		request:     req,
-		bodyContent: bodyContent,
+		bodies:      bodies,
 		requestPool: nil, // 单请求模式
 		client:      client,
-		rateLimiter: limiter,
+		tracker:     tracker,
+		sampler:     newAssertSampler(cfg.AssertSampleRate),
 	}
 }
 
 // acquireRequestSlot 尝试为一次请求占用一个请求名额。
 // 返回值为 false 表示已经达到上限，调用方应当退出当前连接循环。
 func (b *NetHTTPBenchmark) acquireRequestSlot(cancel context.CancelFunc, requestCount *int64) bool {
+	// 预热阶段的请求不占用名额
+	if b.phase.isWarming() {
+		atomic.AddInt64(requestCount, 1)
+		return true
+	}
 	for {
 		cur := atomic.LoadInt64(requestCount)
 		if cur >= b.config.Requests {
@@ -94,42 +98,59 @@ func (b *NetHTTPBenchmark) acquireRequestSlot(cancel context.CancelFunc, request
 // NewNetHTTPBenchmarkWithMultipleRequests creates a new net/http benchmark with multiple requests
 func NewNetHTTPBenchmarkWithMultipleRequests(cfg config.Config, requests []*http.Request) *NetHTTPBenchmark {
 	// 创建HTTP客户端
-	client := &http.Client{
-		Timeout: cfg.Timeout,
-		Transport: &http.Transport{
-			MaxIdleConns:        cfg.Connections,
-			MaxIdleConnsPerHost: cfg.Connections,
-			IdleConnTimeout:     30 * time.Second,
-		},
-	}
-
-	// 创建 Uber 限流器（所有连接共享）
-	var limiter ratelimit.Limiter
-	if cfg.Rate > 0 {
-		limiter = ratelimit.New(cfg.Rate) // 每秒请求数
-	}
+	tracker := &connTracker{guard: cfg.DialGuard}
+	client := newNetHTTPClient(cfg, tracker)
 
 	// 创建请求池
 	requestPool := NewRequestPool(requests, cfg.LoadStrategy)
 
+	// 读取并保存每个请求的 body，克隆出的请求各自使用新的 Body
+	bodies := make(map[*http.Request][]byte, len(requests))
+	for _, req := range requests {
+		if req.Body != nil && req.Body != http.NoBody {
+			bodyBytes, _ := io.ReadAll(req.Body)
+			req.Body.Close()
+			if len(bodyBytes) > 0 {
+				bodies[req] = bodyBytes
+			}
+			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+		}
+	}
+
 	return &NetHTTPBenchmark{
 		config:      cfg,
 		request:     nil, // 多请求模式不使用单个请求
+		bodies:      bodies,
 		requestPool: requestPool,
 		client:      client,
-		rateLimiter: limiter,
+		tracker:     tracker,
+		sampler:     newAssertSampler(cfg.AssertSampleRate),
 	}
 }
 
 // Run executes the net/http benchmark
 func (b *NetHTTPBenchmark) Run(ctx context.Context) (*stats.Results, error) {
+	cookieJars, err := newCookieJars(b.config)
+	if err != nil {
+		return nil, err
+	}
+	b.cookieJars = cookieJars
+
+	provider, err := auth.New(b.config.Auth)
+	if err != nil {
+		return nil, err
+	}
+	b.auth = provider
+
 	results := stats.NewResults()
+	b.tracker.attach(results)
+	// 结束时关闭空闲连接，使连接计数归零
+	defer b.client.CloseIdleConnections()
 
-	// 创建上下文，在指定时间后取消；如果配置了最大请求数，也会在达到后取消
-	testCtx, cancel := context.WithTimeout(ctx, b.config.Duration)
+	// 创建上下文，在预热加测试时间后取消；如果配置了最大请求数，也会在达到后取消
+	testCtx, cancel := context.WithTimeout(ctx, b.config.Warmup+b.config.Duration)
 	defer cancel()
 
-	var wg sync.WaitGroup
 	var requestCount int64
 	var errorCount int64
 
@@ -137,7 +158,7 @@ func (b *NetHTTPBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 	var liveUI *LiveUI
 	var uiErr error
 	if b.config.LiveUI {
-		liveUI, uiErr = NewLiveUIWithTheme(b.config.Duration, b.config.UITheme)
+		liveUI, uiErr = NewLiveUIWithTheme(b.config.Warmup+b.config.Duration, b.config.UITheme)
 		if uiErr != nil {
 			// 如果 UI 初始化失败，继续运行但不显示 UI
 			b.config.LiveUI = false
@@ -146,23 +167,31 @@ func (b *NetHTTPBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 		}
 	}
 
-	// 记录开始时间
-	startTime := time.Now()
+	// 记录开始时间，预热结束后测量阶段重新计时
+	b.phase = newRunPhase(b.config.Warmup)
+
+	// 运行时控制：暂停、调整速率和连接数，新增的连接与初始连接一样运行
+	b.conns = &connGroup{}
+	control := b.controller(b.config)
+	control.attach(results, b.phase, func() {
+		b.conns.start(func() {
+			b.runConnection(testCtx, cancel, &requestCount, &errorCount, results)
+		})
+	})
+	if liveUI != nil {
+		liveUI.SetController(control)
+	}
 
 	// 启动采样 goroutine，每秒记录请求数
-	samplingDone := StartSampling(testCtx, cancel, &requestCount, &errorCount, results, liveUI, b.requestPool, startTime)
+	samplingDone := StartSampling(testCtx, cancel, &requestCount, &errorCount, results, liveUI, b.requestPool, b.phase, newAbortBreaker(b.config.Abort), &b.runHooks)
 
-	// 启动工作线程
+	// 按工作线程分配并启动连接
 	for i := 0; i < b.config.Threads; i++ {
-		wg.Add(1)
-		go func(threadID int) {
-			defer wg.Done()
-			b.runWorker(testCtx, cancel, threadID, &requestCount, &errorCount, results)
-		}(i)
+		b.runWorker(testCtx, cancel, i, &requestCount, &errorCount, results)
 	}
 
-	// 等待所有工作线程完成
-	wg.Wait()
+	// 等待所有连接退出
+	b.conns.wait()
 
 	if b.config.Requests <= 0 {
 		// 等待采样完成
@@ -173,33 +202,36 @@ func (b *NetHTTPBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 	results.TotalRequests = atomic.LoadInt64(&requestCount)
 	results.TotalErrors = atomic.LoadInt64(&errorCount)
 	// 使用实际运行时间，而不是配置的时间（支持提前中断）
-	results.Duration = time.Since(startTime)
+	results.Duration = time.Since(b.phase.measureStart())
 
 	return results, nil
 }
 
-// runWorker runs a single worker thread
+// runWorker starts the connections of a single worker thread
 func (b *NetHTTPBenchmark) runWorker(ctx context.Context, cancel context.CancelFunc, threadID int, requestCount, errorCount *int64, results *stats.Results) {
 	connectionsPerThread := b.config.Connections / b.config.Threads
 	if threadID < b.config.Connections%b.config.Threads {
 		connectionsPerThread++
 	}
 
-	var wg sync.WaitGroup
-
 	// 为每个连接启动一个goroutine
 	for i := 0; i < connectionsPerThread; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		b.conns.start(func() {
 			b.runConnection(ctx, cancel, requestCount, errorCount, results)
-		}()
+		})
 	}
-	wg.Wait()
 }
 
 // runConnection handles a single connection's requests
 func (b *NetHTTPBenchmark) runConnection(ctx context.Context, cancel context.CancelFunc, requestCount, errorCount *int64, results *stats.Results) {
+	// 开启 cookie jar 时每个连接使用自己的客户端，底层 Transport 仍然共享
+	client := b.client
+	if jar := b.cookieJars.forConnection(); jar != nil {
+		c := *b.client
+		c.Jar = jar
+		client = &c
+	}
+
 	for {
 		// 先检查 context 是否已取消
 		select {
@@ -208,6 +240,11 @@ func (b *NetHTTPBenchmark) runConnection(ctx context.Context, cancel context.Can
 		default:
 		}
 
+		// 暂停期间阻塞；连接数被调低时多余的连接退出
+		if !b.control.wait(ctx) || b.control.retire() {
+			return
+		}
+
 		// 如果配置了最大请求数（Requests > 0），使用 CAS 控制总请求次数
 		if b.config.Requests > 0 {
 			if !b.acquireRequestSlot(cancel, requestCount) {
@@ -217,11 +254,11 @@ func (b *NetHTTPBenchmark) runConnection(ctx context.Context, cancel context.Can
 
 		// Uber 限流器：自动等待并获取令牌
 		// Take() 会阻塞，所以在调用前先检查 context
-		if b.rateLimiter != nil {
+		if limiter := b.control.rateLimiter(); limiter != nil {
 			// 在 goroutine 中调用 Take()，这样可以响应 context 取消
 			done := make(chan struct{})
 			go func() {
-				b.rateLimiter.Take()
+				limiter.Take()
 				close(done)
 			}()
 
@@ -236,81 +273,136 @@ func (b *NetHTTPBenchmark) runConnection(ctx context.Context, cancel context.Can
 
 		// 获取要执行的请求
 		var req *http.Request
-		writeBytes := int(0)
 		if b.requestPool != nil {
 			// 多请求模式：从请求池获取
-			req, writeBytes = b.requestPool.GetRequest()
+			req, _ = b.requestPool.GetRequest()
 		} else {
 			// 单请求模式
 			req = b.request
 		}
 
-		// 克隆请求并创建新的 Body（避免数据竞争）
-		clonedReq := req.Clone(ctx)
-		if b.bodyContent != "" {
-			clonedReq.Body = io.NopCloser(strings.NewReader(b.bodyContent))
+		// 克隆请求并创建新的 Body（避免数据竞争），通过 httptrace 拿到本次请求使用的连接
+		var conn *trackedConn
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				conn = asTrackedConn(info.Conn)
+			},
+		}
+		clonedReq := req.Clone(httptrace.WithClientTrace(ctx, trace))
+		body := b.bodies[req]
+		if body != nil {
+			clonedReq.Body = io.NopCloser(bytes.NewReader(body))
 		}
-		// 执行请求
-		start := time.Now()
-		resp, err := b.client.Do(clonedReq)
-		duration := time.Since(start)
 
 		// 如果没有配置 Requests（=0），使用简单的每请求计数
 		if b.config.Requests == 0 {
 			atomic.AddInt64(requestCount, 1)
 		}
 
-		var bytesRead int64
+		// 每个请求单独签名，令牌过期前会自动刷新
+		if b.auth != nil {
+			if err := b.auth.Apply(clonedReq, body); err != nil {
+				atomic.AddInt64(errorCount, 1)
+				results.AddError(err)
+				if b.requestPool != nil {
+					results.AddEndpointResult(parser.RequestName(req), req.URL.String(), 0, 0, 0, 0, err)
+				}
+				continue
+			}
+		}
+
+		// 执行请求
+		start := time.Now()
+		resp, err := client.Do(clonedReq)
+		duration := time.Since(start)
+
 		var statusCode int
+		reqErr := err // 传输错误或断言失败，计入端点统计
 
 		if err != nil {
 			atomic.AddInt64(errorCount, 1)
 			results.AddError(err)
 		} else {
-			// 根据是否配置了断言决定是否需要读取响应体
-			if b.config.Asserts != "" {
-				// 需要做断言：读取完整响应体，保留 headers 和 duration
-				bodyBytes, _ := io.ReadAll(resp.Body)
+			statusCode = resp.StatusCode
+			results.AddLatency(duration)
+			results.AddStatusCode(statusCode)
+
+			// 只有被采样的响应才读取响应体执行断言，且最多读取 MaxBodySize 字节，
+			// 其余部分和未采样的响应一样直接丢弃，避免断言拖慢压测
+			if b.config.Asserts != "" && b.sampler.sample() {
+				bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, b.config.GetMaxBodySize()))
+				_, _ = io.Copy(io.Discard, resp.Body)
 				_ = resp.Body.Close()
-				bytesRead = int64(len(bodyBytes))
-				statusCode = resp.StatusCode
-
-				results.AddLatency(duration)
-				results.AddStatusCode(statusCode)
-				results.AddBytes(bytesRead)
 
-				// 构造断言所需的 HTTPResponse 并执行断言
 				assertResp := &asserts.HTTPResponse{
 					Status:   statusCode,
 					Headers:  resp.Header,
 					Body:     bodyBytes,
 					Duration: duration,
 				}
-
-				if errAssert := asserts.Evaluate(b.config.Asserts, assertResp); errAssert != nil {
+				if errAssert := evaluateAsserts(b.config.Asserts, assertResp, results); errAssert != nil {
 					atomic.AddInt64(errorCount, 1)
 					results.AddError(errAssert)
+					reqErr = errAssert
 				}
 			} else {
-				// 未配置断言：保持原有高性能行为，仅统计字节数
-				bytesRead, _ = io.Copy(io.Discard, resp.Body)
+				_, _ = io.Copy(io.Discard, resp.Body)
 				_ = resp.Body.Close()
-				statusCode = resp.StatusCode
-
-				results.AddLatency(duration)
-				results.AddStatusCode(statusCode)
-				results.AddBytes(bytesRead)
 			}
 		}
 
-		// 如果是多请求模式，记录每个 URL 的统计
+		// 响应体读完后，连接上读写的字节数就是本次请求在线上的流量（含状态行、头部和分块编码）
+		var readBytes, writeBytes int64
+		if conn != nil {
+			readBytes, writeBytes = conn.takeBytes()
+		}
+		results.AddBytes(readBytes)
+		results.AddWriteBytes(writeBytes)
+
+		// 如果是多请求模式，按端点（curl 文件中的名称或 URL）记录统计
 		if b.requestPool != nil {
-			results.AddLatencyWithURL(req.URL.String(), duration, statusCode, bytesRead, int64(writeBytes), err)
+			results.AddEndpointResult(parser.RequestName(req), req.URL.String(), duration, statusCode, readBytes, writeBytes, reqErr)
 		}
+	}
+}
 
-		// 记录写入字节数（请求体），在完成一次请求后累加
-		if writeBytes > 0 {
-			results.AddWriteBytes(int64(writeBytes))
+// connGroup 跟踪运行中的连接 goroutine。与 sync.WaitGroup 不同，
+// 运行期间可以随时新增连接，wait 返回之后不再接受新的连接
+type connGroup struct {
+	mu     sync.Mutex
+	active int
+	closed bool
+	idle   sync.Cond
+}
+
+// start 在新的 goroutine 中运行一个连接，wait 已经返回时直接丢弃
+func (g *connGroup) start(run func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return
+	}
+	g.active++
+	go func() {
+		run()
+		g.mu.Lock()
+		g.active--
+		if g.active == 0 {
+			g.idle.Broadcast()
 		}
+		g.mu.Unlock()
+	}()
+}
+
+// wait 等待所有连接退出，之后不再接受新的连接
+func (g *connGroup) wait() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.idle.L == nil {
+		g.idle.L = &g.mu
+	}
+	for g.active > 0 {
+		g.idle.Wait()
 	}
+	g.closed = true
 }
@@ -0,0 +1,50 @@
+package benchmark
+
+import (
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// Observer 接收采样循环每秒产生的快照，用于把运行中的数据导出到外部
+// （如 Prometheus、指标推送等）。回调在采样 goroutine 中同步执行，实现方不应阻塞。
+type Observer interface {
+	// OnSample 在每次采样之后调用，results 为本次运行到目前为止的累计结果
+	OnSample(results *stats.Results, sample stats.Sample)
+	// OnFinish 在运行结束后调用一次，运行出错或被取消时也会调用；
+	// 没有得到结果时传入空的结果
+	OnFinish(results *stats.Results)
+}
+
+// runHooks 保存运行期间的扩展点，由 Benchmark 注入到具体的 Runner 中
+type runHooks struct {
+	observers []Observer
+	control   *Controller // 运行时控制，Benchmark 未注入时按配置创建
+}
+
+// hooks 返回 runner 的扩展点，所有内嵌 runHooks 的 runner 都会实现该方法
+func (h *runHooks) hooks() *runHooks {
+	return h
+}
+
+// controller 返回本次运行的控制器，没有注入时按配置创建一个
+func (h *runHooks) controller(cfg config.Config) *Controller {
+	if h.control == nil {
+		h.control = newController(cfg)
+	}
+	return h.control
+}
+
+// hookedRunner 是内嵌了 runHooks 的 Runner
+type hookedRunner interface {
+	hooks() *runHooks
+}
+
+// notifySample 把一次采样分发给所有 observer
+func (h *runHooks) notifySample(results *stats.Results, sample stats.Sample) {
+	if h == nil {
+		return
+	}
+	for _, o := range h.observers {
+		o.OnSample(results, sample)
+	}
+}
@@ -0,0 +1,37 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// failingRunner 模拟被取消的运行
+type failingRunner struct{}
+
+func (failingRunner) Run(ctx context.Context) (*stats.Results, error) {
+	return nil, context.Canceled
+}
+
+type finishRecorder struct {
+	finished []*stats.Results
+}
+
+func (r *finishRecorder) OnSample(*stats.Results, stats.Sample) {}
+func (r *finishRecorder) OnFinish(results *stats.Results)       { r.finished = append(r.finished, results) }
+
+func TestRunNotifiesObserversOnError(t *testing.T) {
+	b := &Benchmark{runner: failingRunner{}}
+	rec := &finishRecorder{}
+	b.AddObserver(rec)
+
+	if _, err := b.Run(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the runner error, got %v", err)
+	}
+	// 出错的运行同样通知一次，没有结果时传入空的结果
+	if len(rec.finished) != 1 || rec.finished[0] == nil {
+		t.Fatalf("expected OnFinish once with empty results, got %v", rec.finished)
+	}
+}
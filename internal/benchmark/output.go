@@ -3,6 +3,7 @@ package benchmark
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/antlabs/gurl/internal/config"
@@ -11,6 +12,13 @@ import (
 
 // PrintResults prints the benchmark results in wrk-like format
 func PrintResults(results *stats.Results, cfg config.Config) {
+	if reason := results.AbortReason(); reason != "" {
+		fmt.Printf("  ABORTED: %s\n", reason)
+	}
+
+	// 预热阶段的数据单独显示，不计入下面的统计
+	fmt.Print(FormatWarmup(results, "  "))
+
 	fmt.Printf("  Thread Stats   Avg      Stdev     Max   +/- Stdev\n")
 
 	// 计算延迟统计
@@ -102,7 +110,7 @@ func PrintResults(results *stats.Results, cfg config.Config) {
 	if len(endpointStats) > 1 {
 		fmt.Printf("\n=== Per-Endpoint Statistics ===\n")
 
-		// 按 URL 排序以保持输出一致性
+		// 按名称或 URL 排序以保持输出一致性
 		urls := make([]string, 0, len(endpointStats))
 		for url := range endpointStats {
 			urls = append(urls, url)
@@ -114,17 +122,103 @@ func PrintResults(results *stats.Results, cfg config.Config) {
 			printEndpointStats(stats, results.Duration)
 		}
 	}
+
+	// 打印断言结果分布
+	if text := FormatAsserts(results, ""); text != "" {
+		fmt.Printf("\n%s", text)
+	}
+
+	// 打印运行期间的调整（暂停、速率、连接数）
+	if text := FormatAnnotations(results, "  "); text != "" {
+		fmt.Printf("\n%s", text)
+	}
+}
+
+// FormatWarmup 格式化预热阶段的摘要，没有预热时返回空字符串。indent 用于嵌入批量测试报告
+func FormatWarmup(results *stats.Results, indent string) string {
+	w := results.Warmup
+	if w == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sWarm-up (excluded): %d requests in %s", indent, w.TotalRequests, w.Duration.Round(time.Millisecond))
+	if w.Duration > 0 {
+		fmt.Fprintf(&b, ", %.2f req/s", float64(w.TotalRequests)/w.Duration.Seconds())
+	}
+	if w.GetMaxLatency() > 0 {
+		p := w.GetLatencyPercentiles()
+		fmt.Fprintf(&b, ", avg %s, p50 %s, p99 %s", formatDuration(w.GetAverageLatency()), formatDuration(p[50]), formatDuration(p[99]))
+	}
+	fmt.Fprintf(&b, ", %d errors\n", w.TotalErrors)
+	return b.String()
+}
+
+// FormatAnnotations 格式化运行期间记录在时间线上的调整，没有时返回空字符串
+func FormatAnnotations(results *stats.Results, indent string) string {
+	annotations := results.GetAnnotations()
+	if len(annotations) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sAdjustments:\n", indent)
+	for _, a := range annotations {
+		fmt.Fprintf(&b, "%s  %7.1fs  %s\n", indent, a.Elapsed.Seconds(), a.Label)
+	}
+	return b.String()
+}
+
+// FormatAsserts 格式化每条断言的通过/失败分布以及失败响应样本，
+// 没有执行过断言时返回空字符串。indent 用于嵌入批量测试报告
+func FormatAsserts(results *stats.Results, indent string) string {
+	assertStats := results.GetAssertStats()
+	if len(assertStats) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sAssertions (%d responses evaluated):\n", indent, results.GetAssertsEvaluated())
+	for _, st := range assertStats {
+		total := st.Passed + st.Failed
+		passRate := float64(st.Passed) / float64(total) * 100
+		fmt.Fprintf(&b, "%s  %-40s passed %d, failed %d (%.1f%% pass)\n", indent, st.Assert, st.Passed, st.Failed, passRate)
+	}
+
+	failures := results.GetAssertFailures()
+	if len(failures) > 0 {
+		fmt.Fprintf(&b, "%sFailing response samples:\n", indent)
+		for i, f := range failures {
+			fmt.Fprintf(&b, "%s  #%d [%d] %s\n", indent, i+1, f.Status, f.Message)
+			if f.Body != "" {
+				body := strings.ReplaceAll(f.Body, "\n", " ")
+				if f.Truncated {
+					body += "..."
+				}
+				fmt.Fprintf(&b, "%s     body: %s\n", indent, body)
+			}
+		}
+	}
+	return b.String()
 }
 
 // printEndpointStats prints statistics for a single endpoint
 func printEndpointStats(stats *stats.EndpointStats, duration time.Duration) {
-	fmt.Printf("\n[%s]\n", stats.URL)
+	if stats.Name != "" {
+		fmt.Printf("\n[%s] %s\n", stats.Name, stats.URL)
+	} else {
+		fmt.Printf("\n[%s]\n", stats.URL)
+	}
 
 	// 基本统计
-	fmt.Printf("  Requests:     %d\n", stats.Requests)
+	fmt.Printf("  Requests:     %d (%.1f%% of traffic)\n", stats.Requests, stats.Share*100)
 	if stats.Errors > 0 {
 		errorRate := float64(stats.Errors) / float64(stats.Requests) * 100
-		fmt.Printf("  Errors:       %d (%.1f%%)\n", stats.Errors, errorRate)
+		fmt.Printf("  Errors:       %d (%.1f%%)", stats.Errors, errorRate)
+		if classes := formatErrorClasses(stats.ErrorClasses); classes != "" {
+			fmt.Printf(" %s", classes)
+		}
+		fmt.Printf("\n")
 	}
 
 	// TPS
@@ -138,21 +232,28 @@ func printEndpointStats(stats *stats.EndpointStats, duration time.Duration) {
 			formatDuration(avgLatency),
 			formatDuration(stats.MinLatency),
 			formatDuration(stats.MaxLatency))
+		percentiles := stats.GetLatencyPercentiles()
+		fmt.Printf("  Percentiles:  p50=%s, p90=%s, p99=%s\n",
+			formatDuration(percentiles[50]),
+			formatDuration(percentiles[90]),
+			formatDuration(percentiles[99]))
 	}
 
-	// 状态码分布
+	// 状态码分布（按状态码排序）
 	if len(stats.StatusCodes) > 0 {
-		fmt.Printf("  Status codes: ")
-		first := true
-		for code, count := range stats.StatusCodes {
-			if !first {
-				fmt.Printf(", ")
-			}
+		codes := make([]int, 0, len(stats.StatusCodes))
+		for code := range stats.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+
+		parts := make([]string, 0, len(codes))
+		for _, code := range codes {
+			count := stats.StatusCodes[code]
 			percentage := float64(count) / float64(stats.Requests) * 100
-			fmt.Printf("[%d] %d (%.1f%%)", code, count, percentage)
-			first = false
+			parts = append(parts, fmt.Sprintf("[%d] %d (%.1f%%)", code, count, percentage))
 		}
-		fmt.Printf("\n")
+		fmt.Printf("  Status codes: %s\n", strings.Join(parts, ", "))
 	}
 
 	// 数据传输
@@ -170,6 +271,29 @@ func printEndpointStats(stats *stats.EndpointStats, duration time.Duration) {
 	}
 }
 
+// formatErrorClasses 把按类别分组的错误数格式化为 "(timeout 3, connect 1)"，按数量降序
+func formatErrorClasses(classes map[string]int64) string {
+	if len(classes) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if classes[names[i]] != classes[names[j]] {
+			return classes[names[i]] > classes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, classes[name])
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
 // formatDuration formats a duration for display
 func formatDuration(d time.Duration) string {
 	if d < time.Microsecond {
@@ -3,32 +3,33 @@ package benchmark
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
-	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/antlabs/gurl/internal/asserts"
+	"github.com/antlabs/gurl/internal/auth"
 	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/parser"
 	"github.com/antlabs/gurl/internal/stats"
-	"github.com/antlabs/httparser"
 	"github.com/antlabs/pulse"
 	"github.com/antlabs/pulse/core"
-	"go.uber.org/ratelimit"
 )
 
-var bytesContentLength = []byte("Content-Length")
-
 // PulseBenchmark 使用 pulse 库进行单请求 HTTP 压测的实现
 type PulseBenchmark struct {
 	config  config.Config
 	request *http.Request
 	target  *url.URL
+	runHooks
 }
 
 // PulseBenchmarkMulti 使用 pulse 库进行多请求 HTTP 压测的实现
@@ -37,64 +38,123 @@ type PulseBenchmarkMulti struct {
 	config      config.Config
 	requestPool *RequestPool
 	target      *url.URL
+	runHooks
 }
 
-// HTTPParseResult 存储HTTP解析结果
-type HTTPParseResult struct {
-	statusCode       int
-	contentLength    int64
-	headersComplete  bool
-	messageComplete  bool
-	hasContentLength bool
-	// 为 asserts 收集的数据
-	enableAsserts bool
-	maxBodySize   int64
-	headers       http.Header
-	body          []byte
-	currentHeader string
+// wireRequest 预先编码好的请求报文，压测过程中直接写入连接
+type wireRequest struct {
+	head bool // HEAD 请求的响应没有响应体
+	data []byte
+
+	// 多请求模式下按端点统计：name 为 curl 文件中的名称，endpoint 为请求 URL
+	name     string
+	endpoint string
+
+	// 配置了认证时每个请求都要单独签名，发送时由 req 和 body 重新编码
+	req  *http.Request
+	body []byte
+
+	// 开启 cookie jar 时使用：原始 Cookie 头从 data 中移除，发送时与 jar 中的 cookie 合并后
+	// 插入到 headerEnd 处（最后一个头部行之后）
+	url       *url.URL
+	cookie    string
+	headerEnd int
 }
 
-func (h *HTTPParseResult) Reset() {
-	h.statusCode = 0
-	h.contentLength = 0
-	h.headersComplete = false
-	h.messageComplete = false
-	h.hasContentLength = false
-	if h.enableAsserts {
-		for k := range h.headers {
-			delete(h.headers, k)
-		}
-		if h.body != nil {
-			h.body = h.body[:0]
+// cookieLine 合并原始 Cookie 头和 jar 中的 cookie
+func (w wireRequest) cookieLine(jarCookies []*http.Cookie) string {
+	var line strings.Builder
+	line.WriteString(w.cookie)
+	for _, c := range jarCookies {
+		if line.Len() > 0 {
+			line.WriteString("; ")
 		}
-		h.currentHeader = ""
+		line.WriteString(c.Name)
+		line.WriteByte('=')
+		line.WriteString(c.Value)
 	}
+	return line.String()
 }
 
-// ConnSession 每个连接的会话状态
+// withCookies 返回带有原始 Cookie 头和 jar 中 cookie 的请求报文
+func (w wireRequest) withCookies(jarCookies []*http.Cookie) []byte {
+	line := w.cookieLine(jarCookies)
+	if line == "" {
+		return w.data
+	}
+
+	buf := make([]byte, 0, len(w.data)+len(line)+len("Cookie: \r\n"))
+	buf = append(buf, w.data[:w.headerEnd]...)
+	buf = append(buf, "Cookie: "...)
+	buf = append(buf, line...)
+	buf = append(buf, "\r\n"...)
+	return append(buf, w.data[w.headerEnd:]...)
+}
+
+// signed 为本次发送签名并重新编码请求报文，开启 cookie jar 时同时带上 cookie
+func (w wireRequest) signed(jarCookies []*http.Cookie, provider auth.Provider) ([]byte, error) {
+	out := w.req.Clone(context.Background())
+	if line := w.cookieLine(jarCookies); line != "" {
+		out.Header.Set("Cookie", line)
+	}
+	if err := provider.Apply(out, w.body); err != nil {
+		return nil, err
+	}
+	if len(w.body) > 0 {
+		out.Body = io.NopCloser(bytes.NewReader(w.body))
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(w.data) + 256)
+	if err := out.Write(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode request %s: %w", w.url, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ConnSession 每个连接的会话状态。事件循环之外（暂停恢复后）也会发送请求，
+// 因此回调和恢复发送都持有 mu 再访问会话
 type ConnSession struct {
-	startTime    time.Time
-	parser       *httparser.Parser
-	parseResult  *HTTPParseResult
-	request      *http.Request
-	requestCount *int64
-	errorCount   *int64
-	results      *stats.Results
-	maxBodySize  int64
+	mu         sync.Mutex
+	closed     bool // 连接已关闭，恢复时不再发送
+	startTime  time.Time
+	parser     responseParser
+	readBytes  int64 // 当前响应已经从连接读取的字节数（含状态行、头部和分块编码）
+	writeBytes int64 // 当前请求写入连接的字节数
+	results    *stats.Results
+	jar        http.CookieJar // 开启 cookie jar 时该连接（虚拟用户）使用的 jar
+	current    wireRequest    // 正在等待响应的请求
+	pending    bool           // current 已发送且尚未收到完整响应
 }
 
 // HTTPClientHandler 处理HTTP客户端连接的回调
 type HTTPClientHandler struct {
-	request      *http.Request // 单请求模式使用
-	requestPool  *RequestPool  // 多请求模式使用
+	request      *http.Request                 // 单请求模式使用
+	requestPool  *RequestPool                  // 多请求模式使用
+	wire         map[*http.Request]wireRequest // 请求对应的报文
 	requestCount *int64
 	errorCount   *int64
 	results      *stats.Results
 	maxBodySize  int64
-	rateLimiter  ratelimit.Limiter
+	control      *Controller // 暂停、速率和连接数的运行时控制
+	ctx          context.Context
 	asserts      string
+	sampler      *assertSampler
 	maxRequests  int64
 	cancel       context.CancelFunc
+	cookieJars   *cookieJars   // cookie jar 分配，未开启时为 nil
+	auth         auth.Provider // 为每个请求添加认证信息，未配置时为 nil
+	phase        *runPhase     // 预热/测量阶段
+
+	parkMu   sync.Mutex
+	parked   []parkedConn // 暂停期间等待恢复的连接
+	resuming bool         // 等待恢复的 goroutine 是否已经启动
+}
+
+// parkedConn 是暂停期间没有发送下一个请求的连接
+type parkedConn struct {
+	conn    *pulse.Conn
+	session *ConnSession
 }
 
 // NewPulseBenchmark 创建新的pulse基准测试实例
@@ -121,66 +181,67 @@ func NewPulseBenchmarkWithMultipleRequests(cfg config.Config, requests []*http.R
 	}
 }
 
-// OnOpen 连接建立时的回调
-func (h *HTTPClientHandler) OnOpen(c *pulse.Conn) {
-	session := &ConnSession{
-		startTime: time.Now(),
-		parseResult: &HTTPParseResult{
-			enableAsserts: h.asserts != "",
-			maxBodySize:   h.maxBodySize,
-		},
-		request:      h.request,
-		requestCount: h.requestCount,
-		errorCount:   h.errorCount,
-		results:      h.results,
-		maxBodySize:  h.maxBodySize,
-	}
-
-	session.parser = httparser.New(httparser.RESPONSE)
-	session.parser.SetUserData(session.parseResult)
-
-	c.SetSession(session)
-
-	// 在发送第一个请求前，根据 MaxRequests 使用 CAS 占用一个请求名额
-	if h.maxRequests > 0 {
-		for {
-			cur := atomic.LoadInt64(h.requestCount)
-			if cur >= h.maxRequests {
-				// 已达到上限，取消测试并关闭连接
-				if h.cancel != nil {
-					h.cancel()
-				}
-				c.Close()
-				return
-			}
-			if atomic.CompareAndSwapInt64(h.requestCount, cur, cur+1) {
-				// 如果这是最后一个名额，占用后立即取消上下文
-				if cur+1 >= h.maxRequests && h.cancel != nil {
-					h.cancel()
-				}
-				break
+// encodeRequests 把请求编码成 HTTP/1.1 报文。与 net/http 一样由 Request.Write 生成，
+// 因此带请求体的请求会有正确的 Content-Length。withCookies 为 true 时把 Cookie 头
+// 单独保存，发送时再与 cookie jar 合并
+func encodeRequests(requests []*http.Request, withCookies bool) (map[*http.Request]wireRequest, error) {
+	wire := make(map[*http.Request]wireRequest, len(requests))
+	for _, req := range requests {
+		var body []byte
+		if req.Body != nil && req.Body != http.NoBody {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read request body: %w", err)
 			}
+			// 还原请求体，其他使用者仍然可以读取
+			req.Body = io.NopCloser(bytes.NewReader(body))
 		}
-	}
 
-	// 如果配置了频率限制，在发送第一个请求前获取令牌
-	if h.rateLimiter != nil {
-		h.rateLimiter.Take()
-	}
+		out := req.Clone(context.Background())
+		out.ContentLength = int64(len(body))
+		out.Body = nil
+		if len(body) > 0 {
+			out.Body = io.NopCloser(bytes.NewReader(body))
+		}
 
-	// 构建HTTP请求（单请求或多请求）
-	httpReq := h.buildHTTPRequest()
+		w := wireRequest{
+			head:     req.Method == http.MethodHead,
+			name:     parser.RequestName(req),
+			endpoint: req.URL.String(),
+			url:      req.URL,
+		}
+		if withCookies {
+			w.cookie = strings.Join(out.Header.Values("Cookie"), "; ")
+			out.Header.Del("Cookie")
+		}
 
-	// 发送HTTP请求
-	written, err := c.Write(httpReq)
-	if err != nil {
-		atomic.AddInt64(h.errorCount, 1)
-		h.results.AddError(err)
-		c.Close()
-		return
+		var buf bytes.Buffer
+		if err := out.Write(&buf); err != nil {
+			return nil, fmt.Errorf("failed to encode request %s: %w", req.URL, err)
+		}
+		w.data = buf.Bytes()
+		w.headerEnd = bytes.Index(w.data, []byte("\r\n\r\n")) + 2
+		out.Body = nil
+		w.req = out
+		w.body = body
+		wire[req] = w
 	}
+	return wire, nil
+}
 
-	h.results.AddWriteBytes(int64(written))
+// OnOpen 连接建立时的回调
+func (h *HTTPClientHandler) OnOpen(c *pulse.Conn) {
+	session := &ConnSession{results: h.results, jar: h.cookieJars.forConnection()}
+	session.parser.maxBodySize = h.maxBodySize
+	session.parser.collectCookies = session.jar != nil
+	c.SetSession(session)
+	h.results.ConnOpened()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	h.sendNext(c, session)
 }
 
 // OnData 接收到数据时的回调
@@ -189,89 +250,39 @@ func (h *HTTPClientHandler) OnData(c *pulse.Conn, data []byte) {
 	if !ok {
 		return
 	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
 
-	// 流式解析HTTP响应
-	n, err := session.parser.Execute(&httpParserSetting, data)
-	if err != nil || n < 0 {
-		atomic.AddInt64(h.errorCount, 1)
-		session.results.AddError(fmt.Errorf("HTTP parse error: %v", err))
-		c.Close()
-		return
-	}
+	// 按线上实际读取的字节数统计流量
+	session.readBytes += int64(len(data))
 
-	// 检查是否收到完整的HTTP响应
-	if session.parseResult.messageComplete {
-		duration := time.Since(session.startTime)
-
-		// 如果没有配置 maxRequests（=0），在每次完成响应时递增请求计数
-		if h.maxRequests == 0 {
-			atomic.AddInt64(h.requestCount, 1)
+	// 流式解析HTTP响应，一次回调中可能包含多个响应
+	for len(data) > 0 {
+		n, done, err := session.parser.feed(data)
+		if err != nil {
+			atomic.AddInt64(h.errorCount, 1)
+			session.results.AddError(err)
+			h.recordEndpointError(session, err)
+			c.Close()
+			return
 		}
-
-		// 记录统计数据
-		session.results.AddLatency(duration)
-		session.results.AddStatusCode(session.parseResult.statusCode)
-		session.results.AddBytes(session.parseResult.contentLength)
-
-		// 如果配置了断言，则执行断言
-		if h.asserts != "" && session.parseResult.enableAsserts {
-			assertResp := &asserts.HTTPResponse{
-				Status:   session.parseResult.statusCode,
-				Headers:  session.parseResult.headers,
-				Body:     session.parseResult.body,
-				Duration: duration,
-			}
-
-			if errAssert := asserts.Evaluate(h.asserts, assertResp); errAssert != nil {
-				atomic.AddInt64(h.errorCount, 1)
-				session.results.AddError(errAssert)
-			}
+		data = data[n:]
+		if !done {
+			return
 		}
 
-		// 重置解析器状态，准备下次请求
-		session.parseResult.Reset()
-		session.parser.SetUserData(session.parseResult)
-		session.startTime = time.Now()
-
-		// 在发送下一个请求前，根据 MaxRequests 使用 CAS 占用一个请求名额
-		if h.maxRequests > 0 {
-			for {
-				cur := atomic.LoadInt64(h.requestCount)
-				if cur >= h.maxRequests {
-					// 已达到上限，取消测试并关闭连接
-					if h.cancel != nil {
-						h.cancel()
-					}
-					c.Close()
-					return
-				}
-				if atomic.CompareAndSwapInt64(h.requestCount, cur, cur+1) {
-					// 如果这是最后一个名额，占用后立即取消上下文
-					if cur+1 >= h.maxRequests && h.cancel != nil {
-						h.cancel()
-					}
-					break
-				}
-			}
-		}
+		h.recordResponse(session)
 
-		// 在发送下一个请求前应用频率限制（如果配置了Rate）
-		if h.rateLimiter != nil {
-			h.rateLimiter.Take()
+		// 服务端声明要关闭连接，不再复用
+		if session.parser.connClose {
+			c.Close()
+			return
 		}
 
 		// 立即发送下一个请求（持续压测）
-		httpReq := h.buildHTTPRequest()
-		written, writeErr := c.Write(httpReq)
-		if writeErr != nil {
-			atomic.AddInt64(h.errorCount, 1)
-			session.results.AddError(writeErr)
-			c.Close()
+		if !h.sendNext(c, session) {
 			return
 		}
-
-		// 记录写入字节数（请求体+头部），优先使用 ContentLength
-		session.results.AddWriteBytes(int64(written))
 	}
 }
 
@@ -281,162 +292,280 @@ func (h *HTTPClientHandler) OnClose(c *pulse.Conn, err error) {
 	if !ok {
 		return
 	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.closed = true
 
-	if err != nil {
+	session.results.ConnClosed()
+
+	// 没有长度信息的响应体以连接关闭作为结束
+	if session.parser.closed() {
+		h.recordResponse(session)
+		return
+	}
+
+	if session.readBytes > 0 {
+		session.results.AddBytes(session.readBytes)
+		session.readBytes = 0
+	}
+
+	if err == nil && session.parser.inProgress() {
+		err = fmt.Errorf("connection closed before response complete: %w", io.ErrUnexpectedEOF)
+	}
+	if err != nil && !errors.Is(err, net.ErrClosed) {
 		atomic.AddInt64(h.errorCount, 1)
 		session.results.AddError(err)
+		h.recordEndpointError(session, err)
 	}
-
-	// 只有在连接关闭时才调用 wg.Done()
-	// session.wg.Done()
 }
 
-// buildHTTPRequest 构建HTTP请求字符串
-func (h *HTTPClientHandler) buildHTTPRequest() []byte {
-	var req *http.Request
+// recordResponse 记录一个完整响应的统计数据，并在需要时执行断言
+func (h *HTTPClientHandler) recordResponse(session *ConnSession) {
+	duration := time.Since(session.startTime)
+	p := &session.parser
+
+	// 如果没有配置 maxRequests（=0），在每次完成响应时递增请求计数
+	if h.maxRequests == 0 {
+		atomic.AddInt64(h.requestCount, 1)
+	}
+
+	session.results.AddLatency(duration)
+	session.results.AddStatusCode(p.statusCode)
+	session.results.AddBytes(session.readBytes)
+	readBytes := session.readBytes
+	session.readBytes = 0
+	session.pending = false
+
+	// 保存响应设置的 cookie，之后的请求会带上
+	if session.jar != nil && len(p.setCookies) > 0 {
+		resp := &http.Response{Header: http.Header{"Set-Cookie": p.setCookies}}
+		session.jar.SetCookies(session.current.url, resp.Cookies())
+	}
+
+	// 当前响应被断言采样选中时执行断言
+	var errAssert error
+	if p.capture {
+		assertResp := &asserts.HTTPResponse{
+			Status:   p.statusCode,
+			Headers:  p.headers,
+			Body:     p.body,
+			Duration: duration,
+		}
+
+		if errAssert = evaluateAsserts(h.asserts, assertResp, session.results); errAssert != nil {
+			atomic.AddInt64(h.errorCount, 1)
+			session.results.AddError(errAssert)
+		}
+	}
+
+	// 多请求模式下按端点记录统计
 	if h.requestPool != nil {
-		// 多请求模式：从请求池中获取下一个请求
-		var size int
-		req, size = h.requestPool.GetRequest()
-		_ = size // 目前仅用于选择请求，写入字节数使用实际 written 统计
-	} else {
-		// 单请求模式
-		req = h.request
+		cur := session.current
+		session.results.AddEndpointResult(cur.name, cur.endpoint, duration, p.statusCode, readBytes, session.writeBytes, errAssert)
 	}
+}
 
-	b, err := httputil.DumpRequest(req, true)
-	if err != nil {
-		return nil
+// recordEndpointError 在多请求模式下把没有拿到响应的请求错误计入当前端点
+func (h *HTTPClientHandler) recordEndpointError(session *ConnSession, err error) {
+	if h.requestPool == nil || !session.pending {
+		return
 	}
-	return b
+	session.pending = false
+	cur := session.current
+	session.results.AddEndpointResult(cur.name, cur.endpoint, 0, 0, 0, session.writeBytes, err)
 }
 
-// httpParserSetting 全局HTTP解析器设置，避免每次创建
-var httpParserSetting = httparser.Setting{
-	MessageBegin: func(p *httparser.Parser, _ int) {
-		// 解析器开始工作，重置结果
-		if result := p.GetUserData(); result != nil {
-			if r, ok := result.(*HTTPParseResult); ok {
-				r.statusCode = 0
-				r.contentLength = 0
-				r.headersComplete = false
-				r.messageComplete = false
-				r.contentLength = 0
-				if r.enableAsserts {
-					if r.headers == nil {
-						r.headers = make(http.Header)
-					} else {
-						for k := range r.headers {
-							delete(r.headers, k)
-						}
-					}
-					if r.body != nil {
-						r.body = r.body[:0]
-					}
-					r.currentHeader = ""
-				}
-			}
-		}
-	},
-	URL: func(_ *httparser.Parser, buf []byte, _ int) {
-	},
-	Status: func(p *httparser.Parser, buf []byte, _ int) {
-	},
-	HeaderField: func(p *httparser.Parser, buf []byte, _ int) {
-		// HTTP header field
-		if bytes.Equal(buf, bytesContentLength) {
-			if result := p.GetUserData(); result != nil {
-				if r, ok := result.(*HTTPParseResult); ok {
-					r.hasContentLength = true
-				}
-			}
+// sendNext 在发送下一个请求前依次处理连接数调整、暂停、MaxRequests 和频率限制，
+// 没有立即发送时返回 false。调用方持有 session.mu
+func (h *HTTPClientHandler) sendNext(c *pulse.Conn, session *ConnSession) bool {
+	// 连接数被调低，多余的连接直接关闭
+	if h.control.retire() {
+		c.Close()
+		return false
+	}
+
+	// 暂停期间不阻塞事件循环，连接挂起到恢复后再继续发送
+	if h.control.isPaused() {
+		h.park(c, session)
+		return false
+	}
+
+	// 根据 MaxRequests 占用一个请求名额
+	if !h.acquireRequestSlot(c) {
+		return false
+	}
+
+	// 如果配置了频率限制，在发送前获取令牌
+	if limiter := h.control.rateLimiter(); limiter != nil {
+		limiter.Take()
+	}
+
+	return h.sendRequest(c, session)
+}
+
+// park 挂起一个暂停期间的连接。所有挂起的连接共用一个 goroutine 等待恢复，
+// 恢复后持有各自的 session.mu 继续发送；测试结束时直接放弃挂起的连接
+func (h *HTTPClientHandler) park(c *pulse.Conn, session *ConnSession) {
+	h.parkMu.Lock()
+	defer h.parkMu.Unlock()
+	h.parked = append(h.parked, parkedConn{conn: c, session: session})
+	if h.resuming {
+		return
+	}
+	h.resuming = true
+
+	go func() {
+		resumed := h.control.wait(h.ctx)
+
+		h.parkMu.Lock()
+		parked := h.parked
+		h.parked = nil
+		h.resuming = false
+		h.parkMu.Unlock()
+		if !resumed {
+			return
 		}
-		if result := p.GetUserData(); result != nil {
-			if r, ok := result.(*HTTPParseResult); ok && r.enableAsserts {
-				r.currentHeader = string(buf)
+
+		for _, p := range parked {
+			p.session.mu.Lock()
+			if !p.session.closed {
+				h.sendNext(p.conn, p.session)
 			}
+			p.session.mu.Unlock()
 		}
-	},
-	HeaderValue: func(p *httparser.Parser, buf []byte, _ int) {
-		// HTTP header value
-		// 获取当前header field
-		if result := p.GetUserData(); result != nil {
-			if r, ok := result.(*HTTPParseResult); ok {
-				if r.hasContentLength {
-					if contentLength, err := strconv.Atoi(string(buf)); err == nil {
-						r.contentLength = int64(contentLength)
-					}
-				}
-				if r.enableAsserts && r.currentHeader != "" {
-					if r.headers == nil {
-						r.headers = make(http.Header)
-					}
-					r.headers.Add(r.currentHeader, string(buf))
-				}
+	}()
+}
+
+// acquireRequestSlot 在配置了 MaxRequests 时使用 CAS 占用一个请求名额，
+// 达到上限时取消测试并关闭连接，返回 false
+func (h *HTTPClientHandler) acquireRequestSlot(c *pulse.Conn) bool {
+	if h.maxRequests <= 0 {
+		return true
+	}
+	// 预热阶段的请求不占用名额
+	if h.phase.isWarming() {
+		atomic.AddInt64(h.requestCount, 1)
+		return true
+	}
+	for {
+		cur := atomic.LoadInt64(h.requestCount)
+		if cur >= h.maxRequests {
+			// 已达到上限，取消测试并关闭连接
+			if h.cancel != nil {
+				h.cancel()
 			}
+			c.Close()
+			return false
 		}
-	},
-	HeadersComplete: func(p *httparser.Parser, _ int) {
-		// HTTP header解析结束
-		if result := p.GetUserData(); result != nil {
-			if r, ok := result.(*HTTPParseResult); ok {
-				r.headersComplete = true
+		if atomic.CompareAndSwapInt64(h.requestCount, cur, cur+1) {
+			// 如果这是最后一个名额，占用后立即取消上下文
+			if cur+1 >= h.maxRequests && h.cancel != nil {
+				h.cancel()
 			}
+			return true
 		}
-	},
-	Body: func(p *httparser.Parser, buf []byte, _ int) {
-		// 累积响应体数据并检查大小限制
-		if result := p.GetUserData(); result != nil {
-			if r, ok := result.(*HTTPParseResult); ok {
-				r.contentLength += int64(len(buf))
-				if r.enableAsserts && r.maxBodySize > 0 {
-					remaining := int(r.maxBodySize) - len(r.body)
-					if remaining > 0 {
-						if remaining < len(buf) {
-							r.body = append(r.body, buf[:remaining]...)
-						} else {
-							r.body = append(r.body, buf...)
-						}
-					}
-				}
-			}
+	}
+}
+
+// sendRequest 发送下一个请求并为其响应重置解析器，写入失败时关闭连接并返回 false
+func (h *HTTPClientHandler) sendRequest(c *pulse.Conn, session *ConnSession) bool {
+	req := h.nextRequest()
+	session.current = req
+	session.pending = true
+	session.writeBytes = 0
+	session.parser.reset(req.head, h.shouldAssert())
+
+	data := req.data
+	switch {
+	case h.auth != nil:
+		var jarCookies []*http.Cookie
+		if session.jar != nil {
+			jarCookies = session.jar.Cookies(req.url)
 		}
-	},
-	MessageComplete: func(p *httparser.Parser, _ int) {
-		// 消息解析结束
-		if result := p.GetUserData(); result != nil {
-			if r, ok := result.(*HTTPParseResult); ok {
-				r.statusCode = int(p.StatusCode)
-				r.messageComplete = true
-			}
+		signed, err := req.signed(jarCookies, h.auth)
+		if err != nil {
+			// 签名失败（例如获取令牌失败）时记录错误并关闭连接
+			atomic.AddInt64(h.errorCount, 1)
+			session.results.AddError(err)
+			h.recordEndpointError(session, err)
+			c.Close()
+			return false
 		}
-	},
+		data = signed
+	case session.jar != nil:
+		data = req.withCookies(session.jar.Cookies(req.url))
+	}
+
+	session.startTime = time.Now()
+	written, err := c.Write(data)
+	if err != nil {
+		atomic.AddInt64(h.errorCount, 1)
+		session.results.AddError(err)
+		h.recordEndpointError(session, err)
+		c.Close()
+		return false
+	}
+
+	// 记录写入字节数（请求行、头部和请求体）
+	session.writeBytes = int64(written)
+	session.results.AddWriteBytes(int64(written))
+	return true
+}
+
+// shouldAssert 判断下一个响应是否需要收集响应体并执行断言
+func (h *HTTPClientHandler) shouldAssert() bool {
+	return h.asserts != "" && h.sampler.sample()
+}
+
+// nextRequest 返回下一个要发送的请求报文（单请求或多请求）
+func (h *HTTPClientHandler) nextRequest() wireRequest {
+	if h.requestPool != nil {
+		// 多请求模式：从请求池中获取下一个请求
+		req, _ := h.requestPool.GetRequest()
+		return h.wire[req]
+	}
+	// 单请求模式
+	return h.wire[h.request]
 }
 
 // Run 执行pulse基准测试
 func (pb *PulseBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 	results := stats.NewResults()
 
-	startTime := time.Now()
+	// 记录开始时间，预热结束后测量阶段重新计时
+	phase := newRunPhase(pb.config.Warmup)
 
-	// 创建测试上下文
-	testCtx, cancel := context.WithTimeout(ctx, pb.config.Duration)
+	// 创建测试上下文，预热时间不计入测试时间
+	testCtx, cancel := context.WithTimeout(ctx, pb.config.Warmup+pb.config.Duration)
 	defer cancel()
 
 	var requestCount int64
 	var errorCount int64
 
-	// 创建 Uber 限流器（所有连接共享，与 NetHTTPBenchmark 行为一致）
-	var limiter ratelimit.Limiter
-	if pb.config.Rate > 0 {
-		limiter = ratelimit.New(pb.config.Rate)
+	cookieJars, err := newCookieJars(pb.config)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := auth.New(pb.config.Auth)
+	if err != nil {
+		return nil, err
 	}
 
+	// 预先编码请求报文
+	wire, err := encodeRequests([]*http.Request{pb.request}, cookieJars != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 运行时控制，限流器由所有连接共享（与 NetHTTPBenchmark 行为一致）
+	control := pb.controller(pb.config)
+
 	// 创建 Live UI（如果启用）
 	var liveUI *LiveUI
 	var uiErr error
 	if pb.config.LiveUI {
-		liveUI, uiErr = NewLiveUIWithTheme(pb.config.Duration, pb.config.UITheme)
+		liveUI, uiErr = NewLiveUIWithTheme(pb.config.Warmup+pb.config.Duration, pb.config.UITheme)
 		if uiErr != nil {
 			// 如果 UI 初始化失败，继续运行但不显示 UI
 			pb.config.LiveUI = false
@@ -454,14 +583,20 @@ func (pb *PulseBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 		pulse.WithCallback(&HTTPClientHandler{
 			request:      pb.request,
 			requestPool:  nil,
+			wire:         wire,
 			requestCount: &requestCount,
 			errorCount:   &errorCount,
 			results:      results,
-			maxBodySize:  1 << 20, // 1MB限制
-			rateLimiter:  limiter,
+			maxBodySize:  pb.config.GetMaxBodySize(),
+			control:      control,
+			ctx:          testCtx,
 			asserts:      pb.config.Asserts,
+			sampler:      newAssertSampler(pb.config.AssertSampleRate),
 			maxRequests:  pb.config.Requests,
 			cancel:       cancel,
+			cookieJars:   cookieJars,
+			auth:         provider,
+			phase:        phase,
 		}),
 	)
 
@@ -482,12 +617,20 @@ func (pb *PulseBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 
 	address := net.JoinHostPort(pb.target.Hostname(), port)
 
+	// 运行期间新增的连接同样注册到事件循环
+	control.attach(results, phase, func() {
+		go dialPulseConn(loop, pb.config.DialGuard, address, &errorCount, results)
+	})
+	if liveUI != nil {
+		liveUI.SetController(control)
+	}
+
 	// 启动采样 goroutine，每秒记录请求数和更新 UI（在连接建立之前启动）
-	samplingDone := StartSampling(testCtx, cancel, &requestCount, &errorCount, results, liveUI, nil, startTime)
+	samplingDone := StartSampling(testCtx, cancel, &requestCount, &errorCount, results, liveUI, nil, phase, newAbortBreaker(pb.config.Abort), &pb.runHooks)
 
 	// 创建多个连接（不输出日志，避免破坏 UI）
 	for i := 0; i < pb.config.Connections; i++ {
-		conn, err := net.Dial("tcp", address)
+		conn, err := pb.config.DialGuard.Dial(context.Background(), net.Dialer{}, "tcp", address)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
 		}
@@ -508,7 +651,7 @@ func (pb *PulseBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 	results.TotalRequests = atomic.LoadInt64(&requestCount)
 	results.TotalErrors = atomic.LoadInt64(&errorCount)
 	// 使用实际运行时间，而不是配置的时间（支持提前中断）
-	results.Duration = time.Since(startTime)
+	results.Duration = time.Since(phase.measureStart())
 
 	return results, nil
 }
@@ -517,26 +660,40 @@ func (pb *PulseBenchmark) Run(ctx context.Context) (*stats.Results, error) {
 func (pb *PulseBenchmarkMulti) Run(ctx context.Context) (*stats.Results, error) {
 	results := stats.NewResults()
 
-	startTime := time.Now()
+	// 记录开始时间，预热结束后测量阶段重新计时
+	phase := newRunPhase(pb.config.Warmup)
 
-	// 创建测试上下文
-	testCtx, cancel := context.WithTimeout(ctx, pb.config.Duration)
+	// 创建测试上下文，预热时间不计入测试时间
+	testCtx, cancel := context.WithTimeout(ctx, pb.config.Warmup+pb.config.Duration)
 	defer cancel()
 
 	var requestCount int64
 	var errorCount int64
 
-	// 创建 Uber 限流器（所有连接共享，与 NetHTTPBenchmark 行为一致）
-	var limiter ratelimit.Limiter
-	if pb.config.Rate > 0 {
-		limiter = ratelimit.New(pb.config.Rate)
+	cookieJars, err := newCookieJars(pb.config)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := auth.New(pb.config.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// 预先编码请求报文
+	wire, err := encodeRequests(pb.requestPool.requests, cookieJars != nil)
+	if err != nil {
+		return nil, err
 	}
 
+	// 运行时控制，限流器由所有连接共享（与 NetHTTPBenchmark 行为一致）
+	control := pb.controller(pb.config)
+
 	// 创建 Live UI（如果启用）
 	var liveUI *LiveUI
 	var uiErr error
 	if pb.config.LiveUI {
-		liveUI, uiErr = NewLiveUIWithTheme(pb.config.Duration, pb.config.UITheme)
+		liveUI, uiErr = NewLiveUIWithTheme(pb.config.Warmup+pb.config.Duration, pb.config.UITheme)
 		if uiErr != nil {
 			// 如果 UI 初始化失败，继续运行但不显示 UI
 			pb.config.LiveUI = false
@@ -554,14 +711,20 @@ func (pb *PulseBenchmarkMulti) Run(ctx context.Context) (*stats.Results, error)
 		pulse.WithCallback(&HTTPClientHandler{
 			request:      nil,
 			requestPool:  pb.requestPool,
+			wire:         wire,
 			requestCount: &requestCount,
 			errorCount:   &errorCount,
 			results:      results,
-			maxBodySize:  1 << 20, // 1MB 限制
-			rateLimiter:  limiter,
+			maxBodySize:  pb.config.GetMaxBodySize(),
+			control:      control,
+			ctx:          testCtx,
 			asserts:      pb.config.Asserts,
+			sampler:      newAssertSampler(pb.config.AssertSampleRate),
 			maxRequests:  pb.config.Requests,
 			cancel:       cancel,
+			cookieJars:   cookieJars,
+			auth:         provider,
+			phase:        phase,
 		}),
 	)
 
@@ -582,12 +745,20 @@ func (pb *PulseBenchmarkMulti) Run(ctx context.Context) (*stats.Results, error)
 
 	address := net.JoinHostPort(pb.target.Hostname(), port)
 
+	// 运行期间新增的连接同样注册到事件循环
+	control.attach(results, phase, func() {
+		go dialPulseConn(loop, pb.config.DialGuard, address, &errorCount, results)
+	})
+	if liveUI != nil {
+		liveUI.SetController(control)
+	}
+
 	// 启动采样 goroutine，每秒记录请求数和更新 UI（在连接建立之前启动）
-	samplingDone := StartSampling(testCtx, cancel, &requestCount, &errorCount, results, liveUI, nil, startTime)
+	samplingDone := StartSampling(testCtx, cancel, &requestCount, &errorCount, results, liveUI, pb.requestPool, phase, newAbortBreaker(pb.config.Abort), &pb.runHooks)
 
 	// 创建多个连接（不输出日志，避免破坏 UI）
 	for i := 0; i < pb.config.Connections; i++ {
-		conn, err := net.Dial("tcp", address)
+		conn, err := pb.config.DialGuard.Dial(context.Background(), net.Dialer{}, "tcp", address)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
 		}
@@ -607,7 +778,19 @@ func (pb *PulseBenchmarkMulti) Run(ctx context.Context) (*stats.Results, error)
 	results.TotalRequests = atomic.LoadInt64(&requestCount)
 	results.TotalErrors = atomic.LoadInt64(&errorCount)
 	// 使用实际运行时间，而不是配置的时间（支持提前中断）
-	results.Duration = time.Since(startTime)
+	results.Duration = time.Since(phase.measureStart())
 
 	return results, nil
 }
+
+// dialPulseConn 为运行中的测试新建一个连接并注册到事件循环，失败时记为错误
+func dialPulseConn(loop *pulse.ClientEventLoop, guard config.DialGuard, address string, errorCount *int64, results *stats.Results) {
+	conn, err := guard.Dial(context.Background(), net.Dialer{}, "tcp", address)
+	if err == nil {
+		err = loop.RegisterConn(conn)
+	}
+	if err != nil {
+		atomic.AddInt64(errorCount, 1)
+		results.AddError(err)
+	}
+}
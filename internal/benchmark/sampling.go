@@ -8,8 +8,8 @@ import (
 	"github.com/antlabs/gurl/internal/stats"
 )
 
-// StartSampling 启动采样 goroutine，每秒记录请求数并更新 UI
-// 返回一个 channel，当采样完成时会关闭
+// StartSampling 启动采样 goroutine，每秒记录请求数并更新 UI，预热结束时切换到测量阶段，
+// 熔断条件触发时标记中止原因并取消测试。返回一个 channel，当采样完成时会关闭
 func StartSampling(
 	ctx context.Context,
 	cancel context.CancelFunc,
@@ -18,22 +18,52 @@ func StartSampling(
 	results *stats.Results,
 	liveUI *LiveUI,
 	requestPool *RequestPool,
-	startTime time.Time,
+	phase *runPhase,
+	breaker *abortBreaker,
+	hooks *runHooks,
 ) chan struct{} {
 	samplingDone := make(chan struct{})
 
 	go func() {
-		ticker := time.NewTicker(1 * time.Second)
+		ticker := time.NewTicker(samplingInterval)
 		defer ticker.Stop()
 
 		lastCount := int64(0)
+		lastErrors := int64(0)
+		warmupDone := phase.timer()
 		for {
 			select {
+			case <-warmupDone:
+				// 预热结束，之后的采样和统计只包含测量阶段
+				warmupDone = nil
+				phase.endWarmup(requestCount, errorCount, results)
+				lastCount = 0
+				lastErrors = 0
+				ticker.Reset(samplingInterval)
 			case <-ticker.C:
 				currentCount := atomic.LoadInt64(requestCount)
 				reqThisSecond := currentCount - lastCount
 				results.AddReqPerSecond(reqThisSecond)
 				lastCount = currentCount
+				if hooks != nil {
+					// 不限速时按键降低速率以实际吞吐为起点
+					hooks.control.observe(reqThisSecond)
+				}
+
+				// 记录时间线采样（供 HTML 报告等使用）
+				currentErrors := atomic.LoadInt64(errorCount)
+				sample := results.TakeSample(time.Since(phase.measureStart()), reqThisSecond, currentErrors-lastErrors)
+				lastErrors = currentErrors
+				// 预热阶段的数据不导出给 observer，也不参与熔断判断
+				if !phase.isWarming() {
+					hooks.notifySample(results, sample)
+
+					// 熔断条件触发时中止测试，剩余的收尾由 ctx.Done 分支完成
+					if reason := breaker.check(results, sample); reason != "" {
+						results.Abort(reason)
+						cancel()
+					}
+				}
 
 				// 更新 Live UI
 				if liveUI != nil {
@@ -49,19 +79,19 @@ func StartSampling(
 					//	currentCount, reqThisSecond, avgLatency, statusCodes)
 					
 					liveUI.Update(currentCount, reqThisSecond, statusCodes, avgLatency, minLatency, maxLatency, latencyPercentiles, errors)
+					liveUI.UpdatePanels(results, sample)
 
 					// 如果是多端点模式，更新每个端点的统计
 					if requestPool != nil {
 						endpointStats := results.GetEndpointStats()
-						elapsed := time.Since(startTime)
+						elapsed := time.Since(phase.measureStart())
 						if elapsed == 0 {
 							elapsed = time.Second
 						}
 
-						for url, stats := range endpointStats {
+						for name, stats := range endpointStats {
 							reqPerSec := float64(stats.Requests) / elapsed.Seconds()
-							avgLat := stats.GetAverageLatency()
-							liveUI.UpdateEndpointStats(url, stats.Requests, reqPerSec, avgLat, stats.MinLatency, stats.MaxLatency, stats.Errors)
+							liveUI.UpdateEndpointStats(name, stats, reqPerSec)
 						}
 					}
 
@@ -72,6 +102,10 @@ func StartSampling(
 				currentCount := atomic.LoadInt64(requestCount)
 				if currentCount > lastCount {
 					results.AddReqPerSecond(currentCount - lastCount)
+					sample := results.TakeSample(time.Since(phase.measureStart()), currentCount-lastCount, atomic.LoadInt64(errorCount)-lastErrors)
+					if !phase.isWarming() {
+						hooks.notifySample(results, sample)
+					}
 				}
 				close(samplingDone)
 				return
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/antlabs/gurl/internal/stats"
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
 )
@@ -44,6 +45,10 @@ type ColorTheme struct {
 	LatencyChartLabel  ui.Color
 	LatencyChartNumber ui.Color
 
+	// Trend sparkline colors
+	RPSLine ui.Color
+	P99Line ui.Color
+
 	// Help text color
 	Help ui.Color
 }
@@ -60,6 +65,7 @@ type LiveUI struct {
 	latencyChart  *widgets.BarChart
 	endpointTable *widgets.Table // 新增：端点统计表格
 	helpText      *widgets.Paragraph
+	livePanels                   // 趋势、状态码时间线、直方图、错误流和端点详情
 
 	// Data
 	totalRequests int64
@@ -75,7 +81,9 @@ type LiveUI struct {
 	theme *ColorTheme
 
 	// Control
-	stopChan chan struct{}
+	stopChan  chan struct{}
+	control   *Controller // 运行时控制，未设置时只响应退出按键
+	helpColor string
 }
 
 // EndpointLiveStats holds live statistics for a single endpoint
@@ -83,11 +91,19 @@ type EndpointLiveStats struct {
 	URL        string
 	Requests   int64
 	ReqPerSec  float64
+	Share      float64 // 该端点占全部请求的比例
 	AvgLatency time.Duration
 	MinLatency time.Duration
 	MaxLatency time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
 	Errors     int64
 	LastUpdate time.Time
+
+	Target       string // 请求的 URL，URL 字段是名称时用于详情显示
+	StatusCodes  map[int]int64
+	ErrorClasses map[string]int64
 }
 
 // DarkTheme returns a color theme optimized for dark terminal backgrounds
@@ -119,6 +135,9 @@ func DarkTheme() *ColorTheme {
 		LatencyChartLabel:  ui.ColorWhite,
 		LatencyChartNumber: ui.ColorCyan,
 
+		RPSLine: ui.ColorGreen,
+		P99Line: ui.ColorMagenta,
+
 		Help: ui.ColorYellow,
 	}
 }
@@ -163,6 +182,9 @@ func LightTheme() *ColorTheme {
 		LatencyChartLabel:  238, // 深灰标签 (#444444) - 清晰可读
 		LatencyChartNumber: 238, // 深灰数字 (#444444) - 清晰可读
 
+		RPSLine: 64,  // 绿色吞吐趋势 (#008700) - 与请求柱状图一致
+		P99Line: 166, // 橙色 p99 趋势 (#df5f00) - 与延迟柱状图一致
+
 		Help: 172, // 深橙帮助文本 (#d75f00) - 警示引导
 	}
 }
@@ -300,26 +322,22 @@ func NewLiveUIWithTheme(duration time.Duration, themeName string) (*LiveUI, erro
 	// Progress gauge
 	liveUI.progressGauge = widgets.NewGauge()
 	liveUI.progressGauge.Title = "Progress"
-	liveUI.progressGauge.SetRect(0, 0, 100, 3)
 	liveUI.progressGauge.BarColor = theme.ProgressBar
 	liveUI.progressGauge.BorderStyle.Fg = theme.Border
 
 	// Stats table
 	liveUI.statsTable = widgets.NewParagraph()
 	liveUI.statsTable.Title = "Stats for last sec"
-	liveUI.statsTable.SetRect(0, 3, 50, 12)
 	liveUI.statsTable.BorderStyle.Fg = theme.Border
 
 	// Status code table
 	liveUI.statusTable = widgets.NewParagraph()
 	liveUI.statusTable.Title = "Status code distribution"
-	liveUI.statusTable.SetRect(50, 3, 100, 12)
 	liveUI.statusTable.BorderStyle.Fg = theme.Border
 
 	// Request chart
 	liveUI.reqChart = widgets.NewBarChart()
 	liveUI.reqChart.Title = "Requests / past sec (auto)"
-	liveUI.reqChart.SetRect(0, 12, 50, 30)
 	liveUI.reqChart.BarWidth = 6 // 减小宽度以留出更多空间给数字
 	liveUI.reqChart.BarGap = 2   // 增加柱子间隔以避免数字重叠
 	liveUI.reqChart.BarColors = []ui.Color{theme.ReqChartBar}
@@ -331,7 +349,6 @@ func NewLiveUIWithTheme(duration time.Duration, themeName string) (*LiveUI, erro
 	// Latency chart
 	liveUI.latencyChart = widgets.NewBarChart()
 	liveUI.latencyChart.Title = "Response time histogram (ms)"
-	liveUI.latencyChart.SetRect(50, 12, 100, 30)
 	liveUI.latencyChart.BarWidth = 7 // 增加宽度
 	liveUI.latencyChart.BarGap = 1   // 柱子间隔
 	liveUI.latencyChart.BarColors = []ui.Color{theme.LatencyChartBar}
@@ -342,7 +359,6 @@ func NewLiveUIWithTheme(duration time.Duration, themeName string) (*LiveUI, erro
 	// Endpoint table (initially hidden, shown when multi-endpoint mode)
 	liveUI.endpointTable = widgets.NewTable()
 	liveUI.endpointTable.Title = "Per-Endpoint Statistics (live)"
-	liveUI.endpointTable.SetRect(0, 30, 100, 42)
 	liveUI.endpointTable.TextStyle = ui.NewStyle(theme.Text)
 	liveUI.endpointTable.BorderStyle.Fg = theme.Border
 	liveUI.endpointTable.RowSeparator = false
@@ -355,8 +371,12 @@ func NewLiveUIWithTheme(duration time.Duration, themeName string) (*LiveUI, erro
 		// Paper主题使用深橙色 (172) 作为帮助文本
 		helpColorName = "172"
 	}
-	liveUI.helpText.Text = fmt.Sprintf("[Press 'q' or 'Ctrl+C' to stop test early](fg:%s,mod:bold)", helpColorName)
-	liveUI.helpText.SetRect(0, 42, 100, 45)
+	liveUI.helpColor = helpColorName
+
+	// 扩展面板，按终端大小排列
+	liveUI.livePanels = newLivePanels(theme)
+	liveUI.layout()
+	liveUI.updateHelp(nil)
 	liveUI.helpText.Border = false
 	liveUI.helpText.TextStyle.Fg = theme.Help
 
@@ -497,24 +517,36 @@ func (l *LiveUI) Update(requests int64, reqPerSec int64, statusCodes map[int]int
 	}
 }
 
-// UpdateEndpointStats updates statistics for a specific endpoint
-func (l *LiveUI) UpdateEndpointStats(url string, requests int64, reqPerSec float64, avgLatency, minLatency, maxLatency time.Duration, errors int64) {
+// UpdateEndpointStats updates statistics for a specific endpoint; name is the
+// curl file label or the URL
+func (l *LiveUI) UpdateEndpointStats(name string, ep *stats.EndpointStats, reqPerSec float64) {
+	percentiles := ep.GetLatencyPercentiles()
+	avgLatency := ep.GetAverageLatency()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if l.endpointStats[url] == nil {
-		l.endpointStats[url] = &EndpointLiveStats{URL: url}
+	if l.endpointStats[name] == nil {
+		l.endpointStats[name] = &EndpointLiveStats{URL: name}
 		l.multiEndpoint = true // 启用多端点模式
+		l.layout()             // 端点表格的高度随端点数变化
 	}
 
-	stats := l.endpointStats[url]
-	stats.Requests = requests
+	stats := l.endpointStats[name]
+	stats.Requests = ep.Requests
 	stats.ReqPerSec = reqPerSec
+	stats.Share = ep.Share
 	stats.AvgLatency = avgLatency
-	stats.MinLatency = minLatency
-	stats.MaxLatency = maxLatency
-	stats.Errors = errors
+	stats.MinLatency = ep.MinLatency
+	stats.MaxLatency = ep.MaxLatency
+	stats.P50 = percentiles[50]
+	stats.P90 = percentiles[90]
+	stats.P99 = percentiles[99]
+	stats.Errors = ep.Errors
 	stats.LastUpdate = time.Now()
+	stats.Target = ep.URL
+	stats.StatusCodes = ep.StatusCodes
+	stats.ErrorClasses = ep.ErrorClasses
 
 	// 更新端点表格
 	l.updateEndpointTable()
@@ -528,14 +560,16 @@ func (l *LiveUI) updateEndpointTable() {
 
 	// 表头
 	rows := [][]string{
-		{"Endpoint", "Req/s", "Avg", "Min", "Max", "Errors"},
+		{"Endpoint", "Share", "Req/s", "Avg", "p50", "p90", "p99", "Max", "Errors"},
 	}
 
-	// 按 URL 排序
+	// 按选中的列排序，默认按名称或 URL
 	urls := make([]string, 0, len(l.endpointStats))
 	for url := range l.endpointStats {
 		urls = append(urls, url)
 	}
+	l.sortEndpoints(urls)
+	l.endpointOrder = urls
 
 	// 添加每个端点的数据
 	for _, url := range urls {
@@ -552,21 +586,33 @@ func (l *LiveUI) updateEndpointTable() {
 
 		// 缩短 URL 显示
 		displayURL := url
-		if len(displayURL) > 40 {
-			displayURL = displayURL[:37] + "..."
+		if len(displayURL) > 30 {
+			displayURL = displayURL[:27] + "..."
 		}
 
 		rows = append(rows, []string{
 			displayURL,
+			fmt.Sprintf("%.1f%%", stats.Share*100),
 			fmt.Sprintf("%.1f", stats.ReqPerSec),
 			formatDurationShort(stats.AvgLatency),
-			formatDurationShort(stats.MinLatency),
+			formatDurationShort(stats.P50),
+			formatDurationShort(stats.P90),
+			formatDurationShort(stats.P99),
 			formatDurationShort(stats.MaxLatency),
 			errorRate,
 		})
 	}
 
 	l.endpointTable.Rows = rows
+	l.endpointTable.Title = l.endpointTableTitle()
+
+	// 高亮选中的端点（第 0 行是表头）
+	l.endpointTable.RowStyles = map[int]ui.Style{
+		l.selected + 1: ui.NewStyle(l.theme.Title, ui.ColorClear, ui.ModifierReverse),
+	}
+	if l.drillDown {
+		l.updateEndpointDetail()
+	}
 }
 
 // formatDurationShort formats duration in a short format
@@ -596,13 +642,19 @@ func (l *LiveUI) Render() {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
+	items := []ui.Drawable{
+		l.progressGauge, l.statsTable, l.statusTable, l.reqChart, l.latencyChart,
+		l.trendLines, l.statusTimeline, l.latencyHist, l.errorFeed, l.helpText,
+	}
 	if l.multiEndpoint {
-		// 多端点模式：显示端点表格
-		ui.Render(l.progressGauge, l.statsTable, l.statusTable, l.reqChart, l.latencyChart, l.endpointTable, l.helpText)
-	} else {
-		// 单端点模式：不显示端点表格
-		ui.Render(l.progressGauge, l.statsTable, l.statusTable, l.reqChart, l.latencyChart, l.helpText)
+		// 多端点模式：显示端点表格，或者选中端点的详情
+		if l.drillDown {
+			items = append(items, l.endpointDetail)
+		} else {
+			items = append(items, l.endpointTable)
+		}
 	}
+	ui.Render(items...)
 }
 
 // handleKeyEvents handles keyboard events
@@ -616,6 +668,23 @@ func (l *LiveUI) handleKeyEvents() {
 				// 用户按下 'q' 或 Ctrl+C，触发停止
 				close(l.stopChan)
 				return
+			case "p", "<Space>":
+				l.adjust(func(c *Controller) error {
+					c.TogglePause()
+					return nil
+				})
+			case "+", "=", "<Up>":
+				l.adjust(func(c *Controller) error { return c.StepRate(true) })
+			case "-", "<Down>":
+				l.adjust(func(c *Controller) error { return c.StepRate(false) })
+			case "]", "<Right>":
+				l.adjust(func(c *Controller) error { return c.StepConnections(true) })
+			case "[", "<Left>":
+				l.adjust(func(c *Controller) error { return c.StepConnections(false) })
+			case "<Resize>":
+				l.relayout()
+			default:
+				l.handleEndpointKey(e.ID)
 			}
 		case <-l.stopChan:
 			return
@@ -623,6 +692,51 @@ func (l *LiveUI) handleKeyEvents() {
 	}
 }
 
+// SetController enables the runtime control keys
+func (l *LiveUI) SetController(c *Controller) {
+	l.mu.Lock()
+	l.control = c
+	l.mu.Unlock()
+	l.updateHelp(nil)
+	l.Render()
+}
+
+// adjust applies a key press to the controller and refreshes the help line
+func (l *LiveUI) adjust(apply func(c *Controller) error) {
+	l.mu.RLock()
+	c := l.control
+	l.mu.RUnlock()
+	if c == nil {
+		return
+	}
+	l.updateHelp(apply(c))
+	l.Render()
+}
+
+// updateHelp 刷新帮助文本：可用按键、当前的负载参数以及上一次调整的错误
+func (l *LiveUI) updateHelp(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.control == nil {
+		l.helpText.Text = fmt.Sprintf("[Press 'q' or 'Ctrl+C' to stop test early](fg:%s,mod:bold)", l.helpColor)
+		return
+	}
+
+	state := l.control.State()
+	status := "running"
+	if state.Paused {
+		status = "PAUSED"
+	}
+	text := fmt.Sprintf("[q: stop  p: pause/resume  +/-: rate  ]/[: connections](fg:%s,mod:bold)\n"+
+		"[%s](fg:%s,mod:bold)  rate: %s  connections: %d",
+		l.helpColor, status, l.getColorName(l.theme.ReqSec), formatRate(state.Rate), state.Connections)
+	if err != nil {
+		text += fmt.Sprintf("  [%v](fg:%s)", err, l.getColorName(l.theme.Error))
+	}
+	l.helpText.Text = text
+}
+
 // StopChan returns the stop channel
 func (l *LiveUI) StopChan() <-chan struct{} {
 	return l.stopChan
@@ -0,0 +1,418 @@
+package benchmark
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// 扩展面板的尺寸和历史长度
+const (
+	trendHistory  = 300 // 趋势图最多保留的采样数，实际显示的数量取决于面板宽度
+	histogramBars = 8   // 延迟直方图的柱数
+	errorFeedSize = 8   // 错误流显示的不同错误数
+	minUIWidth    = 80
+	minUIHeight   = 36
+)
+
+// endpointSortKey 是端点表格的排序列
+type endpointSortKey int
+
+const (
+	sortByName endpointSortKey = iota
+	sortByShare
+	sortByRPS
+	sortByP99
+	sortByErrors
+	endpointSortKeys // 排序列的数量
+)
+
+func (k endpointSortKey) String() string {
+	return [...]string{"name", "share", "req/s", "p99", "errors"}[k]
+}
+
+// livePanels 是 LiveUI 的扩展面板及其状态，内嵌在 LiveUI 中，由 LiveUI.mu 保护
+type livePanels struct {
+	trendLines     *widgets.SparklineGroup // RPS 和 p99 的趋势
+	rpsLine        *widgets.Sparkline
+	p99Line        *widgets.Sparkline
+	statusTimeline *widgets.StackedBarChart // 每秒的状态码分布
+	latencyHist    *widgets.BarChart        // 延迟直方图
+	errorFeed      *widgets.List            // 最近出现的不同错误
+	endpointDetail *widgets.Paragraph       // 单个端点的详情
+
+	rpsHistory    []float64
+	p99History    []float64
+	statusHistory [][]float64 // 每秒的 2xx/3xx/4xx/5xx/错误 数量
+	statusLabels  []string
+
+	sortBy        endpointSortKey
+	sortAsc       bool
+	selected      int      // 表格中选中的行，不含表头
+	endpointOrder []string // 表格当前的行顺序
+	drillDown     bool     // 用详情替换端点表格
+}
+
+// newLivePanels 按主题创建扩展面板，位置由 layout 设置
+func newLivePanels(theme *ColorTheme) livePanels {
+	p := livePanels{sortBy: sortByName, sortAsc: true}
+
+	p.rpsLine = widgets.NewSparkline()
+	p.rpsLine.LineColor = theme.RPSLine
+	p.rpsLine.TitleStyle = ui.NewStyle(theme.Text)
+	p.p99Line = widgets.NewSparkline()
+	p.p99Line.LineColor = theme.P99Line
+	p.p99Line.TitleStyle = ui.NewStyle(theme.Text)
+	p.trendLines = widgets.NewSparklineGroup(p.rpsLine, p.p99Line)
+	p.trendLines.Title = "Trend"
+	p.trendLines.BorderStyle.Fg = theme.Border
+
+	p.statusTimeline = widgets.NewStackedBarChart()
+	p.statusTimeline.Title = "Status / sec (2xx 3xx 4xx 5xx errors, bottom up)"
+	p.statusTimeline.BarColors = []ui.Color{theme.Status2xx, theme.Status3xx, theme.Status4xx, theme.Status5xx, theme.Error}
+	p.statusTimeline.LabelStyles = []ui.Style{ui.NewStyle(theme.ReqChartLabel)}
+	p.statusTimeline.NumFormatter = func(float64) string { return "" } // 数字放不下，只看比例
+	p.statusTimeline.BarWidth = 3
+	p.statusTimeline.BarGap = 1
+	p.statusTimeline.BorderStyle.Fg = theme.Border
+
+	p.latencyHist = widgets.NewBarChart()
+	p.latencyHist.Title = "Latency histogram (bucket upper bound)"
+	p.latencyHist.BarGap = 1
+	p.latencyHist.BarColors = []ui.Color{theme.LatencyChartBar}
+	p.latencyHist.LabelStyles = []ui.Style{ui.NewStyle(theme.LatencyChartLabel)}
+	p.latencyHist.NumStyles = []ui.Style{ui.NewStyle(theme.LatencyChartNumber)}
+	p.latencyHist.NumFormatter = formatNumber
+	p.latencyHist.BorderStyle.Fg = theme.Border
+
+	p.errorFeed = widgets.NewList()
+	p.errorFeed.Title = "Latest errors"
+	p.errorFeed.TextStyle = ui.NewStyle(theme.Text)
+	p.errorFeed.SelectedRowStyle = ui.NewStyle(theme.Text) // 只展示，不高亮
+	p.errorFeed.BorderStyle.Fg = theme.Border
+
+	p.endpointDetail = widgets.NewParagraph()
+	p.endpointDetail.TextStyle = ui.NewStyle(theme.Text)
+	p.endpointDetail.BorderStyle.Fg = theme.Border
+	return p
+}
+
+// layout 按终端大小排列所有面板，调用方持有 l.mu 或者 UI 尚未启动
+func (l *LiveUI) layout() {
+	w, h := ui.TerminalDimensions()
+	w = max(w, minUIWidth)
+	h = max(h, minUIHeight)
+	half := w / 2
+	helpHeight := 3
+
+	l.progressGauge.SetRect(0, 0, w, 3)
+	l.statsTable.SetRect(0, 3, half, 11)
+	l.statusTable.SetRect(half, 3, w, 11)
+	l.helpText.SetRect(0, h-helpHeight, w, h)
+
+	top, bottom := 11, h-helpHeight
+	if l.multiEndpoint {
+		// 端点表格最多占剩余高度的三分之一，但要放得下端点详情的 5 行
+		tableHeight := max(min(len(l.endpointStats)+3, (bottom-top)/3), 7)
+		l.endpointTable.SetRect(0, bottom-tableHeight, w, bottom)
+		l.endpointDetail.SetRect(0, bottom-tableHeight, w, bottom)
+		bottom -= tableHeight
+	}
+
+	rowHeight := (bottom - top) / 3
+	l.reqChart.SetRect(0, top, half, top+rowHeight)
+	l.latencyChart.SetRect(half, top, w, top+rowHeight)
+	l.trendLines.SetRect(0, top+rowHeight, half, top+2*rowHeight)
+	l.statusTimeline.SetRect(half, top+rowHeight, w, top+2*rowHeight)
+	l.latencyHist.SetRect(0, top+2*rowHeight, half, bottom)
+	l.errorFeed.SetRect(half, top+2*rowHeight, w, bottom)
+
+	// 直方图的柱宽随面板宽度调整，标签至少需要 5 列
+	l.latencyHist.BarWidth = max((l.latencyHist.Inner.Dx()/histogramBars)-l.latencyHist.BarGap, 5)
+	l.fitPanels()
+}
+
+// relayout 在终端大小变化时重新排列并清屏重绘
+func (l *LiveUI) relayout() {
+	l.mu.Lock()
+	l.layout()
+	l.mu.Unlock()
+	ui.Clear()
+	l.Render()
+}
+
+// UpdatePanels 用一次采样刷新扩展面板：趋势、状态码时间线、延迟直方图和错误流
+func (l *LiveUI) UpdatePanels(results *stats.Results, sample stats.Sample) {
+	histogram := results.GetLatencyHistogram(histogramBars)
+	recent := results.GetRecentErrors(errorFeedSize)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rpsHistory = appendTrend(l.rpsHistory, float64(sample.Requests))
+	l.p99History = appendTrend(l.p99History, float64(sample.P99)/float64(time.Millisecond))
+
+	var stack [5]float64
+	for code, count := range sample.StatusCodes {
+		switch {
+		case code < 300:
+			stack[0] += float64(count)
+		case code < 400:
+			stack[1] += float64(count)
+		case code < 500:
+			stack[2] += float64(count)
+		default:
+			stack[3] += float64(count)
+		}
+	}
+	stack[4] = float64(sample.Errors)
+	l.statusHistory = append(l.statusHistory, stack[:])
+	l.statusLabels = append(l.statusLabels, fmt.Sprintf("%d", int(sample.Elapsed.Round(time.Second).Seconds())))
+	if len(l.statusHistory) > trendHistory {
+		l.statusHistory = l.statusHistory[1:]
+		l.statusLabels = l.statusLabels[1:]
+	}
+
+	data := make([]float64, len(histogram))
+	labels := make([]string, len(histogram))
+	for i, b := range histogram {
+		data[i] = float64(b.Count)
+		labels[i] = formatDurationTiny(b.Upper)
+	}
+	l.latencyHist.Data = data
+	l.latencyHist.Labels = labels
+
+	errorColor := l.getColorName(l.theme.Error)
+	rows := make([]string, 0, len(recent))
+	for _, e := range recent {
+		ago := time.Since(e.Last).Round(time.Second)
+		rows = append(rows, fmt.Sprintf("[%-7s](fg:%s) %5sx %4s ago  %s",
+			e.Class, errorColor, formatNumber(float64(e.Count)), ago, sanitizeStyled(e.Message)))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, fmt.Sprintf("[No errors](fg:%s)", l.getColorName(l.theme.Fastest)))
+	}
+	l.errorFeed.Rows = rows
+
+	l.fitPanels()
+}
+
+// fitPanels 按面板宽度截取趋势和时间线的最近一段，调用方持有 l.mu
+func (l *LiveUI) fitPanels() {
+	width := l.trendLines.Inner.Dx()
+	l.rpsLine.Data = tail(l.rpsHistory, width)
+	l.p99Line.Data = tail(l.p99History, width)
+	rpsMax, p99Max := maxOf(l.rpsLine.Data), maxOf(l.p99Line.Data)
+	if n := len(l.rpsLine.Data); n > 0 {
+		l.rpsLine.Title = fmt.Sprintf("Req/s  now %s  max %s", formatNumber(l.rpsLine.Data[n-1]), formatNumber(rpsMax))
+		l.p99Line.Title = fmt.Sprintf("p99    now %.2fms  max %.2fms", l.p99Line.Data[n-1], p99Max)
+	}
+	// 全为 0 时 termui 会除以 0
+	l.rpsLine.MaxVal = nonZero(rpsMax)
+	l.p99Line.MaxVal = nonZero(p99Max)
+
+	bars := l.statusTimeline.Inner.Dx() / (l.statusTimeline.BarWidth + l.statusTimeline.BarGap)
+	start := max(len(l.statusHistory)-bars, 0)
+	l.statusTimeline.Data = l.statusHistory[start:]
+	l.statusTimeline.Labels = l.statusLabels[start:]
+	var stackMax float64
+	for _, stack := range l.statusTimeline.Data {
+		var sum float64
+		for _, v := range stack {
+			sum += v
+		}
+		stackMax = max(stackMax, sum)
+	}
+	l.statusTimeline.MaxVal = nonZero(stackMax)
+}
+
+// sortEndpoints 按当前排序列排列端点，同值时按名称排序，调用方持有 l.mu
+func (l *LiveUI) sortEndpoints(names []string) {
+	value := func(s *EndpointLiveStats) float64 {
+		switch l.sortBy {
+		case sortByShare:
+			return s.Share
+		case sortByRPS:
+			return s.ReqPerSec
+		case sortByP99:
+			return float64(s.P99)
+		case sortByErrors:
+			return float64(s.Errors)
+		}
+		return 0
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := value(l.endpointStats[names[i]]), value(l.endpointStats[names[j]])
+		if a != b {
+			if l.sortAsc {
+				return a < b
+			}
+			return a > b
+		}
+		if l.sortBy == sortByName && !l.sortAsc {
+			return names[i] > names[j]
+		}
+		return names[i] < names[j]
+	})
+}
+
+// endpointTableTitle 描述排序方式和可用按键
+func (l *LiveUI) endpointTableTitle() string {
+	order := "desc"
+	if l.sortAsc {
+		order = "asc"
+	}
+	return fmt.Sprintf("Per-Endpoint Statistics - sort: %s %s (s sort, r reverse, j/k select, Enter details)", l.sortBy, order)
+}
+
+// handleEndpointKey 处理端点表格的按键，返回 true 表示按键已处理
+func (l *LiveUI) handleEndpointKey(key string) bool {
+	l.mu.Lock()
+	if !l.multiEndpoint {
+		l.mu.Unlock()
+		return false
+	}
+
+	clear := false
+	switch key {
+	case "s":
+		l.sortBy = (l.sortBy + 1) % endpointSortKeys
+		// 名称默认升序，数值默认降序，最大的排在最上面
+		l.sortAsc = l.sortBy == sortByName
+	case "r":
+		l.sortAsc = !l.sortAsc
+	case "j":
+		if l.selected < len(l.endpointOrder)-1 {
+			l.selected++
+		}
+	case "k":
+		l.selected = max(l.selected-1, 0)
+	case "<Enter>":
+		l.drillDown = !l.drillDown
+		clear = true
+	case "<Escape>":
+		clear = l.drillDown
+		l.drillDown = false
+	default:
+		l.mu.Unlock()
+		return false
+	}
+	l.updateEndpointTable()
+	l.mu.Unlock()
+
+	if clear {
+		// 表格和详情共用一块区域，切换时先清屏
+		ui.Clear()
+	}
+	l.Render()
+	return true
+}
+
+// updateEndpointDetail 刷新选中端点的详情，调用方持有 l.mu
+func (l *LiveUI) updateEndpointDetail() {
+	if l.selected >= len(l.endpointOrder) {
+		return
+	}
+	name := l.endpointOrder[l.selected]
+	s := l.endpointStats[name]
+	l.endpointDetail.Title = fmt.Sprintf("Endpoint %s - Esc: back  j/k: previous/next endpoint", name)
+
+	errorRate := 0.0
+	if s.Requests > 0 {
+		errorRate = float64(s.Errors) / float64(s.Requests) * 100
+	}
+
+	var b strings.Builder
+	if s.Target != "" && s.Target != name {
+		fmt.Fprintf(&b, "URL:      %s\n", s.Target)
+	}
+	fmt.Fprintf(&b, "Requests: %d (%.1f%% of traffic)  Req/s: %.1f  Errors: %d (%.2f%%)\n",
+		s.Requests, s.Share*100, s.ReqPerSec, s.Errors, errorRate)
+	fmt.Fprintf(&b, "Latency:  min %s  avg %s  p50 %s  p90 %s  p99 %s  max %s\n",
+		formatDurationShort(s.MinLatency), formatDurationShort(s.AvgLatency), formatDurationShort(s.P50),
+		formatDurationShort(s.P90), formatDurationShort(s.P99), formatDurationShort(s.MaxLatency))
+
+	codes := make([]int, 0, len(s.StatusCodes))
+	for code := range s.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		parts = append(parts, fmt.Sprintf("%d=%d", code, s.StatusCodes[code]))
+	}
+	fmt.Fprintf(&b, "Status:   %s\n", strings.Join(parts, "  "))
+
+	classes := make([]string, 0, len(s.ErrorClasses))
+	for class := range s.ErrorClasses {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	parts = parts[:0]
+	for _, class := range classes {
+		parts = append(parts, fmt.Sprintf("%s=%d", class, s.ErrorClasses[class]))
+	}
+	if len(parts) > 0 {
+		fmt.Fprintf(&b, "Errors:   [%s](fg:%s)\n", strings.Join(parts, "  "), l.getColorName(l.theme.Error))
+	}
+	l.endpointDetail.Text = b.String()
+}
+
+// appendTrend 追加一个趋势采样，最多保留 trendHistory 个
+func appendTrend(history []float64, v float64) []float64 {
+	history = append(history, v)
+	if len(history) > trendHistory {
+		history = history[len(history)-trendHistory:]
+	}
+	return history
+}
+
+// tail 返回最后 n 个元素
+func tail(data []float64, n int) []float64 {
+	if n < 0 || len(data) <= n {
+		return data
+	}
+	return data[len(data)-n:]
+}
+
+// maxOf 返回最大值，空切片返回 0
+func maxOf(data []float64) float64 {
+	m := 0.0
+	for _, v := range data {
+		m = max(m, v)
+	}
+	return m
+}
+
+// nonZero 把 0 替换为 1，用作图表的最大值
+func nonZero(v float64) float64 {
+	if v == 0 {
+		return 1
+	}
+	return v
+}
+
+// formatDurationTiny 把时长格式化为不超过 5 个字符，用作柱状图标签
+func formatDurationTiny(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dus", d.Microseconds())
+	case d < 10*time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < 100*time.Second:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
+// sanitizeStyled 替换方括号，避免错误消息被 termui 当作样式标记解析
+func sanitizeStyled(s string) string {
+	return strings.NewReplacer("[", "(", "]", ")").Replace(s)
+}
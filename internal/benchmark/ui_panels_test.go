@@ -0,0 +1,53 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortEndpoints(t *testing.T) {
+	l := &LiveUI{endpointStats: map[string]*EndpointLiveStats{
+		"b": {P99: 30 * time.Millisecond, Errors: 1},
+		"a": {P99: 10 * time.Millisecond, Errors: 1},
+		"c": {P99: 20 * time.Millisecond, Errors: 5},
+	}}
+
+	for _, tc := range []struct {
+		by   endpointSortKey
+		asc  bool
+		want string
+	}{
+		{sortByName, true, "a,b,c"},
+		{sortByName, false, "c,b,a"},
+		{sortByP99, false, "b,c,a"},
+		{sortByP99, true, "a,c,b"},
+		// 同值时按名称升序
+		{sortByErrors, false, "c,a,b"},
+	} {
+		l.sortBy, l.sortAsc = tc.by, tc.asc
+		names := []string{"c", "a", "b"}
+		l.sortEndpoints(names)
+		if got := strings.Join(names, ","); got != tc.want {
+			t.Errorf("sort by %s asc=%v: got %s, want %s", tc.by, tc.asc, got, tc.want)
+		}
+	}
+}
+
+func TestFormatDurationTiny(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		850 * time.Microsecond:  "850us",
+		3250 * time.Microsecond: "3.2ms",
+		125 * time.Millisecond:  "125ms",
+		2500 * time.Millisecond: "2.5s",
+		150 * time.Second:       "150s",
+	} {
+		got := formatDurationTiny(d)
+		if got != want {
+			t.Errorf("formatDurationTiny(%s) = %q, want %q", d, got, want)
+		}
+		if len(got) > 5 {
+			t.Errorf("formatDurationTiny(%s) = %q is wider than a histogram bar", d, got)
+		}
+	}
+}
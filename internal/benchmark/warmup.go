@@ -0,0 +1,66 @@
+package benchmark
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// runPhase 区分一次运行的预热阶段和测量阶段。
+// 预热期间照常发压，到点后由采样 goroutine 把已收集的数据转移到 results.Warmup
+// 并把计数清零，之后的统计只包含测量阶段。
+// 请求按完成时间归属阶段：切换时仍在途的请求（最多每个连接一个）在测量阶段完成，
+// 计入测量阶段的统计。预热期间的采样也不参与熔断判断。
+type runPhase struct {
+	warmup  time.Duration
+	warming atomic.Bool
+
+	mu    sync.Mutex
+	start time.Time // 测量阶段的开始时间，预热结束前为运行开始时间
+}
+
+// newRunPhase 在运行开始时调用，warmup 为 0 时直接进入测量阶段
+func newRunPhase(warmup time.Duration) *runPhase {
+	p := &runPhase{warmup: warmup, start: time.Now()}
+	p.warming.Store(warmup > 0)
+	return p
+}
+
+// isWarming 返回是否仍处于预热阶段
+func (p *runPhase) isWarming() bool {
+	return p != nil && p.warming.Load()
+}
+
+// measureStart 返回测量阶段的开始时间
+func (p *runPhase) measureStart() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.start
+}
+
+// timer 返回预热结束时触发的 channel，没有预热时返回 nil（永不触发）
+func (p *runPhase) timer() <-chan time.Time {
+	if !p.isWarming() {
+		return nil
+	}
+	return time.After(p.warmup - time.Since(p.measureStart()))
+}
+
+// endWarmup 结束预热：请求数和错误数清零，已收集的数据转移到 results.Warmup
+func (p *runPhase) endWarmup(requestCount, errorCount *int64, results *stats.Results) {
+	if !p.warming.CompareAndSwap(true, false) {
+		return
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(p.start)
+	p.start = now
+	p.mu.Unlock()
+
+	requests := atomic.SwapInt64(requestCount, 0)
+	errors := atomic.SwapInt64(errorCount, 0)
+	results.EndWarmup(requests, errors, elapsed)
+}
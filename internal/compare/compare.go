@@ -1,15 +1,19 @@
 package compare
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/antlabs/gurl/internal/asserts"
+	"github.com/antlabs/gurl/internal/benchmark"
 	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/cookies"
 	"github.com/antlabs/gurl/internal/parser"
 	"github.com/tidwall/gjson"
 )
@@ -27,7 +31,8 @@ type AssertionResult struct {
 
 // RunScenario finds a scenario by name in cfg and executes it.
 // Currently only mode "one_to_one" (or empty) is supported.
-func RunScenario(cfg *config.CompareConfig, scenarioName string) (results []AssertionResult, passed int, failed int, err error) {
+// observers 每秒收到一次场景中请求的采样（例如进度输出），运行结束时收到 OnFinish
+func RunScenario(cfg *config.CompareConfig, scenarioName string, observers ...benchmark.Observer) (results []AssertionResult, passed int, failed int, err error) {
 	var scenario *config.CompareScenario
 	for i := range cfg.Scenarios {
 		if cfg.Scenarios[i].Name == scenarioName {
@@ -44,6 +49,15 @@ func RunScenario(cfg *config.CompareConfig, scenarioName string) (results []Asse
 		mode = "one_to_one"
 	}
 
+	base, target, err := newClients(scenario, cfg.DialGuard)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	s := startSampler(observers)
+	defer s.finish()
+	s.wrap(base)
+	s.wrap(target)
+
 	switch mode {
 	case "one_to_one":
 		if scenario.Base == "" || scenario.Target == "" {
@@ -60,11 +74,11 @@ func RunScenario(cfg *config.CompareConfig, scenarioName string) (results []Asse
 		}
 
 		pairLabel := fmt.Sprintf("%s vs %s", scenario.Base, scenario.Target)
-		baseResp, err := doSingleRequest(baseReqDef.Curl)
+		baseResp, err := doSingleRequest(base, baseReqDef.Curl)
 		if err != nil {
 			return nil, 0, 0, fmt.Errorf("base request '%s' failed: %w", scenario.Base, err)
 		}
-		targetResp, err := doSingleRequest(targetReqDef.Curl)
+		targetResp, err := doSingleRequest(target, targetReqDef.Curl)
 		if err != nil {
 			return nil, 0, 0, fmt.Errorf("target request '%s' failed: %w", scenario.Target, err)
 		}
@@ -93,7 +107,7 @@ func RunScenario(cfg *config.CompareConfig, scenarioName string) (results []Asse
 		}
 
 		// 按设计：base 请求只发送一次，其响应在多个 target 间复用
-		baseResp, err := doSingleRequest(baseReqDef.Curl)
+		baseResp, err := doSingleRequest(base, baseReqDef.Curl)
 		if err != nil {
 			return nil, 0, 0, fmt.Errorf("base request '%s' failed: %w", scenario.Base, err)
 		}
@@ -105,7 +119,7 @@ func RunScenario(cfg *config.CompareConfig, scenarioName string) (results []Asse
 			}
 
 			pairLabel := fmt.Sprintf("%s vs %s", scenario.Base, targetName)
-			targetResp, err := doSingleRequest(targetReqDef.Curl)
+			targetResp, err := doSingleRequest(target, targetReqDef.Curl)
 			if err != nil {
 				return nil, 0, 0, fmt.Errorf("target request '%s' failed: %w", targetName, err)
 			}
@@ -139,11 +153,11 @@ func RunScenario(cfg *config.CompareConfig, scenarioName string) (results []Asse
 			rightReq := rightList[i]
 			pairLabel := fmt.Sprintf("%s vs %s", leftReq.Name, rightReq.Name)
 
-			baseResp, err := doSingleRequest(leftReq.Curl)
+			baseResp, err := doSingleRequest(base, leftReq.Curl)
 			if err != nil {
 				return nil, 0, 0, fmt.Errorf("left request '%s' failed: %w", leftReq.Name, err)
 			}
-			targetResp, err := doSingleRequest(rightReq.Curl)
+			targetResp, err := doSingleRequest(target, rightReq.Curl)
 			if err != nil {
 				return nil, 0, 0, fmt.Errorf("right request '%s' failed: %w", rightReq.Name, err)
 			}
@@ -206,11 +220,11 @@ func RunScenario(cfg *config.CompareConfig, scenarioName string) (results []Asse
 			}
 
 			pairLabel := fmt.Sprintf("%s vs %s (group=%s)", pair.baseReq.Name, pair.targetReq.Name, grp)
-			baseResp, err := doSingleRequest(pair.baseReq.Curl)
+			baseResp, err := doSingleRequest(base, pair.baseReq.Curl)
 			if err != nil {
 				return nil, 0, 0, fmt.Errorf("base request '%s' in group '%s' failed: %w", pair.baseReq.Name, grp, err)
 			}
-			targetResp, err := doSingleRequest(pair.targetReq.Curl)
+			targetResp, err := doSingleRequest(target, pair.targetReq.Curl)
 			if err != nil {
 				return nil, 0, 0, fmt.Errorf("target request '%s' in group '%s' failed: %w", pair.targetReq.Name, grp, err)
 			}
@@ -243,7 +257,46 @@ func findRequestByName(list []config.CompareRequest, name string) (*config.Compa
 	return nil, fmt.Errorf("request '%s' not found", name)
 }
 
-func doSingleRequest(curl string) (*asserts.HTTPResponse, error) {
+// newClients 创建 base 侧和 target 侧使用的客户端。开启 cookie_jar 时保存响应
+// 设置的 cookie，供同一场景中后续的请求使用（例如先登录再访问）：connection
+// 模式两侧各用一个 jar，shared 模式两侧共用一个 jar；
+// 设置了 guard 时所有连接（包括重定向）都先经过 guard 检查
+func newClients(scenario *config.CompareScenario, guard config.DialGuard) (base, target *http.Client, err error) {
+	mode := scenario.GetCookieJar()
+	if err := config.ValidateCookieJar(mode); err != nil {
+		return nil, nil, err
+	}
+
+	base, target = &http.Client{}, &http.Client{}
+	if guard != nil {
+		// 不经过代理直接连接，guard 检查的才是真实的目标地址
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return guard.Dial(ctx, net.Dialer{Timeout: 30 * time.Second}, network, addr)
+		}
+		base.Transport, target.Transport = transport, transport
+	}
+	if mode == config.CookieJarOff {
+		return base, target, nil
+	}
+
+	var file *cookies.File
+	if scenario.CookieFile != "" {
+		if file, err = cookies.Load(scenario.CookieFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if mode == config.CookieJarShared {
+		jar := cookies.NewJar(file)
+		base.Jar, target.Jar = jar, jar
+		return base, target, nil
+	}
+	base.Jar, target.Jar = cookies.NewJar(file), cookies.NewJar(file)
+	return base, target, nil
+}
+
+func doSingleRequest(client *http.Client, curl string) (*asserts.HTTPResponse, error) {
 	if strings.TrimSpace(curl) == "" {
 		return nil, fmt.Errorf("curl command is empty")
 	}
@@ -253,7 +306,6 @@ func doSingleRequest(curl string) (*asserts.HTTPResponse, error) {
 		return nil, fmt.Errorf("failed to parse curl: %w", err)
 	}
 
-	client := &http.Client{}
 	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
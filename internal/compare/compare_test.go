@@ -0,0 +1,37 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+func TestNewClientsCookieJarModes(t *testing.T) {
+	base, target, err := newClients(&config.CompareScenario{}, nil)
+	if err != nil {
+		t.Fatalf("off: %v", err)
+	}
+	if base.Jar != nil || target.Jar != nil {
+		t.Errorf("off: want no jars")
+	}
+
+	base, target, err = newClients(&config.CompareScenario{CookieJar: config.CookieJarConnection}, nil)
+	if err != nil {
+		t.Fatalf("connection: %v", err)
+	}
+	if base.Jar == nil || target.Jar == nil || base.Jar == target.Jar {
+		t.Errorf("connection: want a separate jar per side")
+	}
+
+	base, target, err = newClients(&config.CompareScenario{CookieJar: config.CookieJarShared}, nil)
+	if err != nil {
+		t.Fatalf("shared: %v", err)
+	}
+	if base.Jar == nil || base.Jar != target.Jar {
+		t.Errorf("shared: want one jar for both sides")
+	}
+
+	if _, _, err = newClients(&config.CompareScenario{CookieJar: "true"}, nil); err == nil {
+		t.Errorf("want an error for an unknown cookie jar mode")
+	}
+}
@@ -0,0 +1,116 @@
+package compare
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/antlabs/gurl/internal/benchmark"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// samplingInterval 与压测的采样间隔一致，observer 按整秒计算输出间隔
+const samplingInterval = time.Second
+
+// sampler 记录场景中发出的每个请求，每秒生成一次采样交给 observer，
+// 使 --progress 等输出在对比运行中同样可用
+type sampler struct {
+	results   *stats.Results
+	observers []benchmark.Observer
+	start     time.Time
+
+	requests int64
+	errors   int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startSampler 开始采样；没有 observer 时返回 nil，nil sampler 的方法均为空操作
+func startSampler(observers []benchmark.Observer) *sampler {
+	if len(observers) == 0 {
+		return nil
+	}
+	s := &sampler{
+		results:   stats.NewResults(),
+		observers: observers,
+		start:     time.Now(),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *sampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(samplingInterval)
+	defer ticker.Stop()
+
+	var lastCount, lastErrors int64
+	take := func() {
+		count, errs := atomic.LoadInt64(&s.requests), atomic.LoadInt64(&s.errors)
+		sample := s.results.TakeSample(time.Since(s.start), count-lastCount, errs-lastErrors)
+		lastCount, lastErrors = count, errs
+		for _, o := range s.observers {
+			o.OnSample(s.results, sample)
+		}
+	}
+	for {
+		select {
+		case <-ticker.C:
+			take()
+		case <-s.stop:
+			// 最后一次采样覆盖上次采样之后完成的请求
+			take()
+			return
+		}
+	}
+}
+
+// wrap 让 client 发出的请求都经过采样记录
+func (s *sampler) wrap(client *http.Client) {
+	if s == nil {
+		return
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &sampledTransport{base: base, sampler: s}
+}
+
+// finish 停止采样并通知 observer 运行结束
+func (s *sampler) finish() {
+	if s == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+
+	s.results.TotalRequests = atomic.LoadInt64(&s.requests)
+	s.results.Duration = time.Since(s.start)
+	for _, o := range s.observers {
+		o.OnFinish(s.results)
+	}
+}
+
+// sampledTransport 记录每个请求的延迟、状态码和错误
+type sampledTransport struct {
+	base    http.RoundTripper
+	sampler *sampler
+}
+
+func (t *sampledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	atomic.AddInt64(&t.sampler.requests, 1)
+	if err != nil {
+		atomic.AddInt64(&t.sampler.errors, 1)
+		t.sampler.results.AddError(err)
+		return nil, err
+	}
+	t.sampler.results.AddLatency(time.Since(start))
+	t.sampler.results.AddStatusCode(resp.StatusCode)
+	return resp, nil
+}
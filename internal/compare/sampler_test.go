@@ -0,0 +1,69 @@
+package compare
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+type recordingObserver struct {
+	mu       sync.Mutex
+	requests int64
+	codes    map[int]int64
+	finished *stats.Results
+}
+
+func (o *recordingObserver) OnSample(results *stats.Results, sample stats.Sample) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests += sample.Requests
+	for code, n := range sample.StatusCodes {
+		o.codes[code] += n
+	}
+}
+
+func (o *recordingObserver) OnFinish(results *stats.Results) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished = results
+}
+
+func TestRunScenarioNotifiesObservers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.CompareConfig{
+		Requests: []config.CompareRequest{
+			{Name: "base", Curl: fmt.Sprintf("curl %s/a", srv.URL)},
+			{Name: "target", Curl: fmt.Sprintf("curl %s/b", srv.URL)},
+		},
+		Scenarios: []config.CompareScenario{
+			{Name: "s", Base: "base", Target: "target", ResponseCompare: "status == status"},
+		},
+	}
+
+	obs := &recordingObserver{codes: make(map[int]int64)}
+	_, passed, failed, err := RunScenario(cfg, "s", obs)
+	if err != nil {
+		t.Fatalf("RunScenario: %v", err)
+	}
+	if passed != 1 || failed != 0 {
+		t.Fatalf("passed=%d failed=%d, want 1/0", passed, failed)
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if obs.requests != 2 || obs.codes[200] != 2 {
+		t.Errorf("observer saw %d requests, codes %v; want 2 requests with status 200", obs.requests, obs.codes)
+	}
+	if obs.finished == nil || obs.finished.TotalRequests != 2 {
+		t.Errorf("OnFinish results = %+v, want 2 total requests", obs.finished)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Defaults of the abort conditions
+const (
+	DefaultAbortErrorWindow = 10 * time.Second
+	DefaultAbortP99For      = 10 * time.Second
+)
+
+// AbortConfig holds the circuit breakers that stop a run early when the
+// target is falling over. Every condition is disabled by its zero value.
+type AbortConfig struct {
+	// ErrorRate aborts when more than this percentage of the requests sent
+	// during ErrorWindow (default 10s) failed
+	ErrorRate   float64 `yaml:"error_rate,omitempty" json:"error_rate,omitempty"`
+	ErrorWindow string  `yaml:"error_window,omitempty" json:"error_window,omitempty"`
+
+	// P99 aborts when the p99 latency stays above this value for P99For
+	// (default 10s)
+	P99    string `yaml:"p99,omitempty" json:"p99,omitempty"`
+	P99For string `yaml:"p99_for,omitempty" json:"p99_for,omitempty"`
+
+	// ConnectFailures aborts after this many consecutive connection failures
+	ConnectFailures int `yaml:"connect_failures,omitempty" json:"connect_failures,omitempty"`
+}
+
+// Enabled reports whether any abort condition is set
+func (a *AbortConfig) Enabled() bool {
+	return a != nil && (a.ErrorRate > 0 || a.P99 != "" || a.ConnectFailures > 0)
+}
+
+// GetErrorWindow returns the error rate window, applying the default
+func (a *AbortConfig) GetErrorWindow() time.Duration {
+	return durationOr(a.ErrorWindow, DefaultAbortErrorWindow)
+}
+
+// GetP99 returns the p99 threshold, 0 when the condition is disabled
+func (a *AbortConfig) GetP99() time.Duration {
+	return durationOr(a.P99, 0)
+}
+
+// GetP99For returns how long p99 must stay above the threshold
+func (a *AbortConfig) GetP99For() time.Duration {
+	return durationOr(a.P99For, DefaultAbortP99For)
+}
+
+// Validate checks the abort conditions
+func (a *AbortConfig) Validate() error {
+	if a.ErrorRate < 0 || a.ErrorRate > 100 {
+		return fmt.Errorf("abort error rate must be between 0 and 100")
+	}
+	if a.ConnectFailures < 0 {
+		return fmt.Errorf("abort connect failures cannot be negative")
+	}
+	for name, value := range map[string]string{"error_window": a.ErrorWindow, "p99": a.P99, "p99_for": a.P99For} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid abort %s '%s'", name, value)
+		}
+	}
+	return nil
+}
+
+// durationOr parses s, returning def when s is empty or invalid
+func durationOr(s string, def time.Duration) time.Duration {
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// API server roles, each allowed everything the previous one is
+const (
+	// APIRoleRead may query tasks, results, reports and metrics
+	APIRoleRead = "read"
+	// APIRoleSubmit may also submit tasks, and cancel, control and delete
+	// its own tasks
+	APIRoleSubmit = "submit"
+	// APIRoleAdmin may also cancel, control and delete the tasks of others
+	APIRoleAdmin = "admin"
+)
+
+// APIAccessConfig lists the keys accepted by the API server. Key values may
+// reference environment variables such as ${GURL_CI_KEY}.
+type APIAccessConfig struct {
+	Keys []APIKeyConfig `yaml:"keys" json:"keys"`
+	// AuditLog is the file rejected requests are appended to as JSON lines;
+	// empty logs to stderr
+	AuditLog string `yaml:"audit_log,omitempty" json:"audit_log,omitempty"`
+}
+
+// APIKeyConfig is one key of the API server
+type APIKeyConfig struct {
+	// Name identifies the key in task owners and the audit log
+	Name string `yaml:"name" json:"name"`
+	// Key is sent as "Authorization: Bearer <key>" or "X-API-Key: <key>"
+	Key string `yaml:"key" json:"key"`
+	// Role is read, submit or admin
+	Role string `yaml:"role" json:"role"`
+	// RateLimit caps task submissions, e.g. "10/m"; empty for no limit
+	RateLimit string `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+	// Targets are the hosts the key may load: exact host names, wildcards
+	// such as *.staging.example.com, or CIDRs. Empty allows any host.
+	Targets []string `yaml:"targets,omitempty" json:"targets,omitempty"`
+}
+
+// LoadAPIAccessConfig loads the API keys from a YAML or JSON file
+func LoadAPIAccessConfig(filename string) (*APIAccessConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API access file: %v", err)
+	}
+
+	var cfg APIAccessConfig
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	switch ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	default:
+		return nil, fmt.Errorf("unsupported API access file format: %s (supported: .yaml, .yml, .json)", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse API access file: %v", err)
+	}
+
+	for i := range cfg.Keys {
+		cfg.Keys[i].Key = os.ExpandEnv(cfg.Keys[i].Key)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks the keys of the API access configuration
+func (c *APIAccessConfig) Validate() error {
+	if len(c.Keys) == 0 {
+		return fmt.Errorf("at least one API key is required")
+	}
+
+	names := make(map[string]bool, len(c.Keys))
+	keys := make(map[string]bool, len(c.Keys))
+	for i, k := range c.Keys {
+		if k.Name == "" {
+			return fmt.Errorf("keys[%d]: name is required", i)
+		}
+		if names[k.Name] {
+			return fmt.Errorf("keys[%d]: duplicate name %q", i, k.Name)
+		}
+		names[k.Name] = true
+
+		if k.Key == "" {
+			return fmt.Errorf("key %s: key is required", k.Name)
+		}
+		if keys[k.Key] {
+			return fmt.Errorf("key %s: the key value is used by another key", k.Name)
+		}
+		keys[k.Key] = true
+
+		switch k.Role {
+		case APIRoleRead, APIRoleSubmit, APIRoleAdmin:
+		default:
+			return fmt.Errorf("key %s: invalid role %q (expected %s, %s or %s)", k.Name, k.Role, APIRoleRead, APIRoleSubmit, APIRoleAdmin)
+		}
+
+		if k.RateLimit != "" {
+			if _, _, err := ParseRateLimit(k.RateLimit); err != nil {
+				return fmt.Errorf("key %s: %v", k.Name, err)
+			}
+		}
+		for _, t := range k.Targets {
+			if strings.Contains(t, "/") {
+				if _, _, err := net.ParseCIDR(t); err != nil {
+					return fmt.Errorf("key %s: invalid target %q: %v", k.Name, t, err)
+				}
+			} else if t == "" {
+				return fmt.Errorf("key %s: empty target", k.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseRateLimit parses a rate such as "10/m": a count per second (s),
+// minute (m), hour (h) or duration ("5/30s")
+func ParseRateLimit(s string) (int, time.Duration, error) {
+	countText, perText, ok := strings.Cut(s, "/")
+	count, err := strconv.Atoi(strings.TrimSpace(countText))
+	if !ok || err != nil || count < 1 {
+		return 0, 0, fmt.Errorf("invalid rate limit %q: expected a count per period, e.g. 10/m", s)
+	}
+
+	var per time.Duration
+	switch perText = strings.TrimSpace(perText); perText {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		if per, err = time.ParseDuration(perText); err != nil || per <= 0 {
+			return 0, 0, fmt.Errorf("invalid rate limit %q: the period must be s, m, h or a duration", s)
+		}
+	}
+	return count, per, nil
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported auth provider types
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthOAuth2 = "oauth2"
+	AuthHMAC   = "hmac"
+	AuthSigV4  = "sigv4"
+)
+
+// DefaultAuthRefreshBefore is how long before expiry an OAuth2 token is refreshed
+const DefaultAuthRefreshBefore = 60 * time.Second
+
+// AuthConfig describes how credentials are added to every request of a run.
+// Credential values may reference environment variables such as ${API_TOKEN}.
+type AuthConfig struct {
+	// Type is one of basic, bearer, oauth2, hmac or sigv4
+	Type string `yaml:"type" json:"type"`
+
+	// basic
+	Username string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password string `yaml:"password,omitempty" json:"password,omitempty"`
+
+	// bearer
+	Token string `yaml:"token,omitempty" json:"token,omitempty"`
+
+	// oauth2 client credentials
+	TokenURL     string            `yaml:"token_url,omitempty" json:"token_url,omitempty"`
+	ClientID     string            `yaml:"client_id,omitempty" json:"client_id,omitempty"`
+	ClientSecret string            `yaml:"client_secret,omitempty" json:"client_secret,omitempty"`
+	Scopes       []string          `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+	Params       map[string]string `yaml:"params,omitempty" json:"params,omitempty"` // extra token request parameters, e.g. audience
+	// AuthStyle sends the client credentials as HTTP basic auth ("header",
+	// the default) or as form parameters ("params")
+	AuthStyle string `yaml:"auth_style,omitempty" json:"auth_style,omitempty"`
+	// RefreshBefore refreshes the token this long before it expires (default 60s)
+	RefreshBefore string `yaml:"refresh_before,omitempty" json:"refresh_before,omitempty"`
+
+	// hmac
+	KeyID  string `yaml:"key_id,omitempty" json:"key_id,omitempty"`
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+	// Algorithm is sha256 (default), sha1 or sha512
+	Algorithm string `yaml:"algorithm,omitempty" json:"algorithm,omitempty"`
+	// Encoding of the signature: hex (default) or base64
+	Encoding string `yaml:"encoding,omitempty" json:"encoding,omitempty"`
+	// Canonical is the template of the signed string. Placeholders: {method},
+	// {path}, {query}, {host}, {timestamp}, {nonce}, {body_sha256}, {key_id}
+	// and {header:Name}. Default "{method}\n{path}\n{timestamp}\n{body_sha256}".
+	Canonical string `yaml:"canonical,omitempty" json:"canonical,omitempty"`
+	// Header receives the signature (default "X-Signature")
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	// Format is the template of the header value (default "{signature}");
+	// it accepts {signature}, {key_id}, {timestamp} and {nonce}
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// TimestampHeader and NonceHeader, when set, carry the signed values
+	TimestampHeader string `yaml:"timestamp_header,omitempty" json:"timestamp_header,omitempty"`
+	NonceHeader     string `yaml:"nonce_header,omitempty" json:"nonce_header,omitempty"`
+
+	// sigv4
+	AccessKey    string `yaml:"access_key,omitempty" json:"access_key,omitempty"`
+	SecretKey    string `yaml:"secret_key,omitempty" json:"secret_key,omitempty"`
+	SessionToken string `yaml:"session_token,omitempty" json:"session_token,omitempty"`
+	Region       string `yaml:"region,omitempty" json:"region,omitempty"`
+	Service      string `yaml:"service,omitempty" json:"service,omitempty"`
+}
+
+// ParseAuthSpec parses a command line auth definition: "basic=user:password"
+// or "bearer=token". Other providers are configured with an auth file.
+func ParseAuthSpec(spec string) (*AuthConfig, error) {
+	typ, value, ok := strings.Cut(spec, "=")
+	if !ok || value == "" {
+		return nil, fmt.Errorf("invalid auth '%s': expected basic=user:password or bearer=token", spec)
+	}
+
+	var cfg AuthConfig
+	switch strings.ToLower(strings.TrimSpace(typ)) {
+	case AuthBasic:
+		user, pass, _ := strings.Cut(value, ":")
+		cfg = AuthConfig{Type: AuthBasic, Username: user, Password: pass}
+	case AuthBearer:
+		cfg = AuthConfig{Type: AuthBearer, Token: value}
+	default:
+		return nil, fmt.Errorf("invalid auth '%s': only basic and bearer can be set inline, use --auth-config for %s", spec, typ)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// LoadAuthConfig loads an auth provider definition from a YAML or JSON file
+func LoadAuthConfig(filename string) (*AuthConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config file: %v", err)
+	}
+
+	var cfg AuthConfig
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	switch ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	default:
+		return nil, fmt.Errorf("unsupported auth config file format: %s (supported: .yaml, .yml, .json)", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse auth config file: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// GetRefreshBefore returns the OAuth2 refresh margin, falling back to DefaultAuthRefreshBefore
+func (a *AuthConfig) GetRefreshBefore() time.Duration {
+	if a.RefreshBefore == "" {
+		return DefaultAuthRefreshBefore
+	}
+	d, err := time.ParseDuration(a.RefreshBefore)
+	if err != nil || d < 0 {
+		return DefaultAuthRefreshBefore
+	}
+	return d
+}
+
+// Validate checks that the fields required by the provider type are set
+func (a *AuthConfig) Validate() error {
+	switch a.Type {
+	case AuthBasic:
+		if a.Username == "" {
+			return fmt.Errorf("auth basic: username is required")
+		}
+	case AuthBearer:
+		if a.Token == "" {
+			return fmt.Errorf("auth bearer: token is required")
+		}
+	case AuthOAuth2:
+		if a.TokenURL == "" || a.ClientID == "" {
+			return fmt.Errorf("auth oauth2: token_url and client_id are required")
+		}
+		switch a.AuthStyle {
+		case "", "header", "params":
+		default:
+			return fmt.Errorf("auth oauth2: auth_style must be header or params")
+		}
+		if a.RefreshBefore != "" {
+			if d, err := time.ParseDuration(a.RefreshBefore); err != nil || d < 0 {
+				return fmt.Errorf("auth oauth2: invalid refresh_before '%s'", a.RefreshBefore)
+			}
+		}
+	case AuthHMAC:
+		if a.Secret == "" {
+			return fmt.Errorf("auth hmac: secret is required")
+		}
+		switch strings.ToLower(a.Algorithm) {
+		case "", "sha256", "sha1", "sha512":
+		default:
+			return fmt.Errorf("auth hmac: unsupported algorithm '%s' (supported: sha256, sha1, sha512)", a.Algorithm)
+		}
+		switch strings.ToLower(a.Encoding) {
+		case "", "hex", "base64":
+		default:
+			return fmt.Errorf("auth hmac: encoding must be hex or base64")
+		}
+	case AuthSigV4:
+		if a.AccessKey == "" || a.SecretKey == "" || a.Region == "" || a.Service == "" {
+			return fmt.Errorf("auth sigv4: access_key, secret_key, region and service are required")
+		}
+	default:
+		return fmt.Errorf("unsupported auth type '%s' (supported: basic, bearer, oauth2, hmac, sigv4)", a.Type)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +17,9 @@ type BatchConfig struct {
 	Version  string          `yaml:"version" json:"version"`
 	Tests    []BatchTest     `yaml:"tests" json:"tests"`
 	Notifier *NotifierConfig `yaml:"notifier,omitempty" json:"notifier,omitempty"`
+	Sinks    []SinkConfig    `yaml:"sinks,omitempty" json:"sinks,omitempty"`
+	// WaitFor is polled until the target is ready before the first test starts
+	WaitFor *WaitConfig `yaml:"wait_for,omitempty" json:"wait_for,omitempty"`
 }
 
 // NotifierConfig defines configuration for batch result notifications.
@@ -40,6 +44,32 @@ type BatchTest struct {
 	UseNetHTTP  bool   `yaml:"use_nethttp,omitempty" json:"use_nethttp,omitempty"`
 	Asserts     string `yaml:"asserts,omitempty" json:"asserts,omitempty"`
 	Requests    int64  `yaml:"requests,omitempty" json:"requests,omitempty"`
+	Warmup      string `yaml:"warmup,omitempty" json:"warmup,omitempty"`
+
+	// CurlFile runs every curl command of the file instead of Curl; results
+	// are reported per endpoint
+	CurlFile     string `yaml:"curl_file,omitempty" json:"curl_file,omitempty"`
+	LoadStrategy string `yaml:"load_strategy,omitempty" json:"load_strategy,omitempty"`
+
+	// AssertSampleRate evaluates asserts on this fraction of responses (e.g. 0.01)
+	AssertSampleRate float64 `yaml:"assert_sample_rate,omitempty" json:"assert_sample_rate,omitempty"`
+	// MaxBodySize caps the response body captured for asserts, in bytes
+	MaxBodySize int64 `yaml:"max_body_size,omitempty" json:"max_body_size,omitempty"`
+
+	// CookieJar keeps cookies per connection ("connection") or across all connections ("shared")
+	CookieJar string `yaml:"cookie_jar,omitempty" json:"cookie_jar,omitempty"`
+	// CookieFile preloads cookies from a Netscape cookie file (curl -b/-c format)
+	CookieFile string `yaml:"cookie_file,omitempty" json:"cookie_file,omitempty"`
+
+	// Auth adds credentials to every request of this test
+	Auth *AuthConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
+
+	// Abort stops this test early when a circuit breaker trips
+	Abort *AbortConfig `yaml:"abort,omitempty" json:"abort,omitempty"`
+
+	// Request is run instead of Curl when set, for callers that build the
+	// request themselves rather than from a curl command
+	Request *http.Request `yaml:"-" json:"-"`
 }
 
 // ToConfig converts BatchTest to Config with defaults
@@ -55,6 +85,15 @@ func (bt *BatchTest) ToConfig(defaults *Config) (*Config, error) {
 		UseNetHTTP:   defaults.UseNetHTTP,
 		PrintLatency: defaults.PrintLatency,
 		Requests:     defaults.Requests,
+		Warmup:       defaults.Warmup,
+
+		AssertSampleRate: defaults.AssertSampleRate,
+		MaxBodySize:      defaults.MaxBodySize,
+		CookieJar:        defaults.CookieJar,
+		CookieFile:       defaults.CookieFile,
+		Auth:             defaults.Auth,
+		Abort:            defaults.Abort,
+		DialGuard:        defaults.DialGuard,
 	}
 
 	if bt.Requests > 0 {
@@ -71,6 +110,13 @@ func (bt *BatchTest) ToConfig(defaults *Config) (*Config, error) {
 		}
 		cfg.Duration = duration
 	}
+	if bt.Warmup != "" {
+		warmup, err := time.ParseDuration(bt.Warmup)
+		if err != nil {
+			return nil, fmt.Errorf("invalid warmup '%s' for test '%s': %v", bt.Warmup, bt.Name, err)
+		}
+		cfg.Warmup = warmup
+	}
 	if bt.Threads > 0 {
 		cfg.Threads = bt.Threads
 	}
@@ -90,9 +136,32 @@ func (bt *BatchTest) ToConfig(defaults *Config) (*Config, error) {
 	if bt.UseNetHTTP {
 		cfg.UseNetHTTP = bt.UseNetHTTP
 	}
+	if bt.AssertSampleRate > 0 {
+		cfg.AssertSampleRate = bt.AssertSampleRate
+	}
+	if bt.MaxBodySize > 0 {
+		cfg.MaxBodySize = bt.MaxBodySize
+	}
+	if bt.CookieJar != "" {
+		cfg.CookieJar = bt.CookieJar
+	}
+	if bt.CookieFile != "" {
+		cfg.CookieFile = bt.CookieFile
+	}
+	if bt.Auth != nil {
+		cfg.Auth = bt.Auth
+	}
+	if bt.Abort != nil {
+		cfg.Abort = bt.Abort
+	}
 
 	// Set curl command
 	cfg.CurlCommand = bt.Curl
+	cfg.CurlFile = bt.CurlFile
+	cfg.LoadStrategy = bt.LoadStrategy
+	if cfg.LoadStrategy == "" {
+		cfg.LoadStrategy = "random"
+	}
 
 	// Set asserts text for this test (if any)
 	cfg.Asserts = bt.Asserts
@@ -140,8 +209,8 @@ func (bc *BatchConfig) Validate() error {
 		if test.Name == "" {
 			return fmt.Errorf("test[%d]: name is required", i)
 		}
-		if test.Curl == "" {
-			return fmt.Errorf("test[%d] (%s): curl command is required", i, test.Name)
+		if test.Curl == "" && test.CurlFile == "" && test.Request == nil {
+			return fmt.Errorf("test[%d] (%s): curl or curl_file is required", i, test.Name)
 		}
 		if test.Connections < 0 {
 			return fmt.Errorf("test[%d] (%s): connections cannot be negative", i, test.Name)
@@ -152,12 +221,34 @@ func (bc *BatchConfig) Validate() error {
 		if test.Rate < 0 {
 			return fmt.Errorf("test[%d] (%s): rate cannot be negative", i, test.Name)
 		}
+		if test.AssertSampleRate < 0 || test.AssertSampleRate > 1 {
+			return fmt.Errorf("test[%d] (%s): assert_sample_rate must be between 0 and 1", i, test.Name)
+		}
+		if test.MaxBodySize < 0 {
+			return fmt.Errorf("test[%d] (%s): max_body_size cannot be negative", i, test.Name)
+		}
+		if test.Auth != nil {
+			if err := test.Auth.Validate(); err != nil {
+				return fmt.Errorf("test[%d] (%s): %v", i, test.Name, err)
+			}
+		}
+		if test.Abort != nil {
+			if err := test.Abort.Validate(); err != nil {
+				return fmt.Errorf("test[%d] (%s): %v", i, test.Name, err)
+			}
+		}
 		// Validate duration format if provided
 		if test.Duration != "" {
 			if _, err := time.ParseDuration(test.Duration); err != nil {
 				return fmt.Errorf("test[%d] (%s): invalid duration format '%s'", i, test.Name, test.Duration)
 			}
 		}
+		// Validate warmup format if provided
+		if test.Warmup != "" {
+			if _, err := time.ParseDuration(test.Warmup); err != nil {
+				return fmt.Errorf("test[%d] (%s): invalid warmup format '%s'", i, test.Name, test.Warmup)
+			}
+		}
 		// Validate timeout format if provided
 		if test.Timeout != "" {
 			if _, err := time.ParseDuration(test.Timeout); err != nil {
@@ -166,5 +257,17 @@ func (bc *BatchConfig) Validate() error {
 		}
 	}
 
+	for i := range bc.Sinks {
+		if err := bc.Sinks[i].Validate(); err != nil {
+			return fmt.Errorf("sinks[%d]: %v", i, err)
+		}
+	}
+
+	if bc.WaitFor != nil {
+		if err := bc.WaitFor.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
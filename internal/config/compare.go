@@ -46,6 +46,22 @@ type CompareScenario struct {
 	TargetRole string `yaml:"target_role,omitempty" json:"target_role,omitempty"`
 
 	ResponseCompare string `yaml:"response_compare" json:"response_compare"`
+
+	// CookieJar keeps the cookies set by earlier responses for later requests,
+	// in separate jars for the base side and the target side ("connection")
+	// or in one jar for both sides ("shared")
+	CookieJar string `yaml:"cookie_jar,omitempty" json:"cookie_jar,omitempty"`
+	// CookieFile preloads the jars from a Netscape cookie file; implies
+	// CookieJar "connection" when no mode is set
+	CookieFile string `yaml:"cookie_file,omitempty" json:"cookie_file,omitempty"`
+}
+
+// GetCookieJar returns the effective cookie jar mode of the scenario
+func (s *CompareScenario) GetCookieJar() string {
+	if s.CookieJar == CookieJarOff && s.CookieFile != "" {
+		return CookieJarConnection
+	}
+	return s.CookieJar
 }
 
 // CompareConfig is the top-level configuration for compare mode.
@@ -53,6 +69,19 @@ type CompareConfig struct {
 	Requests    []CompareRequest        `yaml:"requests,omitempty" json:"requests,omitempty"`
 	RequestSets map[string][]CompareRequest `yaml:"request_sets,omitempty" json:"request_sets,omitempty"`
 	Scenarios   []CompareScenario       `yaml:"compare" json:"compare"`
+
+	// DialGuard vets the connections of the scenarios (nil = all allowed)
+	DialGuard DialGuard `yaml:"-" json:"-"`
+}
+
+// ParseCompareConfig parses compare configuration from YAML or JSON text,
+// e.g. a config submitted through the API server.
+func ParseCompareConfig(data []byte) (*CompareConfig, error) {
+	var cfg CompareConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse compare config: %v", err)
+	}
+	return &cfg, nil
 }
 
 // LoadCompareConfig loads compare configuration from a YAML or JSON file.
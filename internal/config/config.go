@@ -14,6 +14,7 @@ type Config struct {
 	Rate        int           // Requests per second (0 = unlimited)
 	Timeout     time.Duration // Request timeout
 	Requests    int64         // Total number of requests to perform (0 = unlimited, duration-limited)
+	Warmup      time.Duration // Load applied before measuring starts; excluded from the statistics
 
 	// Curl parsing
 	CurlCommand  string // Curl command to parse
@@ -35,8 +36,60 @@ type Config struct {
 	// Engine options
 	UseNetHTTP bool // Force use standard library net/http instead of pulse
 
+	// Cookies
+	CookieJar  string // Cookie jar mode: "" (off), "connection" or "shared"
+	CookieFile string // Netscape cookie file preloaded into every jar
+
+	// Auth adds credentials to every request (nil = none)
+	Auth *AuthConfig
+
+	// Abort stops the run early when a circuit breaker trips (nil = never)
+	Abort *AbortConfig
+
+	// DialGuard vets the connections of the run (nil = all allowed). The API
+	// server sets it to hold tasks to the target allowlist of their key.
+	DialGuard DialGuard
+
 	// Assertions
-	Asserts string // Assertions for single HTTP request, used in batch tests
+	Asserts          string  // Assertions for single HTTP request, used in batch tests
+	AssertSampleRate float64 // Fraction of responses whose assertions are evaluated (0 or 1 = all)
+	MaxBodySize      int64   // Maximum response body bytes captured for assertions (0 = DefaultMaxBodySize)
+}
+
+// Cookie jar modes
+const (
+	CookieJarOff        = ""
+	CookieJarConnection = "connection" // every connection (virtual user) keeps its own cookies
+	CookieJarShared     = "shared"     // all connections share one jar
+)
+
+// GetCookieJar returns the effective cookie jar mode. A cookie file without
+// an explicit mode gives every connection its own preloaded jar.
+func (c *Config) GetCookieJar() string {
+	if c.CookieJar == CookieJarOff && c.CookieFile != "" {
+		return CookieJarConnection
+	}
+	return c.CookieJar
+}
+
+// ValidateCookieJar checks that mode is one of the cookie jar modes
+func ValidateCookieJar(mode string) error {
+	switch mode {
+	case CookieJarOff, CookieJarConnection, CookieJarShared:
+		return nil
+	}
+	return fmt.Errorf("cookie jar must be %q or %q", CookieJarConnection, CookieJarShared)
+}
+
+// DefaultMaxBodySize is the response body capture limit used for assertions
+const DefaultMaxBodySize int64 = 1 << 20
+
+// GetMaxBodySize returns the body capture limit, applying the default
+func (c *Config) GetMaxBodySize() int64 {
+	if c.MaxBodySize > 0 {
+		return c.MaxBodySize
+	}
+	return DefaultMaxBodySize
 }
 
 // Validate checks if the configuration is valid
@@ -61,5 +114,33 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("timeout must be greater than 0")
 	}
 
+	if c.Warmup < 0 {
+		return fmt.Errorf("warmup cannot be negative")
+	}
+
+	if c.AssertSampleRate < 0 || c.AssertSampleRate > 1 {
+		return fmt.Errorf("assert sample rate must be between 0 and 1")
+	}
+
+	if c.MaxBodySize < 0 {
+		return fmt.Errorf("max body size cannot be negative")
+	}
+
+	if err := ValidateCookieJar(c.CookieJar); err != nil {
+		return err
+	}
+
+	if c.Auth != nil {
+		if err := c.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Abort != nil {
+		if err := c.Abort.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+	"net"
+	"syscall"
+)
+
+// DialGuard vets every connection of a run before it is made, given the host
+// being dialed and the address it resolved to. Checking at dial time covers
+// the addresses of redirects and of later DNS lookups, which a check of the
+// target when a run is submitted can't see.
+type DialGuard func(host string, ip net.IP) error
+
+// Dial connects to addr with d, refusing the addresses the guard rejects. A
+// nil guard dials as d does.
+func (g DialGuard) Dial(ctx context.Context, d net.Dialer, network, addr string) (net.Conn, error) {
+	if g != nil {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		d.Control = func(_, address string, _ syscall.RawConn) error {
+			ip, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return g(host, net.ParseIP(ip))
+		}
+	}
+	return d.DialContext(ctx, network, addr)
+}
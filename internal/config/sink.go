@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported metrics sink types
+const (
+	SinkStatsD    = "statsd"
+	SinkDogStatsD = "dogstatsd"
+	SinkInflux    = "influx"
+	SinkOTLP      = "otlp"
+)
+
+// DefaultSinkInterval is how often sinks receive data while a run is in progress
+const DefaultSinkInterval = 10 * time.Second
+
+// SinkConfig defines a metrics backend that receives results periodically
+// during a run and once more when it finishes.
+type SinkConfig struct {
+	// Type is one of statsd, dogstatsd, influx or otlp
+	Type string `yaml:"type" json:"type"`
+	// Address is host:port for statsd/dogstatsd, a write URL or file path
+	// for influx, and the OTLP/HTTP metrics URL for otlp
+	Address string `yaml:"address" json:"address"`
+	// Prefix is prepended to metric names (default "gurl")
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// Interval between pushes while running (default 10s)
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+	// Headers are added to HTTP requests (e.g. influx/otlp authorization);
+	// values may reference environment variables
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Tags are attached to every metric; values may reference environment
+	// variables such as ${GIT_SHA}
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// ParseSinkSpec parses a command line sink definition of the form type=address,
+// e.g. "statsd=127.0.0.1:8125" or "otlp=http://localhost:4318/v1/metrics"
+func ParseSinkSpec(spec string) (SinkConfig, error) {
+	typ, addr, ok := strings.Cut(spec, "=")
+	if !ok || typ == "" || addr == "" {
+		return SinkConfig{}, fmt.Errorf("invalid sink '%s': expected type=address", spec)
+	}
+	cfg := SinkConfig{Type: strings.ToLower(strings.TrimSpace(typ)), Address: strings.TrimSpace(addr)}
+	return cfg, cfg.Validate()
+}
+
+// ParseTags parses key=value pairs into a tag map
+func ParseTags(pairs []string) (map[string]string, error) {
+	tags := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid tag '%s': expected key=value", pair)
+		}
+		tags[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return tags, nil
+}
+
+// GetInterval returns the push interval, falling back to DefaultSinkInterval
+func (s *SinkConfig) GetInterval() time.Duration {
+	if s.Interval == "" {
+		return DefaultSinkInterval
+	}
+	d, err := time.ParseDuration(s.Interval)
+	if err != nil || d <= 0 {
+		return DefaultSinkInterval
+	}
+	return d
+}
+
+// Validate checks the sink type, address and interval
+func (s *SinkConfig) Validate() error {
+	switch s.Type {
+	case SinkStatsD, SinkDogStatsD, SinkInflux, SinkOTLP:
+	default:
+		return fmt.Errorf("unsupported sink type '%s' (supported: statsd, dogstatsd, influx, otlp)", s.Type)
+	}
+	if s.Address == "" {
+		return fmt.Errorf("sink %s: address is required", s.Type)
+	}
+	if s.Type == SinkOTLP && !strings.HasPrefix(s.Address, "http://") && !strings.HasPrefix(s.Address, "https://") {
+		return fmt.Errorf("sink otlp: address must be an http(s) URL")
+	}
+	if s.Interval != "" {
+		if d, err := time.ParseDuration(s.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("sink %s: invalid interval '%s'", s.Type, s.Interval)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Defaults of the readiness check
+const (
+	DefaultWaitAssert   = "status == 200"
+	DefaultWaitTimeout  = 60 * time.Second
+	DefaultWaitInterval = time.Second
+)
+
+// WaitConfig describes a readiness check that must pass before a run starts.
+// The target is polled until every assertion passes or the timeout expires.
+type WaitConfig struct {
+	// Target is a URL or a curl command
+	Target string `yaml:"target" json:"target"`
+	// Assert holds assertions in the batch asserts syntax, one per line
+	// (default "status == 200")
+	Assert string `yaml:"assert,omitempty" json:"assert,omitempty"`
+	// Timeout is how long to keep polling (default 60s)
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Interval is the delay between attempts (default 1s)
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// GetAssert returns the readiness assertions, applying the default
+func (w *WaitConfig) GetAssert() string {
+	if strings.TrimSpace(w.Assert) == "" {
+		return DefaultWaitAssert
+	}
+	return w.Assert
+}
+
+// GetTimeout returns how long to wait for readiness
+func (w *WaitConfig) GetTimeout() time.Duration {
+	return durationOr(w.Timeout, DefaultWaitTimeout)
+}
+
+// GetInterval returns the delay between readiness attempts
+func (w *WaitConfig) GetInterval() time.Duration {
+	return durationOr(w.Interval, DefaultWaitInterval)
+}
+
+// Validate checks the readiness configuration
+func (w *WaitConfig) Validate() error {
+	if strings.TrimSpace(w.Target) == "" {
+		return fmt.Errorf("wait_for target is required")
+	}
+	for name, value := range map[string]string{"timeout": w.Timeout, "interval": w.Interval} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid wait_for %s '%s'", name, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package cookies
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in files written by curl
+const httpOnlyPrefix = "#HttpOnly_"
+
+// entry is one cookie of a Netscape cookie file together with the URL it
+// is set for
+type entry struct {
+	url    *url.URL
+	cookie *http.Cookie
+}
+
+// File holds the cookies of a Netscape cookie file, the format read by
+// curl -b and written by curl -c
+type File struct {
+	entries []entry
+}
+
+// Load reads a Netscape cookie file
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookie file: %v", err)
+	}
+	defer f.Close()
+
+	file, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("cookie file %s: %v", path, err)
+	}
+	return file, nil
+}
+
+// Parse reads cookies in Netscape format: one cookie per line with the tab
+// separated fields domain, include-subdomains, path, secure, expiry, name
+// and value. Comment and blank lines are skipped.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := false
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			line = line[len(httpOnlyPrefix):]
+			httpOnly = true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			// curl writes an empty value without the trailing tab
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab separated fields, got %d", lineNo, len(fields))
+		}
+
+		domain := fields[0]
+		host := strings.TrimPrefix(domain, ".")
+		if host == "" {
+			return nil, fmt.Errorf("line %d: empty domain", lineNo)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", lineNo, fields[4])
+		}
+
+		path := fields[2]
+		if path == "" {
+			path = "/"
+		}
+		secure := strings.EqualFold(fields[3], "TRUE")
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+
+		c := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     path,
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+		// A domain cookie is sent to subdomains too; otherwise it is host-only
+		if strings.EqualFold(fields[1], "TRUE") || strings.HasPrefix(domain, ".") {
+			c.Domain = host
+		}
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+
+		file.entries = append(file.entries, entry{
+			url:    &url.URL{Scheme: scheme, Host: host, Path: path},
+			cookie: c,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Len returns the number of cookies in the file
+func (f *File) Len() int {
+	if f == nil {
+		return 0
+	}
+	return len(f.entries)
+}
+
+// NewJar returns an empty cookie jar preloaded with the cookies of f.
+// f may be nil. Expired cookies are dropped by the jar.
+func NewJar(f *File) http.CookieJar {
+	// cookiejar.New only fails on invalid options
+	jar, _ := cookiejar.New(nil)
+	if f != nil {
+		for _, e := range f.entries {
+			jar.SetCookies(e.url, []*http.Cookie{e.cookie})
+		}
+	}
+	return jar
+}
@@ -0,0 +1,70 @@
+package cookies
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const sampleFile = `# Netscape HTTP Cookie File
+# This file was generated by libcurl! Edit at your own risk.
+
+.example.com	TRUE	/	FALSE	0	session	abc123
+#HttpOnly_api.example.com	FALSE	/v1	TRUE	4102444800	token	secret
+example.com	FALSE	/	FALSE	1	expired	old
+example.com	FALSE	/	FALSE	0	empty
+`
+
+func TestParseAndNewJar(t *testing.T) {
+	f, err := Parse(strings.NewReader(sampleFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Len() != 4 {
+		t.Fatalf("expected 4 cookies, got %d", f.Len())
+	}
+
+	jar := NewJar(f)
+	names := func(rawURL string) string {
+		u, _ := url.Parse(rawURL)
+		var out []string
+		for _, c := range jar.Cookies(u) {
+			out = append(out, c.Name+"="+c.Value)
+		}
+		return strings.Join(out, ";")
+	}
+
+	// Domain cookie reaches subdomains, expired cookie is dropped
+	if got := names("http://www.example.com/"); got != "session=abc123" {
+		t.Errorf("www.example.com: got %q", got)
+	}
+	if got := names("http://example.com/"); got != "session=abc123;empty=" {
+		t.Errorf("example.com: got %q", got)
+	}
+	// Secure host-only cookie with a path is only sent over https below that path
+	if got := names("http://api.example.com/v1/users"); got != "session=abc123" {
+		t.Errorf("http api: got %q", got)
+	}
+	if got := names("https://api.example.com/v1/users"); !strings.Contains(got, "token=secret") {
+		t.Errorf("https api: got %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"example.com\tFALSE\t/\n",
+		"example.com\tFALSE\t/\tFALSE\tsoon\tname\tvalue\n",
+	} {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestNewJarNilFile(t *testing.T) {
+	jar := NewJar(nil)
+	u, _ := url.Parse("http://example.com/")
+	if len(jar.Cookies(u)) != 0 {
+		t.Error("expected an empty jar")
+	}
+}
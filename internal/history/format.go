@@ -0,0 +1,183 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/antlabs/gurl/internal/report"
+)
+
+// PrintList prints one line per run
+func PrintList(w io.Writer, records []*Record) {
+	if len(records) == 0 {
+		fmt.Fprintln(w, "No runs recorded yet")
+		return
+	}
+	fmt.Fprintf(w, "%-6s %-19s %-9s %-8s %-9s %s\n", "ID", "Time", "Kind", "Status", "Commit", "Title")
+	for _, rec := range records {
+		fmt.Fprintf(w, "%-6d %-19s %-9s %-8s %-9s %s\n",
+			rec.ID, rec.CreatedAt.Local().Format("2006-01-02 15:04:05"), rec.Kind, rec.Status, shortCommit(rec.Git), rec.Title)
+	}
+}
+
+// PrintRecord prints the metadata, configuration and test summaries of a run
+func PrintRecord(w io.Writer, rec *Record) {
+	fmt.Fprintf(w, "Run #%d: %s\n", rec.ID, rec.Title)
+	fmt.Fprintf(w, "  Kind:    %s\n", rec.Kind)
+	fmt.Fprintf(w, "  Time:    %s\n", rec.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "  Status:  %s\n", rec.Status)
+	if rec.TaskID != "" {
+		fmt.Fprintf(w, "  Task:    %s\n", rec.TaskID)
+	}
+	if rec.Git != nil {
+		dirty := ""
+		if rec.Git.Dirty {
+			dirty = " (dirty)"
+		}
+		fmt.Fprintf(w, "  Git:     %s %s%s\n", rec.Git.Branch, rec.Git.Commit, dirty)
+	}
+	if rec.Error != "" {
+		fmt.Fprintf(w, "  Error:   %s\n", rec.Error)
+	}
+	if rec.Report == nil {
+		return
+	}
+
+	if len(rec.Report.Config) > 0 {
+		fmt.Fprintf(w, "\nConfiguration\n")
+		for _, e := range rec.Report.Config {
+			fmt.Fprintf(w, "  %-14s %s\n", e.Key+":", strings.ReplaceAll(e.Value, "\n", "\n                 "))
+		}
+	}
+
+	for _, run := range rec.Report.Runs {
+		s := run.Summary
+		status := ""
+		if run.Status != "" {
+			status = " [" + run.Status + "]"
+		}
+		fmt.Fprintf(w, "\n%s%s\n", run.Name, status)
+		if run.Error != "" {
+			fmt.Fprintf(w, "  Error: %s\n", run.Error)
+			continue
+		}
+		if run.Aborted != "" {
+			fmt.Fprintf(w, "  Aborted:  %s\n", run.Aborted)
+		}
+		if len(run.Annotations) > 0 {
+			adjustments := make([]string, 0, len(run.Annotations))
+			for _, a := range run.Annotations {
+				adjustments = append(adjustments, fmt.Sprintf("%.1fs %s", a.Second, a.Label))
+			}
+			fmt.Fprintf(w, "  Adjusted: %s\n", strings.Join(adjustments, "; "))
+		}
+		fmt.Fprintf(w, "  Requests: %d  Errors: %d  Duration: %.2fs  Req/sec: %.2f\n", s.Requests, s.Errors, s.DurationSec, s.RequestsPerSec)
+		fmt.Fprintf(w, "  Latency:  avg %.2fms  p50 %.2fms  p90 %.2fms  p99 %.2fms  max %.2fms\n", s.AvgMs, s.P50Ms, s.P90Ms, s.P99Ms, s.MaxMs)
+		if len(run.StatusCodes) > 0 {
+			codes := make([]string, 0, len(run.StatusCodes))
+			for _, c := range run.StatusCodes {
+				codes = append(codes, fmt.Sprintf("%s=%d", c.Label, c.Count))
+			}
+			fmt.Fprintf(w, "  Status:   %s\n", strings.Join(codes, " "))
+		}
+	}
+
+	if cmp := rec.Report.Compare; cmp != nil {
+		fmt.Fprintf(w, "\nCompare scenario %s: %d passed, %d failed\n", cmp.Scenario, cmp.Passed, cmp.Failed)
+	}
+}
+
+// PrintDiff compares the tests two runs have in common
+func PrintDiff(w io.Writer, a, b *Record) {
+	fmt.Fprintf(w, "Diff #%d (%s) -> #%d (%s)\n", a.ID, a.CreatedAt.Local().Format("2006-01-02 15:04"), b.ID, b.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	if a.Report == nil || b.Report == nil {
+		fmt.Fprintln(w, "  one of the runs has no results")
+		return
+	}
+
+	common := 0
+	for _, runA := range a.Report.Runs {
+		runB := b.Run(runA.Name)
+		if runB == nil {
+			continue
+		}
+		common++
+		sa, sb := runA.Summary, runB.Summary
+		fmt.Fprintf(w, "\n%s\n", runA.Name)
+		fmt.Fprintf(w, "  %-10s %14s %14s %10s\n", "Metric", fmt.Sprintf("#%d", a.ID), fmt.Sprintf("#%d", b.ID), "Change")
+		diffRow(w, "req/sec", sa.RequestsPerSec, sb.RequestsPerSec, "")
+		diffRow(w, "avg", sa.AvgMs, sb.AvgMs, "ms")
+		diffRow(w, "p50", sa.P50Ms, sb.P50Ms, "ms")
+		diffRow(w, "p90", sa.P90Ms, sb.P90Ms, "ms")
+		diffRow(w, "p99", sa.P99Ms, sb.P99Ms, "ms")
+		diffRow(w, "max", sa.MaxMs, sb.MaxMs, "ms")
+		diffRow(w, "errors", float64(sa.Errors), float64(sb.Errors), "")
+	}
+	if common == 0 {
+		fmt.Fprintln(w, "  the runs have no tests in common")
+	}
+}
+
+func diffRow(w io.Writer, name string, a, b float64, unit string) {
+	change := "n/a"
+	if a != 0 {
+		change = fmt.Sprintf("%+.1f%%", (b-a)/a*100)
+	} else if b == 0 {
+		change = "0.0%"
+	}
+	fmt.Fprintf(w, "  %-10s %14s %14s %10s\n", name, fmt.Sprintf("%.2f%s", a, unit), fmt.Sprintf("%.2f%s", b, unit), change)
+}
+
+// PrintTrend prints the summary of a test across runs, oldest first
+func PrintTrend(w io.Writer, test string, points []TrendPoint) {
+	if len(points) == 0 {
+		fmt.Fprintf(w, "No runs recorded for %s\n", test)
+		return
+	}
+	fmt.Fprintf(w, "Trend for %s (last %d runs)\n", test, len(points))
+	fmt.Fprintf(w, "%-6s %-16s %-9s %10s %10s %10s %10s %8s\n", "ID", "Time", "Commit", "Req/sec", "p50", "p90", "p99", "Errors")
+	for _, p := range points {
+		fmt.Fprintf(w, "%-6d %-16s %-9s %10.2f %8.2fms %8.2fms %8.2fms %8d\n",
+			p.RecordID, p.CreatedAt.Local().Format("2006-01-02 15:04"), shortCommit(&GitInfo{Commit: p.Commit}),
+			p.Summary.RequestsPerSec, p.Summary.P50Ms, p.Summary.P90Ms, p.Summary.P99Ms, p.Summary.Errors)
+	}
+
+	first, last := points[0].Summary, points[len(points)-1].Summary
+	if len(points) > 1 && first.RequestsPerSec > 0 && first.P99Ms > 0 {
+		fmt.Fprintf(w, "Change: req/sec %+.1f%%, p99 %+.1f%%\n",
+			(last.RequestsPerSec-first.RequestsPerSec)/first.RequestsPerSec*100,
+			(last.P99Ms-first.P99Ms)/first.P99Ms*100)
+	}
+}
+
+// StatusOf derives the record status of a report
+func StatusOf(rep *report.Report) string {
+	if rep == nil {
+		return StatusFailed
+	}
+	if rep.Compare != nil && rep.Compare.Failed > 0 {
+		return StatusFailed
+	}
+	status := StatusPassed
+	for _, run := range rep.Runs {
+		if run.Status == "FAILED" || run.Error != "" {
+			return StatusFailed
+		}
+		if run.Aborted != "" {
+			status = StatusAborted
+		}
+	}
+	return status
+}
+
+func shortCommit(g *GitInfo) string {
+	if g == nil || g.Commit == "" {
+		return "-"
+	}
+	if len(g.Commit) > 8 {
+		return g.Commit[:8]
+	}
+	return g.Commit
+}
@@ -0,0 +1,288 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/antlabs/gurl/internal/report"
+)
+
+// DefaultPath is the history database used when no path is given
+const DefaultPath = ".gurl/history.db"
+
+// Record kinds; benchmark, batch and compare match the report kinds
+const (
+	KindBenchmark = report.KindBenchmark
+	KindBatch     = report.KindBatch
+	KindCompare   = report.KindCompare
+	KindAPI       = "api"
+)
+
+// Record statuses
+const (
+	StatusPassed   = "passed"
+	StatusFailed   = "failed"
+	StatusAborted  = "aborted"
+	StatusCanceled = "canceled"
+)
+
+var (
+	bucketRuns  = []byte("runs")
+	bucketTasks = []byte("tasks")
+)
+
+// ErrNotFound is returned when a run does not exist
+var ErrNotFound = errors.New("run not found")
+
+// Record is one recorded run together with its full report
+type Record struct {
+	ID        uint64         `json:"id"`
+	Kind      string         `json:"kind"`
+	Title     string         `json:"title"`
+	TaskID    string         `json:"task_id,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	Git       *GitInfo       `json:"git,omitempty"`
+	Report    *report.Report `json:"report,omitempty"`
+}
+
+// GitInfo describes the checkout a run was started from
+type GitInfo struct {
+	Commit string `json:"commit"`
+	Branch string `json:"branch,omitempty"`
+	Dirty  bool   `json:"dirty,omitempty"`
+}
+
+// DetectGit returns the git metadata of the working directory, or nil when it
+// is not inside a git repository
+func DetectGit() *GitInfo {
+	run := func(args ...string) (string, bool) {
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(string(out)), true
+	}
+
+	commit, ok := run("rev-parse", "HEAD")
+	if !ok || commit == "" {
+		return nil
+	}
+	info := &GitInfo{Commit: commit}
+	if branch, ok := run("rev-parse", "--abbrev-ref", "HEAD"); ok {
+		info.Branch = branch
+	}
+	if status, ok := run("status", "--porcelain", "--untracked-files=no"); ok {
+		info.Dirty = status != ""
+	}
+	return info
+}
+
+// Store is the bolt backed run history. The file is locked while a Store is
+// open, so callers keep it open only for the duration of an operation.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if needed) the history database at path
+func Open(path string) (*Store, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %v", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketRuns, bucketTasks, bucketAPITasks, bucketAPITaskResults} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history database: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the database file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add stores rec and assigns its ID
+func (s *Store) Add(rec *Record) error {
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(bucketRuns)
+		id, err := runs.NextSequence()
+		if err != nil {
+			return err
+		}
+		rec.ID = id
+
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if err := runs.Put(itob(id), data); err != nil {
+			return err
+		}
+		if rec.TaskID != "" {
+			return tx.Bucket(bucketTasks).Put([]byte(rec.TaskID), itob(id))
+		}
+		return nil
+	})
+}
+
+// Get returns the run with the given ID
+func (s *Store) Get(id uint64) (*Record, error) {
+	var rec *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = decode(tx.Bucket(bucketRuns).Get(itob(id)))
+		return err
+	})
+	return rec, err
+}
+
+// GetByTaskID returns the run recorded for an API server task
+func (s *Store) GetByTaskID(taskID string) (*Record, error) {
+	var rec *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(bucketTasks).Get([]byte(taskID))
+		if id == nil {
+			return ErrNotFound
+		}
+		var err error
+		rec, err = decode(tx.Bucket(bucketRuns).Get(id))
+		return err
+	})
+	return rec, err
+}
+
+// DeleteByTaskID removes the run recorded for an API server task
+func (s *Store) DeleteByTaskID(taskID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		tasks := tx.Bucket(bucketTasks)
+		id := tasks.Get([]byte(taskID))
+		if id == nil {
+			return ErrNotFound
+		}
+		if err := tx.Bucket(bucketRuns).Delete(id); err != nil {
+			return err
+		}
+		return tasks.Delete([]byte(taskID))
+	})
+}
+
+// Filter narrows down List results
+type Filter struct {
+	Kind  string // only runs of this kind
+	Test  string // only runs containing a test (report run) with this name
+	Limit int    // maximum number of runs, 0 for all
+}
+
+// List returns matching runs, newest first
+func (s *Store) List(f Filter) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketRuns).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			rec, err := decode(v)
+			if err != nil {
+				return err
+			}
+			if f.Kind != "" && rec.Kind != f.Kind {
+				continue
+			}
+			if f.Test != "" && rec.Run(f.Test) == nil {
+				continue
+			}
+			records = append(records, rec)
+			if f.Limit > 0 && len(records) >= f.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Run returns the report section of the named test, or nil
+func (r *Record) Run(name string) *report.Run {
+	if r.Report == nil {
+		return nil
+	}
+	for i := range r.Report.Runs {
+		if r.Report.Runs[i].Name == name {
+			return &r.Report.Runs[i]
+		}
+	}
+	return nil
+}
+
+// TrendPoint is the summary of a test in one recorded run
+type TrendPoint struct {
+	RecordID  uint64
+	CreatedAt time.Time
+	Commit    string
+	Summary   report.Summary
+}
+
+// Trend returns the summaries of a test over its last n runs, oldest first
+func (s *Store) Trend(test string, n int) ([]TrendPoint, error) {
+	records, err := s.List(Filter{Test: test, Limit: n})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]TrendPoint, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		p := TrendPoint{RecordID: rec.ID, CreatedAt: rec.CreatedAt, Summary: rec.Run(test).Summary}
+		if rec.Git != nil {
+			p.Commit = rec.Git.Commit
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+func decode(data []byte) (*Record, error) {
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("corrupt history record: %v", err)
+	}
+	return &rec, nil
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
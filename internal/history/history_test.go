@@ -0,0 +1,152 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/report"
+)
+
+func newRecord(kind string, rps float64, tests ...string) *Record {
+	rep := &report.Report{Kind: kind, CreatedAt: time.Now()}
+	for _, name := range tests {
+		rep.Runs = append(rep.Runs, report.Run{Name: name, Summary: report.Summary{RequestsPerSec: rps, P99Ms: 1000 / rps}})
+	}
+	return &Record{Kind: kind, Title: kind, Status: StatusOf(rep), Report: rep}
+}
+
+func TestStoreListAndTrend(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "nested", "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for i, rec := range []*Record{
+		newRecord(KindBatch, 100, "login", "search"),
+		newRecord(KindBenchmark, 50, "http://example.com"),
+		newRecord(KindBatch, 200, "login"),
+	} {
+		if err := store.Add(rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.ID != uint64(i+1) {
+			t.Errorf("expected sequential id %d, got %d", i+1, rec.ID)
+		}
+	}
+
+	all, err := store.List(Filter{})
+	if err != nil || len(all) != 3 || all[0].ID != 3 {
+		t.Fatalf("list should return newest first: %v %+v", err, all)
+	}
+	batches, _ := store.List(Filter{Kind: KindBatch, Limit: 1})
+	if len(batches) != 1 || batches[0].ID != 3 {
+		t.Errorf("kind filter with limit returned %+v", batches)
+	}
+
+	points, err := store.Trend("login", 10)
+	if err != nil || len(points) != 2 {
+		t.Fatalf("expected 2 trend points, got %v %+v", err, points)
+	}
+	if points[0].RecordID != 1 || points[1].Summary.RequestsPerSec != 200 {
+		t.Errorf("trend should be oldest first: %+v", points)
+	}
+
+	var out bytes.Buffer
+	PrintTrend(&out, "login", points)
+	if !strings.Contains(out.String(), "req/sec +100.0%") {
+		t.Errorf("trend output should show the change:\n%s", out.String())
+	}
+
+	a, _ := store.Get(1)
+	b, _ := store.Get(3)
+	out.Reset()
+	PrintDiff(&out, a, b)
+	if !strings.Contains(out.String(), "login") || strings.Contains(out.String(), "search") {
+		t.Errorf("diff should only cover common tests:\n%s", out.String())
+	}
+}
+
+func TestGetByTaskID(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	rec := &Record{Kind: KindAPI, TaskID: "task_1", Status: StatusFailed, Error: "connection refused"}
+	if err := store.Add(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetByTaskID("task_1")
+	if err != nil || got.ID != rec.ID || got.Error != rec.Error {
+		t.Errorf("unexpected record: %v %+v", err, got)
+	}
+	if _, err := store.GetByTaskID("task_2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.DeleteByTaskID("task_1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(rec.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the run to be deleted, got %v", err)
+	}
+	if err := store.DeleteByTaskID("task_1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a deleted task, got %v", err)
+	}
+}
+
+func TestAPITasks(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if err := store.PutTask("task_1", []byte(`{"id":"task_1"}`), []byte(`{"results":{}}`)); err != nil {
+		t.Fatal(err)
+	}
+	rec := newRecord(KindAPI, 10)
+	rec.TaskID = "task_1"
+	if err := store.Add(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	state, output, err := store.GetTask("task_1")
+	if err != nil || string(state) != `{"id":"task_1"}` || string(output) != `{"results":{}}` {
+		t.Fatalf("unexpected task %s %s %v", state, output, err)
+	}
+	// Replacing the state without output drops the stored output
+	if err := store.PutTask("task_1", []byte(`{"id":"task_1"}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, output, _ := store.GetTask("task_1"); output != nil {
+		t.Errorf("expected the output to be removed, got %s", output)
+	}
+
+	var n int
+	store.Tasks(func(state []byte) error { n++; return nil })
+	if n != 1 {
+		t.Errorf("expected 1 task, got %d", n)
+	}
+
+	// Deleting a task also deletes the run recorded for it
+	if err := store.DeleteTask("task_1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.GetTask("task_1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Get(rec.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the recorded run to be deleted, got %v", err)
+	}
+	if err := store.DeleteTask("task_1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a deleted task, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"bytes"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// The API server keeps its tasks in the history database next to the runs it
+// records. Task states and their output (results, report) are kept in
+// separate buckets so listing tasks stays cheap. The store doesn't interpret
+// the data, it is encoded by the api package.
+var (
+	bucketAPITasks       = []byte("api_tasks")
+	bucketAPITaskResults = []byte("api_task_results")
+)
+
+// PutTask creates or replaces an API server task. A nil output removes the
+// stored output of the task.
+func (s *Store) PutTask(id string, state, output []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketAPITasks).Put([]byte(id), state); err != nil {
+			return err
+		}
+		results := tx.Bucket(bucketAPITaskResults)
+		if output == nil {
+			return results.Delete([]byte(id))
+		}
+		return results.Put([]byte(id), output)
+	})
+}
+
+// GetTask returns the state and output of an API server task, or ErrNotFound
+func (s *Store) GetTask(id string) (state, output []byte, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketAPITasks).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		state = bytes.Clone(data)
+		output = bytes.Clone(tx.Bucket(bucketAPITaskResults).Get([]byte(id)))
+		return nil
+	})
+	return state, output, err
+}
+
+// Tasks calls fn with the state of every API server task
+func (s *Store) Tasks(fn func(state []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAPITasks).ForEach(func(k, v []byte) error {
+			return fn(v)
+		})
+	})
+}
+
+// DeleteTask removes an API server task together with the run recorded for
+// it. It returns ErrNotFound when there was neither.
+func (s *Store) DeleteTask(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		tasks := tx.Bucket(bucketAPITasks)
+		found := tasks.Get([]byte(id)) != nil
+		if found {
+			if err := tasks.Delete([]byte(id)); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketAPITaskResults).Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+
+		index := tx.Bucket(bucketTasks)
+		if run := index.Get([]byte(id)); run != nil {
+			if err := tx.Bucket(bucketRuns).Delete(run); err != nil {
+				return err
+			}
+			if err := index.Delete([]byte(id)); err != nil {
+				return err
+			}
+			found = true
+		}
+		if !found {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
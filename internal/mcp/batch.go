@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -116,6 +117,14 @@ func parseInlineTests(inlineTests []any) (*config.BatchConfig, error) {
 			batchTest.Curl = curl
 		}
 
+		if file, ok := testMap["curl_file"].(string); ok {
+			batchTest.CurlFile = file
+		}
+
+		if strategy, ok := testMap["load_strategy"].(string); ok {
+			batchTest.LoadStrategy = strategy
+		}
+
 		if connections, ok := testMap["connections"].(float64); ok {
 			batchTest.Connections = int(connections)
 		}
@@ -124,6 +133,10 @@ func parseInlineTests(inlineTests []any) (*config.BatchConfig, error) {
 			batchTest.Duration = duration
 		}
 
+		if warmup, ok := testMap["warmup"].(string); ok {
+			batchTest.Warmup = warmup
+		}
+
 		if threads, ok := testMap["threads"].(float64); ok {
 			batchTest.Threads = int(threads)
 		}
@@ -148,6 +161,46 @@ func parseInlineTests(inlineTests []any) (*config.BatchConfig, error) {
 			batchTest.Asserts = asserts
 		}
 
+		if rate, ok := testMap["assert_sample_rate"].(float64); ok {
+			batchTest.AssertSampleRate = rate
+		}
+
+		if size, ok := testMap["max_body_size"].(float64); ok {
+			batchTest.MaxBodySize = int64(size)
+		}
+
+		if jar, ok := testMap["cookie_jar"].(string); ok {
+			batchTest.CookieJar = jar
+		}
+
+		if file, ok := testMap["cookie_file"].(string); ok {
+			batchTest.CookieFile = file
+		}
+
+		if authMap, ok := testMap["auth"].(map[string]interface{}); ok {
+			data, err := json.Marshal(authMap)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth at index %d: %v", i, err)
+			}
+			var authCfg config.AuthConfig
+			if err := json.Unmarshal(data, &authCfg); err != nil {
+				return nil, fmt.Errorf("invalid auth at index %d: %v", i, err)
+			}
+			batchTest.Auth = &authCfg
+		}
+
+		if abortMap, ok := testMap["abort"].(map[string]interface{}); ok {
+			data, err := json.Marshal(abortMap)
+			if err != nil {
+				return nil, fmt.Errorf("invalid abort at index %d: %v", i, err)
+			}
+			var abortCfg config.AbortConfig
+			if err := json.Unmarshal(data, &abortCfg); err != nil {
+				return nil, fmt.Errorf("invalid abort at index %d: %v", i, err)
+			}
+			batchTest.Abort = &abortCfg
+		}
+
 		batchConfig.Tests = append(batchConfig.Tests, batchTest)
 	}
 
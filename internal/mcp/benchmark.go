@@ -2,9 +2,11 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,11 +28,14 @@ func (s *Server) handleBenchmark(ctx context.Context, req mcp.CallToolRequest) (
 	// Parse arguments
 	connections := mcp.ParseInt(req, "connections", 10)
 	durationStr := mcp.ParseString(req, "duration", "10s")
+	warmupStr := mcp.ParseString(req, "warmup", "0s")
 	threads := mcp.ParseInt(req, "threads", 2)
 	rate := mcp.ParseInt(req, "rate", 0)
 	requests := mcp.ParseInt(req, "requests", 0)
 	timeoutStr := mcp.ParseString(req, "timeout", "30s")
 	curlCommand := mcp.ParseString(req, "curl", "")
+	curlFile := mcp.ParseString(req, "curl_file", "")
+	loadStrategy := mcp.ParseString(req, "load_strategy", "random")
 	targetURL := mcp.ParseString(req, "url", "")
 	method := mcp.ParseString(req, "method", "GET")
 	headers := mcp.ParseStringMap(req, "headers", map[string]any{})
@@ -39,6 +44,7 @@ func (s *Server) handleBenchmark(ctx context.Context, req mcp.CallToolRequest) (
 	verbose := mcp.ParseBoolean(req, "verbose", false)
 	printLatency := mcp.ParseBoolean(req, "latency", false)
 	useNetHTTP := mcp.ParseBoolean(req, "use_nethttp", false)
+	abortMap := mcp.ParseStringMap(req, "abort", map[string]any{})
 
 	// Parse duration and timeout
 	duration, err := time.ParseDuration(durationStr)
@@ -53,15 +59,24 @@ func (s *Server) handleBenchmark(ctx context.Context, req mcp.CallToolRequest) (
 		return nil, fmt.Errorf("invalid timeout format: %w", err)
 	}
 
+	warmup, err := time.ParseDuration(warmupStr)
+	if err != nil {
+		Logger.Printf("Invalid warmup format: %v", err)
+		return nil, fmt.Errorf("invalid warmup format: %w", err)
+	}
+
 	// Create config
 	cfg := config.Config{
 		Connections:  connections,
 		Duration:     duration,
+		Warmup:       warmup,
 		Threads:      threads,
 		Rate:         rate,
 		Requests:     int64(requests),
 		Timeout:      timeout,
 		CurlCommand:  curlCommand,
+		CurlFile:     curlFile,
+		LoadStrategy: loadStrategy,
 		Method:       method,
 		Headers:      []string{}, // We'll add headers individually
 		Body:         body,
@@ -71,9 +86,28 @@ func (s *Server) handleBenchmark(ctx context.Context, req mcp.CallToolRequest) (
 		UseNetHTTP:   useNetHTTP,
 	}
 
+	if len(abortMap) > 0 {
+		data, err := json.Marshal(abortMap)
+		if err != nil {
+			return nil, fmt.Errorf("invalid abort: %w", err)
+		}
+		cfg.Abort = &config.AbortConfig{}
+		if err := json.Unmarshal(data, cfg.Abort); err != nil {
+			return nil, fmt.Errorf("invalid abort: %w", err)
+		}
+	}
+
 	var httpReq *http.Request
-	// Handle curl command or build request from parameters
-	if curlCommand != "" {
+	var requestList []*http.Request
+	// Handle curl file, curl command or build request from parameters
+	if curlFile != "" {
+		Logger.Printf("Parsing curl file: %s", curlFile)
+		requestList, err = parser.ParseCurlFile(curlFile)
+		if err != nil {
+			Logger.Printf("Failed to parse curl file: %v", err)
+			return nil, fmt.Errorf("failed to parse curl file: %w", err)
+		}
+	} else if curlCommand != "" {
 		Logger.Printf("Parsing curl command: %s", curlCommand)
 		httpReq, err = parser.ParseCurl(curlCommand)
 		if err != nil {
@@ -107,7 +141,12 @@ func (s *Server) handleBenchmark(ctx context.Context, req mcp.CallToolRequest) (
 	// Add headers
 	for k, v := range headers {
 		if vs, ok := v.(string); ok {
-			httpReq.Header.Set(k, vs)
+			if httpReq != nil {
+				httpReq.Header.Set(k, vs)
+			}
+			for _, r := range requestList {
+				r.Header.Set(k, vs)
+			}
 		}
 	}
 
@@ -119,7 +158,12 @@ func (s *Server) handleBenchmark(ctx context.Context, req mcp.CallToolRequest) (
 
 	// Create and run benchmark
 	Logger.Printf("Starting benchmark with %d connections, %d threads, duration %s", connections, threads, durationStr)
-	bench := benchmark.New(cfg, httpReq)
+	var bench *benchmark.Benchmark
+	if len(requestList) > 0 {
+		bench = benchmark.NewWithMultipleRequests(cfg, requestList)
+	} else {
+		bench = benchmark.New(cfg, httpReq)
+	}
 
 	// Create a new context for the benchmark
 	benchCtx, cancel := context.WithCancel(context.Background())
@@ -154,6 +198,14 @@ func formatBenchmarkResults(results *stats.Results, cfg config.Config) string {
 	result.WriteString(fmt.Sprintf("Running %s test\n", cfg.Duration))
 	result.WriteString(fmt.Sprintf("  %d threads and %d connections\n", cfg.Threads, cfg.Connections))
 
+	if reason := results.AbortReason(); reason != "" {
+		result.WriteString(fmt.Sprintf("  ABORTED: %s\n", reason))
+	}
+
+	if cfg.Warmup > 0 {
+		result.WriteString(benchmark.FormatWarmup(results, "  "))
+	}
+
 	// Thread Stats
 	result.WriteString("  Thread Stats   Avg      Stdev     Max   +/- Stdev\n")
 
@@ -219,6 +271,46 @@ func formatBenchmarkResults(results *stats.Results, cfg config.Config) string {
 		}
 	}
 
+	// Per-endpoint breakdown of multi-request runs
+	if endpoints := results.GetEndpointStats(); len(endpoints) > 1 {
+		keys := make([]string, 0, len(endpoints))
+		for key := range endpoints {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		result.WriteString("  Endpoints:\n")
+		for _, key := range keys {
+			ep := endpoints[key]
+			label := ep.URL
+			if ep.Name != "" {
+				label = fmt.Sprintf("[%s] %s", ep.Name, ep.URL)
+			}
+			percentiles := ep.GetLatencyPercentiles()
+			result.WriteString(fmt.Sprintf("    %s\n", label))
+			result.WriteString(fmt.Sprintf("      %d requests (%.1f%% of traffic), %d errors\n", ep.Requests, ep.Share*100, ep.Errors))
+			result.WriteString(fmt.Sprintf("      p50 %s  p90 %s  p99 %s\n", formatDuration(percentiles[50]), formatDuration(percentiles[90]), formatDuration(percentiles[99])))
+
+			codes := make([]int, 0, len(ep.StatusCodes))
+			for code := range ep.StatusCodes {
+				codes = append(codes, code)
+			}
+			sort.Ints(codes)
+			for _, code := range codes {
+				result.WriteString(fmt.Sprintf("      [%d] %d responses\n", code, ep.StatusCodes[code]))
+			}
+
+			classes := make([]string, 0, len(ep.ErrorClasses))
+			for class := range ep.ErrorClasses {
+				classes = append(classes, class)
+			}
+			sort.Strings(classes)
+			for _, class := range classes {
+				result.WriteString(fmt.Sprintf("      %s: %d errors\n", class, ep.ErrorClasses[class]))
+			}
+		}
+	}
+
 	result.WriteString(fmt.Sprintf("Requests/sec: %8.2f\n", rps))
 
 	return result.String()
@@ -56,8 +56,11 @@ func (s *Server) StartWithDebugLog(debugLogFile string) error {
 			mcp.WithDescription("Run an HTTP benchmark test and return performance statistics"),
 			mcp.WithString("url", mcp.Description("The URL to test (required if not using curl)")),
 			mcp.WithString("curl", mcp.Description("Curl command to parse and use for benchmarking (alternative to url)")),
+			mcp.WithString("curl_file", mcp.Description("Path to a file with multiple curl commands; results include a per-endpoint breakdown")),
+			mcp.WithString("load_strategy", mcp.Description("Load distribution strategy for curl_file: random, round-robin"), mcp.DefaultString("random")),
 			mcp.WithNumber("connections", mcp.Description("Number of HTTP connections to keep open"), mcp.DefaultNumber(10)),
 			mcp.WithString("duration", mcp.Description("Duration of test (e.g., \"30s\", \"1m\")"), mcp.DefaultString("10s")),
+			mcp.WithString("warmup", mcp.Description("Warm-up duration before measuring; excluded from the statistics (e.g., \"10s\")"), mcp.DefaultString("0s")),
 			mcp.WithNumber("threads", mcp.Description("Number of threads to use"), mcp.DefaultNumber(2)),
 			mcp.WithNumber("rate", mcp.Description("Work rate (requests/sec) 0=unlimited"), mcp.DefaultNumber(0)),
 			mcp.WithString("timeout", mcp.Description("Socket/request timeout (e.g., \"5s\")"), mcp.DefaultString("30s")),
@@ -68,6 +71,7 @@ func (s *Server) StartWithDebugLog(debugLogFile string) error {
 			mcp.WithBoolean("verbose", mcp.Description("Enable verbose output"), mcp.DefaultBool(false)),
 			mcp.WithBoolean("latency", mcp.Description("Print detailed latency statistics"), mcp.DefaultBool(false)),
 			mcp.WithBoolean("use_nethttp", mcp.Description("Force use standard library net/http instead of pulse"), mcp.DefaultBool(false)),
+			mcp.WithObject("abort", mcp.Description("Circuit breakers that stop the run early: error_rate (percent), error_window, p99, p99_for, connect_failures")),
 		),
 		WithLogging("handleBenchmark", s.handleBenchmark),
 	)
@@ -0,0 +1,304 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// latencyBuckets are the upper bounds (seconds) of the latency histogram
+var latencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry collects live metrics of benchmark runs and renders them in the
+// Prometheus text exposition format. Each run is identified by its labels.
+type Registry struct {
+	mu   sync.RWMutex
+	runs map[string]*RunCollector
+}
+
+// NewRegistry creates an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{
+		runs: make(map[string]*RunCollector),
+	}
+}
+
+// Collector returns a collector for a run identified by labels. It implements
+// benchmark.Observer and should be registered on the benchmark before Run.
+// A previous collector with identical labels is replaced, so counters of a
+// repeated (e.g. scheduled) run start from zero like a process restart.
+func (r *Registry) Collector(labels map[string]string) *RunCollector {
+	c := &RunCollector{
+		labels:      formatLabels(labels),
+		labelSet:    labels,
+		statusCodes: make(map[int]int64),
+		endpoints:   make(map[string]stats.EndpointCounters),
+		errors:      make(map[string]int64),
+		buckets:     make([]int64, len(latencyBuckets)),
+		active:      true,
+	}
+
+	r.mu.Lock()
+	r.runs[c.labels] = c
+	r.mu.Unlock()
+	return c
+}
+
+// Remove drops the collector registered with labels
+func (r *Registry) Remove(labels map[string]string) {
+	r.mu.Lock()
+	delete(r.runs, formatLabels(labels))
+	r.mu.Unlock()
+}
+
+// RemoveMatching drops the collectors whose labels include all of labels,
+// e.g. every test of a batch task
+func (r *Registry) RemoveMatching(labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, c := range r.runs {
+		if c.hasLabels(labels) {
+			delete(r.runs, key)
+		}
+	}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.Write(w)
+}
+
+// Write renders all metrics in the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	keys := make([]string, 0, len(r.runs))
+	for k := range r.runs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	collectors := make([]*RunCollector, 0, len(keys))
+	for _, k := range keys {
+		collectors = append(collectors, r.runs[k])
+	}
+	r.mu.RUnlock()
+
+	var b strings.Builder
+
+	b.WriteString("# HELP gurl_run_active Whether the run is still in progress (1) or finished (0).\n")
+	b.WriteString("# TYPE gurl_run_active gauge\n")
+	for _, c := range collectors {
+		c.mu.RLock()
+		fmt.Fprintf(&b, "gurl_run_active%s %d\n", braces(c.labels), boolToInt(c.active))
+		c.mu.RUnlock()
+	}
+
+	b.WriteString("# HELP gurl_requests_total Completed requests by HTTP status code.\n")
+	b.WriteString("# TYPE gurl_requests_total counter\n")
+	for _, c := range collectors {
+		c.mu.RLock()
+		for _, code := range sortedCodes(c.statusCodes) {
+			fmt.Fprintf(&b, "gurl_requests_total%s %d\n", braces(c.labels, label("status", strconv.Itoa(code))), c.statusCodes[code])
+		}
+		c.mu.RUnlock()
+	}
+
+	b.WriteString("# HELP gurl_endpoint_requests_total Completed requests by endpoint and HTTP status code.\n")
+	b.WriteString("# TYPE gurl_endpoint_requests_total counter\n")
+	for _, c := range collectors {
+		c.mu.RLock()
+		for _, ep := range sortedKeys(c.endpoints) {
+			counters := c.endpoints[ep]
+			for _, code := range sortedCodes(counters.StatusCodes) {
+				fmt.Fprintf(&b, "gurl_endpoint_requests_total%s %d\n",
+					braces(c.labels, label("endpoint", ep), label("status", strconv.Itoa(code))), counters.StatusCodes[code])
+			}
+		}
+		c.mu.RUnlock()
+	}
+
+	b.WriteString("# HELP gurl_endpoint_errors_total Failed requests by endpoint.\n")
+	b.WriteString("# TYPE gurl_endpoint_errors_total counter\n")
+	for _, c := range collectors {
+		c.mu.RLock()
+		for _, ep := range sortedKeys(c.endpoints) {
+			fmt.Fprintf(&b, "gurl_endpoint_errors_total%s %d\n", braces(c.labels, label("endpoint", ep)), c.endpoints[ep].Errors)
+		}
+		c.mu.RUnlock()
+	}
+
+	b.WriteString("# HELP gurl_errors_total Request errors by class.\n")
+	b.WriteString("# TYPE gurl_errors_total counter\n")
+	for _, c := range collectors {
+		c.mu.RLock()
+		for _, class := range sortedKeys(c.errors) {
+			fmt.Fprintf(&b, "gurl_errors_total%s %d\n", braces(c.labels, label("class", class)), c.errors[class])
+		}
+		c.mu.RUnlock()
+	}
+
+	b.WriteString("# HELP gurl_request_duration_seconds Request latency.\n")
+	b.WriteString("# TYPE gurl_request_duration_seconds histogram\n")
+	for _, c := range collectors {
+		c.mu.RLock()
+		var cumulative int64
+		for i, le := range latencyBuckets {
+			cumulative += c.buckets[i]
+			fmt.Fprintf(&b, "gurl_request_duration_seconds_bucket%s %d\n",
+				braces(c.labels, label("le", strconv.FormatFloat(le, 'g', -1, 64))), cumulative)
+		}
+		fmt.Fprintf(&b, "gurl_request_duration_seconds_bucket%s %d\n", braces(c.labels, label("le", "+Inf")), c.count)
+		fmt.Fprintf(&b, "gurl_request_duration_seconds_sum%s %g\n", braces(c.labels), c.sum)
+		fmt.Fprintf(&b, "gurl_request_duration_seconds_count%s %d\n", braces(c.labels), c.count)
+		c.mu.RUnlock()
+	}
+
+	b.WriteString("# HELP gurl_connections_in_flight Open connections to the target.\n")
+	b.WriteString("# TYPE gurl_connections_in_flight gauge\n")
+	for _, c := range collectors {
+		c.mu.RLock()
+		fmt.Fprintf(&b, "gurl_connections_in_flight%s %d\n", braces(c.labels), c.connections)
+		c.mu.RUnlock()
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RunCollector holds the metrics of a single run. It is fed by the sampling
+// loop through the benchmark.Observer callbacks.
+type RunCollector struct {
+	mu       sync.RWMutex
+	labels   string
+	labelSet map[string]string
+
+	active      bool
+	statusCodes map[int]int64
+	endpoints   map[string]stats.EndpointCounters
+	errors      map[string]int64
+	connections int64
+
+	latencyOffset int
+	buckets       []int64
+	sum           float64
+	count         int64
+}
+
+// hasLabels reports whether the labels of the collector include all of labels
+func (c *RunCollector) hasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if got, ok := c.labelSet[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// OnSample implements benchmark.Observer
+func (c *RunCollector) OnSample(results *stats.Results, _ stats.Sample) {
+	c.collect(results)
+}
+
+// OnFinish implements benchmark.Observer
+func (c *RunCollector) OnFinish(results *stats.Results) {
+	c.collect(results)
+	c.mu.Lock()
+	c.active = false
+	c.connections = 0
+	c.mu.Unlock()
+}
+
+// collect adds the latencies recorded since the previous call. The offset
+// is read and advanced under one lock, so concurrent calls never count a
+// latency twice.
+func (c *RunCollector) collect(results *stats.Results) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	latencies, next := results.GetLatenciesSince(c.latencyOffset)
+	statusCodes := results.GetStatusCodes()
+	endpoints := results.GetEndpointCounters()
+	errors := results.GetErrorsByClass()
+	connections := results.GetActiveConnections()
+
+	c.latencyOffset = next
+	for _, lat := range latencies {
+		sec := lat.Seconds()
+		idx := sort.SearchFloat64s(latencyBuckets, sec)
+		if idx < len(latencyBuckets) {
+			c.buckets[idx]++
+		}
+		c.sum += sec
+		c.count++
+	}
+	c.statusCodes = statusCodes
+	c.endpoints = endpoints
+	c.errors = errors
+	c.connections = connections
+}
+
+// formatLabels renders labels as a sorted, escaped `k="v",...` string
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, label(k, labels[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func label(name, value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return name + `="` + value + `"`
+}
+
+func braces(parts ...string) string {
+	nonEmpty := parts[:0:0]
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(nonEmpty, ",") + "}"
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sortedCodes(m map[int]int64) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func TestRegistryExposition(t *testing.T) {
+	results := stats.NewResults()
+	results.AddLatency(3 * time.Millisecond)
+	results.AddStatusCode(200)
+	results.AddLatency(200 * time.Millisecond)
+	results.AddStatusCode(503)
+	results.AddError(errors.New("assertion failed at line 1"))
+	results.ConnOpened()
+
+	reg := NewRegistry()
+	c := reg.Collector(map[string]string{"target": `http://x/"q"`})
+	c.OnSample(results, stats.Sample{})
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`gurl_run_active{target="http://x/\"q\""} 1`,
+		`gurl_requests_total{target="http://x/\"q\"",status="200"} 1`,
+		`gurl_requests_total{target="http://x/\"q\"",status="503"} 1`,
+		`gurl_errors_total{target="http://x/\"q\"",class="assert"} 1`,
+		`gurl_request_duration_seconds_bucket{target="http://x/\"q\"",le="0.005"} 1`,
+		`gurl_request_duration_seconds_bucket{target="http://x/\"q\"",le="0.25"} 2`,
+		`gurl_request_duration_seconds_count{target="http://x/\"q\""} 2`,
+		`gurl_connections_in_flight{target="http://x/\"q\""} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output is missing %q\n%s", want, body)
+		}
+	}
+
+	// Latencies already counted must not be added again by the next sample
+	c.OnFinish(results)
+	rec = httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body = rec.Body.String()
+	if !strings.Contains(body, `gurl_request_duration_seconds_count{target="http://x/\"q\""} 2`) {
+		t.Errorf("latencies must not be double counted:\n%s", body)
+	}
+	if !strings.Contains(body, `gurl_run_active{target="http://x/\"q\""} 0`) {
+		t.Errorf("finished run should be inactive:\n%s", body)
+	}
+}
+
+func TestCollectorConcurrentSamples(t *testing.T) {
+	results := stats.NewResults()
+	for i := 0; i < 1000; i++ {
+		results.AddLatency(time.Millisecond)
+	}
+
+	c := NewRegistry().Collector(map[string]string{"target": "http://x/"})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.OnSample(results, stats.Sample{})
+		}()
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.count != 1000 {
+		t.Errorf("expected 1000 latencies counted once, got %d", c.count)
+	}
+}
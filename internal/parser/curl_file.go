@@ -2,15 +2,32 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
 )
 
+// requestNameKey is the context key of the name given to a request by "# @name"
+type requestNameKey struct{}
+
+// RequestName returns the name given to req in a curl file, or "" if it has none
+func RequestName(req *http.Request) string {
+	name, _ := req.Context().Value(requestNameKey{}).(string)
+	return name
+}
+
+// WithRequestName returns a shallow copy of req carrying name
+func WithRequestName(req *http.Request, name string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), requestNameKey{}, name))
+}
+
 // ParseCurlFile reads and parses multiple curl commands from a file
 // Each line should contain one curl command
-// Empty lines and lines starting with # are ignored
+// Empty lines and lines starting with # are ignored, except "# @name <name>"
+// which names the next command so its statistics are reported under that
+// name instead of the URL
 func ParseCurlFile(filePath string) ([]*http.Request, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -21,13 +38,21 @@ func ParseCurlFile(filePath string) ([]*http.Request, error) {
 	var requests []*http.Request
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
+	name := ""
 
 	for scanner.Scan() {
 		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
+		if comment, ok := strings.CutPrefix(line, "#"); ok {
+			if n, ok := nameDirective(comment); ok {
+				name = n
+			}
+			continue
+		}
+
+		// Skip empty lines
+		if line == "" {
 			continue
 		}
 
@@ -36,6 +61,10 @@ func ParseCurlFile(filePath string) ([]*http.Request, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse curl command at line %d: %w", lineNum, err)
 		}
+		if name != "" {
+			req = WithRequestName(req, name)
+			name = ""
+		}
 
 		requests = append(requests, req)
 	}
@@ -50,3 +79,13 @@ func ParseCurlFile(filePath string) ([]*http.Request, error) {
 
 	return requests, nil
 }
+
+// nameDirective returns the name given by a "# @name <name>" comment. The
+// directive must be followed by whitespace, so "# @namespace" is a plain comment.
+func nameDirective(comment string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(comment), "@name")
+	if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCurlFileNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.txt")
+	content := `# @name list users
+curl http://localhost/users
+# @namespace is a plain comment
+curl http://localhost/orders
+#@name	health
+curl http://localhost/health
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs, err := ParseCurlFile(path)
+	if err != nil {
+		t.Fatalf("ParseCurlFile: %v", err)
+	}
+	want := []string{"list users", "", "health"}
+	if len(reqs) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(reqs), len(want))
+	}
+	for i, req := range reqs {
+		if got := RequestName(req); got != want[i] {
+			t.Errorf("request %d (%s): name = %q, want %q", i, req.URL, got, want[i])
+		}
+	}
+}
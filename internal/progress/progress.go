@@ -0,0 +1,217 @@
+// Package progress prints a compact line every few seconds while a run is in
+// progress, for CI logs and other places where the live terminal UI does not
+// work.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// Progress modes
+const (
+	ModeAuto = "auto" // text when stdout is not a terminal, otherwise off
+	ModeText = "text"
+	ModeJSON = "json" // one JSON object per line
+	ModeOff  = "off"
+)
+
+// DefaultInterval is the default time between progress lines
+const DefaultInterval = 5 * time.Second
+
+// minWindow is the shortest interval a line is printed for
+const minWindow = 500 * time.Millisecond
+
+// Resolve turns a mode into the format to print, "" meaning no progress.
+// Auto only prints when stdout is not a terminal, so interactive runs keep
+// their current output.
+func Resolve(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ModeAuto:
+		if IsTerminal(os.Stdout) {
+			return "", nil
+		}
+		return ModeText, nil
+	case ModeText:
+		return ModeText, nil
+	case ModeJSON:
+		return ModeJSON, nil
+	case ModeOff:
+		return "", nil
+	}
+	return "", fmt.Errorf("invalid progress mode '%s', expected auto, text, json or off", mode)
+}
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Line is one progress report. Interval values cover the time since the
+// previous line, totals cover the whole measured run.
+type Line struct {
+	Time           time.Time         `json:"time"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	ElapsedSec     float64           `json:"elapsed_sec"`
+	Requests       int64             `json:"requests"`
+	Errors         int64             `json:"errors"`
+	RequestsPerSec float64           `json:"requests_per_sec"`
+	P50Ms          float64           `json:"p50_ms"`
+	P99Ms          float64           `json:"p99_ms"`
+	StatusCodes    map[int]int64     `json:"status_codes"`
+	TotalRequests  int64             `json:"total_requests"`
+	TotalErrors    int64             `json:"total_errors"`
+}
+
+// Reporter implements benchmark.Observer and writes a progress line every
+// interval. The sampling loop ticks once per second, so the interval is
+// rounded to whole seconds.
+type Reporter struct {
+	out    io.Writer
+	format string
+	every  int
+	labels map[string]string
+
+	mu            sync.Mutex
+	samples       int
+	requests      int64
+	errors        int64
+	statusCodes   map[int]int64
+	totalRequests int64
+	totalErrors   int64
+	latencyOffset int
+	lastElapsed   time.Duration
+}
+
+// New creates a reporter writing format (ModeText or ModeJSON) to out.
+// labels identify the run, e.g. the batch test name.
+func New(out io.Writer, format string, interval time.Duration, labels map[string]string) *Reporter {
+	return &Reporter{
+		out:         out,
+		format:      format,
+		every:       max(1, int(interval/time.Second)),
+		labels:      labels,
+		statusCodes: make(map[int]int64),
+	}
+}
+
+// OnSample implements benchmark.Observer
+func (r *Reporter) OnSample(results *stats.Results, sample stats.Sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.samples++
+	r.requests += sample.Requests
+	r.errors += sample.Errors
+	r.totalRequests += sample.Requests
+	r.totalErrors += sample.Errors
+	for code, count := range sample.StatusCodes {
+		r.statusCodes[code] += count
+	}
+	// The last sample of a run can cover only a few milliseconds, which gives
+	// a meaningless rate; the final summary reports it instead.
+	window := sample.Elapsed - r.lastElapsed
+	if r.samples < r.every || window < minWindow {
+		return
+	}
+
+	latencies, next := results.GetLatenciesSince(r.latencyOffset)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	line := Line{
+		Time:          time.Now(),
+		Labels:        r.labels,
+		ElapsedSec:    sample.Elapsed.Seconds(),
+		Requests:      r.requests,
+		Errors:        r.errors,
+		P50Ms:         ms(percentile(latencies, 50)),
+		P99Ms:         ms(percentile(latencies, 99)),
+		StatusCodes:   r.statusCodes,
+		TotalRequests: r.totalRequests,
+		TotalErrors:   r.totalErrors,
+	}
+	line.RequestsPerSec = float64(r.requests) / window.Seconds()
+	r.write(line)
+
+	r.samples = 0
+	r.requests = 0
+	r.errors = 0
+	r.statusCodes = make(map[int]int64)
+	r.latencyOffset = next
+	r.lastElapsed = sample.Elapsed
+}
+
+// OnFinish implements benchmark.Observer. The final results are printed by
+// the caller, so nothing is written here.
+func (r *Reporter) OnFinish(results *stats.Results) {}
+
+// write prints a line in the configured format; callers hold r.mu
+func (r *Reporter) write(line Line) {
+	if r.format == ModeJSON {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(r.out, "%s\n", data)
+		return
+	}
+	fmt.Fprintln(r.out, FormatText(line))
+}
+
+// FormatText renders a line as compact text, e.g.
+//
+//	[login] 10s  1234.5 req/s  p50 1.20ms  p99 8.40ms  errors 3 (0.24%)  2xx=1231 5xx=3
+func FormatText(line Line) string {
+	var b strings.Builder
+	if name := line.Labels["test"]; name != "" {
+		fmt.Fprintf(&b, "[%s] ", name)
+	}
+	fmt.Fprintf(&b, "%ds  %.1f req/s  p50 %.2fms  p99 %.2fms  errors %d",
+		int(line.ElapsedSec+0.5), line.RequestsPerSec, line.P50Ms, line.P99Ms, line.Errors)
+	if line.Requests > 0 && line.Errors > 0 {
+		fmt.Fprintf(&b, " (%.2f%%)", float64(line.Errors)/float64(line.Requests)*100)
+	}
+	if summary := statusSummary(line.StatusCodes); summary != "" {
+		fmt.Fprintf(&b, "  %s", summary)
+	}
+	return b.String()
+}
+
+// statusSummary groups status codes by class, e.g. "2xx=1231 5xx=3"
+func statusSummary(codes map[int]int64) string {
+	classes := make(map[int]int64)
+	for code, count := range codes {
+		classes[code/100] += count
+	}
+	keys := make([]int, 0, len(classes))
+	for k := range classes {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%dxx=%d", k, classes[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// percentile returns the p-th percentile of an ascending slice
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p/100.0)]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func TestReporterPrintsEveryInterval(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out, ModeText, 2*time.Second, map[string]string{"test": "login"})
+	results := stats.NewResults()
+
+	for i := 1; i <= 4; i++ {
+		for j := 0; j < 10; j++ {
+			results.AddLatency(time.Duration(j+1) * time.Millisecond)
+		}
+		r.OnSample(results, stats.Sample{
+			Elapsed:     time.Duration(i) * time.Second,
+			Requests:    10,
+			Errors:      1,
+			StatusCodes: map[int]int64{200: 9, 503: 1},
+		})
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), out.String())
+	}
+	want := "[login] 4s  10.0 req/s  p50 5.00ms  p99 10.00ms  errors 2 (10.00%)  2xx=18 5xx=2"
+	if lines[1] != want {
+		t.Errorf("line = %q, want %q", lines[1], want)
+	}
+}
+
+func TestReporterJSONLines(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out, ModeJSON, time.Second, map[string]string{"target": "http://example.com"})
+	results := stats.NewResults()
+	results.AddLatency(3 * time.Millisecond)
+
+	r.OnSample(results, stats.Sample{Elapsed: time.Second, Requests: 5, StatusCodes: map[int]int64{200: 5}})
+	r.OnSample(results, stats.Sample{Elapsed: 2 * time.Second, Requests: 7, StatusCodes: map[int]int64{200: 7}})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var line Line
+	if err := json.Unmarshal([]byte(lines[1]), &line); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if line.Requests != 7 || line.TotalRequests != 12 || line.RequestsPerSec != 7 {
+		t.Errorf("unexpected counts: %+v", line)
+	}
+	if line.P99Ms != 0 {
+		t.Errorf("second interval has no new latencies, p99 = %v", line.P99Ms)
+	}
+	if line.StatusCodes[200] != 7 || line.Labels["target"] != "http://example.com" {
+		t.Errorf("unexpected line: %+v", line)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	for mode, want := range map[string]string{"text": ModeText, "JSON": ModeJSON, "off": ""} {
+		got, err := Resolve(mode)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", mode, got, err, want)
+		}
+	}
+	if _, err := Resolve("xml"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestReporterSkipsShortTail(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out, ModeText, time.Second, nil)
+	results := stats.NewResults()
+
+	r.OnSample(results, stats.Sample{Elapsed: time.Second, Requests: 10})
+	r.OnSample(results, stats.Sample{Elapsed: time.Second + 20*time.Millisecond, Requests: 1})
+
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Errorf("expected the short final sample to be skipped, got %q", out.String())
+	}
+}
@@ -0,0 +1,164 @@
+// Package readiness polls a target until it passes its assertions, so that
+// runs do not start while the service under test is still booting.
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/asserts"
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/parser"
+)
+
+// attemptTimeout caps a single probe so that a hung connection does not use
+// up the whole wait
+const attemptTimeout = 5 * time.Second
+
+// Attempt is the outcome of one readiness probe
+type Attempt struct {
+	N        int
+	Status   int // 0 when no response was received
+	Duration time.Duration
+	Err      error // request error or first failed assertion, nil when ready
+}
+
+// String describes the attempt in one line
+func (a Attempt) String() string {
+	if a.Status == 0 {
+		return fmt.Sprintf("attempt %d: %v", a.N, a.Err)
+	}
+	if a.Err != nil {
+		return fmt.Sprintf("attempt %d: status %d in %s: %v", a.N, a.Status, a.Duration.Round(time.Microsecond), a.Err)
+	}
+	return fmt.Sprintf("attempt %d: status %d in %s", a.N, a.Status, a.Duration.Round(time.Microsecond))
+}
+
+// NotReadyError is returned when the target did not become ready in time
+type NotReadyError struct {
+	Target  string
+	Assert  string
+	Timeout time.Duration
+	Elapsed time.Duration
+	Last    Attempt
+}
+
+func (e *NotReadyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "target not ready after %s (%d attempts, timeout %s)\n", e.Elapsed.Round(time.Millisecond), e.Last.N, e.Timeout)
+	fmt.Fprintf(&b, "  target: %s\n", e.Target)
+	fmt.Fprintf(&b, "  assert: %s\n", strings.ReplaceAll(strings.TrimSpace(e.Assert), "\n", "\n          "))
+	fmt.Fprintf(&b, "  last %s", e.Last)
+	return b.String()
+}
+
+// Wait polls the target of cfg until all of its assertions pass, the timeout
+// expires or ctx is cancelled. onAttempt, when not nil, is called after every
+// attempt. It returns the successful attempt.
+func Wait(ctx context.Context, cfg *config.WaitConfig, onAttempt func(Attempt)) (Attempt, error) {
+	// Parse errors do not go away by retrying
+	if _, err := newRequest(cfg.Target); err != nil {
+		return Attempt{}, err
+	}
+
+	timeout := cfg.GetTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: attemptTimeout}
+	start := time.Now()
+	var last Attempt
+	for n := 1; ctx.Err() == nil; n++ {
+		attempt := probe(ctx, client, cfg, n)
+		if attempt.Status == 0 && ctx.Err() != nil && last.N > 0 {
+			// cut short by the deadline, the previous attempt tells more
+			break
+		}
+		last = attempt
+		if onAttempt != nil {
+			onAttempt(last)
+		}
+		if last.Err == nil {
+			return last, nil
+		}
+
+		timer := time.NewTimer(cfg.GetInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+
+	if ctx.Err() == context.Canceled {
+		return last, ctx.Err()
+	}
+	return last, &NotReadyError{
+		Target:  cfg.Target,
+		Assert:  cfg.GetAssert(),
+		Timeout: timeout,
+		Elapsed: time.Since(start),
+		Last:    last,
+	}
+}
+
+// probe sends one request and evaluates the assertions against its response
+func probe(ctx context.Context, client *http.Client, cfg *config.WaitConfig, n int) Attempt {
+	attempt := Attempt{N: n}
+
+	req, err := newRequest(cfg.Target)
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, config.DefaultMaxBodySize))
+	attempt.Status = resp.StatusCode
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+
+	attempt.Err = asserts.Evaluate(cfg.GetAssert(), &asserts.HTTPResponse{
+		Status:   resp.StatusCode,
+		Headers:  resp.Header,
+		Body:     body,
+		Duration: attempt.Duration,
+	})
+	return attempt
+}
+
+// newRequest builds the probe request from a curl command or a plain URL.
+// The request is rebuilt for every attempt so that its body can be resent.
+func newRequest(target string) (*http.Request, error) {
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(target, "curl ") {
+		req, err := parser.ParseCurl(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse wait_for curl command: %w", err)
+		}
+		return req, nil
+	}
+
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = "http://" + target
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wait_for URL: %w", err)
+	}
+	return req, nil
+}
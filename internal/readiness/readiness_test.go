@@ -0,0 +1,87 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+func TestWaitUntilReady(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"up"}`))
+	}))
+	defer srv.Close()
+
+	var seen []Attempt
+	cfg := &config.WaitConfig{
+		Target:   srv.URL + "/health",
+		Assert:   "status == 200\ngjson \"status\" == \"up\"",
+		Interval: "10ms",
+	}
+	attempt, err := Wait(context.Background(), cfg, func(a Attempt) { seen = append(seen, a) })
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if attempt.N != 3 || attempt.Status != 200 || len(seen) != 3 {
+		t.Fatalf("expected ready on the third attempt, got %+v (%d callbacks)", attempt, len(seen))
+	}
+	if seen[0].Status != 503 || seen[0].Err == nil {
+		t.Errorf("first attempt should fail its assertion: %+v", seen[0])
+	}
+}
+
+func TestWaitCurlCommand(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Probe") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &config.WaitConfig{Target: "curl -X POST -H 'X-Probe: 1' " + srv.URL, Interval: "10ms", Timeout: "1s"}
+	if _, err := Wait(context.Background(), cfg, nil); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+}
+
+func TestWaitNotReady(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	cfg := &config.WaitConfig{Target: srv.URL, Timeout: "100ms", Interval: "20ms"}
+	_, err := Wait(context.Background(), cfg, nil)
+
+	var notReady *NotReadyError
+	if !errors.As(err, &notReady) {
+		t.Fatalf("expected NotReadyError, got %v", err)
+	}
+	if notReady.Last.N < 2 || notReady.Last.Status != 502 {
+		t.Errorf("unexpected last attempt: %+v", notReady.Last)
+	}
+	msg := err.Error()
+	for _, want := range []string{"target not ready after", "assert: status == 200", "status 502"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("report %q does not contain %q", msg, want)
+		}
+	}
+}
+
+func TestWaitInvalidTarget(t *testing.T) {
+	_, err := Wait(context.Background(), &config.WaitConfig{Target: "http://[::1"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid wait_for URL") {
+		t.Fatalf("expected an invalid URL error, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+// GurlCharts: tiny dependency-free canvas charts used by the HTML report
+// and the API dashboard.
+(function (global) {
+  "use strict";
+
+  var PALETTE = ["#1565c0", "#ef6c00", "#2e7d32", "#c62828", "#6a1b9a", "#00838f", "#9e9d24", "#5d4037"];
+
+  function setup(canvas) {
+    var ratio = global.devicePixelRatio || 1;
+    var w = canvas.clientWidth || 600;
+    var h = canvas.clientHeight || 240;
+    canvas.width = w * ratio;
+    canvas.height = h * ratio;
+    var ctx = canvas.getContext("2d");
+    ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+    ctx.clearRect(0, 0, w, h);
+    ctx.font = "11px sans-serif";
+    return { ctx: ctx, w: w, h: h };
+  }
+
+  function niceMax(v) {
+    if (v <= 0) return 1;
+    var p = Math.pow(10, Math.floor(Math.log(v) / Math.LN10));
+    var n = v / p;
+    if (n <= 1) return p;
+    if (n <= 2) return 2 * p;
+    if (n <= 5) return 5 * p;
+    return 10 * p;
+  }
+
+  function fmt(v) {
+    if (v >= 1e6) return (v / 1e6).toFixed(1) + "M";
+    if (v >= 1e3) return (v / 1e3).toFixed(1) + "K";
+    if (v >= 100 || v === Math.floor(v)) return v.toFixed(0);
+    return v.toFixed(2);
+  }
+
+  function axes(c, pad, maxY, unit) {
+    var ctx = c.ctx;
+    ctx.strokeStyle = "#ddd";
+    ctx.fillStyle = "#777";
+    ctx.textAlign = "right";
+    ctx.textBaseline = "middle";
+    for (var i = 0; i <= 4; i++) {
+      var y = pad.t + (c.h - pad.t - pad.b) * (1 - i / 4);
+      ctx.beginPath();
+      ctx.moveTo(pad.l, y);
+      ctx.lineTo(c.w - pad.r, y);
+      ctx.stroke();
+      ctx.fillText(fmt(maxY * i / 4) + (unit || ""), pad.l - 4, y);
+    }
+  }
+
+  function legend(canvas, series) {
+    var el = canvas.nextElementSibling;
+    if (!el || el.className !== "legend") {
+      el = document.createElement("div");
+      el.className = "legend";
+      canvas.parentNode.insertBefore(el, canvas.nextSibling);
+    }
+    el.innerHTML = "";
+    series.forEach(function (s, i) {
+      var span = document.createElement("span");
+      var sw = document.createElement("i");
+      sw.style.background = s.color || PALETTE[i % PALETTE.length];
+      span.appendChild(sw);
+      span.appendChild(document.createTextNode(s.name));
+      el.appendChild(span);
+    });
+  }
+
+  // line draws one or more series sharing the x axis.
+  // opts: {x: [numbers], series: [{name, data, color}], unit, xUnit}
+  function line(canvas, opts) {
+    var c = setup(canvas);
+    var pad = { l: 56, r: 12, t: 10, b: 22 };
+    var xs = opts.x || [];
+    var maxY = 0;
+    opts.series.forEach(function (s) {
+      s.data.forEach(function (v) { if (v > maxY) maxY = v; });
+    });
+    maxY = niceMax(maxY);
+    axes(c, pad, maxY, opts.unit);
+
+    var ctx = c.ctx;
+    var minX = xs.length ? xs[0] : 0;
+    var maxX = xs.length ? xs[xs.length - 1] : 1;
+    if (maxX <= minX) maxX = minX + 1;
+    var px = function (x) { return pad.l + (c.w - pad.l - pad.r) * (x - minX) / (maxX - minX); };
+    var py = function (y) { return pad.t + (c.h - pad.t - pad.b) * (1 - y / maxY); };
+
+    ctx.fillStyle = "#777";
+    ctx.textAlign = "center";
+    ctx.textBaseline = "top";
+    var ticks = Math.min(xs.length, 8);
+    for (var i = 0; i < ticks; i++) {
+      var idx = Math.round(i * (xs.length - 1) / Math.max(ticks - 1, 1));
+      ctx.fillText(fmt(xs[idx]) + (opts.xUnit || ""), px(xs[idx]), c.h - pad.b + 4);
+    }
+
+    (opts.markers || []).forEach(function (m) {
+      ctx.strokeStyle = "#aaa";
+      ctx.setLineDash([4, 3]);
+      ctx.beginPath();
+      ctx.moveTo(px(m.x), pad.t);
+      ctx.lineTo(px(m.x), c.h - pad.b);
+      ctx.stroke();
+      ctx.setLineDash([]);
+      ctx.textAlign = "left";
+      ctx.fillText(m.label, px(m.x) + 3, pad.t);
+    });
+
+    opts.series.forEach(function (s, si) {
+      ctx.strokeStyle = s.color || PALETTE[si % PALETTE.length];
+      ctx.lineWidth = 1.5;
+      ctx.beginPath();
+      s.data.forEach(function (v, i) {
+        var x = px(xs[i]), y = py(v);
+        if (i === 0) ctx.moveTo(x, y); else ctx.lineTo(x, y);
+      });
+      ctx.stroke();
+    });
+    legend(canvas, opts.series);
+  }
+
+  // bar draws a single categorical series.
+  // opts: {labels: [strings], data: [numbers], color, unit}
+  function bar(canvas, opts) {
+    var c = setup(canvas);
+    var pad = { l: 56, r: 12, t: 10, b: 40 };
+    var data = opts.data || [];
+    var maxY = niceMax(Math.max.apply(null, data.concat([0])));
+    axes(c, pad, maxY, opts.unit);
+
+    var ctx = c.ctx;
+    var n = Math.max(data.length, 1);
+    var slot = (c.w - pad.l - pad.r) / n;
+    var bw = Math.max(slot * 0.8, 1);
+    ctx.fillStyle = opts.color || PALETTE[0];
+    data.forEach(function (v, i) {
+      var hgt = (c.h - pad.t - pad.b) * v / maxY;
+      ctx.fillRect(pad.l + i * slot + (slot - bw) / 2, c.h - pad.b - hgt, bw, hgt);
+    });
+
+    ctx.fillStyle = "#777";
+    ctx.textBaseline = "top";
+    var labels = opts.labels || [];
+    var every = Math.ceil(labels.length / Math.max(Math.floor((c.w - pad.l - pad.r) / 70), 1));
+    labels.forEach(function (l, i) {
+      if (i % every !== 0) return;
+      ctx.save();
+      ctx.translate(pad.l + i * slot + slot / 2, c.h - pad.b + 4);
+      ctx.rotate(-Math.PI / 10);
+      ctx.textAlign = "right";
+      ctx.fillText(l, 0, 0);
+      ctx.restore();
+    });
+  }
+
+  global.GurlCharts = { line: line, bar: bar, palette: PALETTE, format: fmt };
+})(window);
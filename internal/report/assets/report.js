@@ -0,0 +1,194 @@
+// GurlReport renders the JSON produced by internal/report into a DOM node.
+(function (global) {
+  "use strict";
+
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) { node.setAttribute(k, attrs[k]); });
+    (children || []).forEach(function (c) {
+      if (c === null || c === undefined) return;
+      node.appendChild(typeof c === "string" || typeof c === "number" ? document.createTextNode(String(c)) : c);
+    });
+    return node;
+  }
+
+  function table(headers, rows, numeric) {
+    numeric = numeric || {};
+    var head = el("tr", {}, headers.map(function (h, i) {
+      return el("th", numeric[i] ? { "class": "num" } : {}, [h]);
+    }));
+    var body = rows.map(function (r) {
+      return el("tr", {}, r.map(function (v, i) {
+        var cell = v instanceof Node ? v : String(v);
+        return el("td", numeric[i] ? { "class": "num" } : {}, [cell]);
+      }));
+    });
+    return el("table", {}, [el("thead", {}, [head]), el("tbody", {}, body)]);
+  }
+
+  function kpi(label, value) {
+    return el("div", { "class": "kpi" }, [el("div", { "class": "v" }, [value]), el("div", { "class": "k" }, [label])]);
+  }
+
+  function bytes(n) {
+    var units = ["B", "KB", "MB", "GB", "TB"];
+    var i = 0;
+    while (n >= 1024 && i < units.length - 1) { n /= 1024; i++; }
+    return (i === 0 ? n : n.toFixed(1)) + units[i];
+  }
+
+  function ms(v) { return v.toFixed(2) + "ms"; }
+
+  function configTable(entries) {
+    if (!entries || !entries.length) return el("p", { "class": "empty" }, ["no configuration recorded"]);
+    return table(["Option", "Value"], entries.map(function (e) { return [e.key, el("pre", {}, [e.value])]; }));
+  }
+
+  function chartCard(title, draw) {
+    var canvas = el("canvas", {});
+    var card = el("div", { "class": "card" }, [el("h3", {}, [title]), canvas]);
+    // 等节点挂到文档后再绘制，才能拿到实际宽度
+    global.requestAnimationFrame(function () { draw(canvas); });
+    return card;
+  }
+
+  function renderRun(run, charts) {
+    var s = run.summary;
+    var children = [];
+    var title = [run.name];
+    if (run.status) title.push(" ", el("span", { "class": "status-" + run.status }, [run.status]));
+    children.push(el("h2", {}, title));
+    if (run.error) children.push(el("p", { "class": "fail" }, [run.error]));
+    if (run.aborted) children.push(el("p", { "class": "warn" }, ["Aborted: " + run.aborted]));
+
+    children.push(el("div", { "class": "card kpis" }, [
+      kpi("requests", s.requests),
+      kpi("errors", s.errors),
+      kpi("req/sec", s.requests_per_sec.toFixed(2)),
+      kpi("duration", s.duration_sec.toFixed(2) + "s"),
+      kpi("avg", ms(s.avg_ms)),
+      kpi("p50", ms(s.p50_ms)),
+      kpi("p90", ms(s.p90_ms)),
+      kpi("p99", ms(s.p99_ms)),
+      kpi("max", ms(s.max_ms)),
+      kpi("read", bytes(s.read_bytes)),
+      kpi("write", bytes(s.write_bytes))
+    ]));
+    if (run.warmup) {
+      var w = run.warmup;
+      children.push(el("p", { "class": "meta" }, [
+        "Warm-up (excluded): " + w.requests + " requests in " + w.duration_sec.toFixed(2) + "s, " +
+        w.requests_per_sec.toFixed(2) + " req/s, avg " + ms(w.avg_ms) + ", p99 " + ms(w.p99_ms) + ", " + w.errors + " errors"
+      ]));
+    }
+
+    var t = run.timeline || [];
+    var x = t.map(function (p) { return p.t; });
+    var markers = (run.annotations || []).map(function (a) { return { x: a.t, label: a.label }; });
+    if (charts !== false) {
+      children.push(el("div", { "class": "grid" }, [
+        chartCard("Throughput (req/s)", function (c) {
+          GurlCharts.line(c, { x: x, xUnit: "s", markers: markers, series: [
+            { name: "requests", data: t.map(function (p) { return p.rps; }) },
+            { name: "errors", data: t.map(function (p) { return p.errors; }), color: "#c62828" }
+          ] });
+        }),
+        chartCard("Latency percentiles (ms)", function (c) {
+          GurlCharts.line(c, { x: x, xUnit: "s", unit: "ms", markers: markers, series: [
+            { name: "p50", data: t.map(function (p) { return p.p50; }) },
+            { name: "p90", data: t.map(function (p) { return p.p90; }) },
+            { name: "p99", data: t.map(function (p) { return p.p99; }) }
+          ] });
+        }),
+        chartCard("Latency histogram", function (c) {
+          GurlCharts.bar(c, {
+            labels: (run.histogram || []).map(function (b) { return b.label; }),
+            data: (run.histogram || []).map(function (b) { return b.count; })
+          });
+        })
+      ]));
+    }
+
+    var breakdown = function (title, rows) {
+      var total = rows.reduce(function (a, r) { return a + r.count; }, 0);
+      return el("div", { "class": "card" }, [
+        el("h3", {}, [title]),
+        rows.length ? table(["", "Count", "%"], rows.map(function (r) {
+          return [r.label, r.count, total ? (100 * r.count / total).toFixed(1) : "0"];
+        }), { 1: true, 2: true }) : el("p", { "class": "empty" }, ["none"])
+      ]);
+    };
+    children.push(el("div", { "class": "grid" }, [
+      breakdown("Status codes", run.status_codes || []),
+      breakdown("Errors by class", run.errors_by_class || [])
+    ]));
+
+    if (run.endpoints && run.endpoints.length) {
+      children.push(el("div", { "class": "card" }, [
+        el("h3", {}, ["Per-endpoint statistics"]),
+        table(["Endpoint", "Requests", "Share", "Errors", "Req/s", "Avg", "p50", "p90", "p99", "Max", "Status", "Errors by class"],
+          run.endpoints.map(function (e) {
+            var name = e.url ? el("span", { "title": e.url }, [e.endpoint]) : e.endpoint;
+            return [name, e.requests, (100 * (e.share || 0)).toFixed(1) + "%", e.errors, e.requests_per_sec.toFixed(2),
+              ms(e.avg_ms), ms(e.p50_ms || 0), ms(e.p90_ms || 0), ms(e.p99_ms || 0), ms(e.max_ms), e.status_codes, e.errors_by_class || ""];
+          }), { 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true })
+      ]));
+    }
+
+    if (run.asserts) {
+      var a = run.asserts;
+      var assertCard = [
+        el("h3", {}, ["Assertions (" + a.evaluated + " responses evaluated)"]),
+        table(["Assertion", "Passed", "Failed", "Pass %"], (a.rows || []).map(function (r) {
+          var total = r.passed + r.failed;
+          return [el("pre", {}, [r.assert]), r.passed, el("span", { "class": r.failed ? "fail" : "ok" }, [String(r.failed)]),
+            total ? (100 * r.passed / total).toFixed(1) : "0"];
+        }), { 1: true, 2: true, 3: true })
+      ];
+      if (a.failures && a.failures.length) {
+        assertCard.push(el("h3", {}, ["Failing response samples"]));
+        assertCard.push(table(["Status", "Reason", "Body"], a.failures.map(function (f) {
+          return [f.status, f.message, el("pre", {}, [(f.body || "") + (f.truncated ? "..." : "")])];
+        })));
+      }
+      children.push(el("div", { "class": "card" }, assertCard));
+    }
+
+    if (run.config && run.config.length) {
+      children.push(el("div", { "class": "card" }, [el("h3", {}, ["Configuration"]), configTable(run.config)]));
+    }
+    return el("section", {}, children);
+  }
+
+  function renderCompare(cmp) {
+    var rows = (cmp.results || []).map(function (r) {
+      return [
+        r.pair,
+        el("pre", {}, [r.line]),
+        el("span", { "class": r.ok ? "ok" : "fail" }, [r.ok ? "OK" : "FAIL"]),
+        el("pre", {}, [r.base_value || ""]),
+        el("pre", {}, [r.target_value || ""]),
+        r.message || ""
+      ];
+    });
+    return el("section", {}, [
+      el("div", { "class": "card kpis" }, [kpi("passed", cmp.passed), kpi("failed", cmp.failed)]),
+      el("div", { "class": "card" }, [
+        el("h3", {}, ["Assertions"]),
+        table(["Pair", "Assertion", "Result", "Base", "Target", "Reason"], rows)
+      ])
+    ]);
+  }
+
+  function render(container, report) {
+    container.innerHTML = "";
+    container.appendChild(el("h1", {}, [report.title]));
+    container.appendChild(el("div", { "class": "meta" }, [report.kind + " · generated " + new Date(report.created_at).toLocaleString()]));
+    (report.runs || []).forEach(function (run) { container.appendChild(renderRun(run)); });
+    if (report.compare) container.appendChild(renderCompare(report.compare));
+    container.appendChild(el("h2", {}, ["Run configuration"]));
+    container.appendChild(el("div", { "class": "card" }, [configTable(report.config)]));
+  }
+
+  global.GurlReport = { render: render, renderRun: renderRun, el: el, table: table };
+})(window);
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+)
+
+// Assets holds the report stylesheet and scripts. They are exported so other
+// pages (e.g. the API dashboard) can draw the same charts.
+//
+//go:embed assets/report.css assets/charts.js assets/report.js
+var Assets embed.FS
+
+//go:embed assets/report.html
+var pageTemplate string
+
+var pageTmpl = template.Must(template.New("report").Parse(pageTemplate))
+
+// WriteHTML renders rep as a single self-contained HTML page.
+// All CSS and JS are inlined so the file works offline.
+func WriteHTML(w io.Writer, rep *Report) error {
+	css, err := Assets.ReadFile("assets/report.css")
+	if err != nil {
+		return err
+	}
+	charts, err := Assets.ReadFile("assets/charts.js")
+	if err != nil {
+		return err
+	}
+	script, err := Assets.ReadFile("assets/report.js")
+	if err != nil {
+		return err
+	}
+	// json.Marshal 默认会转义 <、>、&，可以安全地嵌入 <script> 中
+	data, err := json.Marshal(rep)
+	if err != nil {
+		return fmt.Errorf("failed to encode report data: %w", err)
+	}
+
+	return pageTmpl.Execute(w, map[string]any{
+		"Title":  rep.Title,
+		"CSS":    template.CSS(css),
+		"Charts": template.JS(charts),
+		"Script": template.JS(script),
+		"Data":   template.JS(data),
+	})
+}
+
+// WriteHTMLFile renders rep into the file at path
+func WriteHTMLFile(path string, rep *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create html report: %w", err)
+	}
+	if err := WriteHTML(f, rep); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
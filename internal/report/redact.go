@@ -0,0 +1,173 @@
+package report
+
+import "strings"
+
+// redacted replaces credentials in the config shown by reports, which are
+// shared as self-contained files
+const redacted = "[REDACTED]"
+
+// sensitiveHeader reports whether the value of a header carries credentials,
+// e.g. Authorization, Cookie or X-API-Key
+func sensitiveHeader(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, s := range []string{"auth", "cookie", "token", "secret", "key", "password", "session"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeader hides the value of a "Name: value" header carrying credentials
+func redactHeader(header string) string {
+	name, _, ok := strings.Cut(header, ":")
+	if !ok || !sensitiveHeader(name) {
+		return header
+	}
+	return name + ": " + redacted
+}
+
+// redactUser hides the password of a "user:password" argument
+func redactUser(user string) string {
+	name, _, ok := strings.Cut(user, ":")
+	if !ok {
+		return user
+	}
+	return name + ":" + redacted
+}
+
+// redactCookie hides cookie data; a cookie file name is kept
+func redactCookie(cookie string) string {
+	if !strings.Contains(cookie, "=") {
+		return cookie
+	}
+	return redacted
+}
+
+// curlRedactors maps the curl options whose argument carries credentials to
+// the function hiding them
+var curlRedactors = map[string]func(string) string{
+	"-H":             redactHeader,
+	"--header":       redactHeader,
+	"--proxy-header": redactHeader,
+	"-u":             redactUser,
+	"--user":         redactUser,
+	"-U":             redactUser,
+	"--proxy-user":   redactUser,
+	"-b":             redactCookie,
+	"--cookie":       redactCookie,
+	"--oauth2-bearer": func(string) string {
+		return redacted
+	},
+}
+
+// redactCurl hides the credentials passed to a curl command in headers,
+// user options and cookies. The rest of the command is kept as written.
+func redactCurl(cmd string) string {
+	args := curlArgs(cmd)
+
+	var out strings.Builder
+	last := 0
+	replace := func(a curlArg, value string) {
+		out.WriteString(cmd[last:a.start])
+		out.WriteString(shellQuote(value))
+		last = a.end
+	}
+	for i := 0; i < len(args); i++ {
+		flag, inline, hasInline := args[i].value, "", false
+		if strings.HasPrefix(flag, "--") {
+			flag, inline, hasInline = strings.Cut(flag, "=")
+		}
+		redact := curlRedactors[flag]
+		if redact == nil {
+			continue
+		}
+
+		if hasInline {
+			if r := redact(inline); r != inline {
+				replace(args[i], flag+"="+r)
+			}
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			if r := redact(args[i].value); r != args[i].value {
+				replace(args[i], r)
+			}
+		}
+	}
+	out.WriteString(cmd[last:])
+	return out.String()
+}
+
+// curlArg is a shell word of a curl command and its position in the command
+type curlArg struct {
+	value      string
+	start, end int
+}
+
+// curlArgs splits a curl command into shell words, handling quotes,
+// backslash escapes and line continuations
+func curlArgs(cmd string) []curlArg {
+	var args []curlArg
+	var word strings.Builder
+	start, inWord := 0, false
+	var quote byte
+
+	begin := func(i int) {
+		if !inWord {
+			inWord, start = true, i
+		}
+	}
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case quote == '"':
+			switch {
+			case c == '\\' && i+1 < len(cmd):
+				i++
+				word.WriteByte(cmd[i])
+			case c == '"':
+				quote = 0
+			default:
+				word.WriteByte(c)
+			}
+		case c == '\\' && i+1 < len(cmd):
+			if cmd[i+1] == '\n' {
+				i++
+				continue
+			}
+			begin(i)
+			i++
+			word.WriteByte(cmd[i])
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				args = append(args, curlArg{value: word.String(), start: start, end: i})
+				word.Reset()
+				inWord = false
+			}
+		default:
+			begin(i)
+			if c == '\'' || c == '"' {
+				quote = c
+			} else {
+				word.WriteByte(c)
+			}
+		}
+	}
+	if inWord {
+		args = append(args, curlArg{value: word.String(), start: start, end: len(cmd)})
+	}
+	return args
+}
+
+// shellQuote quotes s as a single shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
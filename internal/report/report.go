@@ -0,0 +1,508 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/batch"
+	"github.com/antlabs/gurl/internal/compare"
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// Report kinds
+const (
+	KindBenchmark = "benchmark"
+	KindBatch     = "batch"
+	KindCompare   = "compare"
+)
+
+// histogramBuckets is the number of latency histogram bars in a run section
+const histogramBuckets = 20
+
+// Report is the data model rendered by WriteHTML. It is plain JSON so the
+// same structure can be served by the API server and drawn by the same JS.
+type Report struct {
+	Title     string         `json:"title"`
+	Kind      string         `json:"kind"`
+	CreatedAt time.Time      `json:"created_at"`
+	Config    []ConfigEntry  `json:"config,omitempty"`
+	Runs      []Run          `json:"runs,omitempty"`
+	Compare   *CompareReport `json:"compare,omitempty"`
+}
+
+// ConfigEntry is a single key/value line of the run configuration
+type ConfigEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Run holds everything needed to chart a single benchmark run
+type Run struct {
+	Name          string          `json:"name"`
+	Status        string          `json:"status,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Aborted       string          `json:"aborted,omitempty"` // reason the run was stopped by a circuit breaker
+	Config        []ConfigEntry   `json:"config,omitempty"`
+	Summary       Summary         `json:"summary"`
+	Warmup        *Summary        `json:"warmup,omitempty"` // warm-up phase, excluded from everything else
+	Timeline      []TimelinePoint `json:"timeline"`
+	Annotations   []Annotation    `json:"annotations,omitempty"` // adjustments made while the run was in progress
+	Histogram     []HistogramBar  `json:"histogram"`
+	StatusCodes   []LabelCount    `json:"status_codes"`
+	ErrorsByClass []LabelCount    `json:"errors_by_class"`
+	Endpoints     []EndpointRow   `json:"endpoints,omitempty"`
+	Asserts       *AssertReport   `json:"asserts,omitempty"`
+}
+
+// Summary holds the headline numbers of a run. Latencies are in milliseconds.
+type Summary struct {
+	Requests       int64   `json:"requests"`
+	Errors         int64   `json:"errors"`
+	DurationSec    float64 `json:"duration_sec"`
+	RequestsPerSec float64 `json:"requests_per_sec"`
+	ReadBytes      int64   `json:"read_bytes"`
+	WriteBytes     int64   `json:"write_bytes"`
+	AvgMs          float64 `json:"avg_ms"`
+	MinMs          float64 `json:"min_ms"`
+	MaxMs          float64 `json:"max_ms"`
+	P50Ms          float64 `json:"p50_ms"`
+	P90Ms          float64 `json:"p90_ms"`
+	P99Ms          float64 `json:"p99_ms"`
+}
+
+// TimelinePoint is one per-second sample. Latencies are in milliseconds.
+type TimelinePoint struct {
+	Second   float64 `json:"t"`
+	Requests int64   `json:"rps"`
+	Errors   int64   `json:"errors"`
+	P50Ms    float64 `json:"p50"`
+	P90Ms    float64 `json:"p90"`
+	P99Ms    float64 `json:"p99"`
+}
+
+// Annotation marks a point on the timeline, such as a rate change
+type Annotation struct {
+	Second float64 `json:"t"`
+	Label  string  `json:"label"`
+}
+
+// HistogramBar is one latency histogram bucket
+type HistogramBar struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// LabelCount is a generic label/count pair used for breakdown tables
+type LabelCount struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// EndpointRow is one line of the per-endpoint table. Endpoint is the name
+// given in the curl file, or the URL when the request is not named.
+type EndpointRow struct {
+	Endpoint       string  `json:"endpoint"`
+	URL            string  `json:"url,omitempty"`
+	Requests       int64   `json:"requests"`
+	Errors         int64   `json:"errors"`
+	Share          float64 `json:"share"` // fraction of all requests sent to this endpoint
+	RequestsPerSec float64 `json:"requests_per_sec"`
+	AvgMs          float64 `json:"avg_ms"`
+	MinMs          float64 `json:"min_ms"`
+	MaxMs          float64 `json:"max_ms"`
+	P50Ms          float64 `json:"p50_ms"`
+	P90Ms          float64 `json:"p90_ms"`
+	P99Ms          float64 `json:"p99_ms"`
+	StatusCodes    string  `json:"status_codes"`
+	ErrorsByClass  string  `json:"errors_by_class,omitempty"`
+}
+
+// AssertReport holds the per-assertion outcome of a run with asserts
+type AssertReport struct {
+	Evaluated int64           `json:"evaluated"`
+	Rows      []AssertRow     `json:"rows"`
+	Failures  []AssertFailure `json:"failures,omitempty"`
+}
+
+// AssertRow is the pass/fail distribution of one assertion line
+type AssertRow struct {
+	Assert string `json:"assert"`
+	Passed int64  `json:"passed"`
+	Failed int64  `json:"failed"`
+}
+
+// AssertFailure is a sample of a failing response
+type AssertFailure struct {
+	Assert    string `json:"assert"`
+	Status    int    `json:"status"`
+	Message   string `json:"message"`
+	Body      string `json:"body,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// CompareReport holds the assertion outcome of a compare scenario
+type CompareReport struct {
+	Scenario string          `json:"scenario"`
+	Passed   int             `json:"passed"`
+	Failed   int             `json:"failed"`
+	Results  []CompareResult `json:"results"`
+}
+
+// CompareResult is a single compare assertion line
+type CompareResult struct {
+	Pair        string `json:"pair"`
+	Line        string `json:"line"`
+	OK          bool   `json:"ok"`
+	BaseValue   string `json:"base_value,omitempty"`
+	TargetValue string `json:"target_value,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// FromBenchmark builds a report for a single benchmark run
+func FromBenchmark(target string, results *stats.Results, cfg config.Config) *Report {
+	run := NewRun(target, results)
+	return &Report{
+		Title:     fmt.Sprintf("gurl benchmark @ %s", target),
+		Kind:      KindBenchmark,
+		CreatedAt: time.Now(),
+		Config:    ConfigEntries(cfg),
+		Runs:      []Run{run},
+	}
+}
+
+// FromLive builds a report for a benchmark that is still running. The totals
+// of results are only filled in when the run ends, so the summary is computed
+// from the timeline samples up to elapsed.
+func FromLive(target string, results *stats.Results, cfg config.Config, elapsed time.Duration) *Report {
+	rep := FromBenchmark(target, results, cfg)
+	summary := &rep.Runs[0].Summary
+	summary.Requests, summary.Errors = 0, 0
+	for _, p := range rep.Runs[0].Timeline {
+		summary.Requests += p.Requests
+		summary.Errors += p.Errors
+	}
+	if elapsed > 0 {
+		summary.DurationSec = elapsed.Seconds()
+		summary.RequestsPerSec = float64(summary.Requests) / elapsed.Seconds()
+	}
+	return rep
+}
+
+// FromBatch builds a report with one run section per batch test
+func FromBatch(result *batch.BatchResult) *Report {
+	rep := &Report{
+		Title:     fmt.Sprintf("gurl batch (%d tests)", len(result.Tests)),
+		Kind:      KindBatch,
+		CreatedAt: time.Now(),
+		Config: []ConfigEntry{
+			{Key: "tests", Value: fmt.Sprintf("%d", len(result.Tests))},
+			{Key: "success_rate", Value: fmt.Sprintf("%.2f%%", result.SuccessRate)},
+			{Key: "start_time", Value: result.StartTime.Format(time.RFC3339)},
+			{Key: "end_time", Value: result.EndTime.Format(time.RFC3339)},
+			{Key: "total_time", Value: result.TotalTime.String()},
+		},
+	}
+
+	for _, test := range result.Tests {
+		run := NewRun(test.Name, test.Stats)
+		if run.Status == "" {
+			run.Status = "SUCCESS"
+			if test.Error != nil || (test.Stats != nil && len(test.Stats.GetErrors()) > 0) {
+				run.Status = "FAILED"
+			}
+		}
+		if test.Error != nil {
+			run.Error = test.Error.Error()
+		}
+		if test.Config != nil {
+			run.Config = ConfigEntries(*test.Config)
+		}
+		rep.Runs = append(rep.Runs, run)
+	}
+	return rep
+}
+
+// FromCompare builds a report for a compare scenario
+func FromCompare(scenario *config.CompareScenario, results []compare.AssertionResult, passed, failed int) *Report {
+	rep := &Report{
+		Kind:      KindCompare,
+		CreatedAt: time.Now(),
+		Compare: &CompareReport{
+			Passed: passed,
+			Failed: failed,
+		},
+	}
+
+	if scenario != nil {
+		rep.Title = fmt.Sprintf("gurl compare: %s", scenario.Name)
+		rep.Compare.Scenario = scenario.Name
+		rep.Config = []ConfigEntry{
+			{Key: "scenario", Value: scenario.Name},
+			{Key: "mode", Value: scenario.Mode},
+		}
+		if scenario.Base != "" {
+			rep.Config = append(rep.Config, ConfigEntry{Key: "base", Value: scenario.Base})
+		}
+		if scenario.Target != "" {
+			rep.Config = append(rep.Config, ConfigEntry{Key: "target", Value: scenario.Target})
+		}
+		if len(scenario.Targets) > 0 {
+			rep.Config = append(rep.Config, ConfigEntry{Key: "targets", Value: strings.Join(scenario.Targets, ", ")})
+		}
+		if scenario.LeftSet != "" || scenario.RightSet != "" {
+			rep.Config = append(rep.Config, ConfigEntry{Key: "sets", Value: scenario.LeftSet + " vs " + scenario.RightSet})
+		}
+		rep.Config = append(rep.Config, ConfigEntry{Key: "response_compare", Value: scenario.ResponseCompare})
+	} else {
+		rep.Title = "gurl compare"
+	}
+
+	for _, r := range results {
+		rep.Compare.Results = append(rep.Compare.Results, CompareResult{
+			Pair:        r.PairLabel,
+			Line:        r.Line,
+			OK:          r.OK,
+			BaseValue:   r.BaseValue,
+			TargetValue: r.TargetValue,
+			Message:     r.Message,
+		})
+	}
+	return rep
+}
+
+// NewRun converts stats.Results into a chartable run section.
+// A nil results yields an empty run so failed batch tests still show up.
+func NewRun(name string, results *stats.Results) Run {
+	run := Run{
+		Name:          name,
+		Timeline:      []TimelinePoint{},
+		Histogram:     []HistogramBar{},
+		StatusCodes:   []LabelCount{},
+		ErrorsByClass: []LabelCount{},
+	}
+	if results == nil {
+		return run
+	}
+
+	run.Summary = summaryOf(results)
+	if reason := results.AbortReason(); reason != "" {
+		run.Status = "ABORTED"
+		run.Aborted = reason
+	}
+	if results.Warmup != nil {
+		warmup := summaryOf(results.Warmup)
+		run.Warmup = &warmup
+	}
+
+	for _, s := range results.GetTimeline() {
+		run.Timeline = append(run.Timeline, TimelinePoint{
+			Second:   s.Elapsed.Seconds(),
+			Requests: s.Requests,
+			Errors:   s.Errors,
+			P50Ms:    ms(s.P50),
+			P90Ms:    ms(s.P90),
+			P99Ms:    ms(s.P99),
+		})
+	}
+
+	for _, a := range results.GetAnnotations() {
+		run.Annotations = append(run.Annotations, Annotation{Second: a.Elapsed.Seconds(), Label: a.Label})
+	}
+
+	for _, b := range results.GetLatencyHistogram(histogramBuckets) {
+		run.Histogram = append(run.Histogram, HistogramBar{
+			Label: fmt.Sprintf("%.2f-%.2fms", ms(b.Lower), ms(b.Upper)),
+			Count: b.Count,
+		})
+	}
+
+	codes := results.GetStatusCodes()
+	for _, code := range sortedIntKeys(codes) {
+		run.StatusCodes = append(run.StatusCodes, LabelCount{Label: fmt.Sprintf("%d", code), Count: codes[code]})
+	}
+
+	classes := results.GetErrorsByClass()
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		run.ErrorsByClass = append(run.ErrorsByClass, LabelCount{Label: name, Count: classes[name]})
+	}
+
+	endpointStats := results.GetEndpointStats()
+	keys := make([]string, 0, len(endpointStats))
+	for key := range endpointStats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ep := endpointStats[key]
+		percentiles := ep.GetLatencyPercentiles()
+		row := EndpointRow{
+			Endpoint: key,
+			Requests: ep.Requests,
+			Errors:   ep.Errors,
+			Share:    ep.Share,
+			AvgMs:    ms(ep.GetAverageLatency()),
+			MinMs:    ms(ep.MinLatency),
+			MaxMs:    ms(ep.MaxLatency),
+			P50Ms:    ms(percentiles[50]),
+			P90Ms:    ms(percentiles[90]),
+			P99Ms:    ms(percentiles[99]),
+		}
+		if ep.Name != "" {
+			row.URL = ep.URL
+		}
+		if results.Duration > 0 {
+			row.RequestsPerSec = float64(ep.Requests) / results.Duration.Seconds()
+		}
+		parts := make([]string, 0, len(ep.StatusCodes))
+		for _, code := range sortedIntKeys(ep.StatusCodes) {
+			parts = append(parts, fmt.Sprintf("%d:%d", code, ep.StatusCodes[code]))
+		}
+		row.StatusCodes = strings.Join(parts, " ")
+		classes := make([]string, 0, len(ep.ErrorClasses))
+		for class := range ep.ErrorClasses {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for i, class := range classes {
+			classes[i] = fmt.Sprintf("%s:%d", class, ep.ErrorClasses[class])
+		}
+		row.ErrorsByClass = strings.Join(classes, " ")
+		run.Endpoints = append(run.Endpoints, row)
+	}
+
+	if assertStats := results.GetAssertStats(); len(assertStats) > 0 {
+		run.Asserts = &AssertReport{Evaluated: results.GetAssertsEvaluated()}
+		for _, st := range assertStats {
+			run.Asserts.Rows = append(run.Asserts.Rows, AssertRow{Assert: st.Assert, Passed: st.Passed, Failed: st.Failed})
+		}
+		for _, f := range results.GetAssertFailures() {
+			run.Asserts.Failures = append(run.Asserts.Failures, AssertFailure{
+				Assert:    f.Assert,
+				Status:    f.Status,
+				Message:   f.Message,
+				Body:      f.Body,
+				Truncated: f.Truncated,
+			})
+		}
+	}
+
+	return run
+}
+
+// summaryOf computes the headline numbers of results
+func summaryOf(results *stats.Results) Summary {
+	percentiles := results.GetLatencyPercentiles()
+	summary := Summary{
+		Requests:   results.TotalRequests,
+		Errors:     results.TotalErrors,
+		ReadBytes:  results.GetTotalBytes(),
+		WriteBytes: results.GetTotalWriteBytes(),
+		AvgMs:      ms(results.GetAverageLatency()),
+		MinMs:      ms(results.GetMinLatency()),
+		MaxMs:      ms(results.GetMaxLatency()),
+		P50Ms:      ms(percentiles[50]),
+		P90Ms:      ms(percentiles[90]),
+		P99Ms:      ms(percentiles[99]),
+	}
+	if results.Duration > 0 {
+		summary.DurationSec = results.Duration.Seconds()
+		summary.RequestsPerSec = float64(results.TotalRequests) / results.Duration.Seconds()
+	}
+	return summary
+}
+
+// ConfigEntries flattens a config.Config into displayable key/value pairs,
+// skipping options that were left at their zero value. Credentials in the
+// curl command and the headers are redacted, reports are meant to be shared.
+func ConfigEntries(cfg config.Config) []ConfigEntry {
+	entries := []ConfigEntry{
+		{Key: "connections", Value: fmt.Sprintf("%d", cfg.Connections)},
+		{Key: "threads", Value: fmt.Sprintf("%d", cfg.Threads)},
+		{Key: "duration", Value: cfg.Duration.String()},
+		{Key: "timeout", Value: cfg.Timeout.String()},
+		{Key: "rate", Value: fmt.Sprintf("%d", cfg.Rate)},
+	}
+	add := func(key, value string) {
+		if value != "" {
+			entries = append(entries, ConfigEntry{Key: key, Value: value})
+		}
+	}
+	if cfg.Requests > 0 {
+		add("requests", fmt.Sprintf("%d", cfg.Requests))
+	}
+	if cfg.Warmup > 0 {
+		add("warmup", cfg.Warmup.String())
+	}
+	add("curl", redactCurl(cfg.CurlCommand))
+	add("curl_file", cfg.CurlFile)
+	if cfg.CurlFile != "" {
+		add("load_strategy", cfg.LoadStrategy)
+	}
+	if cfg.CurlCommand == "" && cfg.CurlFile == "" {
+		add("method", cfg.Method)
+		headers := make([]string, len(cfg.Headers))
+		for i, h := range cfg.Headers {
+			headers[i] = redactHeader(h)
+		}
+		add("headers", strings.Join(headers, "; "))
+		add("body", cfg.Body)
+		add("content_type", cfg.ContentType)
+	}
+	if cfg.UseNetHTTP {
+		add("engine", "net/http")
+	} else {
+		add("engine", "auto")
+	}
+	add("asserts", cfg.Asserts)
+	if cfg.Asserts != "" {
+		if cfg.AssertSampleRate > 0 && cfg.AssertSampleRate < 1 {
+			add("assert_sample_rate", fmt.Sprintf("%g", cfg.AssertSampleRate))
+		}
+		add("max_body_size", fmt.Sprintf("%d", cfg.GetMaxBodySize()))
+	}
+	add("cookie_jar", cfg.GetCookieJar())
+	add("cookie_file", cfg.CookieFile)
+	if cfg.Auth != nil {
+		add("auth", cfg.Auth.Type)
+	}
+	if cfg.Abort.Enabled() {
+		add("abort", abortSpec(cfg.Abort))
+	}
+	return entries
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func sortedIntKeys(m map[int]int64) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// abortSpec describes the enabled abort conditions in one line
+func abortSpec(a *config.AbortConfig) string {
+	var parts []string
+	if a.ErrorRate > 0 {
+		parts = append(parts, fmt.Sprintf("error rate > %g%% over %s", a.ErrorRate, a.GetErrorWindow()))
+	}
+	if p99 := a.GetP99(); p99 > 0 {
+		parts = append(parts, fmt.Sprintf("p99 > %s for %s", p99, a.GetP99For()))
+	}
+	if a.ConnectFailures > 0 {
+		parts = append(parts, fmt.Sprintf("%d consecutive connect failures", a.ConnectFailures))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,251 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/batch"
+	"github.com/antlabs/gurl/internal/compare"
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func newTestResults() *stats.Results {
+	results := stats.NewResults()
+	for i := 1; i <= 100; i++ {
+		results.AddLatency(time.Duration(i) * time.Millisecond)
+		results.AddStatusCode(200)
+	}
+	results.AddError(errors.New("assertion failed at line 1: status == 201: expected 201"))
+	results.TakeSample(time.Second, 100, 1)
+	results.Annotate(500*time.Millisecond, "rate 100/s -> 200/s")
+	results.TotalRequests = 100
+	results.TotalErrors = 1
+	results.Duration = time.Second
+	return results
+}
+
+func TestFromBenchmark(t *testing.T) {
+	cfg := config.Config{Connections: 4, Threads: 2, Duration: time.Second, Timeout: 5 * time.Second}
+	rep := FromBenchmark("http://127.0.0.1:8080", newTestResults(), cfg)
+
+	if rep.Kind != KindBenchmark || len(rep.Runs) != 1 {
+		t.Fatalf("unexpected report shape: kind=%s runs=%d", rep.Kind, len(rep.Runs))
+	}
+	run := rep.Runs[0]
+	if len(run.Timeline) != 1 || run.Timeline[0].Requests != 100 {
+		t.Errorf("expected one timeline point with 100 requests, got %+v", run.Timeline)
+	}
+	if len(run.Annotations) != 1 || run.Annotations[0].Second != 0.5 {
+		t.Errorf("expected the rate change annotation at 0.5s, got %+v", run.Annotations)
+	}
+	if len(run.Histogram) != histogramBuckets {
+		t.Errorf("expected %d histogram buckets, got %d", histogramBuckets, len(run.Histogram))
+	}
+	var total int64
+	for _, b := range run.Histogram {
+		total += b.Count
+	}
+	if total != 100 {
+		t.Errorf("histogram should cover all 100 latencies, got %d", total)
+	}
+	if len(run.ErrorsByClass) != 1 || run.ErrorsByClass[0].Label != stats.ErrorClassAssert {
+		t.Errorf("expected a single assert error class, got %+v", run.ErrorsByClass)
+	}
+	if run.Summary.P99Ms < run.Summary.P50Ms {
+		t.Errorf("p99 (%v) should not be below p50 (%v)", run.Summary.P99Ms, run.Summary.P50Ms)
+	}
+}
+
+func TestFromLiveSummarizesTimeline(t *testing.T) {
+	results := stats.NewResults()
+	for i := 1; i <= 30; i++ {
+		results.AddLatency(time.Duration(i) * time.Millisecond)
+	}
+	results.TakeSample(time.Second, 10, 0)
+	results.TakeSample(2*time.Second, 20, 2)
+
+	rep := FromLive("http://127.0.0.1:8080", results, config.Config{Duration: time.Minute}, 2*time.Second)
+	s := rep.Runs[0].Summary
+	if s.Requests != 30 || s.Errors != 2 {
+		t.Errorf("expected 30 requests and 2 errors from the timeline, got %d and %d", s.Requests, s.Errors)
+	}
+	if s.DurationSec != 2 || s.RequestsPerSec != 15 {
+		t.Errorf("expected 15 req/s over 2s, got %v over %vs", s.RequestsPerSec, s.DurationSec)
+	}
+}
+
+func TestFromBatchMarksFailedTests(t *testing.T) {
+	result := &batch.BatchResult{
+		Tests: []batch.TestResult{
+			{Name: "ok", Stats: stats.NewResults()},
+			{Name: "broken", Error: errors.New("failed to parse curl command")},
+		},
+	}
+	rep := FromBatch(result)
+	if len(rep.Runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(rep.Runs))
+	}
+	if rep.Runs[0].Status != "SUCCESS" || rep.Runs[1].Status != "FAILED" {
+		t.Errorf("unexpected statuses: %s, %s", rep.Runs[0].Status, rep.Runs[1].Status)
+	}
+}
+
+func TestWriteHTMLIsSelfContained(t *testing.T) {
+	scenario := &config.CompareScenario{Name: "cache</script>", Mode: "one_to_one"}
+	rep := FromCompare(scenario, []compare.AssertionResult{{Line: "status == status", OK: true}}, 1, 0)
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, rep); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	page := buf.String()
+
+	for _, want := range []string{"GurlCharts", "GurlReport.render", "status == status"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html report is missing %q", want)
+		}
+	}
+	if strings.Contains(page, "<script src=") || strings.Contains(page, "<link ") {
+		t.Error("html report must not reference external assets")
+	}
+	if strings.Contains(page, "cache</script>") {
+		t.Error("report data must be escaped inside <script>")
+	}
+}
+
+func TestNewRunAsserts(t *testing.T) {
+	results := stats.NewResults()
+	for i := 0; i < 3; i++ {
+		results.AddAssertEvaluated()
+		results.AddAssertResult("status == 200", nil, 200, nil)
+	}
+	body := []byte(strings.Repeat("x", 1000))
+	for i := 0; i < stats.MaxAssertFailureSamples+2; i++ {
+		results.AddAssertResult(`gjson "ok" == true`, errors.New("assertion failed"), 500, body)
+	}
+
+	run := NewRun("asserts", results)
+	if run.Asserts == nil || run.Asserts.Evaluated != 3 {
+		t.Fatalf("expected assert report with 3 evaluated responses, got %+v", run.Asserts)
+	}
+	if len(run.Asserts.Rows) != 2 || run.Asserts.Rows[0].Passed != 3 || run.Asserts.Rows[1].Failed != int64(stats.MaxAssertFailureSamples+2) {
+		t.Errorf("unexpected assert rows: %+v", run.Asserts.Rows)
+	}
+	if len(run.Asserts.Failures) != stats.MaxAssertFailureSamples {
+		t.Errorf("expected %d failure samples, got %d", stats.MaxAssertFailureSamples, len(run.Asserts.Failures))
+	}
+	if f := run.Asserts.Failures[0]; !f.Truncated || len(f.Body) >= len(body) || f.Status != 500 {
+		t.Errorf("expected a truncated sample body with status 500, got %+v", f)
+	}
+}
+
+func TestNewRunEndpoints(t *testing.T) {
+	results := stats.NewResults()
+	for i := 1; i <= 30; i++ {
+		results.AddEndpointResult("login", "http://127.0.0.1/login", time.Duration(i)*time.Millisecond, 200, 10, 5, nil)
+	}
+	results.AddEndpointResult("login", "http://127.0.0.1/login", 0, 0, 0, 5, errors.New("dial tcp: connection refused"))
+	for i := 0; i < 9; i++ {
+		results.AddEndpointResult("", "http://127.0.0.1/items", time.Millisecond, 404, 10, 5, nil)
+	}
+	results.Duration = time.Second
+
+	run := NewRun("endpoints", results)
+	if len(run.Endpoints) != 2 {
+		t.Fatalf("expected 2 endpoint rows, got %+v", run.Endpoints)
+	}
+	items, login := run.Endpoints[0], run.Endpoints[1]
+	if items.Endpoint != "http://127.0.0.1/items" || items.URL != "" || items.StatusCodes != "404:9" {
+		t.Errorf("unexpected unnamed row: %+v", items)
+	}
+	if login.Endpoint != "login" || login.URL != "http://127.0.0.1/login" || login.Requests != 31 || login.Errors != 1 {
+		t.Errorf("unexpected named row: %+v", login)
+	}
+	if login.Share < 0.77 || login.Share > 0.78 {
+		t.Errorf("expected login share 31/40, got %v", login.Share)
+	}
+	if login.P50Ms < 14 || login.P50Ms > 17 || login.P99Ms < login.P90Ms {
+		t.Errorf("unexpected login percentiles: p50=%v p90=%v p99=%v", login.P50Ms, login.P90Ms, login.P99Ms)
+	}
+	if login.ErrorsByClass != stats.ClassifyError(errors.New("dial tcp: connection refused"))+":1" {
+		t.Errorf("unexpected error classes: %q", login.ErrorsByClass)
+	}
+}
+
+func TestNewRunWarmup(t *testing.T) {
+	results := stats.NewResults()
+	for i := 0; i < 10; i++ {
+		results.AddLatency(100 * time.Millisecond)
+		results.AddStatusCode(503)
+	}
+	results.TakeSample(time.Second, 10, 0)
+	results.EndWarmup(10, 0, time.Second)
+
+	results.AddLatency(time.Millisecond)
+	results.AddStatusCode(200)
+	results.TotalRequests = 1
+	results.Duration = time.Second
+
+	run := NewRun("warmup", results)
+	if run.Warmup == nil || run.Warmup.Requests != 10 || run.Warmup.AvgMs != 100 {
+		t.Fatalf("unexpected warm-up summary: %+v", run.Warmup)
+	}
+	if run.Summary.Requests != 1 || run.Summary.MaxMs != 1 {
+		t.Errorf("warm-up latencies leaked into the summary: %+v", run.Summary)
+	}
+	if len(run.StatusCodes) != 1 || run.StatusCodes[0].Label != "200" || len(run.Timeline) != 0 {
+		t.Errorf("warm-up status codes or samples leaked: %+v %+v", run.StatusCodes, run.Timeline)
+	}
+}
+
+func TestNewRunAborted(t *testing.T) {
+	results := stats.NewResults()
+	results.Abort("5 consecutive connection failures")
+	results.Abort("error rate 80.0% over the last 10s exceeded 50.0%")
+
+	run := NewRun("aborted", results)
+	if run.Status != "ABORTED" || run.Aborted != "5 consecutive connection failures" {
+		t.Fatalf("expected the first abort reason, got %q %q", run.Status, run.Aborted)
+	}
+
+	entries := ConfigEntries(config.Config{Abort: &config.AbortConfig{ErrorRate: 50, ConnectFailures: 5}})
+	last := entries[len(entries)-1]
+	if last.Key != "abort" || last.Value != "error rate > 50% over 10s; 5 consecutive connect failures" {
+		t.Errorf("unexpected abort config entry: %+v", last)
+	}
+}
+
+func TestConfigEntriesRedactsCredentials(t *testing.T) {
+	curl := `curl -X POST -H 'Authorization: Bearer abc' --header="X-Api-Key: k1" -H "Content-Type: application/json" ` +
+		`-u admin:hunter2 -b 'session=s1' -d '{"q":1}' http://example.com/`
+	entries := ConfigEntries(config.Config{CurlCommand: curl})
+	entries = append(entries, ConfigEntries(config.Config{Headers: []string{"Cookie: a=b", "Accept: */*"}})...)
+
+	var values []string
+	for _, e := range entries {
+		values = append(values, e.Value)
+	}
+	all := strings.Join(values, "\n")
+	for _, secret := range []string{"abc", "k1", "hunter2", "s1", "a=b"} {
+		if strings.Contains(all, secret) {
+			t.Errorf("config entries leak %q:\n%s", secret, all)
+		}
+	}
+	for _, want := range []string{
+		`-H 'Authorization: [REDACTED]'`,
+		`'--header=X-Api-Key: [REDACTED]'`,
+		`-H "Content-Type: application/json"`,
+		`-u 'admin:[REDACTED]'`,
+		`-b '[REDACTED]'`,
+		`-d '{"q":1}' http://example.com/`,
+		`Cookie: [REDACTED]; Accept: */*`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("config entries are missing %q:\n%s", want, all)
+		}
+	}
+}
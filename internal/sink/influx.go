@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// influxSink writes InfluxDB line protocol either to an HTTP write endpoint
+// (e.g. /api/v2/write?bucket=gurl) or appends it to a local file.
+type influxSink struct {
+	prefix  string
+	url     string
+	headers map[string]string
+	file    *os.File
+}
+
+func newInfluxHTTP(url, prefix string, headers map[string]string) *influxSink {
+	return &influxSink{prefix: prefix, url: url, headers: headers}
+}
+
+func newInfluxFile(path, prefix string) (*influxSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("influx: %w", err)
+	}
+	return &influxSink{prefix: prefix, file: f}, nil
+}
+
+// Write implements Sink
+func (s *influxSink) Write(p Point) error {
+	body := []byte(s.lines(p))
+	if s.file != nil {
+		if _, err := s.file.Write(body); err != nil {
+			return fmt.Errorf("influx: %w", err)
+		}
+		return nil
+	}
+	if err := post(s.url, "text/plain; charset=utf-8", s.headers, body); err != nil {
+		return fmt.Errorf("influx: %w", err)
+	}
+	return nil
+}
+
+// lines renders a point as line protocol with nanosecond timestamps
+func (s *influxSink) lines(p Point) string {
+	var b strings.Builder
+	ts := strconv.FormatInt(p.Time.UnixNano(), 10)
+	tags := influxTags(p.Tags)
+
+	measurement := s.prefix
+	if p.Final {
+		measurement += "_summary"
+	}
+	fmt.Fprintf(&b, "%s%s requests=%di,errors=%di,read_bytes=%di,write_bytes=%di,connections=%di,rps=%s,"+
+		"latency_mean_ms=%s,latency_p50_ms=%s,latency_p90_ms=%s,latency_p99_ms=%s,latency_max_ms=%s %s\n",
+		influxEscape(measurement, ", "), tags,
+		p.Requests, p.Errors, p.ReadBytes, p.WriteBytes, p.Connections, influxFloat(p.RequestsPerSec),
+		influxFloat(ms(p.Latency.Mean)), influxFloat(ms(p.Latency.P50)), influxFloat(ms(p.Latency.P90)),
+		influxFloat(ms(p.Latency.P99)), influxFloat(ms(p.Latency.Max)), ts)
+
+	for _, code := range sortedCodes(p.StatusCodes) {
+		fmt.Fprintf(&b, "%s%s,code=%d count=%di %s\n",
+			influxEscape(s.prefix+"_status", ", "), tags, code, p.StatusCodes[code], ts)
+	}
+	for _, class := range sortedClasses(p.ErrorsByClass) {
+		fmt.Fprintf(&b, "%s%s,class=%s count=%di %s\n",
+			influxEscape(s.prefix+"_errors", ", "), tags, influxEscape(class, ",= "), p.ErrorsByClass[class], ts)
+	}
+	return b.String()
+}
+
+// Close implements Sink
+func (s *influxSink) Close() error {
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
+
+// influxTags renders sorted ",k=v" pairs; empty values are not allowed by the protocol
+func influxTags(tags map[string]string) string {
+	var b strings.Builder
+	for _, k := range sortedTagKeys(tags) {
+		if tags[k] == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(influxEscape(k, ",= "))
+		b.WriteByte('=')
+		b.WriteString(influxEscape(tags[k], ",= "))
+	}
+	return b.String()
+}
+
+func influxEscape(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func influxFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
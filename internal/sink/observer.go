@@ -0,0 +1,188 @@
+package sink
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+// Observer feeds one sink from the benchmark sampling loop. It implements
+// benchmark.Observer: every interval a point is pushed in the background so a
+// slow backend never stalls sampling, and a final point is pushed
+// synchronously when the run finishes.
+type Observer struct {
+	sink   Sink
+	name   string
+	tags   map[string]string
+	every  int // push every n samples; the sampling loop ticks once per second
+	errOut io.Writer
+
+	mu            sync.Mutex
+	start         time.Time
+	lastPush      time.Time
+	requests      int64
+	errors        int64
+	latencyOffset int
+	samples       int
+	pending       sync.WaitGroup
+	busy          bool
+	closed        bool
+}
+
+// NewObservers creates an observer per sink config. tags are merged over each
+// sink's own tags; tag values may reference environment variables.
+func NewObservers(cfgs []config.SinkConfig, tags map[string]string) ([]*Observer, error) {
+	observers := make([]*Observer, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		s, err := New(cfg)
+		if err != nil {
+			for _, o := range observers {
+				o.sink.Close()
+			}
+			return nil, err
+		}
+
+		merged := make(map[string]string, len(cfg.Tags)+len(tags))
+		for k, v := range cfg.Tags {
+			merged[k] = os.ExpandEnv(v)
+		}
+		for k, v := range tags {
+			merged[k] = os.ExpandEnv(v)
+		}
+
+		observers = append(observers, &Observer{
+			sink:   s,
+			name:   cfg.Type,
+			tags:   merged,
+			every:  max(1, int(cfg.GetInterval()/time.Second)),
+			errOut: os.Stderr,
+		})
+	}
+	return observers, nil
+}
+
+// OnSample implements benchmark.Observer
+func (o *Observer) OnSample(results *stats.Results, sample stats.Sample) {
+	o.mu.Lock()
+	now := time.Now()
+	if o.start.IsZero() {
+		o.start = now.Add(-sample.Elapsed)
+		o.lastPush = o.start
+	}
+	o.requests += sample.Requests
+	o.errors += sample.Errors
+	o.samples++
+	if o.closed || o.busy || o.samples < o.every {
+		o.mu.Unlock()
+		return
+	}
+
+	latencies, next := results.GetLatenciesSince(o.latencyOffset)
+	p := o.point(results, now)
+	p.RequestsPerSec = float64(len(latencies)) / now.Sub(o.lastPush).Seconds()
+	p.Latency = summarize(latencies)
+	o.latencyOffset = next
+	o.lastPush = now
+	o.samples = 0
+	o.busy = true
+	o.pending.Add(1)
+	o.mu.Unlock()
+
+	go func() {
+		defer o.pending.Done()
+		o.write(p)
+		o.mu.Lock()
+		o.busy = false
+		o.mu.Unlock()
+	}()
+}
+
+// OnFinish implements benchmark.Observer
+func (o *Observer) OnFinish(results *stats.Results) {
+	o.pending.Wait()
+
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if o.start.IsZero() {
+		o.start = now.Add(-results.Duration)
+	}
+	p := o.point(results, now)
+	p.Final = true
+	p.Requests = results.TotalRequests
+	p.Errors = results.TotalErrors
+	p.Connections = 0
+	if results.Duration > 0 {
+		p.RequestsPerSec = float64(results.TotalRequests) / results.Duration.Seconds()
+	}
+	percentiles := results.GetLatencyPercentiles()
+	p.Latency = Latency{
+		Mean: results.GetAverageLatency(),
+		P50:  percentiles[50],
+		P90:  percentiles[90],
+		P99:  percentiles[99],
+		Max:  results.GetMaxLatency(),
+	}
+	o.closed = true
+	o.mu.Unlock()
+
+	o.write(p)
+	if err := o.sink.Close(); err != nil {
+		fmt.Fprintf(o.errOut, "sink %s: %v\n", o.name, err)
+	}
+}
+
+// point fills the cumulative counters of a point; callers hold o.mu
+func (o *Observer) point(results *stats.Results, now time.Time) Point {
+	return Point{
+		Time:          now,
+		Start:         o.start,
+		Tags:          o.tags,
+		Requests:      o.requests,
+		Errors:        o.errors,
+		StatusCodes:   results.GetStatusCodes(),
+		ErrorsByClass: results.GetErrorsByClass(),
+		ReadBytes:     results.GetTotalBytes(),
+		WriteBytes:    results.GetTotalWriteBytes(),
+		Connections:   results.GetActiveConnections(),
+	}
+}
+
+// write pushes a point, reporting failures without interrupting the run
+func (o *Observer) write(p Point) {
+	if err := o.sink.Write(p); err != nil {
+		fmt.Fprintf(o.errOut, "sink %s: %v\n", o.name, err)
+	}
+}
+
+// summarize computes latency statistics, sorting latencies in place
+func summarize(sorted []time.Duration) Latency {
+	if len(sorted) == 0 {
+		return Latency{}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	at := func(p float64) time.Duration {
+		return sorted[int(float64(len(sorted)-1)*p/100.0)]
+	}
+	return Latency{
+		Mean: total / time.Duration(len(sorted)),
+		P50:  at(50),
+		P90:  at(90),
+		P99:  at(99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
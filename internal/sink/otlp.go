@@ -0,0 +1,171 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// otlpSink exports metrics with OTLP/HTTP using the JSON encoding, which
+// collectors accept on /v1/metrics without any protobuf dependency.
+type otlpSink struct {
+	url     string
+	prefix  string
+	headers map[string]string
+}
+
+func newOTLP(url, prefix string, headers map[string]string) *otlpSink {
+	return &otlpSink{url: url, prefix: prefix, headers: headers}
+}
+
+// OTLP JSON payload, see opentelemetry-proto metrics/v1
+type otlpPayload struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name  string     `json:"name"`
+	Unit  string     `json:"unit,omitempty"`
+	Sum   *otlpSum   `json:"sum,omitempty"`
+	Gauge *otlpGauge `json:"gauge,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             *string         `json:"asInt,omitempty"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// aggregationTemporalityCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE
+const aggregationTemporalityCumulative = 2
+
+// Write implements Sink
+func (s *otlpSink) Write(p Point) error {
+	body, err := json.Marshal(s.payload(p))
+	if err != nil {
+		return fmt.Errorf("otlp: %w", err)
+	}
+	if err := post(s.url, "application/json", s.headers, body); err != nil {
+		return fmt.Errorf("otlp: %w", err)
+	}
+	return nil
+}
+
+func (s *otlpSink) payload(p Point) otlpPayload {
+	start := strconv.FormatInt(p.Start.UnixNano(), 10)
+	now := strconv.FormatInt(p.Time.UnixNano(), 10)
+	attrs := make([]otlpAttribute, 0, len(p.Tags))
+	for _, k := range sortedTagKeys(p.Tags) {
+		attrs = append(attrs, otlpAttribute{Key: k, Value: otlpAnyValue{StringValue: p.Tags[k]}})
+	}
+	with := func(key, value string) []otlpAttribute {
+		out := append([]otlpAttribute{}, attrs...)
+		return append(out, otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: value}})
+	}
+
+	intPoint := func(a []otlpAttribute, v int64) otlpDataPoint {
+		str := strconv.FormatInt(v, 10)
+		return otlpDataPoint{Attributes: a, StartTimeUnixNano: start, TimeUnixNano: now, AsInt: &str}
+	}
+	sum := func(name, unit string, points ...otlpDataPoint) otlpMetric {
+		return otlpMetric{Name: s.prefix + "." + name, Unit: unit, Sum: &otlpSum{
+			DataPoints:             points,
+			AggregationTemporality: aggregationTemporalityCumulative,
+			IsMonotonic:            true,
+		}}
+	}
+	gauge := func(name, unit string, v float64) otlpMetric {
+		return otlpMetric{Name: s.prefix + "." + name, Unit: unit, Gauge: &otlpGauge{
+			DataPoints: []otlpDataPoint{{Attributes: attrs, TimeUnixNano: now, AsDouble: &v}},
+		}}
+	}
+
+	metrics := []otlpMetric{
+		sum("requests", "{request}", intPoint(attrs, p.Requests)),
+		sum("errors", "{error}", intPoint(attrs, p.Errors)),
+		sum("bytes.read", "By", intPoint(attrs, p.ReadBytes)),
+		sum("bytes.written", "By", intPoint(attrs, p.WriteBytes)),
+	}
+	if len(p.StatusCodes) > 0 {
+		points := make([]otlpDataPoint, 0, len(p.StatusCodes))
+		for _, code := range sortedCodes(p.StatusCodes) {
+			points = append(points, intPoint(with("http.response.status_code", strconv.Itoa(code)), p.StatusCodes[code]))
+		}
+		metrics = append(metrics, sum("responses", "{response}", points...))
+	}
+	if len(p.ErrorsByClass) > 0 {
+		points := make([]otlpDataPoint, 0, len(p.ErrorsByClass))
+		for _, class := range sortedClasses(p.ErrorsByClass) {
+			points = append(points, intPoint(with("error.type", class), p.ErrorsByClass[class]))
+		}
+		metrics = append(metrics, sum("errors.by_class", "{error}", points...))
+	}
+
+	scope := ""
+	if p.Final {
+		scope = "summary."
+	}
+	metrics = append(metrics,
+		gauge("connections", "{connection}", float64(p.Connections)),
+		gauge(scope+"rps", "{request}/s", p.RequestsPerSec),
+		gauge(scope+"latency.mean", "ms", ms(p.Latency.Mean)),
+		gauge(scope+"latency.p50", "ms", ms(p.Latency.P50)),
+		gauge(scope+"latency.p90", "ms", ms(p.Latency.P90)),
+		gauge(scope+"latency.p99", "ms", ms(p.Latency.P99)),
+		gauge(scope+"latency.max", "ms", ms(p.Latency.Max)),
+	)
+
+	return otlpPayload{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpAnyValue{StringValue: "gurl"}},
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: "github.com/antlabs/gurl"},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+// Close implements Sink
+func (s *otlpSink) Close() error {
+	return nil
+}
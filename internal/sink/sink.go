@@ -0,0 +1,138 @@
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+)
+
+// Sink receives points from a run and forwards them to a metrics backend.
+type Sink interface {
+	// Write pushes one point. It is called from a single goroutine at a time.
+	Write(p Point) error
+	// Close flushes pending data and releases the underlying connection.
+	Close() error
+}
+
+// Point is a snapshot of a run. Counters are cumulative since the start of the
+// run; RequestsPerSec and Latency cover the interval since the previous point,
+// or the whole run when Final is set.
+type Point struct {
+	Time  time.Time
+	Start time.Time
+	Final bool
+	Tags  map[string]string
+
+	Requests      int64
+	Errors        int64
+	StatusCodes   map[int]int64
+	ErrorsByClass map[string]int64
+	ReadBytes     int64
+	WriteBytes    int64
+	Connections   int64
+
+	RequestsPerSec float64
+	Latency        Latency
+}
+
+// Latency summarises a set of request latencies
+type Latency struct {
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// New creates the sink described by cfg
+func New(cfg config.SinkConfig) (Sink, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "gurl"
+	}
+
+	headers := make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+
+	switch cfg.Type {
+	case config.SinkStatsD, config.SinkDogStatsD:
+		return newStatsD(cfg.Address, prefix, cfg.Type == config.SinkDogStatsD)
+	case config.SinkInflux:
+		if strings.HasPrefix(cfg.Address, "http://") || strings.HasPrefix(cfg.Address, "https://") {
+			return newInfluxHTTP(cfg.Address, prefix, headers), nil
+		}
+		return newInfluxFile(strings.TrimPrefix(cfg.Address, "file://"), prefix)
+	case config.SinkOTLP:
+		return newOTLP(cfg.Address, prefix, headers), nil
+	}
+	return nil, fmt.Errorf("unsupported sink type '%s'", cfg.Type)
+}
+
+// httpClient is shared by HTTP based sinks
+var httpClient = &http.Client{Timeout: 5 * time.Second}
+
+// post sends body to url and treats any non-2xx response as an error
+func post(url, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+func sortedTagKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCodes(m map[int]int64) []int {
+	codes := make([]int, 0, len(m))
+	for code := range m {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+func sortedClasses(m map[string]int64) []string {
+	classes := make([]string, 0, len(m))
+	for class := range m {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
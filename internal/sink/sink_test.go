@@ -0,0 +1,195 @@
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antlabs/gurl/internal/config"
+	"github.com/antlabs/gurl/internal/stats"
+)
+
+func testPoint() Point {
+	start := time.Unix(1700000000, 0)
+	return Point{
+		Time:          start.Add(10 * time.Second),
+		Start:         start,
+		Tags:          map[string]string{"test": "login api", "env": "ci"},
+		Requests:      120,
+		Errors:        2,
+		StatusCodes:   map[int]int64{200: 118},
+		ErrorsByClass: map[string]int64{stats.ErrorClassTimeout: 2},
+		ReadBytes:     4096,
+		WriteBytes:    1024,
+		Connections:   4,
+
+		RequestsPerSec: 12,
+		Latency:        Latency{Mean: 5 * time.Millisecond, P50: 4 * time.Millisecond, P99: 20 * time.Millisecond},
+	}
+}
+
+func TestStatsDSendsDeltasWithTags(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s, err := New(config.SinkConfig{Type: config.SinkDogStatsD, Address: conn.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	read := func() string {
+		buf := make([]byte, 64*1024)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("no statsd packet received: %v", err)
+		}
+		return string(buf[:n])
+	}
+
+	p := testPoint()
+	if err := s.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	packet := read()
+	for _, want := range []string{
+		"gurl.requests:120|c|#env:ci,test:login api",
+		"gurl.status.200:118|c|#env:ci,test:login api",
+		"gurl.errors.timeout:2|c|#env:ci,test:login api",
+		"gurl.latency.p99_ms:20|g|#env:ci,test:login api",
+	} {
+		if !strings.Contains(packet, want) {
+			t.Errorf("packet is missing %q:\n%s", want, packet)
+		}
+	}
+
+	p.Requests = 150
+	if err := s.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	if packet := read(); !strings.Contains(packet, "gurl.requests:30|c") {
+		t.Errorf("second push should carry the counter delta:\n%s", packet)
+	}
+}
+
+func TestInfluxHTTPAndFile(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		if r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Type: config.SinkInflux, Address: srv.URL + "/api/v2/write?bucket=gurl",
+		Headers: map[string]string{"Authorization": "Token secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(testPoint()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`gurl,env=ci,test=login\ api requests=120i,errors=2i,`,
+		`gurl_status,env=ci,test=login\ api,code=200 count=118i 1700000010000000000`,
+		`gurl_errors,env=ci,test=login\ api,class=timeout count=2i`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("line protocol is missing %q:\n%s", want, body)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "gurl.lp")
+	fs, err := New(config.SinkConfig{Type: config.SinkInflux, Address: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := testPoint()
+	p.Final = true
+	if err := fs.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	fs.Close()
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "gurl_summary,") {
+		t.Errorf("final point should be written as gurl_summary:\n%s", data)
+	}
+}
+
+func TestOTLPPayload(t *testing.T) {
+	var payload otlpPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Type: config.SinkOTLP, Address: srv.URL + "/v1/metrics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(testPoint()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payload.ResourceMetrics) != 1 {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	metrics := map[string]otlpMetric{}
+	for _, m := range payload.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	req, ok := metrics["gurl.requests"]
+	if !ok || req.Sum == nil || !req.Sum.IsMonotonic || *req.Sum.DataPoints[0].AsInt != "120" {
+		t.Errorf("gurl.requests should be a cumulative sum of 120: %+v", req)
+	}
+	if len(req.Sum.DataPoints[0].Attributes) != 2 {
+		t.Errorf("tags should become data point attributes: %+v", req.Sum.DataPoints[0].Attributes)
+	}
+	if p99, ok := metrics["gurl.latency.p99"]; !ok || p99.Gauge == nil || *p99.Gauge.DataPoints[0].AsDouble != 20 {
+		t.Errorf("gurl.latency.p99 should be a 20ms gauge: %+v", p99)
+	}
+}
+
+func TestObserverPushesFinalPoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gurl.lp")
+	observers, err := NewObservers([]config.SinkConfig{{Type: config.SinkInflux, Address: path, Interval: "1h"}},
+		map[string]string{"test": "checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := observers[0]
+
+	results := stats.NewResults()
+	results.AddLatency(10 * time.Millisecond)
+	results.AddStatusCode(200)
+	o.OnSample(results, results.TakeSample(time.Second, 1, 0))
+
+	results.TotalRequests = 1
+	results.Duration = time.Second
+	o.OnFinish(results)
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if !strings.HasPrefix(lines[0], "gurl_summary,test=checkout requests=1i,") {
+		t.Errorf("expected only the final summary before the interval elapsed:\n%s", data)
+	}
+}
@@ -0,0 +1,122 @@
+package sink
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// maxStatsDPacket keeps datagrams below a typical MTU
+const maxStatsDPacket = 1432
+
+// statsDSink writes StatsD (or DogStatsD, with tags) metrics over UDP.
+// StatsD counters are deltas, so the sink remembers the last cumulative
+// value of every counter it has sent.
+type statsDSink struct {
+	conn   net.Conn
+	prefix string
+	tagged bool
+	last   map[string]int64
+}
+
+func newStatsD(addr, prefix string, tagged bool) (*statsDSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	return &statsDSink{
+		conn:   conn,
+		prefix: prefix,
+		tagged: tagged,
+		last:   make(map[string]int64),
+	}, nil
+}
+
+// Write implements Sink
+func (s *statsDSink) Write(p Point) error {
+	var lines []string
+	suffix := s.tagSuffix(p.Tags)
+
+	counter := func(name string, total int64) {
+		delta := total - s.last[name]
+		s.last[name] = total
+		if delta > 0 {
+			lines = append(lines, fmt.Sprintf("%s.%s:%d|c%s", s.prefix, name, delta, suffix))
+		}
+	}
+	gauge := func(name string, v float64) {
+		lines = append(lines, fmt.Sprintf("%s.%s:%s|g%s", s.prefix, name, strconv.FormatFloat(v, 'f', -1, 64), suffix))
+	}
+
+	counter("requests", p.Requests)
+	counter("errors", p.Errors)
+	for _, code := range sortedCodes(p.StatusCodes) {
+		counter("status."+strconv.Itoa(code), p.StatusCodes[code])
+	}
+	for _, class := range sortedClasses(p.ErrorsByClass) {
+		counter("errors."+class, p.ErrorsByClass[class])
+	}
+	counter("bytes.read", p.ReadBytes)
+	counter("bytes.written", p.WriteBytes)
+	gauge("connections", float64(p.Connections))
+
+	scope := ""
+	if p.Final {
+		scope = "summary."
+	}
+	gauge(scope+"rps", p.RequestsPerSec)
+	gauge(scope+"latency.mean_ms", ms(p.Latency.Mean))
+	gauge(scope+"latency.p50_ms", ms(p.Latency.P50))
+	gauge(scope+"latency.p90_ms", ms(p.Latency.P90))
+	gauge(scope+"latency.p99_ms", ms(p.Latency.P99))
+	gauge(scope+"latency.max_ms", ms(p.Latency.Max))
+
+	return s.send(lines)
+}
+
+// send packs lines into datagrams no larger than maxStatsDPacket
+func (s *statsDSink) send(lines []string) error {
+	var packet strings.Builder
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := s.conn.Write([]byte(packet.String()))
+		packet.Reset()
+		return err
+	}
+
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsDPacket {
+			if err := flush(); err != nil {
+				return fmt.Errorf("statsd: %w", err)
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("statsd: %w", err)
+	}
+	return nil
+}
+
+// tagSuffix renders DogStatsD tags; plain StatsD has no tag support
+func (s *statsDSink) tagSuffix(tags map[string]string) string {
+	if !s.tagged || len(tags) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(tags))
+	for _, k := range sortedTagKeys(tags) {
+		parts = append(parts, k+":"+tags[k])
+	}
+	return "|#" + strings.Join(parts, ",")
+}
+
+// Close implements Sink
+func (s *statsDSink) Close() error {
+	return s.conn.Close()
+}
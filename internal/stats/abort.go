@@ -0,0 +1,30 @@
+package stats
+
+// TakeConnectFailureStreak returns the longest run of consecutive connection
+// failures since the previous call. A response of any status ends a run.
+func (r *Results) TakeConnectFailureStreak() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	peak := r.connectStreakPeak
+	r.connectStreakPeak = r.connectStreak
+	return peak
+}
+
+// Abort marks the run as stopped early by a circuit breaker. Only the first
+// reason is kept.
+func (r *Results) Abort(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.abortReason == "" {
+		r.abortReason = reason
+	}
+}
+
+// AbortReason returns why the run was aborted, or "" if it ran to the end
+func (r *Results) AbortReason() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.abortReason
+}
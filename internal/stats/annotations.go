@@ -0,0 +1,28 @@
+package stats
+
+import "time"
+
+// Annotation marks a point on the timeline, such as a load adjustment made
+// while the run was in progress
+type Annotation struct {
+	Elapsed time.Duration `json:"elapsed"`
+	Label   string        `json:"label"`
+}
+
+// Annotate records an annotation at elapsed, measured on the same clock as
+// the timeline samples
+func (r *Results) Annotate(elapsed time.Duration, label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.annotations = append(r.annotations, Annotation{Elapsed: elapsed, Label: label})
+}
+
+// GetAnnotations returns a copy of the timeline annotations
+func (r *Results) GetAnnotations() []Annotation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	annotations := make([]Annotation, len(r.annotations))
+	copy(annotations, r.annotations)
+	return annotations
+}
@@ -0,0 +1,100 @@
+package stats
+
+// MaxAssertFailureSamples is the number of failing responses kept per run
+const MaxAssertFailureSamples = 5
+
+// maxSampleBodySize caps the response body stored with a failure sample
+const maxSampleBodySize = 512
+
+// AssertStat is the pass/fail distribution of a single assertion line
+type AssertStat struct {
+	Assert string
+	Passed int64
+	Failed int64
+}
+
+// AssertFailure is a failing response kept for the report
+type AssertFailure struct {
+	Assert  string
+	Status  int
+	Message string
+	Body    string
+	// Truncated is set when Body is only the beginning of the captured body
+	Truncated bool
+}
+
+// assertState tracks assertion outcomes; guarded by Results.mu
+type assertState struct {
+	evaluated int64
+	order     []string
+	stats     map[string]*AssertStat
+	failures  []AssertFailure
+}
+
+// AddAssertEvaluated counts a response whose assertions were evaluated
+func (r *Results) AddAssertEvaluated() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.asserts.evaluated++
+}
+
+// AddAssertResult records the outcome of one assertion line for a response.
+// The first MaxAssertFailureSamples failures keep the status and body.
+func (r *Results) AddAssertResult(assert string, err error, status int, body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a := &r.asserts
+	if a.stats == nil {
+		a.stats = make(map[string]*AssertStat)
+	}
+	st := a.stats[assert]
+	if st == nil {
+		st = &AssertStat{Assert: assert}
+		a.stats[assert] = st
+		a.order = append(a.order, assert)
+	}
+
+	if err == nil {
+		st.Passed++
+		return
+	}
+	st.Failed++
+
+	if len(a.failures) >= MaxAssertFailureSamples {
+		return
+	}
+	sample := AssertFailure{Assert: assert, Status: status, Message: err.Error()}
+	if len(body) > maxSampleBodySize {
+		body = body[:maxSampleBodySize]
+		sample.Truncated = true
+	}
+	sample.Body = string(body)
+	a.failures = append(a.failures, sample)
+}
+
+// GetAssertsEvaluated returns the number of responses whose assertions ran
+func (r *Results) GetAssertsEvaluated() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.asserts.evaluated
+}
+
+// GetAssertStats returns per-assertion pass/fail counts in definition order
+func (r *Results) GetAssertStats() []AssertStat {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]AssertStat, 0, len(r.asserts.order))
+	for _, name := range r.asserts.order {
+		out = append(out, *r.asserts.stats[name])
+	}
+	return out
+}
+
+// GetAssertFailures returns the kept failing response samples
+func (r *Results) GetAssertFailures() []AssertFailure {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]AssertFailure(nil), r.asserts.failures...)
+}
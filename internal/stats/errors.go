@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Error classes used to group request errors in reports
+const (
+	ErrorClassConnect = "connect"
+	ErrorClassTimeout = "timeout"
+	ErrorClassRead    = "read"
+	ErrorClassWrite   = "write"
+	ErrorClassParse   = "parse"
+	ErrorClassAssert  = "assert"
+	ErrorClassOther   = "other"
+)
+
+// maxRecentErrors caps the number of distinct error messages kept for the
+// live error feed
+const maxRecentErrors = 20
+
+// RecentError is a distinct error message seen during the run
+type RecentError struct {
+	Class   string
+	Message string
+	Count   int64     // occurrences since the message entered the feed
+	Last    time.Time // when it was last seen
+}
+
+// ClassifyError maps a request error to one of the ErrorClass* constants.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	msg := err.Error()
+	// 断言和解析错误由 gurl 自身构造，只能通过消息前缀识别
+	if strings.HasPrefix(msg, "assertion failed") {
+		return ErrorClassAssert
+	}
+	if strings.HasPrefix(msg, "HTTP parse error") {
+		return ErrorClassParse
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		switch opErr.Op {
+		case "dial":
+			return ErrorClassConnect
+		case "read":
+			return ErrorClassRead
+		case "write":
+			return ErrorClassWrite
+		}
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorClassConnect
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return ErrorClassRead
+	}
+
+	return ErrorClassOther
+}
+
+// GetErrorsByClass returns error counts grouped by ClassifyError
+func (r *Results) GetErrorsByClass() map[string]int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	classes := make(map[string]int64, len(r.errorClasses))
+	for k, v := range r.errorClasses {
+		classes[k] = v
+	}
+	return classes
+}
+
+// trackRecentError moves message to the front of the recent error feed,
+// dropping the oldest message when the feed is full. Called with r.mu held.
+func (r *Results) trackRecentError(class, message string) {
+	now := time.Now()
+	for i := range r.recentErrors {
+		if r.recentErrors[i].Message == message {
+			e := r.recentErrors[i]
+			e.Count++
+			e.Last = now
+			copy(r.recentErrors[i:], r.recentErrors[i+1:])
+			r.recentErrors[len(r.recentErrors)-1] = e
+			return
+		}
+	}
+	if len(r.recentErrors) >= maxRecentErrors {
+		r.recentErrors = r.recentErrors[1:]
+	}
+	r.recentErrors = append(r.recentErrors, RecentError{Class: class, Message: message, Count: 1, Last: now})
+}
+
+// GetRecentErrors returns up to n distinct error messages, most recently
+// seen first
+func (r *Results) GetRecentErrors(n int) []RecentError {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n > len(r.recentErrors) {
+		n = len(r.recentErrors)
+	}
+	recent := make([]RecentError, 0, n)
+	for i := len(r.recentErrors) - 1; i >= 0 && len(recent) < n; i-- {
+		recent = append(recent, r.recentErrors[i])
+	}
+	return recent
+}
@@ -4,20 +4,24 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // EndpointStats holds statistics for a single endpoint
 type EndpointStats struct {
-	URL         string
-	Requests    int64
-	Errors      int64
-	Latencies   []time.Duration
-	StatusCodes map[int]int64
-	ReadBytes   int64
-	WriteBytes  int64
-	MinLatency  time.Duration
-	MaxLatency  time.Duration
+	Name         string // label from the curl file (# @name), empty when grouped by URL
+	URL          string
+	Requests     int64
+	Errors       int64
+	Latencies    []time.Duration
+	StatusCodes  map[int]int64
+	ErrorClasses map[string]int64
+	ReadBytes    int64
+	WriteBytes   int64
+	MinLatency   time.Duration
+	MaxLatency   time.Duration
+	Share        float64 // fraction of all endpoint requests sent to this endpoint, set by GetEndpointStats
 }
 
 // Results holds benchmark results
@@ -35,9 +39,38 @@ type Results struct {
 	// 按 URL 分组的统计
 	endpointStats map[string]*EndpointStats
 
+	// 按错误类别分组的计数
+	errorClasses map[string]int64
+
+	// 最近出现的不同错误消息，供实时界面展示，预热结束时不清空
+	recentErrors []RecentError
+
+	// 当前打开的连接数，使用原子操作更新
+	activeConns int64
+
+	// 按断言行统计的通过/失败次数及失败样本
+	asserts assertState
+
+	// 熔断：连续连接失败次数及其峰值，以及中止原因
+	connectStreak     int64
+	connectStreakPeak int64
+	abortReason       string
+
+	// 时间线采样
+	timeline            []Sample
+	sampleLatencyOffset int
+	sampleStatusCodes   map[int]int64
+
+	// 时间线注释（运行中的负载调整等）
+	annotations []Annotation
+
 	TotalRequests int64
 	TotalErrors   int64
 	Duration      time.Duration
+
+	// Warmup holds what was recorded during the warm-up phase, nil when the
+	// run had none. It is kept out of every other statistic.
+	Warmup *Results
 }
 
 // NewResults creates a new Results instance
@@ -48,6 +81,7 @@ func NewResults() *Results {
 		errors:        make([]error, 0),
 		reqPerSecond:  make([]int64, 0),
 		endpointStats: make(map[string]*EndpointStats),
+		errorClasses:  make(map[string]int64),
 	}
 }
 
@@ -66,40 +100,44 @@ func (r *Results) AddLatency(latency time.Duration) {
 	}
 }
 
-// AddLatencyWithURL adds a latency measurement for a specific URL
-func (r *Results) AddLatencyWithURL(url string, latency time.Duration, statusCode int, bytes int64, writeBytes int64, err error) {
+// AddEndpointResult records one request of a multi-request run under its
+// endpoint. Requests are grouped by name when the curl file labels them and
+// by URL otherwise. The overall latency and status code are recorded
+// separately with AddLatency and AddStatusCode.
+func (r *Results) AddEndpointResult(name, url string, latency time.Duration, statusCode int, bytes int64, writeBytes int64, err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// 全局统计
-	r.latencies = append(r.latencies, latency)
-	if r.minLatency == 0 || latency < r.minLatency {
-		r.minLatency = latency
-	}
-	if latency > r.maxLatency {
-		r.maxLatency = latency
+	key := name
+	if key == "" {
+		key = url
 	}
-
-	// 按 URL 统计
-	if r.endpointStats[url] == nil {
-		r.endpointStats[url] = &EndpointStats{
-			URL:         url,
-			Latencies:   make([]time.Duration, 0),
-			StatusCodes: make(map[int]int64),
+	if r.endpointStats[key] == nil {
+		r.endpointStats[key] = &EndpointStats{
+			Name:         name,
+			URL:          url,
+			Latencies:    make([]time.Duration, 0),
+			StatusCodes:  make(map[int]int64),
+			ErrorClasses: make(map[string]int64),
 		}
 	}
 
-	stats := r.endpointStats[url]
+	stats := r.endpointStats[key]
 	stats.Requests++
-	stats.Latencies = append(stats.Latencies, latency)
 	stats.ReadBytes += bytes
 	stats.WriteBytes += writeBytes
 
+	// 断言失败的请求既有状态码也有错误
 	if err != nil {
 		stats.Errors++
-	} else {
-		stats.StatusCodes[statusCode]++
+		stats.ErrorClasses[ClassifyError(err)]++
+	}
+	// 没有拿到响应（statusCode 为 0）的请求不计入状态码和延迟
+	if statusCode == 0 {
+		return
 	}
+	stats.StatusCodes[statusCode]++
+	stats.Latencies = append(stats.Latencies, latency)
 
 	if stats.MinLatency == 0 || latency < stats.MinLatency {
 		stats.MinLatency = latency
@@ -109,11 +147,41 @@ func (r *Results) AddLatencyWithURL(url string, latency time.Duration, statusCod
 	}
 }
 
+// ConnOpened records that a connection to the target was established
+func (r *Results) ConnOpened() {
+	atomic.AddInt64(&r.activeConns, 1)
+}
+
+// ConnClosed records that a connection to the target was closed
+func (r *Results) ConnClosed() {
+	atomic.AddInt64(&r.activeConns, -1)
+}
+
+// GetActiveConnections returns the number of currently open connections
+func (r *Results) GetActiveConnections() int64 {
+	return atomic.LoadInt64(&r.activeConns)
+}
+
+// GetLatenciesSince returns the latencies added after the first offset ones,
+// together with the offset to pass on the next call.
+func (r *Results) GetLatenciesSince(offset int) ([]time.Duration, int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if offset > len(r.latencies) {
+		offset = len(r.latencies)
+	}
+	latencies := make([]time.Duration, len(r.latencies)-offset)
+	copy(latencies, r.latencies[offset:])
+	return latencies, len(r.latencies)
+}
+
 // AddStatusCode adds a status code count
 func (r *Results) AddStatusCode(code int) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.statusCodes[code]++
+	r.connectStreak = 0
 }
 
 // AddError adds an error
@@ -121,6 +189,15 @@ func (r *Results) AddError(err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.errors = append(r.errors, err)
+	class := ClassifyError(err)
+	r.errorClasses[class]++
+	r.trackRecentError(class, err.Error())
+	if class == ErrorClassConnect {
+		r.connectStreak++
+		if r.connectStreak > r.connectStreakPeak {
+			r.connectStreakPeak = r.connectStreak
+		}
+	}
 }
 
 // AddBytes adds to the total bytes transferred
@@ -237,24 +314,28 @@ func (r *Results) getAverageLatencyUnsafe() time.Duration {
 
 // GetConnectErrors returns the number of connection errors
 func (r *Results) GetConnectErrors() int64 {
-	// 这里可以根据具体的错误类型进行分类
-	// 暂时返回0，实际实现需要解析错误类型
-	return 0
+	return r.getErrorClassCount(ErrorClassConnect)
 }
 
 // GetReadErrors returns the number of read errors
 func (r *Results) GetReadErrors() int64 {
-	return 0
+	return r.getErrorClassCount(ErrorClassRead)
 }
 
 // GetWriteErrors returns the number of write errors
 func (r *Results) GetWriteErrors() int64 {
-	return 0
+	return r.getErrorClassCount(ErrorClassWrite)
 }
 
 // GetTimeoutErrors returns the number of timeout errors
 func (r *Results) GetTimeoutErrors() int64 {
-	return 0
+	return r.getErrorClassCount(ErrorClassTimeout)
+}
+
+func (r *Results) getErrorClassCount(class string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.errorClasses[class]
 }
 
 // AddReqPerSecond adds a request per second sample
@@ -341,22 +422,32 @@ func (r *Results) GetLatencyPercentiles() map[float64]time.Duration {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	return latencyPercentiles(r.latencies)
+}
+
+// GetLatencyPercentiles returns the p50/p75/p90/p95/p99 latencies of the endpoint
+func (stats *EndpointStats) GetLatencyPercentiles() map[float64]time.Duration {
+	return latencyPercentiles(stats.Latencies)
+}
+
+// latencyPercentiles 计算 p50/p75/p90/p95/p99，最多使用最近的 10000 个样本
+func latencyPercentiles(latencies []time.Duration) map[float64]time.Duration {
 	percentiles := map[float64]time.Duration{}
 
-	if len(r.latencies) == 0 {
+	if len(latencies) == 0 {
 		return percentiles
 	}
 
 	// 采样：最多使用 10000 个样本
-	sampleSize := len(r.latencies)
+	sampleSize := len(latencies)
 	if sampleSize > 10000 {
 		sampleSize = 10000
 	}
 
 	// 使用最近的样本
-	startIdx := len(r.latencies) - sampleSize
+	startIdx := len(latencies) - sampleSize
 	sample := make([]time.Duration, sampleSize)
-	copy(sample, r.latencies[startIdx:])
+	copy(sample, latencies[startIdx:])
 
 	// 使用标准库排序（快速排序，O(n log n)）
 	sort.Slice(sample, func(i, j int) bool {
@@ -378,22 +469,64 @@ func (r *Results) GetEndpointStats() map[string]*EndpointStats {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	var total int64
+	for _, stats := range r.endpointStats {
+		total += stats.Requests
+	}
+
 	// 返回副本以避免并发问题
 	result := make(map[string]*EndpointStats)
+	for key, stats := range r.endpointStats {
+		cp := &EndpointStats{
+			Name:         stats.Name,
+			URL:          stats.URL,
+			Requests:     stats.Requests,
+			Errors:       stats.Errors,
+			Latencies:    append([]time.Duration{}, stats.Latencies...),
+			StatusCodes:  make(map[int]int64, len(stats.StatusCodes)),
+			ErrorClasses: make(map[string]int64, len(stats.ErrorClasses)),
+			ReadBytes:    stats.ReadBytes,
+			WriteBytes:   stats.WriteBytes,
+			MinLatency:   stats.MinLatency,
+			MaxLatency:   stats.MaxLatency,
+		}
+		for code, count := range stats.StatusCodes {
+			cp.StatusCodes[code] = count
+		}
+		for class, count := range stats.ErrorClasses {
+			cp.ErrorClasses[class] = count
+		}
+		if total > 0 {
+			cp.Share = float64(stats.Requests) / float64(total)
+		}
+		result[key] = cp
+	}
+	return result
+}
+
+// EndpointCounters holds the request counters of an endpoint without the
+// latency samples, for callers polling frequently (e.g. metrics exporters).
+type EndpointCounters struct {
+	Requests    int64
+	Errors      int64
+	StatusCodes map[int]int64
+}
+
+// GetEndpointCounters returns request counters for all endpoints
+func (r *Results) GetEndpointCounters() map[string]EndpointCounters {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string]EndpointCounters, len(r.endpointStats))
 	for url, stats := range r.endpointStats {
-		result[url] = &EndpointStats{
-			URL:         stats.URL,
+		codes := make(map[int]int64, len(stats.StatusCodes))
+		for code, count := range stats.StatusCodes {
+			codes[code] = count
+		}
+		result[url] = EndpointCounters{
 			Requests:    stats.Requests,
 			Errors:      stats.Errors,
-			Latencies:   append([]time.Duration{}, stats.Latencies...),
-			StatusCodes: make(map[int]int64),
-			ReadBytes:   stats.ReadBytes,
-			WriteBytes:  stats.WriteBytes,
-			MinLatency:  stats.MinLatency,
-			MaxLatency:  stats.MaxLatency,
-		}
-		for code, count := range stats.StatusCodes {
-			result[url].StatusCodes[code] = count
+			StatusCodes: codes,
 		}
 	}
 	return result
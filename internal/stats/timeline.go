@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Sample is a timeline snapshot recorded once per sampling interval.
+// Counters and percentiles only cover the interval itself, not the whole run.
+type Sample struct {
+	Elapsed     time.Duration `json:"elapsed"`
+	Requests    int64         `json:"requests"`
+	Errors      int64         `json:"errors"`
+	P50         time.Duration `json:"p50"`
+	P90         time.Duration `json:"p90"`
+	P99         time.Duration `json:"p99"`
+	StatusCodes map[int]int64 `json:"status_codes,omitempty"`
+}
+
+// HistogramBucket is a single latency histogram bucket covering [Lower, Upper).
+type HistogramBucket struct {
+	Lower time.Duration `json:"lower"`
+	Upper time.Duration `json:"upper"`
+	Count int64         `json:"count"`
+}
+
+// TakeSample records a timeline sample covering every latency and status code
+// added since the previous call. requests and errors are the interval deltas
+// already computed by the caller (the sampling loop owns those counters).
+func (r *Results) TakeSample(elapsed time.Duration, requests, errors int64) Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := Sample{
+		Elapsed:     elapsed,
+		Requests:    requests,
+		Errors:      errors,
+		StatusCodes: make(map[int]int64),
+	}
+
+	if r.sampleLatencyOffset > len(r.latencies) {
+		r.sampleLatencyOffset = len(r.latencies)
+	}
+	window := r.latencies[r.sampleLatencyOffset:]
+	if len(window) > 0 {
+		sorted := make([]time.Duration, len(window))
+		copy(sorted, window)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		s.P50 = percentileOfSorted(sorted, 50)
+		s.P90 = percentileOfSorted(sorted, 90)
+		s.P99 = percentileOfSorted(sorted, 99)
+	}
+	r.sampleLatencyOffset = len(r.latencies)
+
+	if r.sampleStatusCodes == nil {
+		r.sampleStatusCodes = make(map[int]int64)
+	}
+	for code, count := range r.statusCodes {
+		if delta := count - r.sampleStatusCodes[code]; delta > 0 {
+			s.StatusCodes[code] = delta
+		}
+		r.sampleStatusCodes[code] = count
+	}
+
+	r.timeline = append(r.timeline, s)
+	return s
+}
+
+// GetTimeline returns a copy of all recorded timeline samples
+func (r *Results) GetTimeline() []Sample {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	timeline := make([]Sample, len(r.timeline))
+	copy(timeline, r.timeline)
+	return timeline
+}
+
+// GetLatencyHistogram groups all latencies into n log-spaced buckets between
+// the fastest and slowest response. Log spacing keeps the long tail readable.
+func (r *Results) GetLatencyHistogram(n int) []HistogramBucket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return latencyHistogram(r.latencies, r.minLatency, r.maxLatency, n)
+}
+
+func latencyHistogram(latencies []time.Duration, min, max time.Duration, n int) []HistogramBucket {
+	if len(latencies) == 0 || n <= 0 {
+		return nil
+	}
+	if min <= 0 {
+		min = 1
+	}
+	if max <= min {
+		return []HistogramBucket{{Lower: min, Upper: max + 1, Count: int64(len(latencies))}}
+	}
+
+	buckets := make([]HistogramBucket, n)
+	ratio := float64(max) / float64(min)
+	lower := float64(min)
+	for i := range buckets {
+		upper := float64(min) * math.Pow(ratio, float64(i+1)/float64(n))
+		buckets[i].Lower = time.Duration(lower)
+		buckets[i].Upper = time.Duration(upper)
+		lower = upper
+	}
+	// 最后一个桶包含 max 本身
+	buckets[n-1].Upper = max + 1
+
+	for _, lat := range latencies {
+		idx := sort.Search(n, func(i int) bool { return lat < buckets[i].Upper })
+		if idx == n {
+			idx = n - 1
+		}
+		buckets[idx].Count++
+	}
+	return buckets
+}
+
+// percentileOfSorted returns the p-th percentile of an ascending slice.
+func percentileOfSorted(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p / 100.0)
+	return sorted[idx]
+}
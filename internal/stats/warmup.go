@@ -0,0 +1,51 @@
+package stats
+
+import "time"
+
+// EndWarmup moves everything recorded so far into r.Warmup and resets r, so
+// that from now on it only covers the measurement phase. requests, errors and
+// elapsed are the warm-up totals, which are counted by the caller.
+func (r *Results) EndWarmup(requests, errors int64, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Warmup = &Results{
+		latencies:       r.latencies,
+		statusCodes:     r.statusCodes,
+		errors:          r.errors,
+		totalReadBytes:  r.totalReadBytes,
+		totalWriteBytes: r.totalWriteBytes,
+		reqPerSecond:    r.reqPerSecond,
+		minLatency:      r.minLatency,
+		maxLatency:      r.maxLatency,
+		endpointStats:   r.endpointStats,
+		errorClasses:    r.errorClasses,
+		asserts:         r.asserts,
+		timeline:        r.timeline,
+		annotations:     r.annotations,
+		TotalRequests:   requests,
+		TotalErrors:     errors,
+		Duration:        elapsed,
+	}
+
+	// Open connections carry over to the measurement phase
+	r.latencies = make([]time.Duration, 0)
+	r.statusCodes = make(map[int]int64)
+	r.errors = make([]error, 0)
+	r.totalReadBytes = 0
+	r.totalWriteBytes = 0
+	r.reqPerSecond = make([]int64, 0)
+	r.minLatency = 0
+	r.maxLatency = 0
+	r.endpointStats = make(map[string]*EndpointStats)
+	r.errorClasses = make(map[string]int64)
+	r.asserts = assertState{}
+	r.timeline = nil
+	r.annotations = nil
+	r.sampleLatencyOffset = 0
+	r.sampleStatusCodes = nil
+	// Connection failures during warm-up must not trip the breaker once
+	// measurement starts
+	r.connectStreak = 0
+	r.connectStreakPeak = 0
+}